@@ -0,0 +1,406 @@
+package errclose
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// IdleConnections returns a closer that calls CloseIdleConnections on the given HTTP client when
+// closed. This lets you close an [http.Client]'s idle connections as part of the same teardown as
+// your other resources, e.g. by adding it to a [errclose.Frame]:
+//
+//	frame.Add(errclose.IdleConnections(httpClient), "HTTP client")
+//
+// [http.Client.CloseIdleConnections] closes the idle connections of the client's transport, if the
+// transport supports it. You can also pass an [http.Transport] directly.
+//
+// CloseIdleConnections can't fail, but if it panics, the returned closer recovers the panic and
+// returns it as an error.
+func IdleConnections(client interface{ CloseIdleConnections() }) interface{ Close() error } {
+	return voidCloser(client.CloseIdleConnections)
+}
+
+// voidCloser adapts a teardown function that doesn't return an error to the Close() error
+// signature, converting panics in the teardown function to errors.
+type voidCloser func()
+
+func (closer voidCloser) Close() (returnedErr error) {
+	defer recoverAsError(&returnedErr)
+
+	closer()
+	return nil
+}
+
+// NoError returns a closer for a resource whose Close method doesn't return an error, such as some
+// caches and connection pools. This lets you register such resources in the same teardown as your
+// other resources, e.g. in a [errclose.Frame]:
+//
+//	frame.Add(errclose.NoError(cache), "cache")
+//
+// The resource's Close can't fail, but if it panics, the returned closer recovers the panic and
+// returns it as an error.
+//
+// If the resource is nil, the returned closer's Close returns [errclose.ErrNilResource], following
+// the nil resource policy in the same way as [errclose.Named] (see
+// [errclose.SetNilResourcePolicy]).
+//
+// To close such a resource directly, use [errclose.CloseNoError].
+func NoError(resource interface{ Close() }) interface{ Close() error } {
+	return noErrorCloser{resource: resource}
+}
+
+type noErrorCloser struct {
+	resource interface{ Close() }
+}
+
+func (closer noErrorCloser) Close() error {
+	if isNilResource(closer.resource) {
+		return nilResourceError()
+	}
+	return voidCloser(closer.resource.Close).Close()
+}
+
+// CloseNoError closes a resource whose Close method doesn't return an error, like
+// [errclose.Close] would with [errclose.NoError]. The close still goes through the package's
+// hooks, so it's written to the event log in debug mode (see [errclose.SetDebugEvents]), and if
+// Close panics, the panic is handled as a close error:
+//
+//	failed to close <resourceName>: panic: <panic value>
+//
+// Nil resources and the defaults from [errclose.SetDefaults] and [errclose.SetDefaultsFor] (for
+// the type of the given resource) are handled in the same way as in errclose.Close.
+func CloseNoError(resource interface{ Close() }, returnedErr *error, resourceName string) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+	closer := voidCloser(resource.Close)
+	lists := withDefaults(resource, nil)
+	recordCloseAttempt(resourceName)
+	if closeEventsEnabled() {
+		closeWithDebugEvent(closer, returnedErr, resourceName, lists, 1)
+		return
+	}
+
+	stats := captureStats(lists)
+	if closeErr := closeWithOptions(closer, resourceName, lists); closeErr != nil {
+		handleCloseErrorWithOptions(returnedErr, closeErr, resourceName, lists, stats, 1)
+	}
+}
+
+// recoverAsError recovers a panic, and sets the error pointed to by returnedErr to the panic value.
+// It must be called directly in a defer statement.
+func recoverAsError(returnedErr *error) {
+	if recovered := recover(); recovered != nil {
+		if err, ok := recovered.(error); ok {
+			*returnedErr = fmt.Errorf("panic: %w", err)
+		} else {
+			*returnedErr = fmt.Errorf("panic: %v", recovered)
+		}
+	}
+}
+
+// Stop returns a closer that calls Stop on the given resource when closed, such as a
+// [time.Ticker]. This lets you register resources that are stopped rather than closed in the same
+// teardown as your other resources, e.g. in a [errclose.Frame]:
+//
+//	ticker := time.NewTicker(time.Second)
+//	frame.Add(errclose.Stop(ticker), "ticker")
+//
+// Stop can't fail, but if it panics, the returned closer recovers the panic and returns it as an
+// error.
+//
+// For a [time.Timer] (where Stop returns a bool), use [errclose.StopTimer].
+func Stop(resource interface{ Stop() }) interface{ Close() error } {
+	return voidCloser(resource.Stop)
+}
+
+// Stopper returns a closer that calls Stop on the given resource when closed, for resources whose
+// teardown method is named Stop and returns an error, such as some background workers and clients.
+// This lets you pass them to [errclose.Close], or register them in a [errclose.Frame] or with
+// [errclose.DeferGlobal], like any other resource:
+//
+//	defer errclose.Close(errclose.Stopper(worker), &returnedErr, "worker")
+//
+// Close errors from the returned closer use "stop" instead of "close" in the error message:
+//
+//	failed to stop <resourceName>: <stop error>
+//
+// If you call Close on the returned closer yourself, the stop error is returned as-is (in a wrapper
+// that can be unwrapped with [errors.Unwrap]). For resources where Stop doesn't return an error,
+// use [errclose.Stop].
+func Stopper(resource interface{ Stop() error }) interface{ Close() error } {
+	return teardownCloser{teardown: resource.Stop, action: "stop", fatal: false}
+}
+
+// Shutdowner returns a closer that gracefully shuts down the given resource when closed, for
+// servers with a Shutdown method that takes a context, such as [http.Server]. This lets you
+// register them with a [errclose.ShutdownManager] like any other resource:
+//
+//	manager.DeferPhase(errclose.Shutdowner(server), "HTTP server", phaseServers)
+//
+// When the returned closer is closed by a ShutdownManager (or another errclose function that has
+// a context), Shutdown gets that context, through [errclose.CloseContextSetter]. Otherwise, it gets
+// [context.Background]. Close errors from the returned closer use "shut down" instead of "close" in
+// the error message:
+//
+//	failed to shut down <resourceName>: <shutdown error>
+func Shutdowner(resource interface{ Shutdown(context.Context) error }) interface{ Close() error } {
+	return &shutdownCloser{shutdown: resource.Shutdown, ctx: context.Background()}
+}
+
+type shutdownCloser struct {
+	shutdown func(ctx context.Context) error
+	//nolint:containedctx // Set by SetCloseContext, since Close takes no context
+	ctx context.Context
+}
+
+func (closer *shutdownCloser) SetCloseContext(ctx context.Context) {
+	closer.ctx = ctx
+}
+
+func (closer *shutdownCloser) Close() error {
+	if err := closer.shutdown(closer.ctx); err != nil {
+		return &teardownError{action: "shut down", err: err, fatal: false}
+	}
+	return nil
+}
+
+// Disconnecter returns a closer that calls Disconnect on the given resource when closed, such as
+// database and message queue clients. Close errors from the returned closer use "disconnect"
+// instead of "close" in the error message:
+//
+//	failed to disconnect <resourceName>: <disconnect error>
+//
+// See [errclose.Stopper] for more on how to use the returned closer. For clients where Disconnect
+// takes a context, use [errclose.CloseContextFunc] with a method value.
+func Disconnecter(resource interface{ Disconnect() error }) interface{ Close() error } {
+	return teardownCloser{
+		teardown: resource.Disconnect,
+		action:   "disconnect",
+		fatal:    false,
+	}
+}
+
+// teardownCloser adapts a teardown method with a different name than Close, and marks its errors
+// with the action to use in close error messages, and whether they are fatal (see
+// [CloseError.Fatal]).
+type teardownCloser struct {
+	teardown func() error
+	action   string
+	fatal    bool
+}
+
+func (closer teardownCloser) Close() error {
+	if err := closer.teardown(); err != nil {
+		return &teardownError{action: closer.action, err: err, fatal: closer.fatal}
+	}
+	return nil
+}
+
+// teardownError is returned by adapters such as [errclose.Stopper], to tell the package which
+// action to use in the error message instead of "close" (see newCloseError).
+type teardownError struct {
+	action string
+	err    error
+	fatal  bool
+}
+
+func (err *teardownError) Error() string {
+	return err.err.Error()
+}
+
+func (err *teardownError) Unwrap() error {
+	return err.err
+}
+
+// StopTimer returns a closer that calls Stop on the given timer when closed, ignoring the returned
+// bool. See [errclose.Stop] for more on how to use the returned closer.
+//
+// For stop functions returned by [context.AfterFunc], use [errclose.StopFunc].
+func StopTimer(timer interface{ Stop() bool }) interface{ Close() error } {
+	return StopFunc(timer.Stop)
+}
+
+// StopFunc returns a closer that calls the given stop function when closed, ignoring the returned
+// bool. This is useful for the stop function returned by [context.AfterFunc]:
+//
+//	stopWatching := context.AfterFunc(ctx, cancelWork)
+//	frame.Add(errclose.StopFunc(stopWatching), "context watcher")
+//
+// See [errclose.Stop] for more on how to use the returned closer.
+func StopFunc(stop func() bool) interface{ Close() error } {
+	return voidCloser(func() { stop() })
+}
+
+// CFree returns a closer that calls the given free function when closed. This is meant for
+// resources owned by C code (through cgo), where the function that frees the resource returns an
+// integer error code:
+//
+//	handle := C.open_handle()
+//	defer errclose.Close(
+//		errclose.CFree(func() int { return int(C.free_handle(handle)) }, mapHandleError),
+//		&returnedErr,
+//		"C handle",
+//	)
+//
+// If free returns a non-zero code, the closer returns an error for that code. The error is created
+// by calling mapErr with the code, which lets you translate the code to a descriptive error. If
+// mapErr is nil (or returns nil), the error message is:
+//
+//	C error code <code>
+//
+// If free panics, the closer recovers the panic and returns it as an error. Note that this can't
+// recover from crashes in C code.
+func CFree(free func() int, mapErr func(code int) error) interface{ Close() error } {
+	return cFreeCloser{free: free, mapErr: mapErr}
+}
+
+type cFreeCloser struct {
+	free   func() int
+	mapErr func(code int) error
+}
+
+func (closer cFreeCloser) Close() (returnedErr error) {
+	defer recoverAsError(&returnedErr)
+
+	code := closer.free()
+	if code == 0 {
+		return nil
+	}
+
+	if closer.mapErr != nil {
+		if err := closer.mapErr(code); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("C error code %d", code)
+}
+
+// Optional returns a closer for a resource that may be nil, such as a subsystem that is only
+// created when a feature flag is enabled. If the resource is nil (including a nil pointer of a
+// concrete type stored in the interface), the returned closer does nothing. Otherwise, it closes
+// the resource.
+//
+// This lets you register optional resources without wrapping each registration in a nil check:
+//
+//	frame.Add(errclose.Optional(cache), "cache")
+func Optional(resource interface{ Close() error }) interface{ Close() error } {
+	return optionalCloser{resource: resource}
+}
+
+type optionalCloser struct {
+	resource interface{ Close() error }
+}
+
+func (closer optionalCloser) Close() error {
+	if isNil(closer.resource) {
+		return nil
+	}
+	return closer.resource.Close()
+}
+
+// isNil returns true if the given resource is nil, or if it's an interface holding a nil value of
+// a nilable kind (pointer, map, slice, func, channel or interface).
+func isNil(resource any) bool {
+	if resource == nil {
+		return true
+	}
+
+	value := reflect.ValueOf(resource)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
+
+// Via returns a closer that closes the given resource by passing the close call to the given run
+// function, and waiting for it to complete. This is meant for thread-affine resources that must be
+// closed on a specific goroutine or OS thread, such as some GUI and driver handles, where run
+// schedules the task on that goroutine:
+//
+//	frame.Add(errclose.Via(uiThread.Run, window), "window")
+//
+// The run function may call the task directly, or on another goroutine, but it must call it
+// exactly once, since the closer blocks until the task has completed. If the resource's Close
+// method panics, the closer recovers the panic on the goroutine where it ran, and returns it as an
+// error.
+func Via(run func(task func()), resource interface{ Close() error }) interface{ Close() error } {
+	return viaCloser{run: run, resource: resource}
+}
+
+type viaCloser struct {
+	run      func(task func())
+	resource interface{ Close() error }
+}
+
+func (closer viaCloser) Close() error {
+	done := make(chan error, 1)
+	closer.run(func() {
+		var closeErr error
+		defer func() { done <- closeErr }()
+		defer recoverAsError(&closeErr)
+
+		closeErr = closer.resource.Close()
+	})
+	return <-done
+}
+
+// Once returns a closer that closes the given resource at most once. Later calls to Close return
+// the error from the first call. This is for resources that are closed both on an early path and
+// in a blanket defer, where the early close's error is not handled, so the deferred call should
+// still report it:
+//
+//	conn := errclose.Once(rawConn)
+//	defer errclose.Close(conn, &returnedErr, "connection")
+//
+//	if handshakeFailed {
+//		// Free the connection right away, the deferred close reports any close error
+//		_ = conn.Close()
+//		return retryHandshake(ctx)
+//	}
+//
+// The returned closer is safe for concurrent use. If the early close does handle its error, use
+// [errclose.OnceThenNil] instead, since the deferred call would otherwise report the same error a
+// second time.
+func Once(resource interface{ Close() error }) interface{ Close() error } {
+	return &onceCloser{resource: resource, once: sync.Once{}, closeErr: nil, repeatErr: true}
+}
+
+// OnceThenNil returns a closer that closes the given resource at most once, like [errclose.Once],
+// but where later calls to Close return nil. Use this when the first close reports its error
+// elsewhere, so that the error isn't reported twice:
+//
+//	conn := errclose.OnceThenNil(rawConn)
+//	defer errclose.Close(conn, &returnedErr, "connection")
+//
+//	if handshakeFailed {
+//		return conn.Close() // The deferred close doesn't close again, or repeat the error
+//	}
+func OnceThenNil(resource interface{ Close() error }) interface{ Close() error } {
+	return &onceCloser{resource: resource, once: sync.Once{}, closeErr: nil, repeatErr: false}
+}
+
+type onceCloser struct {
+	resource  interface{ Close() error }
+	once      sync.Once
+	closeErr  error
+	repeatErr bool
+}
+
+func (closer *onceCloser) Close() error {
+	first := false
+	closer.once.Do(func() {
+		first = true
+		closer.closeErr = closer.resource.Close()
+	})
+
+	if first || closer.repeatErr {
+		return closer.closeErr
+	}
+	return nil
+}
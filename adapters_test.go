@@ -0,0 +1,357 @@
+package errclose_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestIdleConnections(t *testing.T) {
+	transport := &mockTransport{closeIdleConnectionsWasCalled: false}
+	client := &http.Client{Transport: transport}
+
+	useClient := func() (returnedErr error) {
+		defer errclose.Close(errclose.IdleConnections(client), &returnedErr, "HTTP client")
+		return nil
+	}
+
+	err := useClient()
+	assertEqual(t, err, nil, "error")
+	assertEqual(
+		t,
+		transport.closeIdleConnectionsWasCalled,
+		true,
+		"transport.closeIdleConnectionsWasCalled",
+	)
+}
+
+func TestIdleConnectionsPanic(t *testing.T) {
+	panicErr := errors.New("transport broken")
+	client := panickingIdleCloser{panicValue: panicErr}
+
+	useClient := func() (returnedErr error) {
+		defer errclose.Close(errclose.IdleConnections(client), &returnedErr, "HTTP client")
+		return nil
+	}
+
+	err := useClient()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close HTTP client: panic: transport broken",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, panicErr), true, "errors.Is result")
+}
+
+func TestStop(t *testing.T) {
+	ticker := time.NewTicker(time.Hour)
+	timer := time.NewTimer(time.Hour)
+	watcherStopped := false
+
+	useTimers := func() (returnedErr error) {
+		defer errclose.Close(errclose.Stop(ticker), &returnedErr, "ticker")
+		defer errclose.Close(errclose.StopTimer(timer), &returnedErr, "timer")
+		defer errclose.Close(
+			errclose.StopFunc(func() bool {
+				watcherStopped = true
+				return true
+			}),
+			&returnedErr,
+			"context watcher",
+		)
+		return nil
+	}
+
+	err := useTimers()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, timer.Stop(), false, "timer.Stop() after errclose.StopTimer")
+	assertEqual(t, watcherStopped, true, "watcherStopped")
+}
+
+func TestStopPanic(t *testing.T) {
+	useTicker := func() (returnedErr error) {
+		defer errclose.Close(
+			errclose.Stop(panickingStopper{panicValue: "stopped twice"}),
+			&returnedErr,
+			"ticker",
+		)
+		return fallibleOperation()
+	}
+
+	err := useTicker()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close ticker: panic: stopped twice)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+}
+
+func TestCFree(t *testing.T) {
+	errHandleBusy := errors.New("handle busy")
+	mapErr := func(code int) error {
+		if code == 2 {
+			return errHandleBusy
+		}
+		return nil
+	}
+
+	freeWithCode := func(code int) (returnedErr error) {
+		defer errclose.Close(
+			errclose.CFree(func() int { return code }, mapErr),
+			&returnedErr,
+			"C handle",
+		)
+		return nil
+	}
+
+	err := freeWithCode(0)
+	assertEqual(t, err, nil, "error for code 0")
+
+	err = freeWithCode(2)
+	assertEqual(t, err.Error(), "failed to close C handle: handle busy", "error string for code 2")
+	assertEqual(t, errors.Is(err, errHandleBusy), true, "errors.Is result for code 2")
+
+	err = freeWithCode(5)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close C handle: C error code 5",
+		"error string for code 5",
+	)
+}
+
+func TestOptional(t *testing.T) {
+	var nilFile *mockFile
+	file := openFileWithCloseError()
+
+	var frame errclose.Frame
+	frame.Add(errclose.Optional(nil), "nil interface")
+	frame.Add(errclose.Optional(nilFile), "nil pointer")
+	frame.Add(errclose.Optional(file), "file")
+
+	err := frame.Err()
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+}
+
+func TestVia(t *testing.T) {
+	tasks := make(chan func())
+	threadDone := make(chan struct{})
+	go func() {
+		defer close(threadDone)
+		for task := range tasks {
+			task()
+		}
+	}()
+	runOnThread := func(task func()) { tasks <- task }
+
+	file := openFileWithCloseError()
+	panicking := closerFunc(func() error { panic("handle destroyed twice") })
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.Close(errclose.Via(runOnThread, file), &returnedErr, "file")
+		defer errclose.Close(errclose.Via(runOnThread, panicking), &returnedErr, "window")
+		return nil
+	}
+
+	err := useFiles()
+	close(tasks)
+	<-threadDone
+
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close window: panic: handle destroyed twice "+
+			"(and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+}
+
+type mockTransport struct {
+	closeIdleConnectionsWasCalled bool
+}
+
+func (transport *mockTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (transport *mockTransport) CloseIdleConnections() {
+	transport.closeIdleConnectionsWasCalled = true
+}
+
+type panickingIdleCloser struct {
+	panicValue any
+}
+
+func (closer panickingIdleCloser) CloseIdleConnections() {
+	panic(closer.panicValue)
+}
+
+type panickingStopper struct {
+	panicValue any
+}
+
+func (stopper panickingStopper) Stop() {
+	panic(stopper.panicValue)
+}
+
+func TestOnce(t *testing.T) {
+	closeCalls := 0
+	closer := errclose.Once(closerFunc(func() error {
+		closeCalls++
+		return errors.New("close error")
+	}))
+
+	assertEqual(t, closer.Close().Error(), "close error", "first close error")
+	assertEqual(t, closer.Close().Error(), "close error", "second close error")
+	assertEqual(t, closeCalls, 1, "closeCalls")
+}
+
+func TestOnceThenNil(t *testing.T) {
+	closeCalls := 0
+	closer := errclose.OnceThenNil(closerFunc(func() error {
+		closeCalls++
+		return errors.New("close error")
+	}))
+
+	assertEqual(t, closer.Close().Error(), "close error", "first close error")
+	assertEqual(t, closer.Close(), nil, "second close error")
+	assertEqual(t, closeCalls, 1, "closeCalls")
+}
+
+func TestNoError(t *testing.T) {
+	cache := &voidCloserMock{closeWasCalled: false, panicValue: nil}
+
+	useCache := func() (returnedErr error) {
+		defer errclose.Close(errclose.NoError(cache), &returnedErr, "cache")
+		return nil
+	}
+
+	err := useCache()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, cache.closeWasCalled, true, "closeWasCalled")
+}
+
+func TestCloseNoError(t *testing.T) {
+	cache := &voidCloserMock{closeWasCalled: false, panicValue: "cache corrupted"}
+
+	useCache := func() (returnedErr error) {
+		defer errclose.CloseNoError(cache, &returnedErr, "cache")
+		return fallibleOperation()
+	}
+
+	err := useCache()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close cache: panic: cache corrupted)",
+		"error string",
+	)
+	assertEqual(t, cache.closeWasCalled, true, "closeWasCalled")
+}
+
+func TestCloseNoErrorWithNilResource(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceStrict)
+
+	var cache *voidCloserMock
+	var err error
+	errclose.CloseNoError(cache, &err, "cache")
+	assertEqual(t, err.Error(), "failed to close cache: nil resource", "error string")
+
+	err = errclose.NoError(nil).Close()
+	assertEqual(t, err, errclose.ErrNilResource, "close error from NoError(nil)")
+}
+
+func TestCloseNoErrorDefaults(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	var alsoReported []string
+	errclose.SetDefaultsFor[*voidCloserMock](
+		errclose.Also(func(resourceName string, _ error) {
+			alsoReported = append(alsoReported, resourceName)
+		}),
+	)
+
+	var err error
+	errclose.CloseNoError(
+		&voidCloserMock{closeWasCalled: false, panicValue: "cache corrupted"},
+		&err,
+		"cache",
+	)
+	assertEqual(t, alsoReported, []string{"cache"}, "resources reported to Also")
+}
+
+type voidCloserMock struct {
+	closeWasCalled bool
+	panicValue     any
+}
+
+func (mock *voidCloserMock) Close() {
+	mock.closeWasCalled = true
+	if mock.panicValue != nil {
+		panic(mock.panicValue)
+	}
+}
+
+func TestStopper(t *testing.T) {
+	stopErr := errors.New("worker busy")
+	worker := teardownMock{err: stopErr}
+
+	useWorker := func() (returnedErr error) {
+		defer errclose.Close(errclose.Stopper(worker), &returnedErr, "worker")
+		return fallibleOperation()
+	}
+
+	err := useWorker()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to stop worker: worker busy)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, stopErr), true, "errors.Is result")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.Err, stopErr, "CloseError.Err")
+}
+
+func TestDisconnecter(t *testing.T) {
+	client := teardownMock{err: errors.New("connection reset")}
+
+	var frame errclose.Frame
+	frame.Add(errclose.Disconnecter(client), "client")
+
+	var err error
+	frame.CloseAll(&err)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to disconnect client: connection reset",
+		"error string",
+	)
+}
+
+func TestStopperWithoutError(t *testing.T) {
+	err := errclose.Stopper(teardownMock{err: nil}).Close()
+	assertEqual(t, err, nil, "error")
+}
+
+type teardownMock struct {
+	err error
+}
+
+func (mock teardownMock) Stop() error {
+	return mock.err
+}
+
+func (mock teardownMock) Disconnect() error {
+	return mock.err
+}
@@ -0,0 +1,117 @@
+// Package analyzer provides a static analyzer that finds close errors that are silently dropped,
+// and suggests handling them with [hermannm.dev/errclose].
+//
+// The analyzer is compatible with the [golang.org/x/tools/go/analysis] framework, so it can be
+// run with go vet, through the errclosevet command in this module:
+//
+//	go install hermannm.dev/errclose/analyzer/cmd/errclosevet@latest
+//	go vet -vettool=$(which errclosevet) ./...
+//
+// It can also be used as a plugin for linters built on the framework, such as golangci-lint.
+package analyzer
+
+import (
+	"errors"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer reports calls to Close methods where the returned error is discarded, either in a defer
+// statement or as a standalone statement:
+//
+//	defer file.Close()
+//	file.Close()
+//
+// Explicitly discarded errors (_ = file.Close()) are not reported, since they show that ignoring
+// the error was a choice.
+//
+// It also reports deferred errclose calls that are given a pointer to a local error variable,
+// instead of a named return value of the enclosing function. The close error is then set on the
+// local variable after the function has returned, so it's silently dropped:
+//
+//	func example() error {
+//		var err error
+//		defer errclose.Close(file, &err, "file") // Reported
+//		// ...
+//	}
+var Analyzer = &analysis.Analyzer{
+	Name: "errclose",
+	Doc: "reports Close calls where the returned error is discarded, and deferred errclose " +
+		"calls with pointers to local errors",
+	URL:      "https://pkg.go.dev/hermannm.dev/errclose/analyzer",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect, ok := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	if !ok {
+		return nil, errors.New("missing result from inspect analyzer")
+	}
+
+	nodeFilter := []ast.Node{(*ast.DeferStmt)(nil), (*ast.ExprStmt)(nil)}
+	inspect.WithStack(nodeFilter, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch statement := node.(type) {
+		case *ast.DeferStmt:
+			checkIgnoredClose(pass, statement.Call, true)
+			checkDeferredErrorPointers(pass, statement, stack)
+		case *ast.ExprStmt:
+			if call, ok := statement.X.(*ast.CallExpr); ok {
+				checkIgnoredClose(pass, call, false)
+			}
+		}
+		return true
+	})
+
+	return nil, nil //nolint:nilnil // The analyzer has no result
+}
+
+func checkIgnoredClose(pass *analysis.Pass, call *ast.CallExpr, deferred bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Close" || len(call.Args) != 0 {
+		return
+	}
+	if !returnsOnlyError(pass.TypesInfo.TypeOf(selector)) {
+		return
+	}
+
+	resource := types.ExprString(selector.X)
+	if deferred {
+		pass.Reportf(
+			call.Pos(),
+			"error from deferred %s.Close is discarded; "+
+				"use defer errclose.Close(%s, &returnedErr, %q) to handle it",
+			resource,
+			resource,
+			resource,
+		)
+	} else {
+		pass.Reportf(
+			call.Pos(),
+			"error from %s.Close is discarded; "+
+				"use errclose.Close(%s, &returnedErr, %q) to handle it",
+			resource,
+			resource,
+			resource,
+		)
+	}
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func returnsOnlyError(funcType types.Type) bool {
+	signature, ok := funcType.(*types.Signature)
+	if !ok {
+		return false
+	}
+	results := signature.Results()
+	return results.Len() == 1 && types.Identical(results.At(0).Type(), errorType)
+}
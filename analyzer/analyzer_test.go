@@ -0,0 +1,17 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"hermannm.dev/errclose/analyzer"
+)
+
+func TestIgnoredClose(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "ignored")
+}
+
+func TestErrorPointer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "errorpointer")
+}
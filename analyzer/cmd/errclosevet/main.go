@@ -0,0 +1,16 @@
+// Command errclosevet runs the errclose analyzer (see [hermannm.dev/errclose/analyzer]). It can be
+// run standalone on packages, or by go vet:
+//
+//	errclosevet ./...
+//	go vet -vettool=$(which errclosevet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"hermannm.dev/errclose/analyzer"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
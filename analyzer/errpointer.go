@@ -0,0 +1,176 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	errclosePackagePath = "hermannm.dev/errclose"
+	errorPointerParam   = "returnedErr"
+)
+
+// checkDeferredErrorPointers reports errclose calls in the given defer statement (either deferred
+// directly, or called in a deferred function literal) where the returnedErr argument is a pointer
+// to a local variable of the function containing the defer, which is not a named result of that
+// function. Such variables are not read after the function has returned, so the error set by the
+// deferred call is dropped.
+//
+// Variables declared outside the function containing the defer are not reported, since when the
+// defer is in a function literal, the enclosing function is still running when the deferred call
+// sets them, and can return them. Neither are variables declared in a deferred function literal,
+// since calls in the literal are not deferred themselves, so the literal can use the error after
+// the call.
+func checkDeferredErrorPointers(pass *analysis.Pass, deferStmt *ast.DeferStmt, stack []ast.Node) {
+	function := enclosingFunction(stack)
+	if function == nil {
+		return
+	}
+	deferringFunc := deferringFunction{node: function, namedResults: namedResults(pass, function)}
+
+	if funcLit, ok := deferStmt.Call.Fun.(*ast.FuncLit); ok {
+		ast.Inspect(funcLit.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FuncLit:
+				// Nested function literals are not necessarily called by the deferred function
+				return false
+			case *ast.CallExpr:
+				checkErrorPointer(pass, node, deferringFunc, funcLit)
+			}
+			return true
+		})
+		return
+	}
+
+	checkErrorPointer(pass, deferStmt.Call, deferringFunc, nil)
+}
+
+// deferringFunction is the function containing a defer statement.
+type deferringFunction struct {
+	// Either an *ast.FuncDecl or an *ast.FuncLit.
+	node         ast.Node
+	namedResults []types.Object
+}
+
+// checkErrorPointer checks the returnedErr argument of the given call, if it's an errclose call.
+// deferredLit is the deferred function literal that the call is in, or nil if the call itself is
+// deferred.
+func checkErrorPointer(
+	pass *analysis.Pass,
+	call *ast.CallExpr,
+	deferringFunc deferringFunction,
+	deferredLit *ast.FuncLit,
+) {
+	argIndex := errorPointerArgIndex(pass, call)
+	if argIndex == -1 {
+		return
+	}
+
+	unary, ok := ast.Unparen(call.Args[argIndex]).(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		// Pointers passed through from elsewhere can't be checked here
+		return
+	}
+	ident, ok := ast.Unparen(unary.X).(*ast.Ident)
+	if !ok {
+		return
+	}
+	variable := pass.TypesInfo.Uses[ident]
+	if variable == nil {
+		return
+	}
+
+	if !declaredIn(variable, deferringFunc.node) ||
+		(deferredLit != nil && declaredIn(variable, deferredLit)) {
+		return
+	}
+	for _, result := range deferringFunc.namedResults {
+		if variable == result {
+			return
+		}
+	}
+
+	pass.Reportf(
+		unary.Pos(),
+		"&%s is not a pointer to a named return value of the enclosing function, "+
+			"so the error set by the deferred call is dropped",
+		ident.Name,
+	)
+}
+
+// errorPointerArgIndex returns the index of the returnedErr argument in the given call, if it's a
+// call to a function or method in the errclose package. Otherwise, it returns -1.
+func errorPointerArgIndex(pass *analysis.Pass, call *ast.CallExpr) int {
+	var callee *types.Func
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		callee, _ = pass.TypesInfo.Uses[fun].(*types.Func)
+	case *ast.SelectorExpr:
+		callee, _ = pass.TypesInfo.Uses[fun.Sel].(*types.Func)
+	}
+	if callee == nil || callee.Pkg() == nil || callee.Pkg().Path() != errclosePackagePath {
+		return -1
+	}
+
+	signature, ok := callee.Type().(*types.Signature)
+	if !ok {
+		return -1
+	}
+	params := signature.Params()
+	for i := range params.Len() {
+		param := params.At(i)
+		if param.Name() != errorPointerParam || i >= len(call.Args) {
+			continue
+		}
+		if pointer, ok := param.Type().(*types.Pointer); ok &&
+			types.Identical(pointer.Elem(), errorType) {
+			return i
+		}
+	}
+	return -1
+}
+
+// enclosingFunction returns the innermost function declaration or function literal in the given
+// stack, or nil if there is none.
+func enclosingFunction(stack []ast.Node) ast.Node {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch node := stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return node
+		}
+	}
+	return nil
+}
+
+// namedResults returns the named results of the given function declaration or function literal.
+func namedResults(pass *analysis.Pass, function ast.Node) []types.Object {
+	var funcType *ast.FuncType
+	switch function := function.(type) {
+	case *ast.FuncDecl:
+		funcType = function.Type
+	case *ast.FuncLit:
+		funcType = function.Type
+	}
+	if funcType == nil || funcType.Results == nil {
+		return nil
+	}
+
+	var results []types.Object
+	for _, field := range funcType.Results.List {
+		for _, name := range field.Names {
+			if object := pass.TypesInfo.Defs[name]; object != nil {
+				results = append(results, object)
+			}
+		}
+	}
+	return results
+}
+
+// declaredIn returns true if the given variable is declared in the given node, including in its
+// parameters and results if it's a function.
+func declaredIn(variable types.Object, node ast.Node) bool {
+	return variable.Pos() >= node.Pos() && variable.Pos() < node.End()
+}
@@ -0,0 +1,92 @@
+package errorpointer
+
+import (
+	"io"
+
+	"hermannm.dev/errclose"
+)
+
+func namedReturn(closer io.Closer) (returnedErr error) {
+	defer errclose.Close(closer, &returnedErr, "closer")
+	return nil
+}
+
+func localError(closer io.Closer) error {
+	var err error
+	defer errclose.Close(closer, &err, "closer") // want `&err is not a pointer to a named return value of the enclosing function, so the error set by the deferred call is dropped`
+	return err
+}
+
+func localErrorWithNamedReturn(closer io.Closer) (returnedErr error) {
+	var err error
+	defer errclose.Close(closer, &err, "closer") // want `&err is not a pointer`
+	return nil
+}
+
+func deferredClosure(closer io.Closer) (returnedErr error) {
+	var err error
+	defer func() {
+		errclose.Close(closer, &returnedErr, "closer")
+		errclose.Close(closer, &err, "closer") // want `&err is not a pointer`
+	}()
+	return nil
+}
+
+func deferInFuncLit(closers []io.Closer) (returnedErr error) {
+	for _, closer := range closers {
+		func() {
+			defer errclose.Close(closer, &returnedErr, "closer")
+		}()
+	}
+	return returnedErr
+}
+
+func outerLocalInFuncLit(closer io.Closer) error {
+	var err error
+	func() {
+		defer errclose.Close(closer, &err, "closer")
+	}()
+	return err
+}
+
+func funcLitWithLocalError(closer io.Closer) func() error {
+	return func() error {
+		var err error
+		defer errclose.Close(closer, &err, "closer") // want `&err is not a pointer`
+		return err
+	}
+}
+
+func funcLitWithNamedResult(closer io.Closer) func() error {
+	return func() (err error) {
+		defer errclose.Close(closer, &err, "closer")
+		return nil
+	}
+}
+
+func deferredClosureWithOwnError(closer io.Closer) (returnedErr error) {
+	defer func() {
+		var closeErr error
+		errclose.Close(closer, &closeErr, "closer")
+		if closeErr != nil {
+			returnedErr = closeErr
+		}
+	}()
+	return nil
+}
+
+func frameMethod(frame *errclose.Frame) error {
+	var err error
+	defer frame.CloseAll(&err) // want `&err is not a pointer`
+	return nil
+}
+
+func passedPointer(closer io.Closer, returnedErr *error) {
+	defer errclose.Close(closer, returnedErr, "closer")
+}
+
+func notDeferred(closer io.Closer) error {
+	var err error
+	errclose.Close(closer, &err, "closer")
+	return err
+}
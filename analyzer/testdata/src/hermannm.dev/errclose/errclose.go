@@ -0,0 +1,8 @@
+// Package errclose is a stub of hermannm.dev/errclose for testing the analyzer.
+package errclose
+
+func Close(resource interface{ Close() error }, returnedErr *error, resourceName string) {}
+
+type Frame struct{}
+
+func (frame *Frame) CloseAll(returnedErr *error) {}
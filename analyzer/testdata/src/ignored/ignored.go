@@ -0,0 +1,45 @@
+package ignored
+
+import (
+	"io"
+	"os"
+)
+
+func deferredClose() error {
+	file, err := os.Open("/some/path")
+	if err != nil {
+		return err
+	}
+	defer file.Close() // want `error from deferred file.Close is discarded; use defer errclose.Close\(file, &returnedErr, "file"\) to handle it`
+
+	return nil
+}
+
+func statementClose(closer io.Closer) {
+	closer.Close() // want `error from closer.Close is discarded; use errclose.Close\(closer, &returnedErr, "closer"\) to handle it`
+}
+
+func explicitlyDiscarded(closer io.Closer) {
+	_ = closer.Close()
+}
+
+func checked(closer io.Closer) error {
+	return closer.Close()
+}
+
+type noErrorCloser struct{}
+
+func (noErrorCloser) Close() {}
+
+func closeWithoutError(closer noErrorCloser) {
+	defer closer.Close()
+	closer.Close()
+}
+
+type closerWithArgs struct{}
+
+func (closerWithArgs) Close(force bool) error { return nil }
+
+func closeWithArgs(closer closerWithArgs) {
+	closer.Close(true)
+}
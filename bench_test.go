@@ -0,0 +1,199 @@
+package errclose_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// The package documents that the no-error path of Close and Closef does not allocate, so these
+// tests guard against regressions.
+
+func TestCloseDoesNotAllocate(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.Close(file, &err, "file")
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func TestClosefDoesNotAllocate(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.Closef(file, &err, "file %d", 1)
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func TestCloseWithIgnoreDoesNotAllocate(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.Close(file, &err, "file", errclose.Ignore(os.ErrClosed))
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func TestCloseStringerDoesNotAllocate(t *testing.T) {
+	file := openFileWithoutCloseError()
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.CloseStringer(file, &err, addr)
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func TestCloseNameFuncDoesNotAllocate(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.CloseNameFunc(file, &err, func() string { return "file" })
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func TestCloseGDoesNotAllocate(t *testing.T) {
+	handle := valueHandle{id: 1, fail: false}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.CloseG(handle, &err, "handle")
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func TestFrameDoesNotAllocateWhenReused(t *testing.T) {
+	file := openFileWithoutCloseError()
+	var frame errclose.Frame
+
+	allocs := testing.AllocsPerRun(100, func() {
+		frame.Add(file, "file")
+		_ = frame.Err()
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
+
+func BenchmarkFrameReused(b *testing.B) {
+	files := make([]*mockFile, 100)
+	for i := range files {
+		files[i] = openFileWithoutCloseError()
+	}
+	var frame errclose.Frame
+	b.ReportAllocs()
+
+	for range b.N {
+		for _, file := range files {
+			frame.Add(file, "file")
+		}
+		_ = frame.Err()
+	}
+}
+
+func BenchmarkClose(b *testing.B) {
+	file := openFileWithoutCloseError()
+	b.ReportAllocs()
+
+	for range b.N {
+		var err error
+		errclose.Close(file, &err, "file")
+	}
+}
+
+func BenchmarkClosef(b *testing.B) {
+	file := openFileWithoutCloseError()
+	b.ReportAllocs()
+
+	for range b.N {
+		var err error
+		errclose.Closef(file, &err, "file at path %s", "/example/path")
+	}
+}
+
+func BenchmarkCloseWithCloseError(b *testing.B) {
+	file := openFileWithCloseError()
+	b.ReportAllocs()
+
+	for range b.N {
+		var err error
+		errclose.Close(file, &err, "file")
+	}
+}
+
+func BenchmarkCloseWithExistingError(b *testing.B) {
+	file := openFileWithCloseError()
+	b.ReportAllocs()
+
+	for range b.N {
+		err := errFallibleOperation
+		errclose.Close(file, &err, "file")
+	}
+}
+
+// The errors.Is benchmarks check close errors without printing them, as retry and alerting logic
+// often does, which shouldn't pay for formatting the error messages.
+
+func BenchmarkCloseWithExistingErrorIs(b *testing.B) {
+	file := openFileWithCloseError()
+	b.ReportAllocs()
+
+	for range b.N {
+		err := errFallibleOperation
+		errclose.Close(file, &err, "file")
+		if !errors.Is(err, file.closeError) {
+			b.Fatal("expected close error")
+		}
+	}
+}
+
+func BenchmarkCloseWithRetryErrorIs(b *testing.B) {
+	file := openFileWithCloseError()
+	retry := errclose.Retry{Attempts: 2, Backoff: 0, If: func(error) bool { return true }}
+	b.ReportAllocs()
+
+	for range b.N {
+		var err error
+		errclose.CloseWithRetry(file, &err, "file", retry)
+		if !errors.Is(err, file.closeError) {
+			b.Fatal("expected close error")
+		}
+	}
+}
+
+func BenchmarkWrapfErrorIs(b *testing.B) {
+	b.ReportAllocs()
+
+	for range b.N {
+		err := errFallibleOperation
+		errclose.Wrapf(&err, "loading config from %s", "/example/path")
+		if !errors.Is(err, errFallibleOperation) {
+			b.Fatal("expected wrapped error")
+		}
+	}
+}
+
+func BenchmarkCloseValue(b *testing.B) {
+	handle := valueHandle{id: 1, fail: false}
+	for b.Loop() {
+		var err error
+		errclose.Close(handle, &err, "handle")
+	}
+}
+
+func BenchmarkCloseGValue(b *testing.B) {
+	handle := valueHandle{id: 1, fail: false}
+	for b.Loop() {
+		var err error
+		errclose.CloseG(handle, &err, "handle")
+	}
+}
@@ -0,0 +1,86 @@
+package errclose
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var benignErrors struct {
+	lock     sync.Mutex
+	matchers atomic.Pointer[[]func(closeErr error) bool]
+}
+
+// RegisterBenign registers a function for recognizing close errors that are known to be harmless,
+// so that they are dropped by every call in the package that takes options, as if the call had
+// been given [errclose.IgnoreIf] with the function. This lets an application opt into suppressing
+// well-known noise once, instead of listing the same errors at every call site. The package has
+// built-in matchers for common resource kinds, which you can register directly:
+//
+//	errclose.RegisterBenign(errclose.FileProfile)
+//	errclose.RegisterBenign(errclose.NetProfile)
+//
+// RegisterBenign can be called several times, and a close error is dropped if any of the
+// registered functions return true for it. Like errors dropped by IgnoreIf, benign errors are
+// still passed to the observer (see [errclose.SetObserver]), and counted as ignored by the metrics
+// (see [errclose.SetMetrics]).
+//
+// The registered functions may be called concurrently, if resources are closed concurrently. To
+// remove registered functions (in tests), see [errclose.SaveConfig].
+func RegisterBenign(match func(closeErr error) bool) {
+	benignErrors.lock.Lock()
+	defer benignErrors.lock.Unlock()
+
+	var matchers []func(closeErr error) bool
+	if registered := benignErrors.matchers.Load(); registered != nil {
+		matchers = slices.Clone(*registered)
+	}
+	matchers = append(matchers, match)
+	benignErrors.matchers.Store(&matchers)
+}
+
+// isBenign returns true if any of the functions registered with [errclose.RegisterBenign] match
+// the given close error.
+func isBenign(closeErr error) bool {
+	registered := benignErrors.matchers.Load()
+	if registered == nil {
+		return false
+	}
+	for _, match := range *registered {
+		if match(closeErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileProfile matches close errors from files that are harmless, for use with
+// [errclose.RegisterBenign] or [errclose.IgnoreIf]. It matches errors caused by the file already
+// having been closed, which is common when a read-only file is closed explicitly and then again in
+// a deferred call:
+//   - [os.ErrClosed] ("file already closed")
+//   - [errclose.ErrAlreadyClosed] (from [errclose.StandardCloseErrors])
+//
+// Be careful with registering this for files that are written to: the first close of such a file
+// may report a failed write, but later closes only report that the file is already closed.
+func FileProfile(closeErr error) bool {
+	return errors.Is(closeErr, os.ErrClosed) || errors.Is(closeErr, ErrAlreadyClosed)
+}
+
+// NetProfile matches close errors from network connections that are harmless, for use with
+// [errclose.RegisterBenign] or [errclose.IgnoreIf]. It matches errors caused by the connection
+// already having been closed, on either end:
+//   - [net.ErrClosed] ("use of closed network connection")
+//   - [io.ErrClosedPipe] (from connections created by [net.Pipe])
+//   - [errclose.ErrAlreadyClosed] (from [errclose.StandardCloseErrors])
+//   - Broken pipe (EPIPE) and connection reset (ECONNRESET) errors, on Unix
+func NetProfile(closeErr error) bool {
+	return errors.Is(closeErr, net.ErrClosed) ||
+		errors.Is(closeErr, io.ErrClosedPipe) ||
+		errors.Is(closeErr, ErrAlreadyClosed) ||
+		isPeerClosedErrno(closeErr)
+}
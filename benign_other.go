@@ -0,0 +1,9 @@
+//go:build !unix
+
+package errclose
+
+// isPeerClosedErrno is only implemented on Unix (see benign_unix.go), as the error codes differ
+// on other platforms.
+func isPeerClosedErrno(error) bool {
+	return false
+}
@@ -0,0 +1,96 @@
+package errclose_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestRegisterBenign(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	var observed []string
+	errclose.SetObserver(func(resourceName string, _ error) {
+		observed = append(observed, resourceName)
+	})
+	errclose.RegisterBenign(func(closeErr error) bool {
+		return closeErr.Error() == "benign error"
+	})
+
+	var err error
+	errclose.Close(closerFunc(func() error { return errors.New("benign error") }), &err, "client")
+	assertEqual(t, err, nil, "error with benign close error")
+	assertEqual(t, observed, []string{"client"}, "observed resource names")
+
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestRegisterBenignIsRestoredByConfig(t *testing.T) {
+	config := errclose.SaveConfig()
+	errclose.RegisterBenign(func(error) bool { return true })
+	config.Restore()
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestFileProfile(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.RegisterBenign(errclose.FileProfile)
+
+	file, err := os.CreateTemp(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var closeErr error
+	errclose.Close(file, &closeErr, "file")
+	assertEqual(t, closeErr, nil, "error from closing file twice")
+
+	errclose.Close(openFileWithCloseError(), &closeErr, "other file")
+	assertEqual(t, closeErr.Error(), "failed to close other file: close error", "error string")
+}
+
+func TestNetProfile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		closeErr error
+		benign   bool
+	}{
+		{"closed connection", fmt.Errorf("close tcp: %w", net.ErrClosed), true},
+		{"closed pipe", io.ErrClosedPipe, true},
+		{"already closed", errclose.StandardCloseErrors(net.ErrClosed), true},
+		{"other error", errors.New("connection refused"), false},
+		{"file error", os.ErrClosed, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assertEqual(t, errclose.NetProfile(testCase.closeErr), testCase.benign, "NetProfile")
+		})
+	}
+}
+
+func TestNetProfileWithIgnoreIf(t *testing.T) {
+	client, server := net.Pipe()
+	if err := server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	errclose.Close(client, &err, "connection", errclose.IgnoreIf(errclose.NetProfile))
+	assertEqual(t, err, nil, "error from closing connection twice")
+}
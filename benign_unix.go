@@ -0,0 +1,14 @@
+//go:build unix
+
+package errclose
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isPeerClosedErrno returns true if the given error is an error code for writing to a connection
+// that the peer has closed, which is common when closing a connection flushes buffered data.
+func isPeerClosedErrno(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
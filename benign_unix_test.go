@@ -0,0 +1,27 @@
+//go:build unix
+
+package errclose_test
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestNetProfileWithErrno(t *testing.T) {
+	assertEqual(
+		t,
+		errclose.NetProfile(fmt.Errorf("write tcp: %w", syscall.EPIPE)),
+		true,
+		"NetProfile with EPIPE",
+	)
+	assertEqual(
+		t,
+		errclose.NetProfile(fmt.Errorf("write tcp: %w", syscall.ECONNRESET)),
+		true,
+		"NetProfile with ECONNRESET",
+	)
+	assertEqual(t, errclose.NetProfile(syscall.EBADF), false, "NetProfile with EBADF")
+}
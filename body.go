@@ -0,0 +1,131 @@
+package errclose
+
+import (
+	"io"
+)
+
+// DrainAndClose reads the rest of the given body and discards it, then closes the body. This is
+// meant for HTTP response bodies, since the HTTP client can only reuse a keep-alive connection if
+// the previous response body was read to the end before it was closed:
+//
+//	func fetch(client *http.Client, url string) (returnedErr error) {
+//		response, err := client.Get(url)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.DrainAndClose(response.Body, &returnedErr, "response body")
+//
+//		// Read some of response.Body
+//	}
+//
+// By default, the whole remaining body is read. To avoid reading large bodies just to reuse a
+// connection, pass [errclose.MaxDrain] to limit how many bytes are read. If the body is longer than
+// the limit, the rest is left unread, and the body is closed without error (the connection is then
+// simply not reused).
+//
+// Errors from reading and closing the body are handled in the same way as [errclose.Close] handles
+// close errors, on the following formats:
+//
+//	failed to drain <resourceName>: <read error>
+//	failed to close <resourceName>: <close error>
+//
+// The body is closed also if reading it fails. Options passed to DrainAndClose apply to both read
+// and close errors.
+//
+// If the body is nil, the close error is [errclose.ErrNilResource].
+func DrainAndClose(
+	body io.ReadCloser,
+	returnedErr *error,
+	resourceName string,
+	options ...Option,
+) {
+	drainAndClose(body, returnedErr, resourceName, options, 1)
+}
+
+// drainAndClose implements [errclose.DrainAndClose]. callerSkip is the number of stack frames
+// between this function and the caller of the errclose function.
+func drainAndClose(
+	body io.ReadCloser,
+	returnedErr *error,
+	resourceName string,
+	options []Option,
+	callerSkip int,
+) {
+	if isNilResource(body) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	lists := withDefaults(body, options)
+	var reader io.Reader = body
+	if maxDrain := maxDrainBytes(lists); maxDrain > 0 {
+		reader = io.LimitReader(body, maxDrain)
+	}
+	recordCloseAttempt(resourceName)
+	if _, drainErr := io.Copy(io.Discard, reader); drainErr != nil {
+		handleTeardownErrorWithOptions(
+			returnedErr,
+			drainErr,
+			"drain",
+			resourceName,
+			lists,
+			nil,
+			callerSkip+1,
+		)
+	}
+
+	closeResource(body, returnedErr, resourceName, options, callerSkip+1)
+}
+
+// Drain reads and discards the rest of the given stream, up to maxBytes, then closes it. This is
+// for protocols where a stream should be consumed before it's closed, such as multipart parts and
+// chunked decoders:
+//
+//	part, err := multipartReader.NextPart()
+//	if err != nil {
+//		return err
+//	}
+//	defer errclose.Drain(part, &returnedErr, "multipart part", 1<<20)
+//
+// This works like [errclose.DrainAndClose] with [errclose.MaxDrain], with read and close errors on
+// the following formats:
+//
+//	failed to drain <resourceName>: <read error>
+//	failed to close <resourceName>: <close error>
+//
+// If maxBytes is 0 or less, the whole remaining stream is read, also if a MaxDrain limit is set in
+// the defaults (see [errclose.SetDefaults]).
+func Drain(stream io.ReadCloser, returnedErr *error, resourceName string, maxBytes int64) {
+	drainAndClose(stream, returnedErr, resourceName, []Option{MaxDrain(maxBytes)}, 1)
+}
+
+// MaxDrain returns an option that limits how many bytes [errclose.DrainAndClose] reads from the
+// body before closing it. Other functions in the package ignore this option. If multiple MaxDrain
+// options are given, the last one is used.
+//
+// If bytes is 0 or less, the whole body is read. This lets you override a limit from
+// [errclose.SetDefaults] or [errclose.SetDefaultsFor] for a single call.
+func MaxDrain(bytes int64) Option {
+	if bytes <= 0 {
+		bytes = unlimitedDrain
+	}
+	return Option{settings: &optionSettings{maxDrain: bytes}}
+}
+
+// unlimitedDrain is the limit set by [errclose.MaxDrain] for reading the whole body, to tell it
+// apart from 0, which means that no MaxDrain option was given.
+const unlimitedDrain = -1
+
+// maxDrainBytes returns the limit from the last [errclose.MaxDrain] option in the given options,
+// 0 if there is none, or unlimitedDrain if the whole body should be read.
+func maxDrainBytes(options optionLists) int64 {
+	var maxDrain int64
+	for _, list := range options {
+		for _, option := range list {
+			if limit := option.get().maxDrain; limit != 0 {
+				maxDrain = limit
+			}
+		}
+	}
+	return maxDrain
+}
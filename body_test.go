@@ -0,0 +1,155 @@
+package errclose_test
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"hermannm.dev/errclose"
+)
+
+func TestDrainAndClose(t *testing.T) {
+	reader := strings.NewReader("response body")
+	body := &mockBody{Reader: reader, closed: false}
+
+	fetch := func() (returnedErr error) {
+		defer errclose.DrainAndClose(body, &returnedErr, "response body")
+		return nil
+	}
+
+	err := fetch()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, body.closed, true, "closed")
+	assertEqual(t, reader.Len(), 0, "unread bytes")
+}
+
+func TestDrainAndCloseWithMaxDrain(t *testing.T) {
+	reader := strings.NewReader("response body")
+	body := &mockBody{Reader: reader, closed: false}
+
+	fetch := func() (returnedErr error) {
+		defer errclose.DrainAndClose(body, &returnedErr, "response body", errclose.MaxDrain(4))
+		return nil
+	}
+
+	err := fetch()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, body.closed, true, "closed")
+	assertEqual(t, reader.Len(), 9, "unread bytes")
+}
+
+func TestDrainAndCloseWithReadError(t *testing.T) {
+	readErr := errors.New("read error")
+	body := &mockBody{Reader: iotest.ErrReader(readErr), closed: false}
+
+	fetch := func() (returnedErr error) {
+		defer errclose.DrainAndClose(body, &returnedErr, "response body")
+		return fallibleOperation()
+	}
+
+	err := fetch()
+	assertEqual(t, body.closed, true, "closed")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to drain response body: read error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, readErr), true, "errors.Is(err, readErr)")
+}
+
+func TestDrainAndCloseReadErrorWithOptions(t *testing.T) {
+	readErr := errors.New("read error")
+	body := &mockBody{Reader: iotest.ErrReader(readErr), closed: false}
+	var fallbackErr error
+
+	var err error
+	_, _, line, _ := runtime.Caller(0)
+	errclose.DrainAndClose(
+		body,
+		&err,
+		"response body",
+		errclose.Opaque(),
+		errclose.WithCaller(),
+		errclose.WithFallback(func(drainErr error) error {
+			fallbackErr = drainErr
+			return nil
+		}),
+	)
+
+	assertEqual(t, err.Error(), "failed to drain response body: read error", "error string")
+	assertEqual(t, errors.Is(err, readErr), false, "errors.Is(err, readErr) with Opaque")
+	assertEqual(t, fallbackErr, readErr, "error passed to fallback")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As(err, &closeErr)")
+	assertEqual(
+		t,
+		strings.HasSuffix(closeErr.Caller, "body_test.go:"+strconv.Itoa(line+1)),
+		true,
+		"CloseError.Caller ("+closeErr.Caller+")",
+	)
+}
+
+func TestDrainAndCloseReadErrorWithKeepPrimary(t *testing.T) {
+	body := &mockBody{Reader: iotest.ErrReader(errors.New("read error")), closed: false}
+
+	err := errors.New("request failed")
+	errclose.DrainAndClose(body, &err, "response body", errclose.KeepPrimary())
+	assertEqual(t, err.Error(), "request failed", "error string")
+	assertEqual(t, len(errclose.Errors(err)), 1, "number of close errors")
+}
+
+type mockBody struct {
+	io.Reader
+	closed bool
+}
+
+func (body *mockBody) Close() error {
+	body.closed = true
+	return nil
+}
+
+func TestDrain(t *testing.T) {
+	reader := strings.NewReader("multipart part")
+	part := &mockBody{Reader: reader, closed: false}
+
+	var err error
+	errclose.Drain(part, &err, "multipart part", 9)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, part.closed, true, "closed")
+	assertEqual(t, reader.Len(), 5, "unread bytes")
+
+	reader = strings.NewReader("multipart part")
+	part = &mockBody{Reader: reader, closed: false}
+	errclose.Drain(part, &err, "multipart part", 0)
+	assertEqual(t, reader.Len(), 0, "unread bytes without limit")
+}
+
+func TestDrainOverridesDefaultMaxDrain(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaults(errclose.MaxDrain(4))
+
+	reader := strings.NewReader("multipart part")
+	var err error
+	errclose.Drain(&mockBody{Reader: reader, closed: false}, &err, "multipart part", 0)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, reader.Len(), 0, "unread bytes without limit")
+
+	reader = strings.NewReader("response body")
+	errclose.DrainAndClose(&mockBody{Reader: reader, closed: false}, &err, "response body")
+	assertEqual(t, reader.Len(), 9, "unread bytes with default limit")
+
+	reader = strings.NewReader("response body")
+	errclose.DrainAndClose(
+		&mockBody{Reader: reader, closed: false},
+		&err,
+		"response body",
+		errclose.MaxDrain(0),
+	)
+	assertEqual(t, reader.Len(), 0, "unread bytes with default limit overridden")
+}
@@ -0,0 +1,112 @@
+package errclose
+
+// Timeout returns true if the teardown error was caused by a timeout or deadline, for example a
+// flush that didn't finish in time. This is the case if an error in the Err chain is
+// [errclose.ErrCloseTimeout] or [errclose.ErrShutdownDeadlineExceeded], or has a Timeout() bool
+// method that returns true. That covers [context.DeadlineExceeded], [os.ErrDeadlineExceeded],
+// network errors that implement [net.Error], and timeout error codes such as ETIMEDOUT.
+func (err *CloseError) Timeout() bool {
+	return isTimeout(err.Err)
+}
+
+func isTimeout(err error) bool {
+	return anyInErrorTree(err, func(wrapped error) bool {
+		//nolint:errorlint // Checking each error in the tree, so comparing directly
+		if wrapped == ErrCloseTimeout || wrapped == ErrShutdownDeadlineExceeded {
+			return true
+		}
+		//nolint:errorlint // Checking each error in the tree
+		timeout, ok := wrapped.(interface{ Timeout() bool })
+		return ok && timeout.Timeout()
+	})
+}
+
+// Temporary returns true if the teardown error is likely to be transient, so that retrying the
+// operation that produced the resource (or closing it again, if that's safe for the resource) may
+// succeed. This is the case for timeouts (see [CloseError.Timeout]), and for errors in the Err
+// chain with a Temporary() bool method that returns true, such as interrupted system calls
+// (EINTR), unavailable resources (EAGAIN) and reset connections (ECONNRESET).
+//
+// Close errors that are neither timeouts nor temporary should be treated as permanent, e.g. a
+// corrupt file system or a resource that was already closed.
+func (err *CloseError) Temporary() bool {
+	return isTemporary(err.Err)
+}
+
+func isTemporary(err error) bool {
+	if isTimeout(err) {
+		return true
+	}
+	return anyInErrorTree(err, func(wrapped error) bool {
+		//nolint:errorlint // Checking each error in the tree
+		temporary, ok := wrapped.(interface{ Temporary() bool })
+		return ok && temporary.Temporary()
+	})
+}
+
+// Fatal returns true if the close error means that the resource's output is lost or corrupt, as
+// for errors from [errclose.Finalize], or closes with the [errclose.Fatal] option.
+func (err *CloseError) Fatal() bool {
+	return err.fatal
+}
+
+// IsTimeout returns true if the given error contains a [errclose.CloseError] that was caused by a
+// timeout (see [CloseError.Timeout]). This includes close errors that were combined with an
+// existing error, or attached to it with [errclose.KeepPrimary]:
+//
+//	if err := writeReport(path); errclose.IsTimeout(err) {
+//		// Retry
+//	}
+//
+// Only close errors are checked, so IsTimeout returns false if just the existing error is a
+// timeout.
+func IsTimeout(err error) bool {
+	return anyCloseError(err, (*CloseError).Timeout)
+}
+
+// IsTemporary returns true if the given error contains a [errclose.CloseError] that is likely to
+// be transient (see [CloseError.Temporary]). Like [errclose.IsTimeout], it only checks close
+// errors, including ones combined with or attached to an existing error.
+func IsTemporary(err error) bool {
+	return anyCloseError(err, (*CloseError).Temporary)
+}
+
+// IsFatal returns true if the given error contains a fatal [errclose.CloseError] (see
+// [CloseError.Fatal]). Like [errclose.IsTimeout], it only checks close errors, including ones
+// combined with or attached to an existing error.
+func IsFatal(err error) bool {
+	return anyCloseError(err, (*CloseError).Fatal)
+}
+
+func anyCloseError(err error, match func(closeErr *CloseError) bool) bool {
+	return anyInErrorTree(err, func(wrapped error) bool {
+		closeErr, ok := wrapped.(*CloseError) //nolint:errorlint // Checking each error in the tree
+		return ok && match(closeErr)
+	})
+}
+
+// anyInErrorTree returns true if match returns true for the given error, or any error in its tree
+// (following both Unwrap() error and Unwrap() []error, like [errors.Is]). Errors attached with
+// [errclose.KeepPrimary] are also checked, since they aren't part of the unwrap chain.
+func anyInErrorTree(err error, match func(wrapped error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if match(err) {
+		return true
+	}
+
+	switch err := err.(type) { //nolint:errorlint // Walking the tree manually
+	case *attachedCloseError:
+		return anyInErrorTree(err.primary, match) || anyInErrorTree(err.closeErr, match)
+	case interface{ Unwrap() error }:
+		return anyInErrorTree(err.Unwrap(), match)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range err.Unwrap() {
+			if anyInErrorTree(wrapped, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
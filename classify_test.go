@@ -0,0 +1,78 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "try again" }
+func (temporaryError) Temporary() bool { return true }
+
+func TestCloseErrorTimeout(t *testing.T) {
+	testCases := []struct {
+		name      string
+		closeErr  error
+		timeout   bool
+		temporary bool
+	}{
+		{"context deadline", context.DeadlineExceeded, true, true},
+		{"os deadline", fmt.Errorf("flush: %w", os.ErrDeadlineExceeded), true, true},
+		{"close timeout", errclose.StandardCloseErrors(os.ErrDeadlineExceeded), true, true},
+		{"temporary", fmt.Errorf("flush: %w", temporaryError{}), false, true},
+		{"permanent", errors.New("file system corrupt"), false, false},
+		{"already closed", os.ErrClosed, false, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var err error
+			errclose.Close(closerFunc(func() error { return testCase.closeErr }), &err, "file")
+
+			var closeErr *errclose.CloseError
+			assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+			assertEqual(t, closeErr.Timeout(), testCase.timeout, "Timeout()")
+			assertEqual(t, closeErr.Temporary(), testCase.temporary, "Temporary()")
+			assertEqual(t, errclose.IsTimeout(err), testCase.timeout, "IsTimeout")
+			assertEqual(t, errclose.IsTemporary(err), testCase.temporary, "IsTemporary")
+		})
+	}
+}
+
+func TestIsTimeoutWithCombinedError(t *testing.T) {
+	slowFile := closerFunc(func() error { return context.DeadlineExceeded })
+
+	err := errors.New("request failed")
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, errclose.IsTimeout(err), false, "IsTimeout without timeout")
+
+	errclose.Close(slowFile, &err, "slow file")
+	assertEqual(t, errclose.IsTimeout(err), true, "IsTimeout with timeout")
+}
+
+func TestIsTimeoutWithKeepPrimary(t *testing.T) {
+	slowFile := closerFunc(func() error { return context.DeadlineExceeded })
+
+	err := errors.New("request failed")
+	errclose.Close(slowFile, &err, "slow file", errclose.KeepPrimary())
+	assertEqual(t, err.Error(), "request failed", "error string")
+	assertEqual(t, errclose.IsTimeout(err), true, "IsTimeout")
+}
+
+func TestIsTimeoutIgnoresPrimaryError(t *testing.T) {
+	err := error(context.DeadlineExceeded)
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, errclose.IsTimeout(err), false, "IsTimeout")
+	assertEqual(
+		t,
+		errclose.IsTimeout(context.DeadlineExceeded),
+		false,
+		"IsTimeout without close error",
+	)
+}
@@ -0,0 +1,111 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// ToCleanup returns a cleanup function that closes the given resource. This is for APIs that take
+// or return cleanup functions with the func() error signature, such as setup functions that return
+// a cleanup function for the caller to run on shutdown:
+//
+//	func setupDatabase() (cleanup func() error, err error) {
+//		db, err := sql.Open("postgres", dsn)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return errclose.ToCleanup(db, "database"), nil
+//	}
+//
+// If closing the resource fails, the cleanup function returns the close error on the same format
+// as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+func ToCleanup(resource interface{ Close() error }, resourceName string) func() error {
+	return func() (returnedErr error) {
+		Close(resource, &returnedErr, resourceName)
+		return returnedErr
+	}
+}
+
+// DeferCleanup calls the given cleanup function, and handles its error in the same way as
+// [errclose.Close] handles close errors. This is meant for libraries that return a cleanup function
+// instead of a resource with a Close method, such as telemetry exporters and profilers:
+//
+//	func run() (returnedErr error) {
+//		shutdownTracing, err := setupTracing()
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.DeferCleanup(shutdownTracing, &returnedErr, "tracing")
+//
+//		// Run program
+//	}
+//
+// Despite the name, DeferCleanup doesn't defer anything by itself. Like [errclose.Close], you
+// call it in a defer statement.
+//
+// If cleanup is nil, the cleanup error is [errclose.ErrNilResource].
+func DeferCleanup(cleanup func() error, returnedErr *error, resourceName string) {
+	if cleanup == nil {
+		Close(nil, returnedErr, resourceName)
+		return
+	}
+	Close(cleanupCloser(cleanup), returnedErr, resourceName)
+}
+
+// Do calls the given cleanup function, and handles its error in the same way as [errclose.Close]
+// handles close errors. Not every cleanup is closing a resource, so Do takes an action name
+// instead of a resource name, which describes what the cleanup does:
+//
+//	func process(cache *Cache) (returnedErr error) {
+//		defer errclose.Do(cache.Flush, &returnedErr, "flush cache")
+//
+//		// Use cache
+//	}
+//
+// The cleanup error is formatted on the following format, and combined with the error pointed to
+// by returnedErr in the same way as in errclose.Close:
+//
+//	failed to <actionName>: <cleanup error>
+//
+// If cleanup is nil, the cleanup error is [errclose.ErrNilResource].
+//
+// If you want to use format args to format the action name, call [errclose.Dof].
+func Do(cleanup func() error, returnedErr *error, actionName string) {
+	if cleanup == nil {
+		handleNilTeardown(returnedErr, actionName, "")
+		return
+	}
+
+	recordCloseAttempt("")
+	cleanupErr := cleanup()
+	if cleanupErr == nil {
+		return
+	}
+
+	handleTeardownError(returnedErr, cleanupErr, actionName, "")
+}
+
+// Dof works like [errclose.Do], but takes a format string and args to construct the action name,
+// like [errclose.Closef]. The formatting is only performed if there is a cleanup error.
+func Dof(cleanup func() error, returnedErr *error, actionNameFormat string, formatArgs ...any) {
+	if cleanup == nil {
+		handleNilTeardown(returnedErr, fmt.Sprintf(actionNameFormat, formatArgs...), "")
+		return
+	}
+
+	recordCloseAttempt("")
+	cleanupErr := cleanup()
+	if cleanupErr == nil {
+		return
+	}
+
+	actionName := fmt.Sprintf(actionNameFormat, formatArgs...)
+	handleTeardownError(returnedErr, cleanupErr, actionName, "")
+}
+
+type cleanupCloser func() error
+
+func (cleanup cleanupCloser) Close() error {
+	return cleanup()
+}
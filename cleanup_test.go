@@ -0,0 +1,86 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestToCleanup(t *testing.T) {
+	file := openFileWithCloseError()
+	cleanup := errclose.ToCleanup(file, "file")
+	assertEqual(t, file.closeWasCalled, false, "file.closeWasCalled before cleanup")
+
+	err := cleanup()
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled after cleanup")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is result")
+
+	err = errclose.ToCleanup(openFileWithoutCloseError(), "file")()
+	assertEqual(t, err, nil, "error without close error")
+}
+
+func TestDeferCleanup(t *testing.T) {
+	cleanupErr := errors.New("flush failed")
+
+	run := func() (returnedErr error) {
+		defer errclose.DeferCleanup(func() error { return cleanupErr }, &returnedErr, "tracing")
+		return fallibleOperation()
+	}
+
+	err := run()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close tracing: flush failed)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, cleanupErr), true, "errors.Is(cleanupErr)")
+}
+
+func TestDeferCleanupNil(t *testing.T) {
+	run := func() (returnedErr error) {
+		defer errclose.DeferCleanup(nil, &returnedErr, "tracing")
+		return nil
+	}
+
+	err := run()
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is(ErrNilResource)")
+}
+
+func TestDo(t *testing.T) {
+	flushErr := errors.New("disk full")
+
+	process := func() (returnedErr error) {
+		defer errclose.Do(func() error { return flushErr }, &returnedErr, "flush cache")
+		return fallibleOperation()
+	}
+
+	err := process()
+	assertEqual(t, err.Error(), "operation failed (and failed to flush cache: disk full)", "error")
+	assertEqual(t, errors.Is(err, flushErr), true, "errors.Is(flushErr)")
+}
+
+func TestDof(t *testing.T) {
+	process := func() (returnedErr error) {
+		defer errclose.Dof(
+			func() error { return errors.New("disk full") },
+			&returnedErr,
+			"flush cache for tenant %d",
+			42,
+		)
+		return nil
+	}
+
+	err := process()
+	assertEqual(t, err.Error(), "failed to flush cache for tenant 42: disk full", "error string")
+
+	process = func() (returnedErr error) {
+		defer errclose.Dof(func() error { return nil }, &returnedErr, "flush cache %d", 42)
+		return nil
+	}
+
+	err = process()
+	assertEqual(t, err, nil, "error without cleanup error")
+}
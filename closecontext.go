@@ -0,0 +1,278 @@
+package errclose
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// CloseContext closes the given resource with a context, and handles close errors in the same way
+// as [errclose.Close]. This is for resources whose Close method takes a context, such as database
+// pools and message queue clients:
+//
+//	func example(ctx context.Context) (returnedErr error) {
+//		client, err := connect(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.CloseContext(ctx, client, &returnedErr, "client")
+//
+//		// Use client
+//	}
+//
+// The error format is the same as for errclose.Close:
+//
+//	failed to close <resourceName>: <close error>
+//	<existing error> (and failed to close <resourceName>: <close error>)
+//
+// CloseContext takes the same options as errclose.Close (see [errclose.Option]), and applies the
+// defaults from [errclose.SetDefaults] and [errclose.SetDefaultsFor] and the benign errors from
+// [errclose.RegisterBenign] in the same way. For resources with a differently named method, such
+// as Disconnect(ctx) error, use a method value with [errclose.CloseContextFunc].
+//
+// If you want to use format args to format the resource name, call [errclose.CloseContextf].
+func CloseContext(
+	ctx context.Context,
+	resource interface{ Close(context.Context) error },
+	returnedErr *error,
+	resourceName string,
+	options ...Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	lists := withDefaults(resource, options)
+	stats := captureStats(lists)
+	closeErr := resource.Close(ctx)
+	if closeErr == nil {
+		return
+	}
+
+	handleContextTeardownError(ctx, returnedErr, closeErr, "close", resourceName, lists, stats, 1)
+}
+
+// CloseContextf works like [errclose.CloseContext], but takes a format string and args to
+// construct the resource name, like [errclose.Closef]. The formatting is only performed when the
+// name is needed (if there is a close error, or if metrics are enabled), and then at most once.
+//
+// To pass options (see [errclose.Option]), use [errclose.CloseContextfWith].
+func CloseContextf(
+	ctx context.Context,
+	resource interface{ Close(context.Context) error },
+	returnedErr *error,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	closeContextfResource(ctx, resource, returnedErr, nil, resourceNameFormat, formatArgs...)
+}
+
+// CloseContextfWith works like [errclose.CloseContextf], but takes options to change how close
+// errors are handled, like [errclose.CloseContext]. The options come before the format string, so
+// that go vet can check the format args like it does for [fmt.Sprintf] (see
+// [errclose.ClosefWith]).
+func CloseContextfWith(
+	ctx context.Context,
+	resource interface{ Close(context.Context) error },
+	returnedErr *error,
+	options []Option,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	closeContextfResource(ctx, resource, returnedErr, options, resourceNameFormat, formatArgs...)
+}
+
+func closeContextfResource(
+	ctx context.Context,
+	resource interface{ Close(context.Context) error },
+	returnedErr *error,
+	options []Option,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, fmt.Sprintf(resourceNameFormat, formatArgs...))
+		return
+	}
+
+	// The name is formatted at most once, when metrics need it or there is a close error
+	resourceName := ""
+	isFormatted := false
+	if metricsEnabled() {
+		resourceName = fmt.Sprintf(resourceNameFormat, formatArgs...)
+		isFormatted = true
+		recordCloseAttempt(resourceName)
+	}
+	lists := withDefaults(resource, options)
+	stats := captureStats(lists)
+	closeErr := resource.Close(ctx)
+	if closeErr == nil {
+		return
+	}
+
+	if !isFormatted {
+		resourceName = fmt.Sprintf(resourceNameFormat, formatArgs...)
+	}
+	handleContextTeardownError(ctx, returnedErr, closeErr, "close", resourceName, lists, stats, 2)
+}
+
+// CloseContextFunc adapts a close function that takes a context to the interface expected by
+// [errclose.CloseContext]. This lets you pass a method value for resources where the close method
+// has a different name:
+//
+//	defer errclose.CloseContext(
+//		ctx,
+//		errclose.CloseContextFunc(client.Disconnect),
+//		&returnedErr,
+//		"client",
+//	)
+type CloseContextFunc func(ctx context.Context) error
+
+// Close calls the function with the given context.
+func (closeFunc CloseContextFunc) Close(ctx context.Context) error {
+	return closeFunc(ctx)
+}
+
+// CloseContextSetter is an optional interface for resources whose Close method takes no context,
+// but which can respect one internally (for example, database drivers that bound how long Close
+// waits for in-flight queries). When errclose closes a resource with a context available, it calls
+// SetCloseContext with that context right before calling Close.
+//
+// Currently, [ShutdownManager.Shutdown] (and so [errclose.ShutdownGlobal]) and
+// [errclose.CloseAllConcurrent] pass their context to resources that implement this interface.
+type CloseContextSetter interface {
+	SetCloseContext(ctx context.Context)
+}
+
+func setCloseContext(ctx context.Context, resource interface{ Close() error }) {
+	if setter, ok := resource.(CloseContextSetter); ok {
+		setter.SetCloseContext(ctx)
+	}
+}
+
+// AwaitClosed waits for a resource that closes asynchronously to finish closing, and handles its
+// terminal error in the same way as [errclose.Close] handles close errors. This is meant for
+// resources such as watchers and consumers, which stop on their own (or after being told to stop)
+// and signal completion through a Done channel.
+//
+//	func consume(ctx context.Context) (returnedErr error) {
+//		consumer := startConsumer(ctx)
+//		defer errclose.AwaitClosed(ctx, consumer, &returnedErr, "consumer")
+//
+//		// Use consumer
+//	}
+//
+// When the resource's Done channel is closed, AwaitClosed calls Err on the resource. If Err returns
+// a non-nil error, it's combined with the error pointed to by returnedErr, on the same format as in
+// [errclose.Close]:
+//
+//	failed to close <resourceName>: <terminal error>
+//
+// If the given context is canceled before the resource is done, AwaitClosed stops waiting, and uses
+// the context error instead:
+//
+//	failed to close <resourceName>: stopped waiting for close: <context error>
+//
+// If the context deadline was exceeded, the error also matches [errclose.ErrCloseTimeout] with
+// [errors.Is].
+//
+// AwaitClosed takes the same options as [errclose.Close], and applies defaults and benign errors
+// in the same way (like [errclose.CloseContext]).
+func AwaitClosed(
+	ctx context.Context,
+	resource interface {
+		Done() <-chan struct{}
+		Err() error
+	},
+	returnedErr *error,
+	resourceName string,
+	options ...Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	lists := withDefaults(resource, options)
+	stats := captureStats(lists)
+	var err error
+	select {
+	case <-resource.Done():
+		err = resource.Err()
+	case <-ctx.Done():
+		ctxErr := ctx.Err()
+		err = withDeadlineSentinel(
+			lazyErrorf(ctxErr, "stopped waiting for close: %w", ctxErr),
+			ctxErr,
+			ErrCloseTimeout,
+		)
+	}
+	if err != nil {
+		handleContextTeardownError(ctx, returnedErr, err, "close", resourceName, lists, stats, 1)
+	}
+}
+
+// ClosePreparer is an optional interface for resources that want a two-step shutdown: first
+// quiesce (stop accepting work and flush buffered data), then close. When errclose closes a
+// resource with a context available, it calls PrepareClose with that context right before calling
+// Close. Close is called even if PrepareClose fails.
+//
+// Errors from PrepareClose are labeled separately from close errors, on the following format, and
+// combined with other errors in the same way as in [errclose.Close]:
+//
+//	failed to prepare to close <resourceName>: <error>
+//
+// Currently, [ShutdownManager.Shutdown] (and so [errclose.ShutdownGlobal]) and
+// [errclose.CloseAllConcurrent] prepare resources that implement this interface.
+type ClosePreparer interface {
+	PrepareClose(ctx context.Context) error
+}
+
+// closeWithContext closes the given resource like [errclose.Close], but first passes the context
+// to the resource if it implements [errclose.CloseContextSetter] or [errclose.ClosePreparer]. If
+// the resource implements [errclose.Drainable], it's closed with [errclose.CloseDrainable]. The
+// steps are counted as one close attempt in the metrics (see withCloseAttempt).
+func closeWithContext(
+	ctx context.Context,
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	registeredOptions []Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	setCloseContext(ctx, resource)
+	options := contextOptions(ctx)
+	if len(registeredOptions) > 0 {
+		// Copied, so that withCloseAttempt doesn't append to the registered options
+		options = slices.Concat(registeredOptions, options)
+	}
+	options = withCloseAttempt(options, resolveResourceName(resource, resourceName))
+
+	if preparer, ok := resource.(ClosePreparer); ok {
+		if err := preparer.PrepareClose(ctx); err != nil {
+			handleContextTeardownError(
+				ctx,
+				returnedErr,
+				err,
+				"prepare to close",
+				resourceName,
+				withDefaults(resource, options),
+				nil,
+				1,
+			)
+		}
+	}
+
+	if drainable, ok := resource.(Drainable); ok {
+		closeDrainable(ctx, drainable, returnedErr, resourceName, options)
+	} else {
+		closeResource(resource, returnedErr, resourceName, options, 1)
+	}
+}
@@ -0,0 +1,246 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), closeCtxKey{}, "close")
+	client := &contextClient{closeCtxValue: nil, closeErr: errors.New("disconnect failed")}
+
+	useClient := func() (returnedErr error) {
+		defer errclose.CloseContext(ctx, client, &returnedErr, "client")
+		return fallibleOperation()
+	}
+
+	err := useClient()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close client: disconnect failed)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, client.closeErr), true, "errors.Is(closeErr)")
+	assertEqual(t, client.closeCtxValue, "close", "value of context given to Close")
+}
+
+func TestCloseContextf(t *testing.T) {
+	client := &contextClient{closeCtxValue: nil, closeErr: errors.New("disconnect failed")}
+
+	useClient := func() (returnedErr error) {
+		defer errclose.CloseContextf(
+			context.Background(),
+			errclose.CloseContextFunc(client.Disconnect),
+			&returnedErr,
+			"client for %s",
+			"orders",
+		)
+		return nil
+	}
+
+	err := useClient()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close client for orders: disconnect failed",
+		"error string",
+	)
+}
+
+func TestCloseContextWithoutCloseError(t *testing.T) {
+	client := &contextClient{closeCtxValue: nil, closeErr: nil}
+
+	useClient := func() (returnedErr error) {
+		defer errclose.CloseContext(context.Background(), client, &returnedErr, "client")
+		return nil
+	}
+
+	err := useClient()
+	assertEqual(t, err, nil, "error")
+}
+
+func TestCloseContextOptions(t *testing.T) {
+	errDisconnected := errors.New("already disconnected")
+	client := &contextClient{closeCtxValue: nil, closeErr: errDisconnected}
+
+	var err error
+	errclose.CloseContext(
+		context.Background(),
+		client,
+		&err,
+		"client",
+		errclose.Ignore(errDisconnected),
+	)
+	assertEqual(t, err, nil, "error with ignored close error")
+
+	errclose.CloseContextfWith(
+		context.Background(),
+		client,
+		&err,
+		[]errclose.Option{errclose.Ignore(errDisconnected)},
+		"client for %s",
+		"orders",
+	)
+	assertEqual(t, err, nil, "error with ignored close error from CloseContextfWith")
+}
+
+func TestCloseContextDefaults(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errDisconnected := errors.New("already disconnected")
+	errclose.SetDefaultsFor[*contextClient](errclose.Ignore(errDisconnected))
+	errclose.RegisterBenign(func(closeErr error) bool {
+		return errors.Is(closeErr, errBenignClose)
+	})
+
+	var err error
+	errclose.CloseContext(
+		context.Background(),
+		&contextClient{closeCtxValue: nil, closeErr: errDisconnected},
+		&err,
+		"client",
+	)
+	errclose.CloseContextf(
+		context.Background(),
+		&contextClient{closeCtxValue: nil, closeErr: errBenignClose},
+		&err,
+		"client %d",
+		2,
+	)
+	assertEqual(t, err, nil, "error with ignored and benign close errors")
+}
+
+type contextClient struct {
+	closeCtxValue any
+	closeErr      error
+}
+
+func (client *contextClient) Close(ctx context.Context) error {
+	client.closeCtxValue = ctx.Value(closeCtxKey{})
+	return client.closeErr
+}
+
+func (client *contextClient) Disconnect(ctx context.Context) error {
+	return client.Close(ctx)
+}
+
+func TestAwaitClosed(t *testing.T) {
+	consumer := newMockConsumer(nil)
+
+	consume := func() (returnedErr error) {
+		defer errclose.AwaitClosed(context.Background(), consumer, &returnedErr, "consumer")
+		consumer.stop()
+		return nil
+	}
+
+	err := consume()
+	assertEqual(t, err, nil, "error")
+}
+
+func TestAwaitClosedWithTerminalError(t *testing.T) {
+	terminalErr := errors.New("partition revoked")
+	consumer := newMockConsumer(terminalErr)
+
+	consume := func() (returnedErr error) {
+		defer errclose.AwaitClosed(context.Background(), consumer, &returnedErr, "consumer")
+		consumer.stop()
+		return fallibleOperation()
+	}
+
+	err := consume()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close consumer: partition revoked)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, terminalErr), true, "errors.Is(terminalErr)")
+}
+
+func TestAwaitClosedWithCanceledContext(t *testing.T) {
+	consumer := newMockConsumer(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	consume := func() (returnedErr error) {
+		defer errclose.AwaitClosed(ctx, consumer, &returnedErr, "consumer")
+		return nil
+	}
+
+	err := consume()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close consumer: stopped waiting for close: context canceled",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, context.Canceled), true, "errors.Is(context.Canceled)")
+	assertEqual(t, errors.Is(err, errclose.ErrCloseTimeout), false, "errors.Is(ErrCloseTimeout)")
+}
+
+func TestAwaitClosedWithExceededDeadline(t *testing.T) {
+	consumer := newMockConsumer(nil)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	consume := func() (returnedErr error) {
+		defer errclose.AwaitClosed(ctx, consumer, &returnedErr, "consumer")
+		return nil
+	}
+
+	err := consume()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close consumer: stopped waiting for close: context deadline exceeded",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errclose.ErrCloseTimeout), true, "errors.Is(ErrCloseTimeout)")
+	assertEqual(
+		t,
+		errors.Is(err, context.DeadlineExceeded),
+		true,
+		"errors.Is(context.DeadlineExceeded)",
+	)
+}
+
+func TestAwaitClosedOptions(t *testing.T) {
+	errRevoked := errors.New("partition revoked")
+	consumer := newMockConsumer(errRevoked)
+	consumer.stop()
+
+	var err error
+	errclose.AwaitClosed(
+		context.Background(),
+		consumer,
+		&err,
+		"consumer",
+		errclose.Ignore(errRevoked),
+	)
+	assertEqual(t, err, nil, "error with ignored terminal error")
+}
+
+type mockConsumer struct {
+	done        chan struct{}
+	terminalErr error
+}
+
+func newMockConsumer(terminalErr error) *mockConsumer {
+	return &mockConsumer{done: make(chan struct{}), terminalErr: terminalErr}
+}
+
+func (consumer *mockConsumer) stop() {
+	close(consumer.done)
+}
+
+func (consumer *mockConsumer) Done() <-chan struct{} {
+	return consumer.done
+}
+
+func (consumer *mockConsumer) Err() error {
+	return consumer.terminalErr
+}
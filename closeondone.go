@@ -0,0 +1,47 @@
+package errclose
+
+import (
+	"context"
+)
+
+// CloseOnDone closes the given resource when the given context is done, using
+// [context.AfterFunc]. This is for resources that should live as long as a context, such as a
+// connection that should be torn down when a request ends:
+//
+//	conn, err := dialer.DialContext(ctx, "tcp", addr)
+//	if err != nil {
+//		return err
+//	}
+//	errclose.CloseOnDone(ctx, conn, "connection", func(err error) {
+//		logger.Warn("Failed to close connection", "error", err)
+//	})
+//
+// If closing the resource fails, onErr is called with the close error, on the same format as
+// [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+//
+// The close error is also written to the event log and passed to the observer, if they are set
+// (see [errclose.SetEventLog] and [errclose.SetObserver]). onErr may be nil, if the event log or
+// observer is enough. Both the close and onErr run in their own goroutine, with [pprof] labels
+// errclose.resource (the resource name) and errclose.phase ("context done").
+//
+// The returned function stops the resource from being closed when the context is done, like the
+// stop function returned by context.AfterFunc. It returns true if it stopped the close, and false
+// if the close has already started (or the resource was already unregistered).
+func CloseOnDone(
+	ctx context.Context,
+	resource interface{ Close() error },
+	resourceName string,
+	onErr func(error),
+) (stop func() bool) {
+	return context.AfterFunc(ctx, func() {
+		withCloseLabels(resourceName, "context done", func() {
+			var closeErr error
+			Close(resource, &closeErr, resourceName)
+			if closeErr != nil && onErr != nil {
+				onErr(closeErr)
+			}
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package errclose_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseOnDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	file := openFileWithCloseError()
+
+	errs := make(chan error, 1)
+	errclose.CloseOnDone(ctx, file, "file", func(err error) { errs <- err })
+	cancel()
+
+	select {
+	case err := <-errs:
+		assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for close error")
+	}
+	assertEqual(t, file.closeWasCalled, true, "close was called")
+}
+
+func TestCloseOnDoneStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	file := openFileWithCloseError()
+
+	stop := errclose.CloseOnDone(ctx, file, "file", nil)
+	assertEqual(t, stop(), true, "stop result")
+	cancel()
+
+	time.Sleep(10 * time.Millisecond)
+	assertEqual(t, file.closeWasCalled, false, "close was called")
+}
+
+func TestCloseOnDoneObserver(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	observed := make(chan string, 1)
+	errclose.SetObserver(func(resourceName string, _ error) { observed <- resourceName })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	errclose.CloseOnDone(ctx, openFileWithCloseError(), "file", nil)
+	cancel()
+
+	select {
+	case resourceName := <-observed:
+		assertEqual(t, resourceName, "file", "observed resource name")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for observer")
+	}
+}
@@ -0,0 +1,35 @@
+package errclose
+
+// CloseOnError closes the given resource only if the error pointed to by returnedErr is non-nil,
+// and handles close errors in the same way as [errclose.Close]. This is for constructors that
+// return a resource, which must be closed if a later setup step fails, but left open for the
+// caller otherwise:
+//
+//	func NewStore(path string) (store *Store, returnedErr error) {
+//		file, err := os.Open(path)
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer errclose.CloseOnError(file, &returnedErr, "store file")
+//
+//		index, err := readIndex(file)
+//		if err != nil {
+//			return nil, err // File is closed
+//		}
+//
+//		return &Store{file: file, index: index}, nil // File is left open
+//	}
+//
+// If closing the resource fails, the close error is combined with the existing error on the same
+// format as in errclose.Close:
+//
+//	<existing error> (and failed to close <resourceName>: <close error>)
+//
+// If returnedErr is a nil pointer, there is no error to check, so the resource is not closed.
+func CloseOnError(resource interface{ Close() error }, returnedErr *error, resourceName string) {
+	if returnedErr == nil || *returnedErr == nil {
+		return
+	}
+
+	Close(resource, returnedErr, resourceName)
+}
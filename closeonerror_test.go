@@ -0,0 +1,38 @@
+package errclose_test
+
+import (
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseOnError(t *testing.T) {
+	file := openFileWithCloseError()
+
+	newThing := func() (thing *mockFile, returnedErr error) {
+		defer errclose.CloseOnError(file, &returnedErr, "file")
+		return nil, fallibleOperation()
+	}
+
+	_, err := newThing()
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+}
+
+func TestCloseOnErrorWithoutError(t *testing.T) {
+	file := openFileWithCloseError()
+
+	newThing := func() (thing *mockFile, returnedErr error) {
+		defer errclose.CloseOnError(file, &returnedErr, "file")
+		return file, nil
+	}
+
+	thing, err := newThing()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, thing.closeWasCalled, false, "closeWasCalled")
+}
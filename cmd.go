@@ -0,0 +1,188 @@
+package errclose
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// CmdPipes holds the pipes of a command started with [exec.Cmd], to be torn down by
+// [errclose.WaitCmd]. Leave a field nil if you didn't create that pipe.
+type CmdPipes struct {
+	// Stdin is the pipe returned by [exec.Cmd.StdinPipe].
+	Stdin io.WriteCloser
+	// Stdout is the pipe returned by [exec.Cmd.StdoutPipe].
+	Stdout io.Reader
+	// Stderr is the pipe returned by [exec.Cmd.StderrPipe].
+	Stderr io.Reader
+}
+
+// WaitCmd tears down a started command and its pipes in the order that avoids deadlocks, and
+// handles errors from each step in the same way as [errclose.Close] handles close errors:
+//  1. Closes stdin, so the command gets EOF on its input
+//  2. Reads the rest of stdout and stderr (concurrently), discarding the output, so the command
+//     doesn't block on writing to a full pipe
+//  3. Waits for the command to exit (Wait also closes the output pipes)
+//
+// Typical usage:
+//
+//	func runFilter(input []byte) (returnedErr error) {
+//		cmd := exec.Command("filter")
+//		stdin, err := cmd.StdinPipe()
+//		if err != nil {
+//			return err
+//		}
+//		stdout, err := cmd.StdoutPipe()
+//		if err != nil {
+//			return err
+//		}
+//		if err := cmd.Start(); err != nil {
+//			return err
+//		}
+//		pipes := errclose.CmdPipes{Stdin: stdin, Stdout: stdout, Stderr: nil}
+//		defer errclose.WaitCmd(cmd, pipes, &returnedErr, "filter command")
+//
+//		// Write to stdin, read from stdout
+//	}
+//
+// Errors are labeled with their step, on the following formats:
+//
+//	failed to close stdin of <resourceName>: <error>
+//	failed to drain stdout of <resourceName>: <error>
+//	failed to drain stderr of <resourceName>: <error>
+//	failed to wait for <resourceName>: <error>
+//
+// All steps run even if an earlier step fails. If the command exits with a non-zero status, the
+// error from the last step wraps an [exec.ExitError].
+func WaitCmd(cmd *exec.Cmd, pipes CmdPipes, returnedErr *error, resourceName string) {
+	if pipes.Stdin != nil {
+		recordCloseAttempt(resourceName)
+		if err := pipes.Stdin.Close(); err != nil {
+			handleTeardownError(returnedErr, err, "close stdin of", resourceName)
+		}
+	}
+
+	var stdoutErr, stderrErr error
+	var wg sync.WaitGroup
+	drain := func(pipe io.Reader, drainErr *error) {
+		if pipe == nil {
+			return
+		}
+		recordCloseAttempt(resourceName)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, *drainErr = io.Copy(io.Discard, pipe)
+		}()
+	}
+	drain(pipes.Stdout, &stdoutErr)
+	drain(pipes.Stderr, &stderrErr)
+	wg.Wait()
+
+	if stdoutErr != nil {
+		handleTeardownError(returnedErr, stdoutErr, "drain stdout of", resourceName)
+	}
+	if stderrErr != nil {
+		handleTeardownError(returnedErr, stderrErr, "drain stderr of", resourceName)
+	}
+
+	recordCloseAttempt(resourceName)
+	if err := cmd.Wait(); err != nil {
+		handleTeardownError(returnedErr, err, "wait for", resourceName)
+	}
+}
+
+// CloseCmd waits for a started command to exit, and stops it if it doesn't exit within the given
+// grace period. Errors are handled in the same way as [errclose.Close] handles close errors. This
+// is for deferred teardown of subprocesses, so that a failing or returning function doesn't leave
+// them running:
+//
+//	cmd := exec.Command("server", "--port", port)
+//	if err := cmd.Start(); err != nil {
+//		return err
+//	}
+//	defer errclose.CloseCmd(cmd, &returnedErr, "server process", 5*time.Second)
+//
+// If the command is still running after the grace period, CloseCmd sends it SIGTERM, and then
+// waits for another grace period before killing it (on platforms other than Unix, where SIGTERM
+// isn't supported, the command is killed right away). The pipes created by [exec.Cmd.StdinPipe],
+// [exec.Cmd.StdoutPipe] and [exec.Cmd.StderrPipe] are closed once the command exits. If the
+// command reads its stdin until EOF, close the stdin pipe before calling CloseCmd (or use
+// [errclose.WaitCmd]), so it can exit without being stopped.
+//
+// Errors are labeled with their step, on the following formats:
+//
+//	failed to wait for <resourceName>: <error>
+//	failed to wait for <resourceName>: timed out closing resource after <gracePeriod>
+//	failed to terminate <resourceName>: <error>
+//	failed to kill <resourceName>: <error>
+//
+// The timeout error matches [errclose.ErrCloseTimeout] with [errors.Is]. If the command had to be
+// stopped, the exit error from the signal is left out, since the timeout error already explains
+// why the command exited. Other errors from waiting for the command, such as a non-zero exit
+// status or a failure to copy its output, are always included.
+//
+// If cmd is nil, this is handled like a nil resource in [errclose.Close] (see
+// [errclose.SetNilResourcePolicy]).
+func CloseCmd(cmd *exec.Cmd, returnedErr *error, resourceName string, gracePeriod time.Duration) {
+	if cmd == nil {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	waitResult := make(chan error, 1)
+	go func() {
+		waitResult <- cmd.Wait()
+	}()
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case err := <-waitResult:
+		if err != nil {
+			handleTeardownError(returnedErr, err, "wait for", resourceName)
+		}
+		return
+	case <-timer.C:
+	}
+
+	timeoutErr := lazyErrorf(ErrCloseTimeout, "%w after %s", ErrCloseTimeout, gracePeriod)
+	handleTeardownError(returnedErr, timeoutErr, "wait for", resourceName)
+
+	recordCloseAttempt(resourceName)
+	if err := terminateProcess(cmd.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		handleTeardownError(returnedErr, err, "terminate", resourceName)
+	}
+
+	// Waiting again after stopping the command counts as a separate attempt, since it can fail
+	// after the first wait timed out
+	recordCloseAttempt(resourceName)
+	timer.Reset(gracePeriod)
+	select {
+	case err := <-waitResult:
+		handleStoppedCmdWaitError(returnedErr, err, resourceName)
+		return
+	case <-timer.C:
+	}
+
+	recordCloseAttempt(resourceName)
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		handleTeardownError(returnedErr, err, "kill", resourceName)
+	}
+	handleStoppedCmdWaitError(returnedErr, <-waitResult, resourceName)
+}
+
+// handleStoppedCmdWaitError handles the error from waiting for a command that CloseCmd had to
+// stop. Exit errors are expected in that case, so only other errors are reported.
+func handleStoppedCmdWaitError(returnedErr *error, waitErr error, resourceName string) {
+	var exitErr *exec.ExitError
+	if waitErr == nil || errors.As(waitErr, &exitErr) {
+		return
+	}
+	handleTeardownError(returnedErr, waitErr, "wait for", resourceName)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package errclose
+
+import (
+	"os"
+)
+
+// terminateProcess kills the process, since sending SIGTERM is only supported on Unix (see
+// cmd_unix.go).
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package errclose
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminateProcess asks the process to exit, by sending it SIGTERM. Used by
+// [errclose.CloseCmd] before escalating to a kill.
+func terminateProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
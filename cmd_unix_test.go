@@ -0,0 +1,155 @@
+//go:build unix
+
+package errclose_test
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWaitCmd(t *testing.T) {
+	// cat exits when stdin is closed. It writes a lot of output, which must be drained for it to
+	// exit
+	cmd := exec.Command("sh", "-c", "cat; head -c 1000000 /dev/zero")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.WaitCmd(
+		cmd,
+		errclose.CmdPipes{Stdin: stdin, Stdout: stdout, Stderr: nil},
+		&returnedErr,
+		"cat",
+	)
+	assertEqual(t, returnedErr, nil, "error")
+}
+
+func TestWaitCmdWithExitError(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 3")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.WaitCmd(
+		cmd,
+		errclose.CmdPipes{Stdin: nil, Stdout: nil, Stderr: stderr},
+		&returnedErr,
+		"script",
+	)
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"failed to wait for script: exit status 3",
+		"error string",
+	)
+
+	var exitErr *exec.ExitError
+	assertEqual(t, errors.As(returnedErr, &exitErr), true, "errors.As(ExitError)")
+}
+
+func TestCloseCmd(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 0")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.CloseCmd(cmd, &returnedErr, "script", time.Minute)
+	assertEqual(t, returnedErr, nil, "error")
+}
+
+func TestCloseCmdWithExitError(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 3")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.CloseCmd(cmd, &returnedErr, "script", time.Minute)
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"failed to wait for script: exit status 3",
+		"error string",
+	)
+	assertEqual(t, errclose.IsTimeout(returnedErr), false, "IsTimeout")
+}
+
+func TestCloseCmdTerminatesAfterGracePeriod(t *testing.T) {
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.CloseCmd(cmd, &returnedErr, "sleep", 10*time.Millisecond)
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"failed to wait for sleep: timed out closing resource after 10ms",
+		"error string",
+	)
+	assertEqual(t, errors.Is(returnedErr, errclose.ErrCloseTimeout), true, "errors.Is(timeout)")
+	assertEqual(t, cmd.ProcessState.Exited(), false, "process exited by itself")
+}
+
+func TestCloseCmdKillsIfTerminateIsIgnored(t *testing.T) {
+	// exec makes sleep inherit the ignored SIGTERM from the shell
+	cmd := exec.Command("sh", "-c", `trap "" TERM; echo ready; exec sleep 60`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the trap to be set before the grace period starts
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.CloseCmd(cmd, &returnedErr, "sleep", 50*time.Millisecond)
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"failed to wait for sleep: timed out closing resource after 50ms",
+		"error string",
+	)
+	assertEqual(t, cmd.ProcessState.String(), "signal: killed", "process state")
+}
+
+func TestCloseCmdWithExistingError(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 1")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	returnedErr := errors.New("request failed")
+	errclose.CloseCmd(cmd, &returnedErr, "script", time.Minute)
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"request failed (and failed to wait for script: exit status 1)",
+		"error string",
+	)
+}
@@ -0,0 +1,147 @@
+package errclose
+
+// Collector accumulates errors from several independent cleanups, and merges them into the error
+// returned by a function in one place. This is for functions with cleanups of different kinds
+// (closes, unlocks, removing temporary files), where you want to run all of them and report every
+// failure:
+//
+//	func export(path string) (returnedErr error) {
+//		var collector errclose.Collector
+//		defer collector.Into(&returnedErr)
+//
+//		tempFile, err := os.CreateTemp("", "export")
+//		if err != nil {
+//			return err
+//		}
+//		defer func() { collector.Add(os.Remove(tempFile.Name())) }()
+//		defer collector.Close(tempFile, "temporary file")
+//
+//		// Use tempFile
+//	}
+//
+// Errors are kept in the order they were added (which, for deferred calls, is the reverse of the
+// order of the defer statements), and combined on the same format as [errclose.Close] combines
+// errors:
+//
+//	<error 1> (and <error 2>) (and <error 3>)
+//
+// All combined errors can be checked with [errors.Is] and [errors.As]. To keep the collected errors
+// small in long-running loops that fail the same way many times, see [errclose.SetRepeatSummary].
+//
+// The zero value is ready to use. A Collector is not safe for concurrent use.
+type Collector struct {
+	err error
+	// The errors added with Add, which unlike close errors haven't been reported to the event log
+	// and observer.
+	unreported error
+	// When summarizing repeated failures, the collected errors in the order they were added, with
+	// identical close errors only included once. Kept instead of err, since the combined error
+	// caches its message, which would miss later occurrences.
+	summarized []error
+	// The close errors in summarized, by their message before they were summarized.
+	closeErrsByMessage map[string]*CloseError
+}
+
+// Add adds the given error to the collector. If the error is nil, Add does nothing, so you can pass
+// the result of a cleanup function directly.
+func (collector *Collector) Add(err error) {
+	if err != nil && collector.summarized != nil {
+		collector.summarized = append(collector.summarized, err)
+	} else {
+		collector.err = combineErrors(collector.err, err)
+	}
+	collector.unreported = combineErrors(collector.unreported, err)
+}
+
+// Close closes the given resource, and adds the close error (if any) to the collector, formatted
+// in the same way as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+func (collector *Collector) Close(
+	resource interface{ Close() error },
+	resourceName string,
+	options ...Option,
+) {
+	if !repeatSummaryEnabled() {
+		closeResource(resource, &collector.err, resourceName, options, 1)
+		return
+	}
+
+	if collector.summarized == nil {
+		// Errors collected before summarizing was enabled are kept first
+		collector.summarized = make([]error, 0, 1)
+		if collector.err != nil {
+			collector.summarized = append(collector.summarized, collector.err)
+			collector.err = nil
+		}
+		collector.closeErrsByMessage = make(map[string]*CloseError)
+	}
+
+	var closeErr error
+	closeResource(
+		resource,
+		&closeErr,
+		resourceName,
+		withReportFilter(options, func(closeErr *CloseError) bool {
+			occurrence := 1
+			if previous, ok := collector.closeErrsByMessage[closeErr.Error()]; ok {
+				occurrence = previous.Occurrences + 1
+			}
+			return isSummaryOccurrence(occurrence)
+		}),
+		1,
+	)
+	if closeErr == nil {
+		return
+	}
+
+	//nolint:errorlint // Only direct CloseErrors are summarized
+	if wrapped, ok := closeErr.(*CloseError); ok {
+		message := wrapped.Error()
+		if previous, ok := collector.closeErrsByMessage[message]; ok {
+			previous.Occurrences++
+			return
+		}
+		wrapped.Occurrences = 1
+		collector.closeErrsByMessage[message] = wrapped
+	}
+	collector.summarized = append(collector.summarized, closeErr)
+}
+
+// Err returns the errors collected so far, combined into one error, or nil if there are none.
+func (collector *Collector) Err() error {
+	if collector.summarized == nil {
+		return collector.err
+	}
+
+	var err error
+	for _, summarizedErr := range collector.summarized {
+		combineIntoReturnedErr(&err, summarizedErr, "")
+	}
+	return err
+}
+
+// Into combines the collected errors with the error pointed to by returnedErr:
+//
+//	<existing error> (and <collected errors>)
+//
+// If returnedErr points to a nil error, it's set to the collected errors. The collector is then
+// emptied, so it can be reused.
+//
+// You'll typically call Into in a defer statement right after creating the collector, so it runs
+// after all other deferred cleanups. If returnedErr is a nil pointer, collected errors are handled
+// according to the [errclose.NilErrorPolicy].
+func (collector *Collector) Into(returnedErr *error) {
+	err, unreported := collector.Err(), collector.unreported
+	collector.err, collector.unreported = nil, nil
+	collector.summarized, collector.closeErrsByMessage = nil, nil
+	if err == nil {
+		return
+	}
+
+	if returnedErr == nil {
+		handleNilCollectedErr(err, unreported)
+		return
+	}
+	*returnedErr = combineErrors(*returnedErr, err)
+}
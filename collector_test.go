@@ -0,0 +1,72 @@
+package errclose_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCollector(t *testing.T) {
+	removeErr := errors.New("remove error")
+	file := openFileWithCloseError()
+
+	export := func() (returnedErr error) {
+		var collector errclose.Collector
+		defer collector.Into(&returnedErr)
+
+		defer func() { collector.Add(removeErr) }()
+		defer collector.Close(file, "temporary file")
+		defer collector.Close(openFileWithoutCloseError(), "other file")
+		defer collector.Add(nil)
+
+		return fallibleOperation()
+	}
+
+	err := export()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close temporary file: close error (and remove error))",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, removeErr), true, "errors.Is(err, removeErr)")
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is(err, closeError)")
+}
+
+func TestCollectorCloseWithCaller(t *testing.T) {
+	var collector errclose.Collector
+	collector.Close(openFileWithCloseError(), "file", errclose.WithCaller())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(collector.Err(), &closeErr), true, "errors.As result")
+	assertEqual(
+		t,
+		strings.Contains(closeErr.Caller, "collector_test.go:"),
+		true,
+		"caller is the Collector.Close call",
+	)
+}
+
+func TestCollectorWithoutErrors(t *testing.T) {
+	var collector errclose.Collector
+	collector.Add(nil)
+	collector.Close(openFileWithoutCloseError(), "file")
+
+	var err error
+	collector.Into(&err)
+	assertEqual(t, err, nil, "error")
+}
+
+func TestCollectorReuse(t *testing.T) {
+	var collector errclose.Collector
+	collector.Add(errors.New("first error"))
+
+	var err error
+	collector.Into(&err)
+	assertEqual(t, collector.Err(), nil, "collector.Err() after Into")
+
+	collector.Add(errors.New("second error"))
+	assertEqual(t, collector.Err().Error(), "second error", "collector.Err()")
+}
@@ -0,0 +1,167 @@
+package errclose
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+)
+
+// CloseAllConcurrent closes the given resources concurrently, waits for all of them to finish, and
+// handles close errors. This is for fan-out code that holds many independent resources, such as
+// connections to many peers, where closing them one by one adds up to a noticeable delay:
+//
+//	func broadcast(ctx context.Context, addrs []string) (returnedErr error) {
+//		conns := make([]errclose.NamedCloser, 0, len(addrs))
+//		defer func() { errclose.CloseAllConcurrent(ctx, &returnedErr, 16, conns...) }()
+//
+//		for _, addr := range addrs {
+//			conn, err := net.Dial("tcp", addr)
+//			if err != nil {
+//				return err
+//			}
+//			conns = append(conns, errclose.Named(conn, "connection to "+addr))
+//		}
+//
+//		// Use conns
+//	}
+//
+// At most maxConcurrent resources are closed at the same time. If maxConcurrent is 0 or less, all
+// resources are closed concurrently.
+//
+// The context is passed to resources that implement [errclose.CloseContextSetter],
+// [errclose.ClosePreparer] or [errclose.Drainable], in the same way as in
+// [errclose.ShutdownGlobal]. Every resource is closed, even if the context is done: the context
+// only bounds how long to wait for one of the maxConcurrent slots to free up. Once it's done, the
+// remaining resources are closed right away without the limit, and all closes are waited for.
+//
+// # Error format
+//
+// Close errors are formatted and combined with the existing error pointed to by returnedErr in the
+// same way as calling [errclose.Close] for each resource. To make the error deterministic
+// regardless of the order that the closes finish in, close errors are combined in the order of the
+// resource names (sorted by [strings.Compare]).
+func CloseAllConcurrent(
+	ctx context.Context,
+	returnedErr *error,
+	maxConcurrent int,
+	resources ...NamedCloser,
+) {
+	namedResources := make([]namedResource, len(resources))
+	for i, resource := range resources {
+		namedResources[i] = namedResource{resource: nil, resourceName: "", options: nil}
+		if resource != nil {
+			namedResources[i] = namedResource{
+				resource:     resource,
+				resourceName: resource.Name(),
+				options:      nil,
+			}
+		}
+	}
+
+	closeConcurrently(
+		ctx,
+		returnedErr,
+		maxConcurrent,
+		namedResources,
+		closeHooks{before: nil, after: nil, propagate: nil},
+	)
+}
+
+// closeHooks are called by closeConcurrently before and after closing each resource, with the
+// index of the resource. Nil hooks are skipped. If propagate is set, it's called for each close
+// error after all resources have been closed, and the error is only combined into the returned
+// error if it returns true.
+type closeHooks struct {
+	before    func(i int)
+	after     func(i int, closeErr error)
+	propagate func(i int, closeErr error) bool
+}
+
+// closeConcurrently implements [errclose.CloseAllConcurrent] for resources that have already been
+// named.
+func closeConcurrently(
+	ctx context.Context,
+	returnedErr *error,
+	maxConcurrent int,
+	resources []namedResource,
+	hooks closeHooks,
+) {
+	if maxConcurrent <= 0 || maxConcurrent > len(resources) {
+		maxConcurrent = len(resources)
+	}
+
+	closeErrs := make([]error, len(resources))
+	semaphore := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+	for i, resource := range resources {
+		// Once the context is done, stop waiting for a slot, so the rest are closed right away
+		acquiredSlot := false
+		select {
+		case semaphore <- struct{}{}:
+			acquiredSlot = true
+		case <-ctx.Done():
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if acquiredSlot {
+					<-semaphore
+				}
+				wg.Done()
+			}()
+			if hooks.before != nil {
+				hooks.before(i)
+			}
+			withCloseLabels(resource.resourceName, "close", func() {
+				closeWithContext(
+					ctx,
+					resource.resource,
+					&closeErrs[i],
+					resource.resourceName,
+					resource.options,
+				)
+			})
+			if hooks.after != nil {
+				hooks.after(i, closeErrs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	order := make([]int, len(resources))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a int, b int) int {
+		return cmp.Compare(resources[a].resourceName, resources[b].resourceName)
+	})
+
+	for _, i := range order {
+		if closeErrs[i] != nil && (hooks.propagate == nil || hooks.propagate(i, closeErrs[i])) {
+			combineIntoReturnedErr(returnedErr, closeErrs[i], resources[i].resourceName)
+		}
+	}
+}
+
+// combineIntoReturnedErr combines an error that has already been reported (from handling it with
+// an error pointer of its own) with the error pointed to by returnedErr.
+func combineIntoReturnedErr(returnedErr *error, err error, resourceName string) {
+	closeErr, isCloseErr := err.(*CloseError) //nolint:errorlint // Only direct CloseErrors
+
+	if returnedErr == nil {
+		if isCloseErr {
+			handleNilReturnedErr(closeErr.Err, closeErr.actionOrDefault(), closeErr.ResourceName)
+		} else {
+			handleNilReturnedErr(err, "close", resourceName)
+		}
+		return
+	}
+
+	if isCloseErr {
+		*returnedErr = combineCloseError(*returnedErr, closeErr)
+	} else {
+		*returnedErr = combineErrors(*returnedErr, err)
+	}
+}
@@ -0,0 +1,111 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestCloseAllConcurrent(t *testing.T) {
+	conn1 := errclosetest.NewSlow(10*time.Millisecond, errors.New("error 1"))
+	conn2 := errclosetest.NewSlow(time.Millisecond, nil)
+	conn3 := errclosetest.NewSlow(time.Millisecond, errors.New("error 3"))
+
+	err := errors.New("existing error")
+	errclose.CloseAllConcurrent(
+		context.Background(),
+		&err,
+		0,
+		errclose.Named(conn3, "conn c"),
+		errclose.Named(conn1, "conn a"),
+		errclose.Named(conn2, "conn b"),
+	)
+
+	errclosetest.AssertClosed(t, conn1)
+	errclosetest.AssertClosed(t, conn2)
+	errclosetest.AssertClosed(t, conn3)
+	assertEqual(
+		t,
+		err.Error(),
+		"existing error (and failed to close conn a: error 1) "+
+			"(and failed to close conn c: error 3)",
+		"error string",
+	)
+}
+
+func TestCloseAllConcurrentWithLimit(t *testing.T) {
+	resources := make([]errclose.NamedCloser, 0, 10)
+	fakes := make([]*errclosetest.Slow, 0, 10)
+	for range 10 {
+		fake := errclosetest.NewSlow(time.Millisecond, nil)
+		fakes = append(fakes, fake)
+		resources = append(resources, errclose.Named(fake, "conn"))
+	}
+
+	var err error
+	errclose.CloseAllConcurrent(context.Background(), &err, 3, resources...)
+	assertEqual(t, err, nil, "error")
+	for _, fake := range fakes {
+		errclosetest.AssertClosed(t, fake)
+	}
+}
+
+func TestCloseAllConcurrentDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	slow := errclosetest.NewSlow(time.Second, nil)
+	notStarted := errclosetest.NewMockCloser(nil)
+
+	var err error
+	errclose.CloseAllConcurrent(
+		ctx,
+		&err,
+		1,
+		namedSlow{Slow: slow, name: "slow conn"},
+		errclose.Named(notStarted, "other conn"),
+	)
+
+	errclosetest.AssertClosed(t, notStarted)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close slow conn: context deadline exceeded",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, context.DeadlineExceeded), true, "errors.Is DeadlineExceeded")
+}
+
+func TestCloseAllConcurrentWithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	closers := make([]*errclosetest.MockCloser, 10)
+	resources := make([]errclose.NamedCloser, len(closers))
+	for i := range closers {
+		closers[i] = errclosetest.NewMockCloser(nil)
+		resources[i] = errclose.Named(closers[i], fmt.Sprintf("conn %d", i))
+	}
+
+	var err error
+	errclose.CloseAllConcurrent(ctx, &err, 2, resources...)
+	assertEqual(t, err, nil, "error")
+	for _, closer := range closers {
+		errclosetest.AssertClosed(t, closer)
+	}
+}
+
+// namedSlow embeds Slow instead of using errclose.Named, to keep its SetCloseContext method.
+type namedSlow struct {
+	*errclosetest.Slow
+	name string
+}
+
+func (slow namedSlow) Name() string {
+	return slow.name
+}
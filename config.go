@@ -0,0 +1,96 @@
+package errclose
+
+import (
+	"context"
+	"io"
+)
+
+// Config is a snapshot of the package's global configuration, returned by [errclose.SaveConfig].
+type Config struct {
+	eventLog               io.Writer
+	debugEvents            bool
+	errorFormat            ErrorFormat
+	nilErrorPolicy         NilErrorPolicy
+	nilResourcePolicy      NilResourcePolicy
+	lateRegistrationPolicy LateRegistrationPolicy
+	observer               *func(resourceName string, closeErr error)
+	messageFormat          *messageFormat
+	strict                 bool
+	repeatSummary          bool
+	metrics                *Metrics
+	diagnostics            *closeDiagnostics
+	defaultOptions         *[]Option
+	typeDefaults           *[]typeDefaultsEntry
+	benignErrors           *[]func(closeErr error) bool
+	wrapper                *func(primary error, closeErr error, resourceName string) error
+	traceIDExtractor       *func(ctx context.Context) string
+}
+
+// SaveConfig returns a snapshot of the package's global configuration, which can be restored
+// later with [Config.Restore]. This covers everything set by [errclose.SetEventLog],
+// [errclose.SetDebugEvents], [errclose.SetErrorFormat], [errclose.SetNilErrorPolicy],
+// [errclose.SetNilResourcePolicy], [errclose.SetLateRegistrationPolicy], [errclose.SetObserver],
+// [errclose.SetMessageFormat], [errclose.SetStrict], [errclose.SetRepeatSummary],
+// [errclose.SetMetrics], [errclose.SetCloseDiagnostics], [errclose.SetDefaults],
+// [errclose.SetDefaultsFor], [errclose.RegisterBenign], [errclose.SetWrapper] and
+// [errclose.SetTraceIDExtractor].
+//
+// This is useful in tests that change the configuration, to make sure it's restored afterwards:
+//
+//	func TestSomething(t *testing.T) {
+//		defer errclose.SaveConfig().Restore()
+//		errclose.SetErrorFormat(errclose.ErrorFormatCompact)
+//
+//		// Run test
+//	}
+//
+// Note that the configuration is still global while it's changed, so tests that change it should
+// not run in parallel with other tests that depend on it.
+func SaveConfig() Config {
+	eventLog.lock.Lock()
+	writer := eventLog.writer
+	eventLog.lock.Unlock()
+
+	return Config{
+		eventLog:               writer,
+		debugEvents:            debugEvents.Load(),
+		errorFormat:            ErrorFormat(errorFormat.Load()),
+		nilErrorPolicy:         NilErrorPolicy(nilErrorPolicy.Load()),
+		nilResourcePolicy:      NilResourcePolicy(nilResourcePolicy.Load()),
+		lateRegistrationPolicy: LateRegistrationPolicy(lateRegistrationPolicy.Load()),
+		observer:               observer.Load(),
+		messageFormat:          messageFormats.Load(),
+		strict:                 strict.Load(),
+		repeatSummary:          repeatSummary.Load(),
+		metrics:                metrics.Load(),
+		diagnostics:            diagnostics.Load(),
+		defaultOptions:         defaultOptions.Load(),
+		typeDefaults:           typeDefaults.entries.Load(),
+		benignErrors:           benignErrors.matchers.Load(),
+		wrapper:                wrapper.Load(),
+		traceIDExtractor:       traceIDExtractor.Load(),
+	}
+}
+
+// Restore sets the package's global configuration back to the snapshot.
+func (config Config) Restore() {
+	SetEventLog(config.eventLog)
+	SetDebugEvents(config.debugEvents)
+	SetErrorFormat(config.errorFormat)
+	SetNilErrorPolicy(config.nilErrorPolicy)
+	SetNilResourcePolicy(config.nilResourcePolicy)
+	SetLateRegistrationPolicy(config.lateRegistrationPolicy)
+	observer.Store(config.observer)
+	messageFormats.Store(config.messageFormat)
+	SetStrict(config.strict)
+	SetRepeatSummary(config.repeatSummary)
+	metrics.Store(config.metrics)
+	diagnostics.Store(config.diagnostics)
+	defaultOptions.Store(config.defaultOptions)
+	typeDefaults.lock.Lock()
+	typeDefaults.entries.Store(config.typeDefaults)
+	typeDefaults.lock.Unlock()
+	benignErrors.matchers.Store(config.benignErrors)
+	wrapper.Store(config.wrapper)
+	traceIDExtractor.Store(config.traceIDExtractor)
+}
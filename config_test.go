@@ -0,0 +1,40 @@
+package errclose_test
+
+import (
+	"bytes"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestSaveConfig(t *testing.T) {
+	var buffer bytes.Buffer
+
+	func() {
+		defer errclose.SaveConfig().Restore()
+		errclose.SetEventLog(&buffer)
+		errclose.SetErrorFormat(errclose.ErrorFormatCompact)
+
+		err := errclose.Combine(errFallibleOperation, openFileWithCloseError().closeError, "file")
+		assertEqual(
+			t,
+			err.Error(),
+			"operation failed; also: close file: close error",
+			"error string with changed config",
+		)
+	}()
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file")
+		return fallibleOperation()
+	}
+
+	err := useFile()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string with restored config",
+	)
+	assertEqual(t, buffer.Len(), 0, "event log length after restore")
+}
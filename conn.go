@@ -0,0 +1,136 @@
+package errclose
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// CloseConn closes the given network connection, and handles close errors in the same way as
+// [errclose.Close]. On its own, it works like Close, but with the [errclose.HalfClose] and
+// [errclose.CloseDeadline] options, it closes the connection gracefully, so that data that was
+// written just before closing is not lost:
+//
+//	func send(addr string, message []byte) (returnedErr error) {
+//		conn, err := net.Dial("tcp", addr)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.CloseConn(
+//			conn,
+//			&returnedErr,
+//			"connection",
+//			errclose.HalfClose(),
+//			errclose.CloseDeadline(5*time.Second),
+//		)
+//
+//		_, err = conn.Write(message)
+//		return err
+//	}
+//
+// The connection is closed in the following steps:
+//   - If CloseDeadline is given, the connection's deadline is set
+//   - If HalfClose is given, and the connection has a CloseWrite method (such as [net.TCPConn]),
+//     the write side of the connection is closed, and the rest of the incoming data is read and
+//     discarded until the peer closes its side (or the deadline expires)
+//   - The connection is closed
+//
+// All steps are attempted even if an earlier one fails. Errors from the earlier steps use their own
+// action in the error message:
+//
+//	failed to set deadline for <resourceName>: <error>
+//	failed to close writes to <resourceName>: <error>
+//	failed to drain <resourceName>: <error>
+//
+// Expiry of the close deadline while draining is not an error. Other options, such as
+// [errclose.Ignore], apply to all steps.
+func CloseConn(conn net.Conn, returnedErr *error, resourceName string, options ...Option) {
+	if isNilResource(conn) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	lists := withDefaults(conn, options)
+	if deadline := closeDeadline(lists); deadline > 0 {
+		recordCloseAttempt(resourceName)
+		if err := conn.SetDeadline(time.Now().Add(deadline)); err != nil {
+			handleConnTeardownError(returnedErr, err, "set deadline for", resourceName, lists)
+		}
+	}
+
+	if isHalfClose(lists) {
+		if writeCloser, ok := conn.(interface{ CloseWrite() error }); ok {
+			recordCloseAttempt(resourceName)
+			if err := writeCloser.CloseWrite(); err != nil {
+				handleConnTeardownError(returnedErr, err, "close writes to", resourceName, lists)
+			} else {
+				recordCloseAttempt(resourceName)
+				if _, err := io.Copy(io.Discard, conn); err != nil &&
+					!errors.Is(err, os.ErrDeadlineExceeded) {
+					handleConnTeardownError(returnedErr, err, "drain", resourceName, lists)
+				}
+			}
+		}
+	}
+
+	closeResource(conn, returnedErr, resourceName, options, 1)
+}
+
+// handleConnTeardownError handles an error from a step of [errclose.CloseConn] before the close,
+// applying the options in the same way as for the close error.
+func handleConnTeardownError(
+	returnedErr *error,
+	err error,
+	action string,
+	resourceName string,
+	options optionLists,
+) {
+	// Skips this function and CloseConn, for errclose.WithCaller
+	handleTeardownErrorWithOptions(returnedErr, err, action, resourceName, options, nil, 2)
+}
+
+// HalfClose returns an option that makes [errclose.CloseConn] close the write side of the
+// connection first, and read the rest of the incoming data before closing it (see CloseConn for
+// details). This lets the peer read everything that was written, and finish its side of the
+// exchange. Other functions in the package ignore this option.
+//
+// Without [errclose.CloseDeadline], reading the rest of the incoming data blocks until the peer
+// closes its side of the connection, so you should usually pass both.
+func HalfClose() Option {
+	return Option{settings: &optionSettings{halfClose: true}}
+}
+
+// CloseDeadline returns an option that makes [errclose.CloseConn] set a deadline on the connection
+// before closing it, so that a graceful close with [errclose.HalfClose] can't block for longer than
+// the given timeout. Other functions in the package ignore this option. If multiple CloseDeadline
+// options are given, the last one is used.
+func CloseDeadline(timeout time.Duration) Option {
+	return Option{settings: &optionSettings{closeDeadline: timeout}}
+}
+
+func isHalfClose(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().halfClose {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closeDeadline returns the timeout from the last [errclose.CloseDeadline] option in the given
+// options, or 0 if there is none.
+func closeDeadline(options optionLists) time.Duration {
+	var deadline time.Duration
+	for _, list := range options {
+		for _, option := range list {
+			if timeout := option.get().closeDeadline; timeout != 0 {
+				deadline = timeout
+			}
+		}
+	}
+	return deadline
+}
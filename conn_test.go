@@ -0,0 +1,117 @@
+package errclose_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseConnHalfClose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errclose.Cleanup(t, listener, "listener")
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer conn.Close() //nolint:errcheck // Test server
+
+		// Reads until the client's half-close
+		data, err := io.ReadAll(conn)
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		_, _ = conn.Write([]byte("response"))
+		received <- string(data)
+	}()
+
+	sendMessage := func() (returnedErr error) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return err
+		}
+		defer errclose.CloseConn(
+			conn,
+			&returnedErr,
+			"connection",
+			errclose.HalfClose(),
+			errclose.CloseDeadline(time.Second),
+		)
+
+		_, err = conn.Write([]byte("message"))
+		return err
+	}
+
+	err = sendMessage()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, <-received, "message", "received data")
+}
+
+func TestCloseConnDeadline(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errclose.Cleanup(t, listener, "listener")
+
+	// Accept the connection, but never close it
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConn := <-accepted
+	defer errclose.Cleanup(t, serverConn, "server connection")
+
+	start := time.Now()
+	var closeErr error
+	errclose.CloseConn(
+		conn,
+		&closeErr,
+		"connection",
+		errclose.HalfClose(),
+		errclose.CloseDeadline(50*time.Millisecond),
+	)
+	assertEqual(t, closeErr, nil, "error")
+	assertEqual(t, time.Since(start) < time.Second, true, "closed within deadline")
+}
+
+func TestCloseConnError(t *testing.T) {
+	client, server := net.Pipe()
+	defer errclose.Cleanup(t, server, "server")
+
+	var err error
+	errclose.CloseConn(client, &err, "pipe")
+	assertEqual(t, err, nil, "error from first close")
+
+	errclose.CloseConn(client, &err, "pipe", errclose.CloseDeadline(time.Second))
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to set deadline for pipe: io: read/write on closed pipe",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, io.ErrClosedPipe), true, "errors.Is result")
+}
+
+func TestCloseConnNil(t *testing.T) {
+	var err error
+	errclose.CloseConn(nil, &err, "connection")
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is result")
+}
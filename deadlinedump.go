@@ -0,0 +1,115 @@
+package errclose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+)
+
+// SetDeadlineDump makes the manager write a report to the given writer if the context deadline
+// given to [ShutdownManager.Shutdown] is exceeded before the shutdown has completed. When shutdown
+// hangs, this shows what it's waiting for: the report lists the resources that were left
+// unfinished, followed by a dump of the stacks of all goroutines (from [runtime.Stack]):
+//
+//	errclose: shutdown deadline exceeded with 2 resources unfinished:
+//		closing: HTTP server (phase -1, resource ID 3)
+//		not started: database (phase 1, resource ID 1)
+//
+//	goroutine 1 [running]:
+//	...
+//
+// The report is written as soon as the deadline is exceeded, even if the resource being closed
+// never returns from Close, and Shutdown waits for it to be written before returning. Errors from
+// writing to the given writer are ignored. Pass nil to stop writing reports (this is the default).
+func (manager *ShutdownManager) SetDeadlineDump(writer io.Writer) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	manager.deadlineDump = writer
+}
+
+// startDeadlineWatchdog starts writing a deadline dump if the given context deadline is exceeded,
+// if enabled with SetDeadlineDump. closing points to the resources that Shutdown is currently
+// closing. The returned function stops the watchdog, or waits for the dump to be written if it
+// has already started.
+func (manager *ShutdownManager) startDeadlineWatchdog(
+	ctx context.Context,
+	closing *atomic.Pointer[[]managedResource],
+) (stop func()) {
+	manager.lock.Lock()
+	writer := manager.deadlineDump
+	manager.lock.Unlock()
+
+	if writer == nil {
+		return func() {}
+	}
+
+	dumped := make(chan struct{})
+	stopWatchdog := context.AfterFunc(ctx, func() {
+		defer close(dumped)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			manager.writeDeadlineDump(writer, closing.Load())
+		}
+	})
+
+	return func() {
+		if !stopWatchdog() {
+			<-dumped
+		}
+	}
+}
+
+func (manager *ShutdownManager) writeDeadlineDump(writer io.Writer, closing *[]managedResource) {
+	plan := manager.Plan()
+
+	var closingResources []managedResource
+	if closing != nil {
+		closingResources = *closing
+	}
+	unfinished := len(closingResources) + len(plan)
+
+	var report bytes.Buffer
+	fmt.Fprintf(
+		&report,
+		"errclose: shutdown deadline exceeded with %d resources unfinished:\n",
+		unfinished,
+	)
+	for _, resource := range closingResources {
+		fmt.Fprintf(
+			&report,
+			"\tclosing: %s (phase %d, resource ID %d)\n",
+			resource.resourceName,
+			resource.phase,
+			resource.id,
+		)
+	}
+	for _, step := range plan {
+		fmt.Fprintf(
+			&report,
+			"\tnot started: %s (phase %d, resource ID %d)\n",
+			step.ResourceName,
+			step.Phase,
+			step.ResourceID,
+		)
+	}
+	report.WriteByte('\n')
+	report.Write(allGoroutineStacks())
+
+	_, _ = writer.Write(report.Bytes())
+}
+
+// allGoroutineStacks returns the stack traces of all goroutines, growing the buffer until it fits.
+func allGoroutineStacks() []byte {
+	buffer := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buffer, true)
+		if n < len(buffer) {
+			return buffer[:n]
+		}
+		buffer = make([]byte, 2*len(buffer))
+	}
+}
@@ -0,0 +1,85 @@
+package errclose_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+// dumpWriter sends the dump written to it on a channel.
+type dumpWriter struct {
+	dump chan string
+}
+
+func (writer dumpWriter) Write(dump []byte) (int, error) {
+	writer.dump <- string(dump)
+	return len(dump), nil
+}
+
+func TestDeadlineDump(t *testing.T) {
+	var manager errclose.ShutdownManager
+	writer := dumpWriter{dump: make(chan string, 1)}
+	manager.SetDeadlineDump(writer)
+
+	release := make(chan struct{})
+	manager.DeferPhase(openFileWithoutCloseError(), "database", 1)
+	manager.Defer(closerFunc(func() error {
+		<-release
+		return nil
+	}), "server")
+	plan := manager.Plan()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- manager.Shutdown(ctx)
+	}()
+
+	report, stacks, _ := strings.Cut(<-writer.dump, "\n\n")
+	close(release)
+	assertEqual(
+		t,
+		report,
+		fmt.Sprintf(
+			"errclose: shutdown deadline exceeded with 2 resources unfinished:\n"+
+				"\tclosing: server (phase 0, resource ID %d)\n"+
+				"\tnot started: database (phase 1, resource ID %d)",
+			plan[0].ResourceID,
+			plan[1].ResourceID,
+		),
+		"report",
+	)
+	assertEqual(
+		t,
+		strings.Contains(stacks, "errclose_test.TestDeadlineDump.func"),
+		true,
+		"stacks include stuck close",
+	)
+
+	err := <-shutdownErr
+	assertEqual(
+		t,
+		err.Error(),
+		"shutdown interrupted with 1 resources left: context deadline exceeded",
+		"error string",
+	)
+}
+
+func TestDeadlineDumpNotWrittenWhenShutdownCompletes(t *testing.T) {
+	var manager errclose.ShutdownManager
+	var dump bytes.Buffer
+	manager.SetDeadlineDump(&dump)
+	manager.Defer(openFileWithoutCloseError(), "file")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := manager.Shutdown(ctx)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, dump.String(), "", "dump")
+}
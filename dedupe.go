@@ -0,0 +1,177 @@
+package errclose
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// closeGroup closes count resources in reverse order (getting each resource and its name from
+// resourceAt), like calling [errclose.Close] for each of them. If several resources fail with the
+// same close error (matched with [errors.Is]), such as wrappers around the same broken connection,
+// the error is only included once, with the number of resources and the names of the first
+// maxMergedNames of them (in closing order), followed by "…" if there are more:
+//
+//	failed to close <count> resources (<name 1>, <name 2>, <name 3>, …): <close error>
+//
+// Errors are not matched by message, since the merged error only wraps one of them, and different
+// errors with the same message should stay checkable with errors.Is. Other close errors are
+// combined as in errclose.Close, in the order of their first occurrence.
+// Each close error is still written to the event log and passed to the observer, with the name of
+// the resource that failed (unless summarized, see [errclose.SetRepeatSummary]). A merged close
+// error has the details (such as Stats and Caller) of the first resource that failed, and is fatal
+// if any of the merged errors are.
+func closeGroup(
+	returnedErr *error,
+	count int,
+	resourceAt func(index int) namedResource,
+) {
+	// The groups are only moved to the heap if the report filter needs them, so that reusing a
+	// frame doesn't allocate
+	var localGroups []closeErrorGroup
+	groups := &localGroups
+	var options []Option
+	if repeatSummaryEnabled() {
+		summary := &groupSummary{groups: nil}
+		groups = &summary.groups
+		options = withReportFilter(nil, summary.isSummaryOccurrence)
+	}
+
+	for i := count - 1; i >= 0; i-- {
+		resource := resourceAt(i)
+		resourceOptions := options
+		if len(resource.options) > 0 {
+			resourceOptions = slices.Concat(resource.options, options)
+		}
+
+		var closeErr error
+		closeResource(resource.resource, &closeErr, resource.resourceName, resourceOptions, 1)
+		if closeErr == nil {
+			continue
+		}
+
+		duplicate := false
+		for j := range *groups {
+			group := &(*groups)[j]
+			if isDuplicateCloseError(group.err, closeErr) {
+				group.names = append(group.names, closeErrorName(closeErr))
+				group.fatal = group.fatal || IsFatal(closeErr)
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			*groups = append(
+				*groups,
+				closeErrorGroup{
+					err:   closeErr,
+					names: []string{closeErrorName(closeErr)},
+					fatal: IsFatal(closeErr),
+				},
+			)
+		}
+	}
+
+	for _, group := range *groups {
+		if len(group.names) == 1 {
+			combineIntoReturnedErr(returnedErr, group.err, "")
+			continue
+		}
+
+		//nolint:errorlint // Close on a nil error always gives a direct CloseError
+		closeErr, ok := group.err.(*CloseError)
+		if !ok {
+			combineIntoReturnedErr(returnedErr, group.err, "")
+			continue
+		}
+		merged := newCloseError(
+			closeErr.Err,
+			closeErr.actionOrDefault(),
+			mergedResourceName(group.names),
+		)
+		// The details are taken from the first resource that failed, but the merged error is
+		// fatal if any of the resources are, so that merging doesn't hide a fatal failure
+		merged.Stats = closeErr.Stats
+		merged.Caller = closeErr.Caller
+		merged.OSDetail = closeErr.OSDetail
+		merged.TraceID = closeErr.TraceID
+		merged.Labels = closeErr.Labels
+		merged.ResourceID = closeErr.ResourceID
+		merged.fatal = group.fatal
+		combineIntoReturnedErr(returnedErr, merged, merged.ResourceName)
+	}
+}
+
+// closeErrorGroup is a close error from closeGroup, with the names of the resources that failed
+// with it.
+type closeErrorGroup struct {
+	err   error
+	names []string
+	// Whether any of the close errors in the group are fatal (see [errclose.IsFatal])
+	fatal bool
+}
+
+// groupSummary holds the close error groups of a call to closeGroup when summarizing repeated
+// failures (see [errclose.SetRepeatSummary]).
+type groupSummary struct {
+	groups []closeErrorGroup
+}
+
+// isSummaryOccurrence is the report filter for closeGroup, which reports a close error if it's
+// the 1st, 2nd, 4th (and so on) occurrence in its group.
+func (summary *groupSummary) isSummaryOccurrence(closeErr *CloseError) bool {
+	for _, group := range summary.groups {
+		if isDuplicateCloseError(group.err, closeErr) {
+			return isSummaryOccurrence(len(group.names) + 1)
+		}
+	}
+	return true
+}
+
+// maxMergedNames is the number of resource names to include in a merged close error from
+// closeGroup, so that the message stays readable when many resources fail together.
+const maxMergedNames = 3
+
+// mergedResourceName returns the resource name for a merged close error from closeGroup, with the
+// number of resources and their names, e.g. "5 resources (a, b, c, …)".
+func mergedResourceName(names []string) string {
+	var name strings.Builder
+	name.WriteString(strconv.Itoa(len(names)))
+	name.WriteString(" resources (")
+	for i, resourceName := range names[:min(len(names), maxMergedNames)] {
+		if i > 0 {
+			name.WriteString(", ")
+		}
+		name.WriteString(resourceName)
+	}
+	if len(names) > maxMergedNames {
+		name.WriteString(", …")
+	}
+	name.WriteString(")")
+	return name.String()
+}
+
+// closeErrorName returns the resource name of the given error from Close, for listing it in a
+// merged close error.
+func closeErrorName(err error) string {
+	//nolint:errorlint // Close on a nil error always gives a direct CloseError
+	if closeErr, ok := err.(*CloseError); ok {
+		return closeErr.ResourceName
+	}
+	return ""
+}
+
+// isDuplicateCloseError returns true if the given errors from closing two different resources
+// have the same underlying cause.
+func isDuplicateCloseError(err1 error, err2 error) bool {
+	//nolint:errorlint // Close on a nil error always gives a direct CloseError
+	closeErr1, ok1 := err1.(*CloseError)
+	//nolint:errorlint // Close on a nil error always gives a direct CloseError
+	closeErr2, ok2 := err2.(*CloseError)
+	if !ok1 || !ok2 || closeErr1.actionOrDefault() != closeErr2.actionOrDefault() {
+		return false
+	}
+
+	return errors.Is(closeErr2.Err, closeErr1.Err)
+}
@@ -0,0 +1,69 @@
+package errclose
+
+// DeferStack collects undo actions for multi-step initialization, and runs them only if the
+// initialization fails. You push an undo action after each step succeeds, and defer
+// [DeferStack.RunOnError]; on success, you call [DeferStack.Disarm] to keep the results:
+//
+//	func setupWorkspace() (workspace *Workspace, returnedErr error) {
+//		var undo errclose.DeferStack
+//		defer undo.RunOnError(&returnedErr)
+//
+//		dir, err := os.MkdirTemp("", "workspace")
+//		if err != nil {
+//			return nil, err
+//		}
+//		undo.Push(func() error { return os.RemoveAll(dir) }, "remove temp dir")
+//
+//		user, err := createUser()
+//		if err != nil {
+//			return nil, err // Temp dir is removed
+//		}
+//		undo.Push(func() error { return deleteUser(user) }, "delete user")
+//
+//		undo.Disarm()
+//		return &Workspace{dir: dir, user: user}, nil // Temp dir and user are kept
+//	}
+//
+// The zero value is ready to use. A DeferStack is not safe for concurrent use.
+type DeferStack struct {
+	actions []undoAction
+}
+
+type undoAction struct {
+	undo       func() error
+	actionName string
+}
+
+// Push adds an undo action to the stack. The action name describes what the undo action does, and
+// is used to format its error, as in [errclose.Do].
+func (stack *DeferStack) Push(undo func() error, actionName string) {
+	stack.actions = append(stack.actions, undoAction{undo: undo, actionName: actionName})
+}
+
+// Disarm removes all undo actions from the stack, so [DeferStack.RunOnError] doesn't run them.
+// Call it when all initialization steps have succeeded. Actions pushed after Disarm are run as
+// usual.
+func (stack *DeferStack) Disarm() {
+	stack.actions = nil
+}
+
+// RunOnError runs the undo actions on the stack in the reverse order of how they were pushed, if
+// the error pointed to by returnedErr is non-nil. All actions are run, even if some of them fail.
+// Errors from the undo actions are combined with the existing error in the same way as
+// [errclose.Do]:
+//
+//	<existing error> (and failed to <actionName>: <undo error>)
+//
+// If returnedErr is a nil pointer, or points to a nil error, nothing is run. In both cases, the
+// stack is emptied.
+func (stack *DeferStack) RunOnError(returnedErr *error) {
+	actions := stack.actions
+	stack.actions = nil
+
+	if returnedErr == nil || *returnedErr == nil {
+		return
+	}
+	for i := len(actions) - 1; i >= 0; i-- {
+		Do(actions[i].undo, returnedErr, actions[i].actionName)
+	}
+}
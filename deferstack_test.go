@@ -0,0 +1,74 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestDeferStackRunOnError(t *testing.T) {
+	var undone []string
+
+	setup := func() (returnedErr error) {
+		var undo errclose.DeferStack
+		defer undo.RunOnError(&returnedErr)
+
+		undo.Push(func() error {
+			undone = append(undone, "temp dir")
+			return errors.New("directory busy")
+		}, "remove temp dir")
+		undo.Push(func() error {
+			undone = append(undone, "user")
+			return nil
+		}, "delete user")
+
+		return fallibleOperation()
+	}
+
+	err := setup()
+	assertEqual(t, undone, []string{"user", "temp dir"}, "undo order")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to remove temp dir: directory busy)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+}
+
+func TestDeferStackDisarm(t *testing.T) {
+	undoCalled := false
+
+	setup := func() (returnedErr error) {
+		var undo errclose.DeferStack
+		defer undo.RunOnError(&returnedErr)
+
+		undo.Push(func() error {
+			undoCalled = true
+			return nil
+		}, "remove temp dir")
+
+		undo.Disarm()
+		return fallibleOperation()
+	}
+
+	err := setup()
+	assertEqual(t, err, errFallibleOperation, "error")
+	assertEqual(t, undoCalled, false, "undo was called")
+}
+
+func TestDeferStackWithoutError(t *testing.T) {
+	undoCalled := false
+
+	var undo errclose.DeferStack
+	undo.Push(func() error {
+		undoCalled = true
+		return nil
+	}, "remove temp dir")
+
+	var err error
+	undo.RunOnError(&err)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, undoCalled, false, "undo was called")
+}
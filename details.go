@@ -0,0 +1,92 @@
+package errclose
+
+// ErrorDetails is a serializable description of an error returned by this package, returned by
+// [errclose.Details]. It exposes the parts of a combined error separately, for passing errors
+// across boundaries where the error values themselves are lost, such as RPC responses.
+type ErrorDetails struct {
+	// Message is the full error message.
+	Message string `json:"message"`
+	// Errors are the messages of the combined errors that are not close errors, in order. The
+	// first of these is typically the primary error, which the close errors were combined with.
+	Errors []string `json:"errors,omitempty"`
+	// CloseErrors are the details of the combined close errors, in order.
+	CloseErrors []CloseErrorDetails `json:"closeErrors,omitempty"`
+}
+
+// CloseErrorDetails is a serializable description of a [errclose.CloseError], as part of
+// [errclose.ErrorDetails].
+type CloseErrorDetails struct {
+	// Resource is the resource name (see [CloseError.ResourceName]).
+	Resource string `json:"resource"`
+	// Action is the teardown action that failed, e.g. "close" or "shut down".
+	Action string `json:"action"`
+	// Error is the message of the teardown error (see [CloseError.Err]), without the resource name.
+	Error string `json:"error"`
+}
+
+// Details splits an error returned by this package into its parts. For example, for the following
+// error from [errclose.Close]:
+//
+//	request failed (and failed to close file: close error)
+//
+// The details are (as JSON):
+//
+//	{
+//		"message": "request failed (and failed to close file: close error)",
+//		"errors": ["request failed"],
+//		"closeErrors": [{"resource": "file", "action": "close", "error": "close error"}]
+//	}
+//
+// Details only splits errors combined by this package. If the error has been wrapped by other
+// code since it was combined (e.g. with [fmt.Errorf]), it's described as a single error. If err is
+// nil, Details returns the zero value.
+func Details(err error) ErrorDetails {
+	details := ErrorDetails{Message: "", Errors: nil, CloseErrors: nil}
+	if err == nil {
+		return details
+	}
+
+	details.Message = err.Error()
+	details.addParts(err)
+	return details
+}
+
+func (details *ErrorDetails) addParts(err error) {
+	switch err := err.(type) { //nolint:errorlint // Only splitting errors combined by this package
+	case *combinedError:
+		details.addParts(err.errs[0])
+		details.addParts(err.errs[1])
+	case *CloseError:
+		details.CloseErrors = append(details.CloseErrors, CloseErrorDetails{
+			Resource: err.ResourceName,
+			Action:   err.actionOrDefault(),
+			Error:    err.Err.Error(),
+		})
+	default:
+		details.Errors = append(details.Errors, err.Error())
+	}
+}
+
+// Errors returns every [errclose.CloseError] in the given error's tree, in the order they are found
+// by a depth-first walk (the same order as [errors.As] checks errors in). This includes close
+// errors combined with an existing error, close errors attached with [errclose.KeepPrimary], and
+// close errors wrapped by other code along the way. It lets you report all close failures as
+// structured data, even when several calls to this package contributed to the error:
+//
+//	for _, closeErr := range errclose.Errors(err) {
+//		slog.Warn("Resource failed to close", "resource", closeErr.ResourceName)
+//	}
+//
+// Close errors that wrap other close errors (such as from [errclose.MultiCloser]) are returned
+// before the ones they wrap. If there are no close errors in the tree, Errors returns nil.
+func Errors(err error) []*CloseError {
+	var closeErrs []*CloseError
+	anyInErrorTree(err, func(wrapped error) bool {
+		//nolint:errorlint // Checking each error in the tree
+		if closeErr, ok := wrapped.(*CloseError); ok {
+			closeErrs = append(closeErrs, closeErr)
+		}
+		return false
+	})
+	return closeErrs
+}
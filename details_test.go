@@ -0,0 +1,77 @@
+package errclose_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestDetails(t *testing.T) {
+	err := errors.New("request failed")
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	errclose.Close(openFileWithCloseError(), &err, "other file")
+
+	details := errclose.Details(err)
+	assertEqual(
+		t,
+		details,
+		errclose.ErrorDetails{
+			Message: "request failed (and failed to close file: close error) " +
+				"(and failed to close other file: close error)",
+			Errors: []string{"request failed"},
+			CloseErrors: []errclose.CloseErrorDetails{
+				{Resource: "file", Action: "close", Error: "close error"},
+				{Resource: "other file", Action: "close", Error: "close error"},
+			},
+		},
+		"details",
+	)
+}
+
+func TestDetailsJSON(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+
+	output, marshalErr := json.Marshal(errclose.Details(err))
+	assertEqual(t, marshalErr, nil, "marshal error")
+	assertEqual(
+		t,
+		string(output),
+		`{"message":"failed to close file: close error",`+
+			`"closeErrors":[{"resource":"file","action":"close","error":"close error"}]}`,
+		"JSON",
+	)
+}
+
+func TestDetailsNil(t *testing.T) {
+	assertEqual(
+		t,
+		errclose.Details(nil),
+		errclose.ErrorDetails{Message: "", Errors: nil, CloseErrors: nil},
+		"details",
+	)
+}
+
+func TestErrors(t *testing.T) {
+	err := errors.New("request failed")
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	errclose.Closef(openFileWithCloseError(), &err, "file %d", 2)
+	err = fmt.Errorf("handler: %w", err)
+	errclose.Close(openFileWithCloseError(), &err, "connection", errclose.KeepPrimary())
+
+	closeErrs := errclose.Errors(err)
+	resourceNames := make([]string, 0, len(closeErrs))
+	for _, closeErr := range closeErrs {
+		resourceNames = append(resourceNames, closeErr.ResourceName)
+	}
+	assertEqual(t, resourceNames, []string{"file", "file 2", "connection"}, "resource names")
+	assertEqual(t, closeErrs[0].Err.Error(), "close error", "close error message")
+}
+
+func TestErrorsWithoutCloseErrors(t *testing.T) {
+	assertEqual(t, len(errclose.Errors(errors.New("request failed"))), 0, "number of close errors")
+	assertEqual(t, len(errclose.Errors(nil)), 0, "number of close errors for nil")
+}
@@ -0,0 +1,135 @@
+package errclose
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type closeDiagnostics struct {
+	threshold      time.Duration
+	dumpGoroutines bool
+}
+
+var diagnostics atomic.Pointer[closeDiagnostics]
+
+// SetCloseDiagnostics enables a debug mode for finding slow or hanging closes, such as a deferred
+// Close that blocks shutdown. When enabled, [errclose.Close] and [errclose.Closef] (and functions
+// built on them) record where they were called from and on which goroutine, and measure how long
+// the close takes. If a close takes longer than the given threshold, a [errclose.SlowCloseError]
+// with the diagnostics is passed to the observer (see [errclose.SetObserver]), and written to the
+// event log as a slow_close event (see [errclose.SetEventLog]):
+//
+//	errclose.SetCloseDiagnostics(5*time.Second, true)
+//	errclose.SetObserver(func(resourceName string, closeErr error) {
+//		var slowErr *errclose.SlowCloseError
+//		if errors.As(closeErr, &slowErr) {
+//			slog.Warn("Slow close", "error", slowErr, "goroutines", string(slowErr.Goroutines))
+//		}
+//	})
+//
+// The diagnostics are reported when the threshold is reached, while the close is still running, so
+// closes that hang forever are reported as well. If dumpGoroutines is true, the stack traces of
+// all goroutines are captured at that point, which shows where the close is blocked.
+//
+// Pass a threshold of 0 to disable close diagnostics (this is the default). Diagnostics make every
+// close allocate, and call [fmt.Sprintf] for the resource name in Closef, so they're meant for
+// debugging rather than production use.
+func SetCloseDiagnostics(threshold time.Duration, dumpGoroutines bool) {
+	if threshold <= 0 {
+		diagnostics.Store(nil)
+	} else {
+		diagnostics.Store(&closeDiagnostics{threshold: threshold, dumpGoroutines: dumpGoroutines})
+	}
+}
+
+// SlowCloseError is passed to the observer when a close takes longer than the threshold set by
+// [errclose.SetCloseDiagnostics]. Its error message has the following format:
+//
+//	closing <resourceName> (called at <caller> on goroutine <id>) took longer than <threshold>
+type SlowCloseError struct {
+	ResourceName string
+	// Caller is the file and line of the errclose call that closed the resource, or empty if it
+	// couldn't be found.
+	Caller string
+	// Goroutine is the ID of the goroutine that closed the resource, as in stack traces.
+	Goroutine uint64
+	Threshold time.Duration
+	// Goroutines is a dump of the stack traces of all goroutines when the threshold was reached,
+	// in the format of [runtime.Stack]. It's nil unless goroutine dumps are enabled.
+	Goroutines []byte
+}
+
+func (err *SlowCloseError) Error() string {
+	return "closing " + err.ResourceName +
+		" (called at " + err.Caller +
+		" on goroutine " + strconv.FormatUint(err.Goroutine, 10) +
+		") took longer than " + err.Threshold.String()
+}
+
+// closeEventsEnabled returns true if closes should go through closeWithDebugEvent, because debug
+// events (see [errclose.SetDebugEvents]) or close diagnostics are enabled.
+func closeEventsEnabled() bool {
+	return debugEvents.Load() || diagnostics.Load() != nil
+}
+
+// startCloseDiagnostics starts a timer that reports a [errclose.SlowCloseError] if the close isn't
+// done within the threshold set by [errclose.SetCloseDiagnostics]. The returned function stops
+// the timer, and must be called when the close is done. callerSkip is the number of stack frames
+// between this function and the caller of the errclose function.
+func startCloseDiagnostics(resourceName string, callerSkip int) (stop func()) {
+	settings := diagnostics.Load()
+	if settings == nil {
+		return func() {}
+	}
+
+	slowErr := &SlowCloseError{
+		ResourceName: resourceName,
+		Caller:       "",
+		Goroutine:    currentGoroutine(),
+		Threshold:    settings.threshold,
+		Goroutines:   nil,
+	}
+	if _, file, line, ok := runtime.Caller(callerSkip + 1); ok {
+		slowErr.Caller = file + ":" + strconv.Itoa(line)
+	}
+
+	timer := time.AfterFunc(settings.threshold, func() {
+		if settings.dumpGoroutines {
+			slowErr.Goroutines = dumpGoroutines()
+		}
+		logEvent(eventSlowClose, resourceName, slowErr)
+		observe(resourceName, slowErr)
+	})
+	return func() { timer.Stop() }
+}
+
+// currentGoroutine returns the ID of the current goroutine, parsed from the header of its stack
+// trace ("goroutine 18 [running]:"), or 0 if it couldn't be parsed.
+func currentGoroutine() uint64 {
+	var buffer [64]byte
+	header := buffer[:runtime.Stack(buffer[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if end := bytes.IndexByte(header, ' '); end >= 0 {
+		header = header[:end]
+	}
+
+	id, err := strconv.ParseUint(string(header), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func dumpGoroutines() []byte {
+	buffer := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buffer, true)
+		if n < len(buffer) {
+			return buffer[:n]
+		}
+		buffer = make([]byte, 2*len(buffer))
+	}
+}
@@ -0,0 +1,83 @@
+package errclose_test
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseDiagnostics(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetCloseDiagnostics(10*time.Millisecond, true)
+
+	slowErrs := make(chan *errclose.SlowCloseError, 1)
+	errclose.SetObserver(func(_ string, closeErr error) {
+		var slowErr *errclose.SlowCloseError
+		if errors.As(closeErr, &slowErr) {
+			slowErrs <- slowErr
+		}
+	})
+
+	slowCloser := closerFunc(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	var err error
+	_, _, line, _ := runtime.Caller(0)
+	errclose.Close(slowCloser, &err, "slow resource")
+	assertEqual(t, err, nil, "error")
+
+	var slowErr *errclose.SlowCloseError
+	select {
+	case slowErr = <-slowErrs:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for slow close diagnostics")
+	}
+
+	assertEqual(t, slowErr.ResourceName, "slow resource", "SlowCloseError.ResourceName")
+	assertEqual(
+		t,
+		strings.HasSuffix(slowErr.Caller, "diagnostics_test.go:"+strconv.Itoa(line+1)),
+		true,
+		"SlowCloseError.Caller ("+slowErr.Caller+")",
+	)
+	assertEqual(t, slowErr.Goroutine != 0, true, "SlowCloseError.Goroutine is set")
+	assertEqual(
+		t,
+		bytes.Contains(slowErr.Goroutines, []byte("goroutine ")),
+		true,
+		"SlowCloseError.Goroutines contains stack traces",
+	)
+	assertEqual(
+		t,
+		strings.HasSuffix(slowErr.Error(), ") took longer than 10ms"),
+		true,
+		"error string ("+slowErr.Error()+")",
+	)
+}
+
+func TestCloseDiagnosticsFastClose(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetCloseDiagnostics(time.Second, false)
+
+	observed := make(chan error, 1)
+	errclose.SetObserver(func(_ string, closeErr error) { observed <- closeErr })
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, (<-observed).Error(), "close error", "observed error")
+
+	select {
+	case closeErr := <-observed:
+		t.Fatalf("Unexpected observed error: %v", closeErr)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
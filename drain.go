@@ -0,0 +1,93 @@
+package errclose
+
+import (
+	"context"
+)
+
+// Drainable is implemented by resources that need a multi-stage teardown, such as worker pools and
+// servers: first stop accepting new work, then wait for in-flight work to finish, then release the
+// underlying resources. Use [errclose.CloseDrainable] to run the stages in order.
+//
+// [ShutdownManager.Shutdown] (and so [errclose.ShutdownGlobal]) also uses CloseDrainable for
+// registered resources that implement Drainable.
+type Drainable interface {
+	// StopAccepting makes the resource stop accepting new work. It should not block.
+	StopAccepting()
+	// Drain waits for in-flight work to finish. It should return when the context is canceled,
+	// and may then return the context error.
+	Drain(ctx context.Context) error
+	// Close releases the resource. It's called after Drain returns, also if Drain failed.
+	Close() error
+}
+
+// CloseDrainable tears down the given resource in three stages: it calls StopAccepting, then Drain
+// with the given context, then Close. This lets you give the drain stage a deadline through the
+// context, while still closing the resource if draining times out:
+//
+//	func serve(ctx context.Context) (returnedErr error) {
+//		pool := startWorkerPool()
+//		defer func() {
+//			drainCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//			defer cancel()
+//			errclose.CloseDrainable(drainCtx, pool, &returnedErr, "worker pool")
+//		}()
+//
+//		// Use pool
+//	}
+//
+// Errors from Drain and Close are labeled with their stage, on the following formats:
+//
+//	failed to drain <resourceName>: <drain error>
+//	failed to close <resourceName>: <close error>
+//
+// The errors are combined with the error pointed to by returnedErr (and each other, if both stages
+// fail) in the same way as in [errclose.Close].
+//
+// If Drain was interrupted because the context deadline was exceeded, the drain error also matches
+// [errclose.ErrCloseTimeout] with [errors.Is].
+func CloseDrainable(
+	ctx context.Context,
+	resource Drainable,
+	returnedErr *error,
+	resourceName string,
+) {
+	closeDrainable(ctx, resource, returnedErr, resourceName, contextOptions(ctx))
+}
+
+// closeDrainable implements [errclose.CloseDrainable], with the given options for the drain and
+// close steps. The drain step is counted as its own close attempt in the metrics, unless the
+// options come from withCloseAttempt.
+func closeDrainable(
+	ctx context.Context,
+	resource Drainable,
+	returnedErr *error,
+	resourceName string,
+	options []Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	resource.StopAccepting()
+
+	lists := withDefaults(resource, options)
+	if attemptFromOptions(lists) == nil {
+		recordCloseAttempt(resourceName)
+	}
+	if drainErr := resource.Drain(ctx); drainErr != nil {
+		drainErr = withDeadlineSentinel(drainErr, drainErr, ErrCloseTimeout)
+		handleContextTeardownError(
+			ctx,
+			returnedErr,
+			drainErr,
+			"drain",
+			resourceName,
+			lists,
+			nil,
+			2,
+		)
+	}
+
+	closeResource(resource, returnedErr, resourceName, options, 2)
+}
@@ -0,0 +1,99 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseDrainable(t *testing.T) {
+	pool := &mockWorkerPool{stages: nil, drainErr: nil, closeErr: nil}
+
+	usePool := func() (returnedErr error) {
+		defer errclose.CloseDrainable(context.Background(), pool, &returnedErr, "worker pool")
+		return nil
+	}
+
+	err := usePool()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, pool.stages, []string{"stop accepting", "drain", "close"}, "stages")
+}
+
+func TestCloseDrainableWithErrors(t *testing.T) {
+	pool := &mockWorkerPool{
+		stages:   nil,
+		drainErr: errors.New("jobs still running"),
+		closeErr: errors.New("close error"),
+	}
+
+	usePool := func() (returnedErr error) {
+		defer errclose.CloseDrainable(context.Background(), pool, &returnedErr, "worker pool")
+		return fallibleOperation()
+	}
+
+	err := usePool()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to drain worker pool: jobs still running) "+
+			"(and failed to close worker pool: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, pool.drainErr), true, "errors.Is(drainErr)")
+	assertEqual(t, errors.Is(err, pool.closeErr), true, "errors.Is(closeErr)")
+	assertEqual(t, pool.stages, []string{"stop accepting", "drain", "close"}, "stages")
+}
+
+func TestCloseDrainableWithExceededDeadline(t *testing.T) {
+	pool := &mockWorkerPool{stages: nil, drainErr: nil, closeErr: nil}
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	usePool := func() (returnedErr error) {
+		defer errclose.CloseDrainable(ctx, pool, &returnedErr, "worker pool")
+		pool.drainErr = ctx.Err()
+		return nil
+	}
+
+	err := usePool()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to drain worker pool: context deadline exceeded",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errclose.ErrCloseTimeout), true, "errors.Is(ErrCloseTimeout)")
+	assertEqual(t, pool.stages, []string{"stop accepting", "drain", "close"}, "stages")
+}
+
+func TestShutdownGlobalDrainsDrainable(t *testing.T) {
+	pool := &mockWorkerPool{stages: nil, drainErr: nil, closeErr: nil}
+	errclose.DeferGlobal(pool, "worker pool")
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, pool.stages, []string{"stop accepting", "drain", "close"}, "stages")
+}
+
+type mockWorkerPool struct {
+	stages   []string
+	drainErr error
+	closeErr error
+}
+
+func (pool *mockWorkerPool) StopAccepting() {
+	pool.stages = append(pool.stages, "stop accepting")
+}
+
+func (pool *mockWorkerPool) Drain(context.Context) error {
+	pool.stages = append(pool.stages, "drain")
+	return pool.drainErr
+}
+
+func (pool *mockWorkerPool) Close() error {
+	pool.stages = append(pool.stages, "close")
+	return pool.closeErr
+}
@@ -1,5 +1,17 @@
 // Package errclose provides [errclose.Close], a function for handling errors when closing
 // resources.
+//
+// # Performance
+//
+// [errclose.Close] and [errclose.Closef] don't allocate when the resource closes without error, so
+// they can be used on hot paths (the package's tests check this with [testing.AllocsPerRun]).
+// Allocations are only made when there is a close error, to format the error message. Note that
+// for Closef, passing format args as ...any may allocate at the call site for some argument types,
+// even though the formatting itself only happens on error.
+//
+// Passing a resource that is not a pointer to Close converts it to an interface, which may
+// allocate at the call site. For such resources, [errclose.CloseG] takes the resource as a type
+// parameter instead, to avoid the conversion.
 package errclose
 
 import (
@@ -44,40 +56,91 @@ import (
 //
 //	<existing error> (and failed to close <resourceName>: <close error>)
 //
-// The error string formatting uses [fmt.Errorf] with the %w verb, so that the underlying errors can
-// be checked with [errors.Is] and [errors.As].
+// To use a shorter format for combined errors, see [errclose.SetErrorFormat].
+//
+// The underlying errors are wrapped (as with the %w verb in [fmt.Errorf]), so that they can be
+// checked with [errors.Is] and [errors.As]. The close error is wrapped in a
+// [errclose.CloseError], which you can use to get the resource name. When the existing error and
+// the close error are combined, the combined error has an Unwrap() []error method (like errors from
+// [errors.Join]), which returns the existing error and the CloseError. This lets multi-error
+// tooling inspect the two failures independently.
+//
+// If the resource is nil, the close error is [errclose.ErrNilResource] (to change how nil
+// resources are handled, see [errclose.SetNilResourcePolicy]).
 //
-// If you want to use format args to format the resource name, call [errclose.Closef].
+// If the resource name is empty and the resource implements [errclose.NamedCloser], the name from
+// the resource is used instead.
+//
+// # Options
+//
+// Close takes optional trailing options to change how close errors are handled, such as
+// [errclose.Ignore] to drop benign close errors:
+//
+//	defer errclose.Close(conn, &returnedErr, "connection", errclose.Ignore(net.ErrClosed))
+//
+// If you want to use format args to format the resource name, call [errclose.Closef] (or
+// [errclose.ClosefWith], to also pass options).
 func Close(
 	resource interface{ Close() error },
 	returnedErr *error,
 	resourceName string,
+	options ...Option,
 ) {
-	closeErr := resource.Close()
-	if closeErr == nil {
+	closeResource(resource, returnedErr, resourceName, options, 1)
+}
+
+// closeResource implements [errclose.Close]. callerSkip is the number of stack frames between this
+// function and the caller of the errclose function.
+func closeResource(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	options []Option,
+	callerSkip int,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
 		return
 	}
-
-	currentReturnedErr := *returnedErr
-	if currentReturnedErr != nil {
-		*returnedErr = fmt.Errorf(
-			"%w (and failed to close %s: %w)",
-			currentReturnedErr,
-			resourceName,
-			closeErr,
+	lists := withDefaults(resource, options)
+	if metricsEnabled() && attemptFromOptions(lists) == nil {
+		recordCloseAttempt(resolveResourceName(resource, resourceName))
+	}
+	if closeEventsEnabled() {
+		closeWithDebugEvent(
+			resource,
+			returnedErr,
+			resolveResourceName(resource, resourceName),
+			lists,
+			callerSkip+1,
 		)
-	} else {
-		*returnedErr = fmt.Errorf("failed to close %s: %w", resourceName, closeErr)
+		return
+	}
+
+	stats := captureStats(lists)
+	closeErr := closeWithOptions(resource, resourceName, lists)
+	if closeErr == nil {
+		return
 	}
+
+	handleCloseErrorWithOptions(
+		returnedErr,
+		closeErr,
+		resolveResourceName(resource, resourceName),
+		lists,
+		stats,
+		callerSkip+1,
+	)
 }
 
 // Closef closes the given resource, and handles close errors.
 //
 // It takes a format string and args to construct a name for the resource (with [fmt.Sprintf]),
 // which is added to the close error for context (see 'Error format' below). The formatting is only
-// performed if there is a close error, so this is more efficient than calling [fmt.Sprintf]
-// yourself and passing the result to [errclose.Close], as that will perform formatting even when
-// there's no error.
+// performed when the name is needed (if there is a close error, or if metrics or debug events are
+// enabled), and then at most once. This is more efficient than calling [fmt.Sprintf] yourself and
+// passing the result to [errclose.Close], as that will perform formatting even when there's no
+// error.
 //
 // You'll typically call this in a defer statement (to close a resource when the function exits),
 // using named returns to give a pointer to the error returned by your function:
@@ -115,30 +178,163 @@ func Close(
 //
 //	<existing error> (and failed to close <formatted resource name>: <close error>)
 //
-// The error string formatting uses [fmt.Errorf] with the %w verb, so that the underlying errors can
-// be checked with [errors.Is] and [errors.As].
+// The underlying errors are wrapped (as with the %w verb in [fmt.Errorf]), so that they can be
+// checked with [errors.Is] and [errors.As].
+//
+// If the resource is nil, the close error is [errclose.ErrNilResource] (see also
+// [errclose.SetNilResourcePolicy]). If the formatted resource name is empty and the resource
+// implements [errclose.NamedCloser], the name from the resource is used instead.
+//
+// To pass options (see [errclose.Option]), use [errclose.ClosefWith].
 func Closef(
 	resource interface{ Close() error },
 	returnedErr *error,
 	resourceNameFormat string,
 	formatArgs ...any,
 ) {
-	closeErr := resource.Close()
+	closefResource(resource, returnedErr, nil, nil, 1, resourceNameFormat, formatArgs...)
+}
+
+// ClosefWith works like [errclose.Closef], but takes options to change how close errors are
+// handled, like [errclose.Close]. The options come before the format string, so that go vet can
+// check the format args like it does for [fmt.Sprintf]:
+//
+//	defer errclose.ClosefWith(
+//		conn,
+//		&returnedErr,
+//		[]errclose.Option{errclose.Ignore(net.ErrClosed)},
+//		"connection to %s",
+//		addr,
+//	)
+func ClosefWith(
+	resource interface{ Close() error },
+	returnedErr *error,
+	options []Option,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	closefResource(resource, returnedErr, nil, options, 1, resourceNameFormat, formatArgs...)
+}
+
+// closefResource implements [errclose.Closef], with the formatted resource name qualified by the
+// given scope, if it's not nil. callerSkip is the number of stack frames between this function and
+// the caller of the errclose function.
+//
+// The format string and args are the last parameters, and are passed on to fmt.Sprintf, so that
+// go vet checks the format args of the functions that call this.
+func closefResource(
+	resource interface{ Close() error },
+	returnedErr *error,
+	scope *NameScope,
+	options []Option,
+	callerSkip int,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	// The name is formatted here rather than in closeWithLazyName, so that go vet sees the format
+	// args being passed to fmt.Sprintf
+	formatName := func() string {
+		return fmt.Sprintf(resourceNameFormat, formatArgs...)
+	}
+	closeWithLazyName(
+		resource,
+		returnedErr,
+		lazyResourceName{scope: scope, name: "", stringer: nil},
+		formatName,
+		options,
+		callerSkip+1,
+	)
+}
+
+// Combine combines an existing error with a close error, on the same format as [errclose.Close]:
+//
+//	<existing error> (and failed to close <resourceName>: <close error>)
+//
+// If existingErr is nil, the close error is wrapped with the resource name:
+//
+//	failed to close <resourceName>: <close error>
+//
+// If closeErr is nil, existingErr is returned as-is.
+//
+// This is for code that can't pass a pointer to its returned error, such as frameworks with their
+// own cleanup mechanisms, but wants close errors to look the same as those from errclose.Close.
+// Unlike Close, Combine doesn't write to the event log (see [errclose.SetEventLog]).
+func Combine(existingErr error, closeErr error, resourceName string) error {
 	if closeErr == nil {
-		return
+		return existingErr
 	}
+	return wrapTeardownError(existingErr, closeErr, "close", resourceName)
+}
+
+// CloseErr closes the given resource, and returns the close error (if any) wrapped with the
+// resource name, on the same format as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+//
+// This is for closing a resource in the middle of a function, with normal error flow instead of a
+// deferred call:
+//
+//	if err := errclose.CloseErr(file, "file"); err != nil {
+//		return err
+//	}
+//
+// CloseErr takes the same options as errclose.Close. To combine the close error with an existing
+// error, use [errclose.CombineClose].
+func CloseErr(resource interface{ Close() error }, resourceName string, options ...Option) error {
+	var closeErr error
+	closeResource(resource, &closeErr, resourceName, options, 1)
+	return closeErr
+}
+
+// CombineClose closes the given resource, and returns the given primary error combined with the
+// close error, on the same format as [errclose.Close]:
+//
+//	<primary error> (and failed to close <resourceName>: <close error>)
+//
+// If primaryErr is nil, this works like [errclose.CloseErr]. If the close succeeds, primaryErr is
+// returned as-is. This is for closing a resource inline after an operation failed:
+//
+//	if err := process(file); err != nil {
+//		return errclose.CombineClose(err, file, "file")
+//	}
+func CombineClose(
+	primaryErr error,
+	resource interface{ Close() error },
+	resourceName string,
+	options ...Option,
+) error {
+	closeResource(resource, &primaryErr, resourceName, options, 1)
+	return primaryErr
+}
 
-	resourceName := fmt.Sprintf(resourceNameFormat, formatArgs...)
+// handleCloseError sets the error pointed to by returnedErr to the given close error, wrapped with
+// the resource name, or combines it with the existing error if returnedErr points to a non-nil
+// error (see 'Error format' on [errclose.Close]).
+func handleCloseError(returnedErr *error, closeErr error, resourceName string) {
+	handleTeardownError(returnedErr, closeErr, "close", resourceName)
+}
 
-	currentReturnedErr := *returnedErr
-	if currentReturnedErr != nil {
-		*returnedErr = fmt.Errorf(
-			"%w (and failed to close %s: %w)",
-			currentReturnedErr,
-			resourceName,
-			closeErr,
-		)
-	} else {
-		*returnedErr = fmt.Errorf("failed to close %s: %w", resourceName, closeErr)
+// handleTeardownError works like handleCloseError, but lets the caller choose the action in the
+// error message for teardown operations other than closing, e.g. "stop":
+//
+//	failed to <action> <resourceName>: <err>
+func handleTeardownError(returnedErr *error, err error, action string, resourceName string) {
+	handleWrappedError(returnedErr, newCloseError(err, action, resourceName))
+}
+
+// handleWrappedError works like handleTeardownError, but takes an already created CloseError.
+func handleWrappedError(returnedErr *error, closeErr *CloseError) {
+	reportWrappedCloseFailure(closeErr)
+	combineWrappedError(returnedErr, closeErr)
+}
+
+// combineWrappedError works like handleWrappedError, but without reporting the close error, for
+// callers that have already reported it.
+func combineWrappedError(returnedErr *error, closeErr *CloseError) {
+	if returnedErr == nil {
+		handleNilReturnedErr(closeErr.Err, closeErr.actionOrDefault(), closeErr.ResourceName)
+		return
 	}
+
+	*returnedErr = combineCloseError(*returnedErr, closeErr)
 }
@@ -2,7 +2,9 @@ package errclose_test
 
 import (
 	"errors"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"hermannm.dev/errclose"
@@ -149,6 +151,126 @@ func TestClosefWithoutCloseErrorWithExistingError(t *testing.T) {
 	assertEqual(t, err, errFallibleOperation, "error")
 }
 
+func TestCloseNilResource(t *testing.T) {
+	useFile := func() (returnedErr error) {
+		var file interface{ Close() error }
+		defer errclose.Close(file, &returnedErr, "file")
+		defer errclose.Closef(file, &returnedErr, "file at path %s", "/example/path")
+
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file at path /example/path: nil resource "+
+			"(and failed to close file: nil resource)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is(ErrNilResource)")
+}
+
+func TestCombinedErrorUnwrapsToBothErrors(t *testing.T) {
+	var file *mockFile
+
+	useFile := func() (returnedErr error) {
+		file = openFileWithCloseError()
+		defer errclose.Close(file, &returnedErr, "file")
+
+		return fallibleOperation()
+	}
+
+	err := useFile()
+	// We want the top-level error here, not an error further down the chain
+	multiErr, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint // See comment above
+	assertEqual(t, ok, true, "error implements Unwrap() []error")
+	unwrapped := multiErr.Unwrap()
+	assertEqual(t, len(unwrapped), 2, "number of unwrapped errors")
+	assertEqual(t, unwrapped[0], errFallibleOperation, "first unwrapped error")
+	closeErr, ok := unwrapped[1].(*errclose.CloseError) //nolint:errorlint // Checking exact error
+	assertEqual(t, ok, true, "second unwrapped error is CloseError")
+	assertEqual(t, closeErr.Err, file.closeError, "CloseError.Err")
+}
+
+func TestCloseErrorType(t *testing.T) {
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file")
+		return fallibleOperation()
+	}
+
+	err := useFile()
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As(CloseError)")
+	assertEqual(t, closeErr.ResourceName, "file", "CloseError.ResourceName")
+	assertEqual(t, closeErr.Err.Error(), "close error", "CloseError.Err")
+	assertEqual(t, closeErr.Error(), "failed to close file: close error", "CloseError string")
+}
+
+func TestCloseErrorFingerprint(t *testing.T) {
+	closeFile := func(path string, closeErr error) *errclose.CloseError {
+		file := &mockFile{
+			closeWasCalled: false,
+			closeError:     &os.PathError{Op: "close", Path: path, Err: closeErr},
+		}
+
+		var err error
+		errclose.Close(file, &err, "file "+path)
+
+		var closeError *errclose.CloseError
+		errors.As(err, &closeError)
+		return closeError
+	}
+
+	fingerprint1 := closeFile("/tmp/upload-123", os.ErrClosed).Fingerprint()
+	fingerprint2 := closeFile("/tmp/upload-456", os.ErrClosed).Fingerprint()
+	fingerprint3 := closeFile("/tmp/upload-123", errors.New("disk full")).Fingerprint()
+
+	assertEqual(t, fingerprint1, fingerprint2, "fingerprints for different paths")
+	assertEqual(t, fingerprint1 == fingerprint3, false, "fingerprints for different errors equal")
+	assertEqual(t, len(fingerprint1), 16, "fingerprint length")
+}
+
+func TestCloseErrorWithNilErr(t *testing.T) {
+	closeErr := &errclose.CloseError{
+		ResourceName: "file",
+		Err:          nil,
+		Stats:        nil,
+		Caller:       "",
+		OSDetail:     nil,
+		Occurrences:  0,
+		TraceID:      "",
+		Labels:       nil,
+		ResourceID:   0,
+	}
+
+	assertEqual(t, closeErr.Error(), "failed to close file: <nil>", "error string")
+	assertEqual(t, len(closeErr.Fingerprint()), 16, "fingerprint length")
+}
+
+func TestCombine(t *testing.T) {
+	closeErr := errors.New("close error")
+
+	err := errclose.Combine(errFallibleOperation, closeErr, "file")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string with existing error",
+	)
+	assertEqual(t, errors.Is(err, closeErr), true, "errors.Is(closeErr)")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is(errFallibleOperation)")
+
+	err = errclose.Combine(nil, closeErr, "file")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+
+	err = errclose.Combine(errFallibleOperation, nil, "file")
+	assertEqual(t, err, errFallibleOperation, "error without close error")
+
+	err = errclose.Combine(nil, nil, "file")
+	assertEqual(t, err, nil, "error without errors")
+}
+
 type mockFile struct {
 	closeWasCalled bool
 	closeError     error
@@ -167,6 +289,12 @@ func openFileWithoutCloseError() *mockFile {
 	return &mockFile{closeWasCalled: false, closeError: nil}
 }
 
+type closerFunc func() error
+
+func (closer closerFunc) Close() error {
+	return closer()
+}
+
 func fallibleOperation() error {
 	return errFallibleOperation
 }
@@ -187,3 +315,71 @@ Want: %+v
 		)
 	}
 }
+
+func TestCloseErr(t *testing.T) {
+	err := errclose.CloseErr(openFileWithCloseError(), "file")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+
+	err = errclose.CloseErr(openFileWithoutCloseError(), "file")
+	assertEqual(t, err, nil, "error without close error")
+}
+
+func TestCombineClose(t *testing.T) {
+	err := errclose.CombineClose(fallibleOperation(), openFileWithCloseError(), "file")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+
+	err = errclose.CombineClose(fallibleOperation(), openFileWithoutCloseError(), "file")
+	assertEqual(t, err, errFallibleOperation, "error without close error")
+}
+
+func TestCloseErrWithCaller(t *testing.T) {
+	err := errclose.CloseErr(openFileWithCloseError(), "file", errclose.WithCaller())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(
+		t,
+		strings.Contains(closeErr.Caller, "errclose_test.go:"),
+		true,
+		"caller '"+closeErr.Caller+"' is in test file",
+	)
+}
+
+func TestClosefWith(t *testing.T) {
+	var alsoErr error
+	useFile := func() (returnedErr error) {
+		defer errclose.ClosefWith(
+			closerFunc(func() error { return os.ErrClosed }),
+			&returnedErr,
+			[]errclose.Option{errclose.Ignore(os.ErrClosed)},
+			"file at path %s",
+			"/some/path",
+		)
+		defer errclose.ClosefWith(
+			openFileWithCloseError(),
+			&returnedErr,
+			[]errclose.Option{
+				errclose.Also(func(_ string, closeErr error) { alsoErr = closeErr }),
+			},
+			"file %d at path %s",
+			2,
+			"/other/path",
+		)
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file 2 at path /other/path: close error",
+		"error string",
+	)
+	assertEqual(t, alsoErr.Error(), "close error", "error passed to Also")
+}
@@ -0,0 +1,77 @@
+// Package errcloseexpvar provides an implementation of [errclose.Metrics] that publishes close
+// metrics with [expvar], so they can be read from the /debug/vars endpoint without depending on a
+// metrics library.
+//
+// This is in a separate package from [hermannm.dev/errclose], since importing expvar registers
+// the /debug/vars handler on [http.DefaultServeMux].
+package errcloseexpvar
+
+import (
+	"expvar"
+
+	"hermannm.dev/errclose"
+)
+
+// Metrics counts close attempts and failures per resource name, in [expvar.Map] variables. Create
+// one with [Publish].
+type Metrics struct {
+	attempts *expvar.Map
+	failures *expvar.Map
+	ignored  *expvar.Map
+	timeouts *expvar.Map
+}
+
+var _ errclose.Metrics = (*Metrics)(nil)
+
+// Publish creates a [Metrics], and publishes it as an expvar variable with the given name. Pass the
+// returned Metrics to [errclose.SetMetrics] to start counting:
+//
+//	errclose.SetMetrics(errcloseexpvar.Publish("errclose"))
+//
+// The variable is a map with the following keys, where each value is a map from resource names to
+// counts:
+//   - attempts: Close attempts
+//   - failures: Close failures (including timeouts)
+//   - ignored: Close errors dropped by [errclose.Ignore] or [errclose.IgnoreIf]
+//   - timeouts: Close failures that match [errclose.ErrCloseTimeout]
+//
+// For example:
+//
+//	"errclose": {"attempts": {"file": 2}, "failures": {"file": 1}, "ignored": {}, "timeouts": {}}
+//
+// Like [expvar.Publish], Publish panics if a variable with the given name is already published.
+func Publish(name string) *Metrics {
+	metrics := &Metrics{
+		attempts: new(expvar.Map).Init(),
+		failures: new(expvar.Map).Init(),
+		ignored:  new(expvar.Map).Init(),
+		timeouts: new(expvar.Map).Init(),
+	}
+
+	published := expvar.NewMap(name)
+	published.Set("attempts", metrics.attempts)
+	published.Set("failures", metrics.failures)
+	published.Set("ignored", metrics.ignored)
+	published.Set("timeouts", metrics.timeouts)
+	return metrics
+}
+
+// CloseAttempted implements [errclose.Metrics].
+func (metrics *Metrics) CloseAttempted(resourceName string) {
+	metrics.attempts.Add(resourceName, 1)
+}
+
+// CloseFailed implements [errclose.Metrics].
+func (metrics *Metrics) CloseFailed(resourceName string, _ error) {
+	metrics.failures.Add(resourceName, 1)
+}
+
+// CloseIgnored implements [errclose.Metrics].
+func (metrics *Metrics) CloseIgnored(resourceName string, _ error) {
+	metrics.ignored.Add(resourceName, 1)
+}
+
+// CloseTimedOut implements [errclose.Metrics].
+func (metrics *Metrics) CloseTimedOut(resourceName string) {
+	metrics.timeouts.Add(resourceName, 1)
+}
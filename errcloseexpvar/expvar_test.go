@@ -0,0 +1,35 @@
+package errcloseexpvar_test
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errcloseexpvar"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestPublish(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetMetrics(errcloseexpvar.Publish("errclose_test"))
+
+	errBenign := errors.New("already closed")
+
+	var err error
+	errclose.Close(errclosetest.NewMockCloser(nil), &err, "file")
+	errclose.Close(errclosetest.NewMockCloser(errors.New("disk full")), &err, "file")
+	errclose.Close(errclosetest.NewMockCloser(errBenign), &err, "conn", errclose.Ignore(errBenign))
+	errclose.Close(errclosetest.NewMockCloser(errclose.ErrCloseTimeout), &err, "conn")
+
+	published := expvar.Get("errclose_test")
+	if published == nil {
+		t.Fatal("Expected errclose_test to be published")
+	}
+
+	expected := `{"attempts": {"conn": 2, "file": 2}, "failures": {"conn": 1, "file": 1}, ` +
+		`"ignored": {"conn": 1}, "timeouts": {"conn": 1}}`
+	if published.String() != expected {
+		t.Errorf("Unexpected published metrics\nWant: %s\n Got: %s", expected, published.String())
+	}
+}
@@ -0,0 +1,88 @@
+// Package errclosehttp reports close failures in HTTP handlers as warnings on the response, for
+// use with [errclose.WarnOnly]. When a handler's operation succeeded but closing a resource
+// failed, this lets the handler respond successfully, while still telling the client that a
+// cleanup failed.
+//
+// This is in a separate package from [hermannm.dev/errclose], so that the errclose package doesn't
+// depend on [net/http].
+package errclosehttp
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermannm.dev/errclose"
+)
+
+// WarningHeader is the name of the header (or trailer) that close failures are added to. It has
+// the format of the Warning header from RFC 7234 (which RFC 9111 has since removed, but which
+// clients and proxies still pass through):
+//
+//	Warning: 199 - "failed to close <resourceName>: <close error>"
+//
+// The warn code is 199 (miscellaneous warning), and the warn agent is "-" (unknown). The message
+// is quoted with [strconv.Quote], so it's always a valid header value. Each close failure adds its
+// own field value.
+const WarningHeader = "Warning"
+
+// HeaderWarning returns an option that adds close errors to the given response header as a
+// [WarningHeader], when only the close failed (see [errclose.WarnOnly]). This only works for
+// resources that are closed before the response header is written, such as the request body or
+// an upstream response:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		err := func() (returnedErr error) {
+//			upstream, err := fetchUpstream(r.Context())
+//			if err != nil {
+//				return err
+//			}
+//			defer errclose.Close(
+//				upstream.Body,
+//				&returnedErr,
+//				"upstream response body",
+//				errclosehttp.HeaderWarning(w.Header()),
+//			)
+//
+//			// Read upstream response
+//		}()
+//
+//		// Write response
+//	}
+//
+// For resources that are closed after the response has started, use [TrailerWarning].
+func HeaderWarning(header http.Header) errclose.Option {
+	return errclose.WarnOnly(func(closeErr *errclose.CloseError) {
+		header.Add(WarningHeader, warningValue(closeErr))
+	})
+}
+
+// TrailerWarning returns an option that adds close errors to the trailers of the given response as
+// a [WarningHeader], when only the close failed (see [errclose.WarnOnly]). Unlike
+// [HeaderWarning], this also works for resources that are closed after the response body has been
+// written, such as in a defer in the handler:
+//
+//	func serveFile(w http.ResponseWriter, path string) (returnedErr error) {
+//		file, err := os.Open(path)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.Close(file, &returnedErr, "file", errclosehttp.TrailerWarning(w))
+//
+//		_, err = io.Copy(w, file)
+//		return err
+//	}
+//
+// The trailer is added with the [http.TrailerPrefix], so it doesn't have to be declared before the
+// header is written. Clients only see it if they read trailers (e.g. [http.Response.Trailer],
+// after reading the body to the end). Note that over HTTP/1.1, [http.Server] only sends trailers
+// for chunked responses, which it uses if the handler flushes the response or writes more than
+// fits in its buffer. For short responses, the trailer is dropped.
+func TrailerWarning(w http.ResponseWriter) errclose.Option {
+	return errclose.WarnOnly(func(closeErr *errclose.CloseError) {
+		w.Header().Add(http.TrailerPrefix+WarningHeader, warningValue(closeErr))
+	})
+}
+
+func warningValue(closeErr *errclose.CloseError) string {
+	return "199 - " + strconv.Quote(closeErr.Error())
+}
@@ -0,0 +1,98 @@
+package errclosehttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosehttp"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestHeaderWarning(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	var err error
+	errclose.Close(
+		errclosetest.NewMockCloser(errors.New("connection reset")),
+		&err,
+		"upstream body",
+		errclosehttp.HeaderWarning(recorder.Header()),
+	)
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	expected := `199 - "failed to close upstream body: connection reset"`
+	if warning := recorder.Header().Get(errclosehttp.WarningHeader); warning != expected {
+		t.Errorf("Unexpected warning header\nWant: %s\n Got: %s", expected, warning)
+	}
+}
+
+func TestHeaderWarningWhenOperationFailed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	err := errors.New("operation failed")
+	errclose.Close(
+		errclosetest.NewMockCloser(errors.New("connection reset")),
+		&err,
+		"upstream body",
+		errclosehttp.HeaderWarning(recorder.Header()),
+	)
+
+	expectedErr := "operation failed (and failed to close upstream body: connection reset)"
+	if err.Error() != expectedErr {
+		t.Errorf("Unexpected error\nWant: %s\n Got: %s", expectedErr, err.Error())
+	}
+	if warning := recorder.Header().Get(errclosehttp.WarningHeader); warning != "" {
+		t.Errorf("Expected no warning header, got %q", warning)
+	}
+}
+
+func TestTrailerWarning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		serve := func() (returnedErr error) {
+			defer errclose.Close(
+				errclosetest.NewMockCloser(errors.New("disk error")),
+				&returnedErr,
+				"file",
+				errclosehttp.TrailerWarning(w),
+			)
+
+			if _, err := io.WriteString(w, "file contents"); err != nil {
+				return err
+			}
+			// Send the response chunked, so that it can have trailers
+			return http.NewResponseController(w).Flush()
+		}
+		if err := serve(); err != nil {
+			t.Errorf("Unexpected handler error: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errclose.Cleanup(t, response.Body, "response body")
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "file contents" {
+		t.Errorf("Unexpected response body %q", body)
+	}
+	expected := `199 - "failed to close file: disk error"`
+	if warning := response.Trailer.Get(errclosehttp.WarningHeader); warning != expected {
+		t.Errorf("Unexpected warning trailer\nWant: %s\n Got: %s", expected, warning)
+	}
+}
@@ -0,0 +1,75 @@
+// Package errcloseotel records close errors from [hermannm.dev/errclose] on [OpenTelemetry] spans.
+//
+// This is in a separate module from errclose, so that errclose itself doesn't depend on
+// OpenTelemetry.
+//
+// [OpenTelemetry]: https://opentelemetry.io/
+package errcloseotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"hermannm.dev/errclose"
+)
+
+// CloseTraced closes the given resource with [errclose.Close], and records close errors on the
+// span in the given context (see [errcloseotel.RecordOnSpan]). This is for request handlers that
+// are traced, where close errors would otherwise be lost from the trace, since they happen after
+// the handler's error has been recorded:
+//
+//	func upload(ctx context.Context, object Object) (returnedErr error) {
+//		writer := bucket.Object(object.Key).NewWriter(ctx)
+//		defer errcloseotel.CloseTraced(ctx, writer, &returnedErr, "S3 object writer")
+//
+//		_, err := io.Copy(writer, object.Content)
+//		return err
+//	}
+//
+// The close error is handled like in errclose.Close, and the given options are passed on to it.
+func CloseTraced(
+	ctx context.Context,
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	options ...errclose.Option,
+) {
+	errclose.Close(resource, returnedErr, resourceName, append(options, RecordOnSpan(ctx))...)
+}
+
+// RecordOnSpan returns an option that records close errors on the span in the given context, with
+// [trace.Span.RecordError]. You can pass it to any errclose function that takes options:
+//
+//	defer errclose.DrainAndClose(
+//		response.Body,
+//		&returnedErr,
+//		"response body",
+//		errcloseotel.RecordOnSpan(ctx),
+//	)
+//
+// The recorded error is formatted like the close error from [errclose.Close] (without the existing
+// error it's combined with):
+//
+//	failed to close <resourceName>: <close error>
+//
+// The exception event also has an errclose.resource attribute with the resource name. The span's
+// status is not changed.
+//
+// The span must still be recording when the resource is closed, so if you end the span in a
+// deferred call, defer ending it before deferring the close. Close errors dropped by
+// [errclose.Ignore] are not recorded.
+func RecordOnSpan(ctx context.Context) errclose.Option {
+	return errclose.Also(func(resourceName string, closeErr error) {
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return
+		}
+
+		span.RecordError(
+			errclose.Combine(nil, closeErr, resourceName),
+			trace.WithAttributes(attribute.String("errclose.resource", resourceName)),
+		)
+	})
+}
@@ -0,0 +1,70 @@
+package errcloseotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errcloseotel"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestCloseTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	handle := func(ctx context.Context) (returnedErr error) {
+		ctx, span := tracer.Start(ctx, "handle")
+		defer span.End()
+
+		writer := errclosetest.NewMockCloser(errors.New("upload failed"))
+		defer errcloseotel.CloseTraced(ctx, writer, &returnedErr, "object writer")
+
+		return nil
+	}
+
+	err := handle(t.Context())
+	if err == nil || err.Error() != "failed to close object writer: upload failed" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	events := spans[0].Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("Expected 1 exception event, got %v", events)
+	}
+
+	attributes := make(map[string]string)
+	for _, attr := range events[0].Attributes {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	expected := map[string]string{
+		"exception.message": "failed to close object writer: upload failed",
+		"errclose.resource": "object writer",
+	}
+	for key, value := range expected {
+		if attributes[key] != value {
+			t.Errorf("Unexpected %s attribute\nWant: %s\n Got: %s", key, value, attributes[key])
+		}
+	}
+}
+
+func TestRecordOnSpanWithoutSpan(t *testing.T) {
+	var err error
+	errclose.Close(
+		errclosetest.NewMockCloser(errors.New("close error")),
+		&err,
+		"file",
+		errcloseotel.RecordOnSpan(t.Context()),
+	)
+	if err == nil || err.Error() != "failed to close file: close error" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
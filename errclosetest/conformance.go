@@ -0,0 +1,125 @@
+package errclosetest
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// CloseFunc is the signature of [errclose.Close] (without options), which [RunConformance] tests.
+type CloseFunc func(resource interface{ Close() error }, returnedErr *error, resourceName string)
+
+// RunConformance runs a suite of tests that checks that the given close function preserves the
+// guarantees of [errclose.Close]. This is for libraries that wrap errclose in functions of their
+// own, to verify that the wrappers don't break its behavior:
+//
+//	func TestCloseConformance(t *testing.T) {
+//		errclosetest.RunConformance(t, platform.Close)
+//	}
+//
+// The suite checks that the close function:
+//   - Closes the resource exactly once
+//   - Leaves the returned error unchanged when Close succeeds
+//   - Formats close errors as "failed to close <resourceName>: <close error>"
+//   - Combines close errors with existing errors as
+//     "<existing error> (and failed to close <resourceName>: <close error>)"
+//   - Keeps both the existing error and the close error reachable with [errors.Is], and the
+//     close error reachable as an [errclose.CloseError] with [errors.As]
+//   - Reports a nil resource as [errclose.ErrNilResource]
+//
+// The suite assumes errclose's default configuration (e.g. [errclose.ErrorFormatParenthetical]).
+// Each check runs as a subtest of t.
+func RunConformance(t *testing.T, closeFunc CloseFunc) {
+	t.Helper()
+
+	closeErr := errors.New("close error")
+	existingErr := errors.New("existing error")
+
+	t.Run("closes resource once", func(t *testing.T) {
+		resource := NewFlaky(1, closeErr)
+		var err error
+		closeFunc(resource, &err, "resource")
+		if calls := resource.CloseCalls(); calls != 1 {
+			t.Errorf("Expected Close to be called once, got %d calls", calls)
+		}
+	})
+
+	t.Run("successful close", func(t *testing.T) {
+		var err error
+		closeFunc(NewFlaky(2, closeErr), &err, "resource")
+		if err != nil {
+			t.Errorf("Expected nil error after successful close, got %q", err)
+		}
+
+		err = existingErr
+		closeFunc(NewFlaky(2, closeErr), &err, "resource")
+		if err != existingErr { //nolint:errorlint // Checking that the error is unchanged
+			t.Errorf("Expected existing error to be unchanged after successful close, got %q", err)
+		}
+	})
+
+	t.Run("close error", func(t *testing.T) {
+		var err error
+		closeFunc(NewFlaky(1, closeErr), &err, "resource")
+		checkError(t, err, "failed to close resource: close error")
+		checkErrorsIs(t, err, closeErr)
+		checkCloseError(t, err, "resource", closeErr)
+	})
+
+	t.Run("close error with existing error", func(t *testing.T) {
+		err := existingErr
+		closeFunc(NewFlaky(1, closeErr), &err, "resource")
+		checkError(t, err, "existing error (and failed to close resource: close error)")
+		checkErrorsIs(t, err, existingErr)
+		checkErrorsIs(t, err, closeErr)
+		checkCloseError(t, err, "resource", closeErr)
+	})
+
+	t.Run("nil resource", func(t *testing.T) {
+		var err error
+		closeFunc(nil, &err, "resource")
+		checkError(t, err, "failed to close resource: nil resource")
+		checkErrorsIs(t, err, errclose.ErrNilResource)
+	})
+}
+
+func checkError(t *testing.T, err error, expectedMessage string) {
+	t.Helper()
+
+	if err == nil {
+		t.Errorf("Expected error %q, got nil", expectedMessage)
+		return
+	}
+	if message := err.Error(); message != expectedMessage {
+		t.Errorf("Unexpected error message\nWant: %s\n Got: %s", expectedMessage, message)
+	}
+}
+
+func checkErrorsIs(t *testing.T, err error, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Errorf("Expected errors.Is(err, %q) to be true for error %q", target, err)
+	}
+}
+
+func checkCloseError(t *testing.T, err error, expectedResourceName string, expectedErr error) {
+	t.Helper()
+
+	var closeErr *errclose.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Errorf("Expected error %q to contain an errclose.CloseError", err)
+		return
+	}
+	if closeErr.ResourceName != expectedResourceName {
+		t.Errorf(
+			"Unexpected CloseError.ResourceName\nWant: %s\n Got: %s",
+			expectedResourceName,
+			closeErr.ResourceName,
+		)
+	}
+	if !errors.Is(closeErr.Err, expectedErr) {
+		t.Errorf("Expected CloseError.Err to be %q, got %q", expectedErr, closeErr.Err)
+	}
+}
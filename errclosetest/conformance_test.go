@@ -0,0 +1,37 @@
+package errclosetest_test
+
+import (
+	"testing"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestConformanceClose(t *testing.T) {
+	errclosetest.RunConformance(
+		t,
+		func(resource interface{ Close() error }, returnedErr *error, resourceName string) {
+			errclose.Close(resource, returnedErr, resourceName)
+		},
+	)
+}
+
+func TestConformanceClosef(t *testing.T) {
+	errclosetest.RunConformance(
+		t,
+		func(resource interface{ Close() error }, returnedErr *error, resourceName string) {
+			errclose.Closef(resource, returnedErr, "%s", resourceName)
+		},
+	)
+}
+
+func TestConformanceFrame(t *testing.T) {
+	errclosetest.RunConformance(
+		t,
+		func(resource interface{ Close() error }, returnedErr *error, resourceName string) {
+			var frame errclose.Frame
+			frame.Add(resource, resourceName)
+			frame.CloseAll(returnedErr)
+		},
+	)
+}
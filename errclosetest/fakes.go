@@ -0,0 +1,222 @@
+// Package errclosetest provides fake resources for testing code that closes resources with
+// [hermannm.dev/errclose]. The fakes have realistic close behavior (slow, flaky, chatty and
+// hierarchical closes), so you can use them to test shutdown paths, and to check how your
+// configuration of errclose behaves before you run it in production.
+//
+// For simpler tests, [MockCloser] returns a fixed error (or panics) on every close, and the
+// assertion helpers [AssertClosed] and [AssertCloseErrorFor] check the results. To check that
+// code under test closes all its resources, wrap them with [Track] and call [VerifyNoLeaks], or
+// call [Main] from TestMain to check them for all tests in the package. To check a part of a test,
+// such as a subtest, take a [Baseline] before it and call [LeakBaseline.AssertNoNewOpen] after.
+// To catch regressions in teardown order, record the close order with [RecordOrder], and check it
+// with [CloseOrder.AssertOrder].
+//
+// All fakes count their Close calls, and are safe for concurrent use.
+package errclosetest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+// Slow is a fake resource that takes a while to close, like a database pool waiting for in-flight
+// queries. Create one with [NewSlow].
+//
+// Slow implements [errclose.CloseContextSetter], so if it's closed by [errclose.ShutdownGlobal],
+// Close stops waiting when the shutdown context is done, and returns the context error.
+type Slow struct {
+	delay      time.Duration
+	err        error
+	closeCalls atomic.Int64
+	ctxLock    sync.Mutex
+	closeCtx   func() <-chan struct{}
+	closeErr   func() error
+}
+
+// NewSlow returns a fake resource that waits for the given delay when closed, then returns the
+// given error (which may be nil).
+func NewSlow(delay time.Duration, err error) *Slow {
+	return &Slow{
+		delay:      delay,
+		err:        err,
+		closeCalls: atomic.Int64{},
+		ctxLock:    sync.Mutex{},
+		closeCtx:   nil,
+		closeErr:   nil,
+	}
+}
+
+// SetCloseContext makes the next Close stop waiting when the given context is done.
+func (slow *Slow) SetCloseContext(ctx context.Context) {
+	slow.ctxLock.Lock()
+	defer slow.ctxLock.Unlock()
+
+	// Store the context's methods rather than the context itself, since the context only applies
+	// to the next close
+	slow.closeCtx = ctx.Done
+	slow.closeErr = ctx.Err
+}
+
+// Close waits for the resource's delay, then returns its error. If a close context was set with
+// SetCloseContext, and it's done before the delay has passed, Close returns the context error.
+func (slow *Slow) Close() error {
+	slow.closeCalls.Add(1)
+
+	slow.ctxLock.Lock()
+	done, ctxErr := slow.closeCtx, slow.closeErr
+	slow.closeCtx, slow.closeErr = nil, nil
+	slow.ctxLock.Unlock()
+
+	timer := time.NewTimer(slow.delay)
+	defer timer.Stop()
+
+	if done == nil {
+		<-timer.C
+		return slow.err
+	}
+
+	select {
+	case <-timer.C:
+		return slow.err
+	case <-done():
+		return ctxErr()
+	}
+}
+
+// CloseCalls returns the number of times Close has been called.
+func (slow *Slow) CloseCalls() int {
+	return int(slow.closeCalls.Load())
+}
+
+// Flaky is a fake resource that fails some of its closes, like a network client with an unreliable
+// connection. Create one with [NewFlaky].
+type Flaky struct {
+	failEvery  int64
+	err        error
+	closeCalls atomic.Int64
+}
+
+// NewFlaky returns a fake resource where every failEvery-th call to Close returns the given error,
+// and other calls succeed. For example, with failEvery 3, the 3rd, 6th, 9th (and so on) closes
+// fail. If failEvery is 1 or less, every close fails.
+func NewFlaky(failEvery int, err error) *Flaky {
+	return &Flaky{failEvery: max(int64(failEvery), 1), err: err, closeCalls: atomic.Int64{}}
+}
+
+// Close returns the resource's error if this is a failing call (see [NewFlaky]), or nil otherwise.
+func (flaky *Flaky) Close() error {
+	if flaky.closeCalls.Add(1)%flaky.failEvery == 0 {
+		return flaky.err
+	}
+	return nil
+}
+
+// CloseCalls returns the number of times Close has been called.
+func (flaky *Flaky) CloseCalls() int {
+	return int(flaky.closeCalls.Load())
+}
+
+// Chatty is a fake resource that reports progress while closing, like a buffered writer flushing
+// its buffers in batches. Create one with [NewChatty].
+type Chatty struct {
+	name       string
+	steps      int
+	stepDelay  time.Duration
+	writer     io.Writer
+	writeLock  sync.Mutex
+	closeCalls atomic.Int64
+}
+
+// NewChatty returns a fake resource that closes in the given number of steps, waiting stepDelay
+// before each step. After each step, it writes a line to the given writer on the following format:
+//
+//	<name>: closed <step>/<steps>
+//
+// Errors from writing are returned by Close.
+func NewChatty(name string, steps int, stepDelay time.Duration, writer io.Writer) *Chatty {
+	return &Chatty{
+		name:       name,
+		steps:      steps,
+		stepDelay:  stepDelay,
+		writer:     writer,
+		writeLock:  sync.Mutex{},
+		closeCalls: atomic.Int64{},
+	}
+}
+
+// Close runs the close steps, writing progress to the resource's writer.
+func (chatty *Chatty) Close() error {
+	chatty.closeCalls.Add(1)
+
+	for step := 1; step <= chatty.steps; step++ {
+		time.Sleep(chatty.stepDelay)
+
+		chatty.writeLock.Lock()
+		_, err := fmt.Fprintf(chatty.writer, "%s: closed %d/%d\n", chatty.name, step, chatty.steps)
+		chatty.writeLock.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseCalls returns the number of times Close has been called.
+func (chatty *Chatty) CloseCalls() int {
+	return int(chatty.closeCalls.Load())
+}
+
+// Parent is a fake resource that owns child resources, like a server that owns its listeners and
+// connection pools. Create one with [NewParent].
+type Parent struct {
+	err        error
+	lock       sync.Mutex
+	children   errclose.Frame
+	closeCalls atomic.Int64
+}
+
+// NewParent returns a fake resource that closes its children before closing itself, and then
+// returns the given error (which may be nil) combined with the children's close errors.
+func NewParent(err error) *Parent {
+	return &Parent{
+		err:        err,
+		lock:       sync.Mutex{},
+		children:   errclose.Frame{},
+		closeCalls: atomic.Int64{},
+	}
+}
+
+// AddChild adds a child resource to be closed when the parent is closed. Children are closed in
+// the reverse order of how they were added, like in [errclose.Frame].
+func (parent *Parent) AddChild(child interface{ Close() error }, childName string) {
+	parent.lock.Lock()
+	defer parent.lock.Unlock()
+
+	parent.children.Add(child, childName)
+}
+
+// Close closes the parent's children, then returns the parent's error, combined with the
+// children's close errors on the following format:
+//
+//	<parent error> (and failed to close <child name>: <child close error>)
+func (parent *Parent) Close() (returnedErr error) {
+	parent.closeCalls.Add(1)
+
+	parent.lock.Lock()
+	defer parent.lock.Unlock()
+
+	returnedErr = parent.err
+	parent.children.CloseAll(&returnedErr)
+	return returnedErr
+}
+
+// CloseCalls returns the number of times Close has been called.
+func (parent *Parent) CloseCalls() int {
+	return int(parent.closeCalls.Load())
+}
@@ -0,0 +1,107 @@
+package errclosetest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestSlow(t *testing.T) {
+	closeErr := errors.New("queries still running")
+	slow := errclosetest.NewSlow(time.Millisecond, closeErr)
+
+	err := slow.Close()
+	assertEqual(t, err, closeErr, "error")
+	assertEqual(t, slow.CloseCalls(), 1, "close calls")
+}
+
+func TestSlowWithCloseContext(t *testing.T) {
+	slow := errclosetest.NewSlow(time.Hour, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	slow.SetCloseContext(ctx)
+	err := slow.Close()
+	assertEqual(t, err, context.DeadlineExceeded, "error")
+}
+
+func TestFlaky(t *testing.T) {
+	closeErr := errors.New("connection reset")
+	flaky := errclosetest.NewFlaky(3, closeErr)
+
+	var errs []error
+	for range 6 {
+		errs = append(errs, flaky.Close())
+	}
+	assertEqual(t, errs, []error{nil, nil, closeErr, nil, nil, closeErr}, "errors")
+	assertEqual(t, flaky.CloseCalls(), 6, "close calls")
+}
+
+func TestChatty(t *testing.T) {
+	var output bytes.Buffer
+	chatty := errclosetest.NewChatty("writer", 3, 0, &output)
+
+	err := chatty.Close()
+	assertEqual(t, err, nil, "error")
+	assertEqual(
+		t,
+		output.String(),
+		"writer: closed 1/3\nwriter: closed 2/3\nwriter: closed 3/3\n",
+		"output",
+	)
+}
+
+func TestParent(t *testing.T) {
+	child1 := errclosetest.NewFlaky(1, errors.New("listener error"))
+	child2 := errclosetest.NewFlaky(2, errors.New("pool error"))
+	parent := errclosetest.NewParent(errors.New("server error"))
+	parent.AddChild(child1, "listener")
+	parent.AddChild(child2, "pool")
+
+	err := parent.Close()
+	assertEqual(
+		t,
+		err.Error(),
+		"server error (and failed to close listener: listener error)",
+		"error string",
+	)
+	assertEqual(t, child1.CloseCalls(), 1, "child 1 close calls")
+	assertEqual(t, child2.CloseCalls(), 1, "child 2 close calls")
+}
+
+func TestParentInFrame(t *testing.T) {
+	parent := errclosetest.NewParent(nil)
+	parent.AddChild(errclosetest.NewFlaky(1, errors.New("pool error")), "pool")
+
+	var frame errclose.Frame
+	frame.Add(parent, "server")
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close server: failed to close pool: pool error",
+		"error string",
+	)
+}
+
+func assertEqual(t *testing.T, actual any, expected any, descriptor string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf(
+			`Unexpected %s
+Want: %+v
+ Got: %+v`,
+			descriptor,
+			expected,
+			actual,
+		)
+	}
+}
@@ -0,0 +1,198 @@
+package errclosetest
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// Tracked is a resource registered with the leak detector, so that [VerifyNoLeaks] fails the test
+// if it was never closed. Create one with [Track].
+type Tracked[T interface{ Close() error }] struct {
+	resource   T
+	resourceID errclose.ResourceID
+	closed     atomic.Bool
+	stack      []byte
+}
+
+var trackedResources struct {
+	lock      sync.Mutex
+	resources []trackedResource
+	// The sequence number to give the next tracked resource, for telling which resources were
+	// tracked after a [Baseline].
+	nextSequence uint64
+}
+
+type trackedResource struct {
+	sequence    uint64
+	description string
+	resourceID  errclose.ResourceID
+	stack       []byte
+	closed      *atomic.Bool
+}
+
+// Track registers the given resource with the leak detector, and returns a wrapper that you close
+// instead of the resource. If the wrapper hasn't been closed when [VerifyNoLeaks] is called, the
+// test fails with the resource's type, its ID and the stack trace of the Track call, so you can
+// find where the leaked resource was opened:
+//
+//	func TestExport(t *testing.T) {
+//		defer errclosetest.VerifyNoLeaks(t)
+//
+//		file := errclosetest.Track(openTestFile(t))
+//		err := export(file)
+//		// Check err
+//	}
+//
+// Use [Tracked.Get] to access the resource itself. Since the tracked resources are global, tests
+// that use Track should not run in parallel with each other.
+//
+// The resource is given a new [errclose.ResourceID] (see [Tracked.ResourceID]), which is included
+// in the leak report, so that a leak can be correlated with close errors and logs from the code
+// under test.
+func Track[T interface{ Close() error }](resource T) *Tracked[T] {
+	tracked := &Tracked[T]{
+		resource:   resource,
+		resourceID: errclose.NewResourceID(),
+		closed:     atomic.Bool{},
+		stack:      debug.Stack(),
+	}
+
+	trackedResources.lock.Lock()
+	defer trackedResources.lock.Unlock()
+
+	trackedResources.resources = append(trackedResources.resources, trackedResource{
+		sequence:    trackedResources.nextSequence,
+		description: fmt.Sprintf("%T", resource),
+		resourceID:  tracked.resourceID,
+		stack:       tracked.stack,
+		closed:      &tracked.closed,
+	})
+	trackedResources.nextSequence++
+	return tracked
+}
+
+// Get returns the tracked resource.
+func (tracked *Tracked[T]) Get() T {
+	return tracked.resource
+}
+
+// ResourceID returns the ID that the resource was given when it was tracked. To include it in close
+// errors, close the resource with the [errclose.WithResourceID] option:
+//
+//	defer errclose.Close(file, &returnedErr, "file", errclose.WithResourceID(file.ResourceID()))
+func (tracked *Tracked[T]) ResourceID() errclose.ResourceID {
+	return tracked.resourceID
+}
+
+// Close marks the resource as closed for the leak detector, then closes it and returns its close
+// error as-is.
+func (tracked *Tracked[T]) Close() error {
+	tracked.closed.Store(true)
+	return tracked.resource.Close()
+}
+
+// AcquisitionSite returns the stack trace of the [Track] call. This implements
+// [errclose.AcquisitionSiteReporter], so that when closing the resource panics (with
+// [errclose.MustClose] or in strict mode), the panic value shows where the resource was tracked.
+func (tracked *Tracked[T]) AcquisitionSite() string {
+	return string(tracked.stack)
+}
+
+// VerifyNoLeaks fails the test for every resource registered with [Track] that hasn't been closed,
+// listing the resource's type and where it was tracked. It then clears the registered resources,
+// so the next test starts fresh. Call it at the end of a test, typically in a defer statement or
+// with t.Cleanup.
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+
+	for _, resource := range takeLeakedResources() {
+		t.Error(resource.leakMessage())
+	}
+}
+
+// takeLeakedResources clears the resources registered with [Track], and returns the ones that
+// haven't been closed.
+func takeLeakedResources() []trackedResource {
+	trackedResources.lock.Lock()
+	resources := trackedResources.resources
+	trackedResources.resources = nil
+	trackedResources.lock.Unlock()
+
+	var leaked []trackedResource
+	for _, resource := range resources {
+		if !resource.closed.Load() {
+			leaked = append(leaked, resource)
+		}
+	}
+	return leaked
+}
+
+// LeakBaseline is a snapshot of the resources registered with [Track], taken with [Baseline].
+type LeakBaseline struct {
+	// The sequence number of the first resource tracked after the snapshot.
+	sequence uint64
+}
+
+// Baseline takes a snapshot of the resources registered with [Track], so that
+// [LeakBaseline.AssertNoNewOpen] can check the resources tracked after it. This is for finding
+// which part of a large test leaks resources, such as a subtest in an integration test that shares
+// resources between subtests:
+//
+//	for _, testCase := range testCases {
+//		t.Run(testCase.name, func(t *testing.T) {
+//			baseline := errclosetest.Baseline()
+//			defer baseline.AssertNoNewOpen(t)
+//
+//			// Run test case
+//		})
+//	}
+//
+// Resources that were tracked before the baseline are not affected, so they can stay open across
+// the subtests.
+func Baseline() LeakBaseline {
+	trackedResources.lock.Lock()
+	defer trackedResources.lock.Unlock()
+
+	return LeakBaseline{sequence: trackedResources.nextSequence}
+}
+
+// AssertNoNewOpen fails the test for every resource registered with [Track] after the baseline was
+// taken that hasn't been closed, in the same way as [VerifyNoLeaks]. It then clears those
+// resources, so that leaks are reported only once, by the test that caused them: a later
+// VerifyNoLeaks or [Main] doesn't report them again.
+func (baseline LeakBaseline) AssertNoNewOpen(t testing.TB) {
+	t.Helper()
+
+	trackedResources.lock.Lock()
+	var leaked []trackedResource
+	kept := trackedResources.resources[:0]
+	for _, resource := range trackedResources.resources {
+		switch {
+		case resource.sequence < baseline.sequence:
+			kept = append(kept, resource)
+		case !resource.closed.Load():
+			leaked = append(leaked, resource)
+		}
+	}
+	clear(trackedResources.resources[len(kept):])
+	trackedResources.resources = kept
+	trackedResources.lock.Unlock()
+
+	for _, resource := range leaked {
+		t.Error(resource.leakMessage())
+	}
+}
+
+func (resource trackedResource) leakMessage() string {
+	return fmt.Sprintf(
+		"Resource of type %s (resource ID %d) was never closed, tracked at:\n%s",
+		resource.description,
+		resource.resourceID,
+		resource.stack,
+	)
+}
@@ -0,0 +1,110 @@
+package errclosetest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestVerifyNoLeaks(t *testing.T) {
+	defer errclosetest.VerifyNoLeaks(t)
+
+	useResource := func() (returnedErr error) {
+		tracked := errclosetest.Track(errclosetest.NewMockCloser(nil))
+		defer errclose.Close(tracked, &returnedErr, "mock")
+
+		errclosetest.AssertNotClosed(t, tracked.Get())
+		return nil
+	}
+
+	err := useResource()
+	assertEqual(t, err, nil, "error")
+}
+
+func TestVerifyNoLeaksFails(t *testing.T) {
+	mock := errclosetest.NewMockCloser(nil)
+	errclosetest.Track(mock)
+
+	fakeT := new(testing.T)
+	errclosetest.VerifyNoLeaks(fakeT)
+	assertEqual(t, fakeT.Failed(), true, "fakeT.Failed()")
+	errclosetest.AssertNotClosed(t, mock)
+
+	// The leaked resource is only reported once
+	secondFakeT := new(testing.T)
+	errclosetest.VerifyNoLeaks(secondFakeT)
+	assertEqual(t, secondFakeT.Failed(), false, "secondFakeT.Failed()")
+}
+
+func TestAssertNoNewOpen(t *testing.T) {
+	defer errclosetest.VerifyNoLeaks(t)
+
+	shared := errclosetest.Track(errclosetest.NewMockCloser(nil))
+
+	baseline := errclosetest.Baseline()
+	closed := errclosetest.Track(errclosetest.NewMockCloser(nil))
+	var err error
+	errclose.Close(closed, &err, "mock")
+	assertEqual(t, err, nil, "close error")
+
+	// The shared resource is still open, but was tracked before the baseline
+	baseline.AssertNoNewOpen(t)
+	assertEqual(t, shared.Close(), nil, "close error of shared resource")
+}
+
+func TestAssertNoNewOpenFails(t *testing.T) {
+	shared := errclosetest.Track(errclosetest.NewMockCloser(nil))
+
+	baseline := errclosetest.Baseline()
+	errclosetest.Track(errclosetest.NewMockCloser(nil))
+
+	fakeT := new(testing.T)
+	baseline.AssertNoNewOpen(fakeT)
+	assertEqual(t, fakeT.Failed(), true, "fakeT.Failed()")
+
+	// The leak is only reported once, and the shared resource is still tracked
+	secondFakeT := new(testing.T)
+	baseline.AssertNoNewOpen(secondFakeT)
+	assertEqual(t, secondFakeT.Failed(), false, "secondFakeT.Failed()")
+
+	assertEqual(t, shared.Close(), nil, "close error")
+	errclosetest.VerifyNoLeaks(t)
+}
+
+func TestTrackedAcquisitionSiteInClosePanic(t *testing.T) {
+	defer errclosetest.VerifyNoLeaks(t)
+
+	tracked := errclosetest.Track(errclosetest.NewMockCloser(errors.New("close error")))
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		errclose.MustClose(tracked, "mock")
+	}()
+
+	closePanic, ok := recovered.(*errclose.ClosePanic)
+	assertEqual(t, ok, true, "panic value is *ClosePanic")
+	assertEqual(
+		t,
+		strings.Contains(closePanic.AcquisitionSite, "TestTrackedAcquisitionSiteInClosePanic"),
+		true,
+		"acquisition site contains test function",
+	)
+}
+
+func TestTrackedResourceID(t *testing.T) {
+	defer errclosetest.VerifyNoLeaks(t)
+
+	tracked1 := errclosetest.Track(errclosetest.NewMockCloser(errors.New("close error")))
+	tracked2 := errclosetest.Track(errclosetest.NewMockCloser(nil))
+	assertEqual(t, tracked1.ResourceID() != 0, true, "ID is set")
+	assertEqual(t, tracked1.ResourceID() != tracked2.ResourceID(), true, "IDs are distinct")
+
+	var err error
+	errclose.Close(tracked1, &err, "mock", errclose.WithResourceID(tracked1.ResourceID()))
+	errclose.Close(tracked2, &err, "mock")
+	assertEqual(t, errclose.ResourceIDOf(err), tracked1.ResourceID(), "ID of close error")
+}
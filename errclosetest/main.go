@@ -0,0 +1,98 @@
+package errclosetest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// MainOption changes how [errclosetest.Main] runs a package's tests.
+type MainOption struct {
+	settings *mainSettings
+}
+
+type mainSettings struct {
+	allowLeaks bool
+	nonStrict  bool
+}
+
+// AllowLeaks returns an option that makes [errclosetest.Main] print the leak summary without
+// failing the tests. This is useful when turning on leak verification for a package that still
+// has known leaks.
+func AllowLeaks() MainOption {
+	return MainOption{settings: &mainSettings{allowLeaks: true, nonStrict: false}}
+}
+
+// NonStrict returns an option that makes [errclosetest.Main] leave strict mode disabled (see
+// [errclose.SetStrict]), for packages whose tests rely on close errors being logged.
+func NonStrict() MainOption {
+	return MainOption{settings: &mainSettings{allowLeaks: false, nonStrict: true}}
+}
+
+// Main runs the tests of a package with the errclose safety net turned on, so you don't have to
+// set it up in every test. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		errclosetest.Main(m)
+//	}
+//
+// Main enables strict mode (see [errclose.SetStrict]) for all tests, so close errors that would
+// otherwise only be logged make the test panic. After the tests have run, it checks the resources
+// registered with [errclosetest.Track], and prints a summary of the ones that were never closed to
+// stderr, listing each resource's type and where it was tracked:
+//
+//	errclosetest: <number> tracked resources were never closed
+//
+//	Resource of type <type> (resource ID <ID>) was never closed, tracked at:
+//	<stack trace>
+//
+// If any resources were leaked, the test binary exits with a non-zero code, even if all tests
+// passed. Leaks are only reported at the end, since Main can't tell which test leaked them: to
+// check a single test, call [errclosetest.VerifyNoLeaks] in it instead.
+//
+// Pass [errclosetest.AllowLeaks] or [errclosetest.NonStrict] to turn off parts of the safety net.
+// Main exits the program, so it must be the last call in TestMain.
+func Main(m *testing.M, options ...MainOption) {
+	os.Exit(runMain(m, os.Stderr, options))
+}
+
+// runMain implements [errclosetest.Main], writing the leak summary to the given output, and
+// returns the exit code.
+func runMain(m *testing.M, leakOutput io.Writer, options []MainOption) int {
+	var settings mainSettings
+	for _, option := range options {
+		if option.settings == nil {
+			continue
+		}
+		settings.allowLeaks = settings.allowLeaks || option.settings.allowLeaks
+		settings.nonStrict = settings.nonStrict || option.settings.nonStrict
+	}
+
+	if !settings.nonStrict {
+		errclose.SetStrict(true)
+	}
+
+	exitCode := m.Run()
+
+	leaked := takeLeakedResources()
+	if len(leaked) == 0 {
+		return exitCode
+	}
+
+	_, _ = fmt.Fprintf(
+		leakOutput,
+		"errclosetest: %d tracked resources were never closed\n",
+		len(leaked),
+	)
+	for _, resource := range leaked {
+		_, _ = fmt.Fprintf(leakOutput, "\n%s\n", resource.leakMessage())
+	}
+
+	if exitCode == 0 && !settings.allowLeaks {
+		exitCode = 1
+	}
+	return exitCode
+}
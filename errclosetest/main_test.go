@@ -0,0 +1,76 @@
+package errclosetest_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose/errclosetest"
+)
+
+const mainTestEnvVar = "ERRCLOSETEST_MAIN_TEST"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainTestEnvVar) == "allowed leak" {
+		errclosetest.Main(m, errclosetest.AllowLeaks())
+	} else {
+		errclosetest.Main(m)
+	}
+}
+
+// TestLeakInSubprocess leaks a tracked resource when run by runMainInSubprocess, to test the leak
+// summary from errclosetest.Main.
+func TestLeakInSubprocess(t *testing.T) {
+	if os.Getenv(mainTestEnvVar) == "" {
+		t.Skip("Only runs in a subprocess started by runMainInSubprocess")
+	}
+	errclosetest.Track(errclosetest.NewMockCloser(nil))
+}
+
+func runMainInSubprocess(t *testing.T, mainTest string) (exitCode int, stderr string) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLeakInSubprocess$")
+	cmd.Env = append(os.Environ(), mainTestEnvVar+"="+mainTest)
+	var stderrBuffer bytes.Buffer
+	cmd.Stderr = &stderrBuffer
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("Failed to run subprocess: %v", err)
+	}
+
+	return cmd.ProcessState.ExitCode(), stderrBuffer.String()
+}
+
+func TestMainReportsLeaks(t *testing.T) {
+	exitCode, stderr := runMainInSubprocess(t, "leak")
+	assertEqual(t, exitCode, 1, "exit code")
+	assertEqual(
+		t,
+		strings.HasPrefix(
+			stderr,
+			"errclosetest: 1 tracked resources were never closed\n\n"+
+				"Resource of type *errclosetest.MockCloser (resource ID 1) was never closed, "+
+				"tracked at:\n",
+		),
+		true,
+		"leak summary ("+stderr+")",
+	)
+	assertEqual(t, strings.Contains(stderr, "main_test.go"), true, "stack trace of Track call")
+}
+
+func TestMainWithAllowLeaks(t *testing.T) {
+	exitCode, stderr := runMainInSubprocess(t, "allowed leak")
+	assertEqual(t, exitCode, 0, "exit code")
+	assertEqual(
+		t,
+		strings.HasPrefix(stderr, "errclosetest: 1 tracked resources were never closed"),
+		true,
+		"leak summary ("+stderr+")",
+	)
+}
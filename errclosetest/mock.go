@@ -0,0 +1,105 @@
+package errclosetest
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// MockCloser is a fake resource with configurable close behavior, for testing how your code
+// handles close errors. Create one with [NewMockCloser] or [NewPanickingMockCloser].
+type MockCloser struct {
+	err        error
+	panicValue any
+	closeCalls atomic.Int64
+}
+
+// NewMockCloser returns a fake resource where every call to Close returns the given error (which
+// may be nil).
+func NewMockCloser(err error) *MockCloser {
+	return &MockCloser{err: err, panicValue: nil, closeCalls: atomic.Int64{}}
+}
+
+// NewPanickingMockCloser returns a fake resource where every call to Close panics with the given
+// value, for testing code that must survive a panicking close, such as shutdown paths.
+func NewPanickingMockCloser(panicValue any) *MockCloser {
+	return &MockCloser{err: nil, panicValue: panicValue, closeCalls: atomic.Int64{}}
+}
+
+// Close returns the resource's error, or panics if the resource was created with
+// [NewPanickingMockCloser].
+func (mock *MockCloser) Close() error {
+	mock.closeCalls.Add(1)
+	if mock.panicValue != nil {
+		panic(mock.panicValue)
+	}
+	return mock.err
+}
+
+// CloseCalls returns the number of times Close has been called.
+func (mock *MockCloser) CloseCalls() int {
+	return int(mock.closeCalls.Load())
+}
+
+// AssertClosed fails the test if Close has not been called on the given resource. It takes any of
+// the fakes in this package.
+func AssertClosed(t testing.TB, resource interface{ CloseCalls() int }) {
+	t.Helper()
+
+	if resource.CloseCalls() == 0 {
+		t.Errorf("Expected resource to be closed, but Close was never called")
+	}
+}
+
+// AssertNotClosed fails the test if Close has been called on the given resource. It takes any of
+// the fakes in this package.
+func AssertNotClosed(t testing.TB, resource interface{ CloseCalls() int }) {
+	t.Helper()
+
+	if calls := resource.CloseCalls(); calls != 0 {
+		t.Errorf("Expected resource to not be closed, but Close was called %d times", calls)
+	}
+}
+
+// AssertCloseErrorFor fails the test if the given error doesn't contain an [errclose.CloseError]
+// for the given resource name. The error may combine several errors (as errclose does when a
+// close error is combined with an existing error), in which case all of them are checked. It
+// returns the matching CloseError, or nil if there is none.
+func AssertCloseErrorFor(t testing.TB, err error, resourceName string) *errclose.CloseError {
+	t.Helper()
+
+	if err == nil {
+		t.Errorf("Expected close error for %q, got nil error", resourceName)
+		return nil
+	}
+
+	closeErr := findCloseError(err, resourceName)
+	if closeErr == nil {
+		t.Errorf("Expected close error for %q, got error %q", resourceName, err)
+	}
+	return closeErr
+}
+
+// findCloseError searches the tree of errors wrapped by the given error for a CloseError with the
+// given resource name. We can't use errors.As, since it stops at the first CloseError.
+func findCloseError(err error, resourceName string) *errclose.CloseError {
+	if closeErr, ok := err.(*errclose.CloseError); ok && //nolint:errorlint // Walking tree manually
+		closeErr.ResourceName == resourceName {
+		return closeErr
+	}
+
+	switch wrapper := err.(type) { //nolint:errorlint // Walking tree manually
+	case interface{ Unwrap() error }:
+		if wrapped := wrapper.Unwrap(); wrapped != nil {
+			return findCloseError(wrapped, resourceName)
+		}
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range wrapper.Unwrap() {
+			if closeErr := findCloseError(wrapped, resourceName); closeErr != nil {
+				return closeErr
+			}
+		}
+	}
+	return nil
+}
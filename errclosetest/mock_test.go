@@ -0,0 +1,55 @@
+package errclosetest_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestMockCloser(t *testing.T) {
+	closeErr := errors.New("close error")
+	mock := errclosetest.NewMockCloser(closeErr)
+	errclosetest.AssertNotClosed(t, mock)
+
+	err := errors.New("existing error")
+	errclose.Close(mock, &err, "file")
+
+	errclosetest.AssertClosed(t, mock)
+	closeError := errclosetest.AssertCloseErrorFor(t, err, "file")
+	assertEqual(t, closeError.Err, closeErr, "CloseError.Err")
+}
+
+func TestAssertCloseErrorForFindsAllResources(t *testing.T) {
+	var err error
+	errclose.Close(errclosetest.NewMockCloser(errors.New("error 1")), &err, "file 1")
+	errclose.Close(errclosetest.NewMockCloser(errors.New("error 2")), &err, "file 2")
+
+	assertEqual(
+		t,
+		errclosetest.AssertCloseErrorFor(t, err, "file 2").Err.Error(),
+		"error 2",
+		"file 2 error",
+	)
+}
+
+func TestAssertCloseErrorForFails(t *testing.T) {
+	var err error
+	errclose.Close(errclosetest.NewMockCloser(errors.New("close error")), &err, "file")
+
+	fakeT := new(testing.T)
+	closeErr := errclosetest.AssertCloseErrorFor(fakeT, err, "other file")
+	assertEqual(t, closeErr == nil, true, "closeErr == nil")
+	assertEqual(t, fakeT.Failed(), true, "fakeT.Failed()")
+}
+
+func TestPanickingMockCloser(t *testing.T) {
+	mock := errclosetest.NewPanickingMockCloser("close panicked")
+
+	defer func() {
+		assertEqual(t, recover(), "close panicked", "recovered value")
+		errclosetest.AssertClosed(t, mock)
+	}()
+	_ = mock.Close()
+}
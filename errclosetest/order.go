@@ -0,0 +1,124 @@
+package errclosetest
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// CloseOrder records the order in which resources are closed, so that tests can check the
+// teardown order of an [errclose.Frame], [errclose.ShutdownManager] or [errclose.DeferStack].
+// Create one with [RecordOrder].
+type CloseOrder struct {
+	t      testing.TB
+	lock   sync.Mutex
+	closed []string
+}
+
+// RecordOrder returns a [CloseOrder] for the given test. Register the resources under test with
+// [CloseOrder.Record] (or observe a shutdown manager with [CloseOrder.Observe]), close them, and
+// check the order with [CloseOrder.AssertOrder]:
+//
+//	func TestTeardownOrder(t *testing.T) {
+//		order := errclosetest.RecordOrder(t)
+//
+//		var frame errclose.Frame
+//		frame.Add(order.Record(openDB(t), "database"), "database")
+//		frame.Add(order.Record(openCache(t), "cache"), "cache")
+//		if err := frame.Err(); err != nil {
+//			t.Fatal(err)
+//		}
+//
+//		order.AssertOrder(t, "cache", "database")
+//	}
+//
+// If a recorded resource is closed more than once, the test fails, since the close order would be
+// ambiguous.
+func RecordOrder(t testing.TB) *CloseOrder {
+	return &CloseOrder{t: t, lock: sync.Mutex{}, closed: nil}
+}
+
+// Record returns a closer that records the given name when it's closed, and then closes the given
+// resource and returns its close error as-is. Pass the returned closer to the code under test
+// instead of the resource. The name is only used for the recorded order, so it doesn't have to
+// match the resource name given to errclose.
+//
+// The returned closer only has a Close method, so resources that implement optional interfaces
+// such as [errclose.Drainable] are closed without them. To record the order of resources closed by
+// a [errclose.ShutdownManager] with their optional interfaces intact, use [CloseOrder.Observe].
+func (order *CloseOrder) Record(
+	resource interface{ Close() error },
+	name string,
+) interface{ Close() error } {
+	return recordedCloser{order: order, resource: resource, name: name}
+}
+
+// RecordFunc works like [CloseOrder.Record], but for close functions, such as undo actions
+// pushed to an [errclose.DeferStack]:
+//
+//	undo.Push(order.RecordFunc(removeDir, "remove temp dir"), "remove temp dir")
+func (order *CloseOrder) RecordFunc(closeFunc func() error, name string) func() error {
+	return func() error {
+		order.add(name)
+		return closeFunc()
+	}
+}
+
+// Observe records the resources closed by the given manager, under the names they were
+// registered with, by registering a handler with [errclose.ShutdownManager.OnEvent]. Resources
+// are recorded when the manager starts closing them, so in phases configured to close in parallel
+// (see [errclose.PhaseConfig]), the order within the phase is not deterministic.
+func (order *CloseOrder) Observe(manager *errclose.ShutdownManager) {
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		if event.Kind == errclose.ShutdownEventResourceClosing {
+			order.add(event.ResourceName)
+		}
+	})
+}
+
+// Names returns the names of the recorded resources that have been closed, in the order they were
+// closed.
+func (order *CloseOrder) Names() []string {
+	order.lock.Lock()
+	defer order.lock.Unlock()
+
+	return slices.Clone(order.closed)
+}
+
+// AssertOrder fails the test if the recorded resources were not closed in exactly the given order,
+// or if other recorded resources were closed as well.
+func (order *CloseOrder) AssertOrder(t testing.TB, names ...string) {
+	t.Helper()
+
+	if closed := order.Names(); !slices.Equal(closed, names) {
+		t.Errorf(
+			"Unexpected close order\nWant: %s\n Got: %s",
+			strings.Join(names, ", "),
+			strings.Join(closed, ", "),
+		)
+	}
+}
+
+func (order *CloseOrder) add(name string) {
+	order.lock.Lock()
+	defer order.lock.Unlock()
+
+	if slices.Contains(order.closed, name) {
+		order.t.Errorf("Resource %q was closed more than once", name)
+	}
+	order.closed = append(order.closed, name)
+}
+
+type recordedCloser struct {
+	order    *CloseOrder
+	resource interface{ Close() error }
+	name     string
+}
+
+func (closer recordedCloser) Close() error {
+	closer.order.add(closer.name)
+	return closer.resource.Close()
+}
@@ -0,0 +1,78 @@
+package errclosetest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestRecordOrderWithFrame(t *testing.T) {
+	order := errclosetest.RecordOrder(t)
+
+	closeErr := errors.New("close error")
+	var frame errclose.Frame
+	frame.Add(order.Record(errclosetest.NewMockCloser(nil), "database"), "database")
+	frame.Add(order.Record(errclosetest.NewMockCloser(closeErr), "cache"), "cache")
+
+	err := frame.Err()
+	assertEqual(t, errors.Is(err, closeErr), true, "close error is returned as-is")
+	order.AssertOrder(t, "cache", "database")
+	assertEqual(t, order.Names(), []string{"cache", "database"}, "names")
+}
+
+func TestRecordOrderWithDeferStack(t *testing.T) {
+	order := errclosetest.RecordOrder(t)
+	undo := func() error { return nil }
+
+	var stack errclose.DeferStack
+	stack.Push(order.RecordFunc(undo, "remove dir"), "remove dir")
+	stack.Push(order.RecordFunc(undo, "delete user"), "delete user")
+
+	err := errors.New("setup failed")
+	stack.RunOnError(&err)
+	order.AssertOrder(t, "delete user", "remove dir")
+}
+
+func TestRecordOrderWithShutdownManager(t *testing.T) {
+	order := errclosetest.RecordOrder(t)
+
+	var manager errclose.ShutdownManager
+	order.Observe(&manager)
+	manager.DeferPhase(errclosetest.NewMockCloser(nil), "database", 1)
+	manager.DeferPhase(errclosetest.NewMockCloser(nil), "server", -1)
+	manager.Defer(errclosetest.NewMockCloser(nil), "cache")
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error")
+	order.AssertOrder(t, "server", "cache", "database")
+}
+
+func TestAssertOrderFails(t *testing.T) {
+	order := errclosetest.RecordOrder(t)
+	var frame errclose.Frame
+	frame.Add(order.Record(errclosetest.NewMockCloser(nil), "database"), "database")
+	frame.Add(order.Record(errclosetest.NewMockCloser(nil), "cache"), "cache")
+	assertEqual(t, frame.Err(), nil, "error")
+
+	fakeT := new(testing.T)
+	order.AssertOrder(fakeT, "database", "cache")
+	assertEqual(t, fakeT.Failed(), true, "fakeT.Failed() with wrong order")
+
+	fakeT = new(testing.T)
+	order.AssertOrder(fakeT, "cache")
+	assertEqual(t, fakeT.Failed(), true, "fakeT.Failed() with missing name")
+}
+
+func TestRecordOrderFailsOnDoubleClose(t *testing.T) {
+	fakeT := new(testing.T)
+	order := errclosetest.RecordOrder(fakeT)
+	recorded := order.Record(errclosetest.NewMockCloser(nil), "file")
+
+	assertEqual(t, recorded.Close(), nil, "first close error")
+	assertEqual(t, fakeT.Failed(), false, "fakeT.Failed() after first close")
+	assertEqual(t, recorded.Close(), nil, "second close error")
+	assertEqual(t, fakeT.Failed(), true, "fakeT.Failed() after second close")
+}
@@ -0,0 +1,51 @@
+//go:build unix || windows
+
+package errclose
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errnoError wraps a platform error code with a hint about what it typically means when returned by
+// a close call. Its error message is the message of the error code followed by the hint, and it
+// unwraps to the error code, so it can still be checked with errors.Is(err, syscall.EBADF).
+type errnoError struct {
+	errno syscall.Errno
+	hint  string
+}
+
+func (err errnoError) Error() string {
+	return err.errno.Error() + " (" + err.hint + ")"
+}
+
+func (err errnoError) Unwrap() error {
+	return err.errno
+}
+
+// withErrnoHint wraps the given close error in an errnoError if it is an error code that we have a
+// hint for (see closeErrnoHint, which is defined per platform). Other errors are returned as-is.
+func withErrnoHint(closeErr error) error {
+	var errno syscall.Errno
+	if !errors.As(closeErr, &errno) {
+		return closeErr
+	}
+
+	hint, ok := closeErrnoHint(errno)
+	if !ok {
+		return closeErr
+	}
+	return errnoError{errno: errno, hint: hint}
+}
+
+// closeErrno returns the platform error code in the given close error's chain (if any), along with
+// a hint about what it means for a close call (empty if we don't have one).
+func closeErrno(closeErr error) (errno error, hint string) {
+	var code syscall.Errno
+	if !errors.As(closeErr, &code) {
+		return nil, ""
+	}
+
+	hint, _ = closeErrnoHint(code)
+	return code, hint
+}
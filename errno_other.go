@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package errclose
+
+// closeErrno is only implemented on Unix and Windows (see errno.go), as other platforms don't have
+// a [syscall.Errno] type with well-known error codes.
+func closeErrno(error) (errno error, hint string) {
+	return nil, ""
+}
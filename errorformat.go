@@ -0,0 +1,198 @@
+package errclose
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrorFormat controls how this package combines a close error with an existing error. Set it with
+// [errclose.SetErrorFormat].
+//
+// The format of a close error that is the only error is the same for all formats:
+//
+//	failed to close <resourceName>: <close error>
+type ErrorFormat int32
+
+const (
+	// ErrorFormatParenthetical adds the close error in parentheses after the existing error. This
+	// is the default:
+	//
+	//	<existing error> (and failed to close <resourceName>: <close error>)
+	ErrorFormatParenthetical ErrorFormat = iota
+	// ErrorFormatCompact adds the close error in a shorter form after the existing error, which
+	// keeps long error chains more readable:
+	//
+	//	<existing error>; also: close <resourceName>: <close error>
+	ErrorFormatCompact
+)
+
+var errorFormat atomic.Int32
+
+// SetErrorFormat sets how this package combines a close error with an existing error (see
+// [errclose.ErrorFormat]). This also applies to errors combined by [errclose.Frame],
+// [errclose.ShutdownGlobal] and the other functions in this package that combine errors.
+//
+// The format only changes the error string, not the wrapped errors: both the existing error and
+// the close error can still be checked with [errors.Is] and [errors.As].
+func SetErrorFormat(format ErrorFormat) {
+	errorFormat.Store(int32(format))
+}
+
+var wrapper atomic.Pointer[func(primary error, closeErr error, resourceName string) error]
+
+// SetWrapper sets a function to combine close errors with existing errors, instead of the
+// package's own formats (see [errclose.SetErrorFormat] and [errclose.SetMessageFormat]). This is
+// for codebases that use an error wrapping library with its own structure, such as
+// [hermannm.dev/wrap], while keeping the calling conventions, resource naming and close
+// orchestration of this package:
+//
+//	errclose.SetWrapper(func(primary error, closeErr error, resourceName string) error {
+//		return wrap.Errors("operation failed", primary, closeErr)
+//	})
+//
+// The function is called whenever a close error is combined with an existing non-nil error, such
+// as in [errclose.Close] and [Frame.CloseAll]. It's given the existing error, the
+// [errclose.CloseError] for the failed close (whose message includes the resource name), and the
+// resource name on its own. To keep [errors.As] and the other functions in this package working
+// on the combined error, the function should wrap both errors. Close errors that are not combined
+// with an existing error are returned as CloseErrors, as without a wrapper.
+//
+// If the function returns nil, the errors are combined as if no wrapper was set. Pass nil to
+// remove the wrapper (this is the default).
+//
+// [hermannm.dev/wrap]: https://pkg.go.dev/hermannm.dev/wrap
+func SetWrapper(wrap func(primary error, closeErr error, resourceName string) error) {
+	if wrap == nil {
+		wrapper.Store(nil)
+	} else {
+		wrapper.Store(&wrap)
+	}
+}
+
+// wrapTeardownError wraps the given teardown error in a [errclose.CloseError] with the action and
+// resource name, and combines it with the existing error if it is non-nil (see handleTeardownError
+// for the format).
+func wrapTeardownError(existingErr error, err error, action string, resourceName string) error {
+	return combineCloseError(existingErr, newCloseError(err, action, resourceName))
+}
+
+func newCloseError(err error, action string, resourceName string) *CloseError {
+	// Errors that are already wrapped with the same name and action, such as from WrapCloser, are
+	// unwrapped, so the name isn't repeated in the message
+	//nolint:errorlint // Only errors returned directly by the resource are unwrapped
+	if closeErr, ok := err.(*CloseError); ok &&
+		closeErr.ResourceName == resourceName &&
+		closeErr.actionOrDefault() == action {
+		err = closeErr.Err
+	}
+
+	// Errors from adapters like Stopper carry their own action, which replaces the default
+	fatal := false
+	if action == "close" {
+		//nolint:errorlint // Only errors returned directly by teardownCloser carry an action
+		if teardownErr, ok := err.(*teardownError); ok {
+			action = teardownErr.action
+			fatal = teardownErr.fatal
+			err = teardownErr.err
+		}
+	}
+
+	return &CloseError{
+		ResourceName: resourceName,
+		Err:          err,
+		Stats:        nil,
+		Caller:       "",
+		OSDetail:     nil,
+		Occurrences:  0,
+		TraceID:      "",
+		Labels:       nil,
+		ResourceID:   0,
+		action:       action,
+		compact:      false,
+		format:       closeMessageFormat(),
+		fatal:        fatal,
+	}
+}
+
+// combineCloseError combines the given close error with the existing error if it is non-nil, using
+// the wrapper set by [errclose.SetWrapper] or else the configured error format.
+func combineCloseError(existingErr error, closeErr *CloseError) error {
+	if existingErr == nil {
+		return closeErr
+	}
+	if wrap := wrapper.Load(); wrap != nil {
+		if wrapped := (*wrap)(existingErr, closeErr, closeErr.ResourceName); wrapped != nil {
+			return wrapped
+		}
+	}
+
+	compact := ErrorFormat(errorFormat.Load()) == ErrorFormatCompact
+	closeErr.compact = compact
+	return &combinedError{
+		errs:    [2]error{existingErr, closeErr},
+		compact: compact,
+		format:  combinedMessageFormat(),
+		message: atomic.Pointer[string]{},
+	}
+}
+
+// combineErrors combines the given errors on the following format, if both are non-nil:
+//
+//	<primary error> (and <secondary error>)
+//
+// Or, with [errclose.ErrorFormatCompact]:
+//
+//	<primary error>; also: <secondary error>
+//
+// If only one of the errors is non-nil, that error is returned as-is.
+func combineErrors(primary error, secondary error) error {
+	switch {
+	case secondary == nil:
+		return primary
+	case primary == nil:
+		return secondary
+	default:
+		return &combinedError{
+			errs:    [2]error{primary, secondary},
+			compact: ErrorFormat(errorFormat.Load()) == ErrorFormatCompact,
+			format:  combinedMessageFormat(),
+			message: atomic.Pointer[string]{},
+		}
+	}
+}
+
+// combinedError is the error returned by combineErrors and combineCloseError. It unwraps to both
+// errors, like errors from [errors.Join].
+type combinedError struct {
+	// The primary error, then the secondary error. Stored as an array, so that Unwrap can return a
+	// slice of it without allocating.
+	errs    [2]error
+	compact bool
+	// Custom message format from SetMessageFormat, or empty for the default message.
+	format string
+	// The message, cached by the first call to Error. Combined errors are often only checked with
+	// errors.Is, so the message isn't formatted until it's needed.
+	message atomic.Pointer[string]
+}
+
+func (err *combinedError) Error() string {
+	if message := err.message.Load(); message != nil {
+		return *message
+	}
+
+	var message string
+	switch {
+	case err.format != "":
+		message = fmt.Errorf(err.format, err.errs[0], err.errs[1]).Error()
+	case err.compact:
+		message = err.errs[0].Error() + "; also: " + err.errs[1].Error()
+	default:
+		message = err.errs[0].Error() + " (and " + err.errs[1].Error() + ")"
+	}
+	err.message.Store(&message)
+	return message
+}
+
+func (err *combinedError) Unwrap() []error {
+	return err.errs[:]
+}
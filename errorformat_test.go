@@ -0,0 +1,94 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestErrorFormatCompact(t *testing.T) {
+	errclose.SetErrorFormat(errclose.ErrorFormatCompact)
+	defer errclose.SetErrorFormat(errclose.ErrorFormatParenthetical)
+
+	file1 := openFileWithCloseError()
+	file2 := openFileWithCloseError()
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.Close(file1, &returnedErr, "file 1")
+		defer errclose.Close(file2, &returnedErr, "file 2")
+		return fallibleOperation()
+	}
+
+	err := useFiles()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed; also: close file 2: close error; also: close file 1: close error",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is(errFallibleOperation)")
+	assertEqual(t, errors.Is(err, file1.closeError), true, "errors.Is(file1.closeError)")
+}
+
+func TestErrorFormatCompactWithoutExistingError(t *testing.T) {
+	errclose.SetErrorFormat(errclose.ErrorFormatCompact)
+	defer errclose.SetErrorFormat(errclose.ErrorFormatParenthetical)
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file")
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+type structuredError struct {
+	primary  error
+	closeErr error
+	resource string
+}
+
+func (err structuredError) Error() string {
+	return err.primary.Error() + "\n- " + err.resource + ": " + err.closeErr.Error()
+}
+
+func (err structuredError) Unwrap() []error {
+	return []error{err.primary, err.closeErr}
+}
+
+func TestSetWrapper(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetWrapper(func(primary error, closeErr error, resourceName string) error {
+		return structuredError{primary: primary, closeErr: closeErr, resource: resourceName}
+	})
+
+	err := errors.New("request failed")
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(
+		t,
+		err.Error(),
+		"request failed\n- file: failed to close file: close error",
+		"error string",
+	)
+	assertEqual(t, errclose.CloseErrorFrom(err).ResourceName, "file", "resource name")
+
+	var onlyCloseErr error
+	errclose.Close(openFileWithCloseError(), &onlyCloseErr, "file")
+	assertEqual(t, onlyCloseErr.Error(), "failed to close file: close error", "error string")
+}
+
+func TestSetWrapperReturningNil(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetWrapper(func(error, error, string) error { return nil })
+
+	err := errors.New("request failed")
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(
+		t,
+		err.Error(),
+		"request failed (and failed to close file: close error)",
+		"error string",
+	)
+}
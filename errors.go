@@ -0,0 +1,222 @@
+package errclose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+)
+
+// Sentinel errors for the failure modes of this package. These can be checked with [errors.Is]
+// on the errors returned by this package, and are part of the package's API.
+var (
+	// ErrAlreadyClosed is returned by [errclose.StandardCloseErrors] for close errors caused by the
+	// resource having already been closed.
+	ErrAlreadyClosed = errors.New("resource already closed")
+	// ErrCloseTimeout is returned by [errclose.StandardCloseErrors] for close errors caused by a
+	// timeout or deadline. Errors from [errclose.AwaitClosed] also match ErrCloseTimeout when the
+	// context deadline is exceeded before the resource is closed.
+	ErrCloseTimeout = errors.New("timed out closing resource")
+	// ErrResourceBroken is returned by [errclose.StandardCloseErrors] for all other close errors.
+	ErrResourceBroken = errors.New("resource broken")
+	// ErrAlreadyShutDown is used when a resource is registered with [errclose.DeferGlobal] after
+	// [errclose.ShutdownGlobal] has completed, or with a [errclose.ShutdownManager] after it has
+	// shut down (see [errclose.LateRegistrationPolicy]).
+	ErrAlreadyShutDown = errors.New("already shut down")
+	// ErrShutdownDeadlineExceeded is matched by errors from [ShutdownManager.Shutdown] and
+	// [errclose.ShutdownGlobal] when the context deadline is exceeded before all resources have
+	// been closed.
+	ErrShutdownDeadlineExceeded = errors.New("shutdown deadline exceeded")
+	// ErrShutdownAborted is matched by errors from [ShutdownManager.Shutdown] when it was
+	// interrupted by a call to [ShutdownManager.Abort]. It's also the cause of the canceled
+	// context that Abort gives to resources (see [context.Cause]).
+	ErrShutdownAborted = errors.New("shutdown aborted")
+	// ErrNilResource is used as the close error when [errclose.Close] or [errclose.Closef] is
+	// given a nil resource, so the error is returned instead of causing a nil pointer panic:
+	//
+	//	failed to close <resourceName>: nil resource
+	ErrNilResource = errors.New("nil resource")
+)
+
+// withDeadlineSentinel makes the given error match the given sentinel with [errors.Is] if ctxErr
+// is [context.DeadlineExceeded], without changing the error message.
+func withDeadlineSentinel(err error, ctxErr error, sentinel error) error {
+	if !errors.Is(ctxErr, context.DeadlineExceeded) {
+		return err
+	}
+	return sentinelError{err: err, sentinel: sentinel}
+}
+
+type sentinelError struct {
+	err      error
+	sentinel error
+}
+
+func (err sentinelError) Error() string {
+	return err.err.Error()
+}
+
+func (err sentinelError) Unwrap() []error {
+	return []error{err.err, err.sentinel}
+}
+
+// CloseError is the error that [errclose.Close] and the other functions in this package wrap
+// teardown errors with, to add the name of the resource. It lets you find out which resource
+// failed to close with [errors.As]:
+//
+//	var closeErr *errclose.CloseError
+//	if errors.As(err, &closeErr) {
+//		slog.Warn("Resource failed to close", "resource", closeErr.ResourceName)
+//	}
+//
+// The error message is on the following format:
+//
+//	failed to close <ResourceName>: <Err>
+//
+// Resources that are torn down in other ways (such as with [Started.Stop]) produce the same type,
+// but with their own action in the message, e.g. "failed to stop". When a CloseError is combined
+// with an existing error using [errclose.ErrorFormatCompact], the "failed to" prefix is left out.
+// To use your own message format, see [errclose.SetMessageFormat]. To print the error along with
+// its details, such as for verbose logs, format it with %+v (see [CloseError.Format]).
+type CloseError struct {
+	ResourceName string
+	Err          error
+	// Stats is a snapshot of the resource's statistics, taken right before the failed close. It's
+	// only set if the resource was closed with the [errclose.Stats] option.
+	Stats any
+	// Caller is the file and line of the errclose call that produced the error, on the format
+	// "<file>:<line>". It's only set if the resource was closed with the [errclose.WithCaller]
+	// option.
+	Caller string
+	// OSDetail describes the operating system error behind the close error, if there is one. It's
+	// only set if the resource was closed with the [errclose.WithOSDetail] option.
+	OSDetail *OSDetail
+	// Occurrences is the number of times the same close failure occurred, if repeated failures
+	// were summarized into this error (see [errclose.SetRepeatSummary]). Otherwise, it's 0. If
+	// it's more than 1, the count is included at the end of the error message.
+	Occurrences int
+	// TraceID is the trace ID from the context of the failed teardown, if a trace ID extractor is
+	// set (see [errclose.SetTraceIDExtractor]) and the resource was closed with a context, such as
+	// by [errclose.CloseContext]. Otherwise, it's empty.
+	TraceID string
+	// Labels are the key/value labels given with the [errclose.WithLabels] option, or nil if the
+	// resource was closed without labels. The map must not be modified.
+	Labels map[string]string
+	// ResourceID is the ID given with the [errclose.WithResourceID] option, or assigned when the
+	// resource was registered with a [errclose.ShutdownManager]. Otherwise, it's 0.
+	ResourceID ResourceID
+
+	// The teardown action in the error message, or "close" if empty.
+	action string
+	// Set when combined with an existing error using ErrorFormatCompact.
+	compact bool
+	// Custom message format from SetMessageFormat, or empty for the default message.
+	format string
+	// Set for errors from [errclose.Finalize], or closes with the [errclose.Fatal] option.
+	fatal bool
+}
+
+func (err *CloseError) Error() string {
+	var message string
+	if err.format != "" {
+		message = fmt.Errorf(err.format, err.actionOrDefault(), err.ResourceName, err.Err).Error()
+	} else {
+		teardown := describeTeardown(err.actionOrDefault(), err.ResourceName)
+		if err.compact {
+			message = teardown + ": " + errorMessage(err.Err)
+		} else {
+			message = "failed to " + teardown + ": " + errorMessage(err.Err)
+		}
+	}
+
+	if err.Occurrences > 1 {
+		message += " (occurred " + strconv.Itoa(err.Occurrences) + " times)"
+	}
+	return message
+}
+
+func (err *CloseError) Unwrap() error {
+	return err.Err
+}
+
+// Fingerprint returns an identifier for the kind of failure, which stays the same for the same
+// failure across processes and hosts. This is meant for error tracking systems, so they can group
+// the same close failure even when resource names and error messages contain variable parts such
+// as file paths and addresses.
+//
+// The fingerprint is a hex-encoded hash of the teardown action, and the type and message of the
+// innermost error in the Err chain (which usually describes the failure without the resource), with
+// every sequence of digits in the message replaced by "#". The resource name is not included.
+func (err *CloseError) Fingerprint() string {
+	innermost := err.Err
+	for {
+		next := errors.Unwrap(innermost)
+		if next == nil {
+			break
+		}
+		innermost = next
+	}
+
+	// A CloseError constructed outside the package may have a nil Err, which has no type
+	innermostType := nilErrorMessage
+	if innermost != nil {
+		innermostType = reflect.TypeOf(innermost).String()
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(err.actionOrDefault()))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(innermostType))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write(replaceDigits(errorMessage(innermost)))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// nilErrorMessage is used in place of the message of a nil Err in a [errclose.CloseError], in the
+// same way as fmt formats a nil error.
+const nilErrorMessage = "<nil>"
+
+// errorMessage returns the message of the given error, or nilErrorMessage if it's nil.
+func errorMessage(err error) string {
+	if err == nil {
+		return nilErrorMessage
+	}
+	return err.Error()
+}
+
+func (err *CloseError) actionOrDefault() string {
+	if err.action == "" {
+		return "close"
+	}
+	return err.action
+}
+
+// describeTeardown returns "<action> <resourceName>", or just the action if the resource name is
+// empty (as for [errclose.Do]).
+func describeTeardown(action string, resourceName string) string {
+	if resourceName == "" {
+		return action
+	}
+	return action + " " + resourceName
+}
+
+// replaceDigits replaces every sequence of digits in the given message with "#".
+func replaceDigits(message string) []byte {
+	replaced := make([]byte, 0, len(message))
+	inDigits := false
+	for i := range len(message) {
+		char := message[i]
+		if char >= '0' && char <= '9' {
+			if !inDigits {
+				replaced = append(replaced, '#')
+			}
+			inDigits = true
+		} else {
+			replaced = append(replaced, char)
+			inDigits = false
+		}
+	}
+	return replaced
+}
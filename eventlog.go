@@ -0,0 +1,172 @@
+package errclose
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var eventLog struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+// SetEventLog makes the package write a line to the given writer for every close error it handles,
+// for auditing close failures without depending on a logging library. Pass nil to stop writing
+// events (this is the default).
+//
+// Events are written in the [logfmt] format, one event per line:
+//
+//	time=2025-08-30T12:00:00.000000000Z event=close_failed resource="file" error="close error"
+//
+// The time is in UTC and formatted as RFC 3339 with a fixed 9 digits of fractional seconds, and the
+// resource, error and trace_id fields are always quoted with [strconv.Quote]. The event field is
+// one of:
+//   - close_failed: A resource failed to close (the error field is the close error), with a
+//     trace_id field if the failure has a trace ID (see [errclose.SetTraceIDExtractor]), and a
+//     resource_id field if the resource has an ID (see [errclose.ResourceID])
+//   - closed: A resource was closed successfully, with a duration field for how long the close
+//     took (only written if enabled with [errclose.SetDebugEvents])
+//   - late_registration: A resource was registered with [errclose.DeferGlobal] after
+//     [errclose.ShutdownGlobal] completed (see [errclose.LateRegistrationPolicy])
+//   - resource_leaked: A resource guarded by [errclose.CloseOrCleanup] was garbage-collected
+//     without being closed (the error field is included if closing it failed), with a
+//     resource_id field for the guard's ID (see [LeakGuard.ResourceID])
+//   - slow_close: A close took longer than the threshold set by [errclose.SetCloseDiagnostics]
+//     (the error field is the [errclose.SlowCloseError] message)
+//
+// Errors from writing to the given writer are ignored. SetEventLog is safe to call concurrently
+// with other functions in the package, and events are never interleaved in the writer.
+//
+// [logfmt]: https://brandur.org/logfmt
+func SetEventLog(writer io.Writer) {
+	eventLog.lock.Lock()
+	defer eventLog.lock.Unlock()
+
+	eventLog.writer = writer
+}
+
+var debugEvents atomic.Bool
+
+// SetDebugEvents makes the package also write an event to the event log (see
+// [errclose.SetEventLog]) when [errclose.Close] or [errclose.Closef] successfully closes a
+// resource, along with the time the close took:
+//
+//	time=2025-08-30T12:00:00.000000000Z event=closed resource="file" duration=1.2ms
+//
+// This is meant for tracing resource lifecycles during development, e.g. when looking for leaks. It
+// doesn't change how errors are handled. Debug events are disabled by default, since they make
+// successful closes allocate, and call [fmt.Sprintf] for the resource name in Closef. To log
+// successful closes of specific resources with [log/slog] instead, see [errclose.WithDebugLog].
+func SetDebugEvents(enabled bool) {
+	debugEvents.Store(enabled)
+}
+
+const (
+	eventClosed           = "closed"
+	eventCloseFailed      = "close_failed"
+	eventResourceLeaked   = "resource_leaked"
+	eventLateRegistration = "late_registration"
+	eventSlowClose        = "slow_close"
+)
+
+const eventTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+func logEvent(event string, resourceName string, err error) {
+	logEventWithDetails(event, resourceName, err, "", 0)
+}
+
+// logEventWithDetails works like logEvent, but adds a trace_id field if the trace ID is not empty,
+// and a resource_id field if the resource ID is not 0.
+func logEventWithDetails(
+	event string,
+	resourceName string,
+	err error,
+	traceID string,
+	resourceID ResourceID,
+) {
+	eventLog.lock.Lock()
+	defer eventLog.lock.Unlock()
+
+	if eventLog.writer == nil {
+		return
+	}
+
+	line := appendEventPrefix(make([]byte, 0, 128), event, resourceName)
+	if err != nil {
+		line = append(line, " error="...)
+		line = strconv.AppendQuote(line, err.Error())
+	}
+	if traceID != "" {
+		line = append(line, " trace_id="...)
+		line = strconv.AppendQuote(line, traceID)
+	}
+	if resourceID != 0 {
+		line = append(line, " resource_id="...)
+		line = strconv.AppendUint(line, uint64(resourceID), 10)
+	}
+	line = append(line, '\n')
+
+	_, _ = eventLog.writer.Write(line)
+}
+
+func logClosedEvent(resourceName string, duration time.Duration) {
+	eventLog.lock.Lock()
+	defer eventLog.lock.Unlock()
+
+	if eventLog.writer == nil {
+		return
+	}
+
+	line := appendEventPrefix(make([]byte, 0, 128), eventClosed, resourceName)
+	line = append(line, " duration="...)
+	line = append(line, duration.String()...)
+	line = append(line, '\n')
+
+	_, _ = eventLog.writer.Write(line)
+}
+
+func appendEventPrefix(line []byte, event string, resourceName string) []byte {
+	line = append(line, "time="...)
+	line = time.Now().UTC().AppendFormat(line, eventTimeFormat)
+	line = append(line, " event="...)
+	line = append(line, event...)
+	line = append(line, " resource="...)
+	line = strconv.AppendQuote(line, resourceName)
+	return line
+}
+
+// closeWithDebugEvent closes the resource like [errclose.Close], and writes a closed event to the
+// event log if the close succeeded and debug events are enabled (see [errclose.SetDebugEvents]).
+// It also runs close diagnostics, if enabled (see [errclose.SetCloseDiagnostics]). callerSkip is
+// the number of stack frames between this function and the caller of the errclose function.
+func closeWithDebugEvent(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	options optionLists,
+	callerSkip int,
+) {
+	stopDiagnostics := startCloseDiagnostics(resourceName, callerSkip+1)
+	stats := captureStats(options)
+	start := time.Now()
+	closeErr := closeWithOptions(resource, resourceName, options)
+	stopDiagnostics()
+	if closeErr == nil {
+		if debugEvents.Load() {
+			logClosedEvent(resourceName, time.Since(start))
+		}
+		return
+	}
+
+	handleCloseErrorWithOptions(
+		returnedErr,
+		closeErr,
+		resourceName,
+		options,
+		stats,
+		callerSkip+1,
+	)
+}
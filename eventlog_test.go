@@ -0,0 +1,60 @@
+package errclose_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestEventLog(t *testing.T) {
+	var buffer bytes.Buffer
+	errclose.SetEventLog(&buffer)
+	defer errclose.SetEventLog(nil)
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.Close(openFileWithoutCloseError(), &returnedErr, "file 1")
+		defer errclose.Closef(openFileWithCloseError(), &returnedErr, "file %d", 2)
+		return nil
+	}
+
+	_ = useFiles()
+
+	timePattern := `time=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{9}Z`
+	expectedLine := regexp.MustCompile(
+		`^` + timePattern + ` event=close_failed resource="file 2" error="close error"\n$`,
+	)
+	if !expectedLine.Match(buffer.Bytes()) {
+		t.Errorf("Unexpected event log output: %q", buffer.String())
+	}
+}
+
+func TestDebugEvents(t *testing.T) {
+	var buffer bytes.Buffer
+	errclose.SetEventLog(&buffer)
+	defer errclose.SetEventLog(nil)
+	errclose.SetDebugEvents(true)
+	defer errclose.SetDebugEvents(false)
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.Close(openFileWithoutCloseError(), &returnedErr, "file 1")
+		defer errclose.Closef(openFileWithCloseError(), &returnedErr, "file %d", 2)
+		defer errclose.Closef(openFileWithoutCloseError(), &returnedErr, "file %d", 3)
+		return nil
+	}
+
+	err := useFiles()
+	assertEqual(t, err.Error(), "failed to close file 2: close error", "error string")
+
+	timePattern := `time=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{9}Z`
+	durationPattern := `duration=[0-9.]+[nµm]?s`
+	expectedLines := regexp.MustCompile(
+		`^` + timePattern + ` event=closed resource="file 3" ` + durationPattern + `\n` +
+			timePattern + ` event=close_failed resource="file 2" error="close error"\n` +
+			timePattern + ` event=closed resource="file 1" ` + durationPattern + `\n$`,
+	)
+	if !expectedLines.Match(buffer.Bytes()) {
+		t.Errorf("Unexpected event log output: %q", buffer.String())
+	}
+}
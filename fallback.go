@@ -0,0 +1,58 @@
+package errclose
+
+import (
+	"errors"
+)
+
+// WithFallback returns an option that calls the given recovery function when a close fails, so
+// that the failure can be remediated where it's detected, and not just reported. For example, to
+// remove a partially written file if closing it fails:
+//
+//	defer errclose.Close(
+//		file,
+//		&returnedErr,
+//		"output file",
+//		errclose.WithFallback(func(closeErr error) error {
+//			return os.Remove(file.Name())
+//		}),
+//	)
+//
+// The fallback gets the unwrapped close error (without the resource name). It's not called for
+// close errors dropped by [errclose.Ignore], or when the close succeeds. The close error is handled
+// as without the option, and if the fallback fails as well, its error is combined with the close
+// error in the same way as an existing error (see 'Error format' on [errclose.Close]):
+//
+//	<wrapped close error> (and failed to run fallback for <resourceName>: <fallback error>)
+//
+// If multiple WithFallback options are given, the fallbacks are called in order, and their errors
+// are joined with [errors.Join].
+func WithFallback(fallback func(closeErr error) error) Option {
+	return Option{settings: &optionSettings{fallback: fallback}}
+}
+
+// runFallbacks calls the fallbacks given with [errclose.WithFallback] in the given options, and
+// returns their errors (nil if there are no fallbacks, or they all succeeded).
+func runFallbacks(options optionLists, closeErr error) error {
+	var fallbackErrs []error
+	for _, list := range options {
+		for _, option := range list {
+			fallback := option.get().fallback
+			if fallback == nil {
+				continue
+			}
+			if err := fallback(closeErr); err != nil {
+				fallbackErrs = append(fallbackErrs, err)
+			}
+		}
+	}
+	return errors.Join(fallbackErrs...)
+}
+
+// reportFallbackFailure writes the error from failed fallbacks to the event log and passes it to
+// the observer, like reportCloseFailureWithOptions. It's not counted in the metrics, since the
+// fallback is part of handling the close failure that was already counted (see [errclose.Metrics]).
+func reportFallbackFailure(fallbackErr *CloseError, options optionLists) {
+	if !isReportFiltered(fallbackErr, options) {
+		logWrappedCloseFailure(fallbackErr)
+	}
+}
@@ -0,0 +1,92 @@
+package errclose_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWithFallback(t *testing.T) {
+	var fallbackCloseErr error
+	fallback := errclose.WithFallback(func(closeErr error) error {
+		fallbackCloseErr = closeErr
+		return nil
+	})
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", fallback)
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, fallbackCloseErr.Error(), "close error", "close error given to fallback")
+}
+
+func TestWithFallbackError(t *testing.T) {
+	fallback := errclose.WithFallback(func(error) error {
+		return errors.New("remove failed")
+	})
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", fallback)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file: close error (and failed to run fallback for file: remove failed)",
+		"error string",
+	)
+
+	closeErrs := errclose.Errors(err)
+	assertEqual(t, len(closeErrs), 2, "number of close errors")
+	assertEqual(t, closeErrs[1].Err.Error(), "remove failed", "fallback error")
+}
+
+func TestWithFallbackNotCalledWithoutCloseError(t *testing.T) {
+	fallbackCalled := false
+	fallback := errclose.WithFallback(func(error) error {
+		fallbackCalled = true
+		return nil
+	})
+
+	var err error
+	errclose.Close(openFileWithoutCloseError(), &err, "file", fallback)
+	ignoreAll := errclose.IgnoreIf(func(error) bool { return true })
+	errclose.Close(openFileWithCloseError(), &err, "file", ignoreAll, fallback)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, fallbackCalled, false, "fallbackCalled")
+}
+
+func TestWithFallbackAndKeepPrimary(t *testing.T) {
+	fallback := errclose.WithFallback(func(error) error {
+		return errors.New("remove failed")
+	})
+
+	err := errors.New("write failed")
+	errclose.Close(openFileWithCloseError(), &err, "file", fallback, errclose.KeepPrimary())
+	assertEqual(t, err.Error(), "write failed", "error string")
+	assertEqual(t, len(errclose.Errors(err)), 2, "number of close errors")
+}
+
+func TestCloseAndLogWithFallbackError(t *testing.T) {
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{
+		ReplaceAttr: removeTime,
+		AddSource:   false,
+		Level:       nil,
+	}))
+
+	errclose.CloseAndLog(
+		openFileWithCloseError(),
+		logger,
+		slog.LevelWarn,
+		"file",
+		errclose.WithFallback(func(error) error { return errors.New("remove failed") }),
+	)
+	assertEqual(
+		t,
+		output.String(),
+		`level=WARN msg="Failed to close resource" resource=file error="close error" `+
+			`fallback_error="remove failed"`+"\n",
+		"log output",
+	)
+}
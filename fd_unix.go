@@ -0,0 +1,48 @@
+//go:build unix
+
+package errclose
+
+import (
+	"syscall"
+)
+
+// FD is a raw Unix file descriptor, such as one returned by [syscall.Socket] or [syscall.Open].
+// Converting a descriptor to FD lets you close it with [errclose.Close] and the other functions in
+// this package, so it gets the same error handling as other resources:
+//
+//	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+//	if err != nil {
+//		return err
+//	}
+//	defer errclose.Close(errclose.FD(fd), &returnedErr, "socket")
+//
+// If closing the descriptor fails with a well-known error code, a hint about what the error code
+// usually means is added to the error message, e.g.:
+//
+//	failed to close socket: bad file descriptor (descriptor may already have been closed)
+//
+// The error still unwraps to the [syscall.Errno], so it can be checked with [errors.Is].
+type FD int
+
+// Close closes the file descriptor with [syscall.Close].
+func (fd FD) Close() error {
+	if err := syscall.Close(int(fd)); err != nil {
+		return withErrnoHint(err)
+	}
+	return nil
+}
+
+func closeErrnoHint(errno syscall.Errno) (hint string, ok bool) {
+	switch errno {
+	case syscall.EBADF:
+		return "descriptor may already have been closed", true
+	case syscall.EINTR:
+		return "close was interrupted; the descriptor may or may not have been closed", true
+	case syscall.EIO:
+		return "data written to the descriptor may have been lost", true
+	case syscall.ENOSPC:
+		return "data written to the descriptor may not have been stored", true
+	default:
+		return "", false
+	}
+}
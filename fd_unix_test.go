@@ -0,0 +1,43 @@
+//go:build unix
+
+package errclose_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseFD(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := syscall.Open(path, syscall.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useFD := func() (returnedErr error) {
+		defer errclose.Close(errclose.FD(fd), &returnedErr, "file descriptor")
+		return nil
+	}
+
+	err = useFD()
+	assertEqual(t, err, nil, "error from first close")
+
+	err = useFD()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file descriptor: bad file descriptor "+
+			"(descriptor may already have been closed)",
+		"error string from second close",
+	)
+	assertEqual(t, errors.Is(err, syscall.EBADF), true, "errors.Is(syscall.EBADF)")
+}
@@ -0,0 +1,135 @@
+package errclose
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// CloseFiles closes the given files concurrently, and handles close errors. It's meant for programs
+// that hold many files open at once, such as backup or indexing tools that walk a directory tree.
+// It accepts any file type with Name and Close methods, such as [os.File] (or the File type of
+// filesystem abstractions like github.com/spf13/afero).
+//
+// At most maxConcurrent files are closed at the same time. If maxConcurrent is 0 or less, all files
+// are closed concurrently.
+//
+// You'll typically call this in a deferred function, with a pointer to your function's named error
+// return value, as with [errclose.Close]. Note that the deferred call must be wrapped in a function
+// literal if you append to the slice of files after the defer statement, since the arguments to a
+// deferred call are evaluated when the defer statement runs:
+//
+//	func indexFiles(paths []string) (returnedErr error) {
+//		var files []*os.File
+//		defer func() { errclose.CloseFiles(files, &returnedErr, 16) }()
+//
+//		for _, path := range paths {
+//			file, err := os.Open(path)
+//			if err != nil {
+//				return err
+//			}
+//			files = append(files, file)
+//		}
+//
+//		// Use files
+//	}
+//
+// # Error format
+//
+// Each close error is wrapped with the file's name (i.e., its path for an [os.File]):
+//
+//	failed to close file <file name>: <close error>
+//
+// Close errors are combined with each other and with the existing error pointed to by returnedErr
+// in the same way as calling [errclose.Close] for each file, in the order that the files were given
+// (regardless of the order that they finished closing).
+//
+// A nil file (including a typed nil pointer, since its Name method can't be called) is not closed,
+// and is handled according to the policy set by [errclose.SetNilResourcePolicy], with "file" as
+// the resource name:
+//
+//	failed to close file: nil resource
+//
+// If a file's Close method panics, the panic is recovered and returned as its close error, since a
+// panic in the goroutine that closes the file would otherwise crash the program:
+//
+//	failed to close file <file name>: panic: <panic value>
+//
+// # Profiling
+//
+// The goroutines that close files are given [pprof] labels, so profiles taken while closing
+// attribute the work to the files being closed:
+//   - errclose.resource: "file <file name>"
+//   - errclose.phase: "close"
+func CloseFiles[File interface {
+	Name() string
+	Close() error
+}](
+	files []File,
+	returnedErr *error,
+	maxConcurrent int,
+) {
+	if maxConcurrent <= 0 || maxConcurrent > len(files) {
+		maxConcurrent = len(files)
+	}
+
+	closeErrs := make([]error, len(files))
+	// Nil files have no name to call, so we name them once up front, and then only use the names
+	resourceNames := make([]string, len(files))
+	nilFiles := make([]bool, len(files))
+	semaphore := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+
+	for i, file := range files {
+		if isNil(file) {
+			nilFiles[i] = true
+			continue
+		}
+		resourceNames[i] = "file " + file.Name()
+		recordCloseAttempt(resourceNames[i])
+
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			withCloseLabels(resourceNames[i], "close", func() {
+				// A panic in this goroutine would crash the program, so we return it as an error
+				defer recoverAsError(&closeErrs[i])
+				closeErrs[i] = file.Close()
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	for i, closeErr := range closeErrs {
+		if nilFiles[i] {
+			handleNilResource(returnedErr, "file")
+		} else if closeErr != nil {
+			handleCloseError(returnedErr, closeErr, resourceNames[i])
+		}
+	}
+}
+
+// CloseFS closes the given filesystem if it has a Close method, and handles close errors in the
+// same way as [errclose.Close]. If the filesystem has no Close method, CloseFS does nothing.
+//
+// Some [fs.FS] implementations hold resources that must be closed (such as [zip.ReadCloser]),
+// while others don't (such as the filesystem returned by [os.DirFS]). CloseFS lets code that is
+// written against fs.FS release the filesystem without knowing which kind it was given:
+//
+//	func loadTemplates(fsys fs.FS) (returnedErr error) {
+//		defer errclose.CloseFS(fsys, &returnedErr, "template filesystem")
+//
+//		// Use fsys
+//	}
+//
+// Files opened from an fs.FS implement Close() error, so they can be passed to [errclose.Close]
+// directly.
+func CloseFS(fsys fs.FS, returnedErr *error, resourceName string) {
+	if closer, ok := fsys.(interface{ Close() error }); ok {
+		Close(closer, returnedErr, resourceName)
+	}
+}
@@ -0,0 +1,201 @@
+package errclose_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseFiles(t *testing.T) {
+	dir := t.TempDir()
+	var files []*os.File
+	for _, name := range []string{"a", "b", "c"} {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.CloseFiles(files, &returnedErr, 2)
+		return nil
+	}
+
+	err := useFiles()
+	assertEqual(t, err, nil, "error")
+
+	for _, file := range files {
+		err := file.Close()
+		assertEqual(t, errors.Is(err, os.ErrClosed), true, "file closed by CloseFiles")
+	}
+}
+
+func TestCloseFilesWithCloseErrors(t *testing.T) {
+	files := []*namedMockFile{
+		{name: "/a", mockFile: *openFileWithCloseError(), onClose: nil},
+		{name: "/b", mockFile: *openFileWithoutCloseError(), onClose: nil},
+		{name: "/c", mockFile: *openFileWithCloseError(), onClose: nil},
+	}
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.CloseFiles(files, &returnedErr, 0)
+		return fallibleOperation()
+	}
+
+	err := useFiles()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file /a: close error) "+
+			"(and failed to close file /c: close error)",
+		"error string",
+	)
+	for _, file := range files {
+		assertEqual(t, file.closeWasCalled, true, "closeWasCalled for "+file.name)
+	}
+}
+
+func TestCloseFilesWithNilFile(t *testing.T) {
+	file := &namedMockFile{name: "/a", mockFile: *openFileWithoutCloseError(), onClose: nil}
+	files := []*namedMockFile{file, nil}
+
+	var err error
+	errclose.CloseFiles(files, &err, 0)
+	assertEqual(t, err.Error(), "failed to close file: nil resource", "error string")
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is(err, ErrNilResource)")
+	assertEqual(t, file.closeWasCalled, true, "closeWasCalled for non-nil file")
+}
+
+func TestCloseFilesWithNilFileSkipped(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceSkip)
+
+	var err error
+	errclose.CloseFiles([]*namedMockFile{nil}, &err, 0)
+	assertEqual(t, err, nil, "error")
+}
+
+func TestCloseFilesRecoversPanic(t *testing.T) {
+	files := []*namedMockFile{
+		{
+			name:     "/a",
+			mockFile: *openFileWithoutCloseError(),
+			onClose:  func() { panic("close panic") },
+		},
+		{name: "/b", mockFile: *openFileWithoutCloseError(), onClose: nil},
+	}
+
+	var err error
+	errclose.CloseFiles(files, &err, 0)
+	assertEqual(t, err.Error(), "failed to close file /a: panic: close panic", "error string")
+	assertEqual(t, files[1].closeWasCalled, true, "closeWasCalled for non-panicking file")
+}
+
+func TestCloseFilesConcurrencyLimit(t *testing.T) {
+	var current, maxObserved atomic.Int32
+	files := make([]*namedMockFile, 10)
+	for i := range files {
+		files[i] = &namedMockFile{
+			name:     "file",
+			mockFile: *openFileWithoutCloseError(),
+			onClose: func() {
+				running := current.Add(1)
+				for {
+					observed := maxObserved.Load()
+					if running <= observed || maxObserved.CompareAndSwap(observed, running) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				current.Add(-1)
+			},
+		}
+	}
+
+	var err error
+	errclose.CloseFiles(files, &err, 3)
+	assertEqual(t, err, nil, "error")
+	if maxObserved.Load() > 3 {
+		t.Errorf("Expected at most 3 concurrent closes, got %d", maxObserved.Load())
+	}
+}
+
+func TestCloseFilesSetsProfilerLabels(t *testing.T) {
+	var profile bytes.Buffer
+	file := &namedMockFile{
+		name:     "/path/to/file",
+		mockFile: *openFileWithoutCloseError(),
+		onClose: func() {
+			_ = pprof.Lookup("goroutine").WriteTo(&profile, 1)
+		},
+	}
+
+	var err error
+	errclose.CloseFiles([]*namedMockFile{file}, &err, 0)
+	assertEqual(t, err, nil, "error")
+	assertEqual(
+		t,
+		strings.Contains(
+			profile.String(),
+			`labels: {"errclose.phase":"close", "errclose.resource":"file /path/to/file"}`,
+		),
+		true,
+		"goroutine profile contains labels",
+	)
+}
+
+func TestCloseFS(t *testing.T) {
+	fsys := &closableFS{FS: fstest.MapFS{}, mockFile: *openFileWithCloseError()}
+
+	useFS := func() (returnedErr error) {
+		defer errclose.CloseFS(fsys, &returnedErr, "filesystem")
+		return nil
+	}
+
+	err := useFS()
+	assertEqual(t, fsys.closeWasCalled, true, "fsys.closeWasCalled")
+	assertEqual(t, err.Error(), "failed to close filesystem: close error", "error string")
+}
+
+func TestCloseFSWithoutCloseMethod(t *testing.T) {
+	useFS := func() (returnedErr error) {
+		defer errclose.CloseFS(fstest.MapFS{}, &returnedErr, "filesystem")
+		return nil
+	}
+
+	err := useFS()
+	assertEqual(t, err, nil, "error")
+}
+
+type closableFS struct {
+	fs.FS
+	mockFile
+}
+
+type namedMockFile struct {
+	mockFile
+	name    string
+	onClose func()
+}
+
+func (file *namedMockFile) Name() string {
+	return file.name
+}
+
+func (file *namedMockFile) Close() error {
+	if file.onClose != nil {
+		file.onClose()
+	}
+	return file.mockFile.Close()
+}
@@ -0,0 +1,58 @@
+package errclose
+
+// Finalize closes the given resource like [errclose.Close], for resources where Close is what
+// finalizes the output, such as [zip.Writer], [tar.Writer] and [gzip.Writer]. For these, Close
+// writes trailing data (like a zip file's central directory or a gzip checksum), so a failed close
+// means that the output is corrupt, not just that a handle leaked:
+//
+//	zipWriter := zip.NewWriter(file)
+//	defer errclose.Finalize(zipWriter, &returnedErr, "zip archive")
+//
+// Close errors from Finalize use "finalize" instead of "close" in the error message:
+//
+//	failed to finalize <resourceName>: <close error>
+//
+// The close error is also marked as fatal, so you can tell it apart from other close errors with
+// [errclose.IsFatal] or [CloseError.Fatal], e.g. to discard the output instead of uploading it.
+// To mark errors from other closes as fatal, use the [errclose.Fatal] option.
+//
+// Remember to close the underlying writer (e.g. the file) after finalizing, typically with a
+// deferred [errclose.Close] before the call to Finalize, so it runs after.
+func Finalize(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	options ...Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	finalizer := teardownCloser{teardown: resource.Close, action: "finalize", fatal: true}
+	closeResource(finalizer, returnedErr, resolveResourceName(resource, resourceName), options, 1)
+}
+
+// Fatal returns an option that marks close errors as fatal, so that [errclose.IsFatal] and
+// [CloseError.Fatal] return true for them. This is for closes where a failure means that the work
+// was lost, such as closing a file after writing to it, so that consumers of the error can treat
+// it with the appropriate severity:
+//
+//	defer errclose.Close(file, &returnedErr, "output file", errclose.Fatal())
+//
+// The error message is the same as without the option. Errors from [errclose.Finalize] are always
+// fatal.
+func Fatal() Option {
+	return Option{settings: &optionSettings{fatal: true}}
+}
+
+func isFatal(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().fatal {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package errclose_test
+
+import (
+	"archive/zip"
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestFinalize(t *testing.T) {
+	writeArchive := func() (returnedErr error) {
+		zipWriter := zip.NewWriter(failingWriter{})
+		defer errclose.Finalize(zipWriter, &returnedErr, "zip archive")
+		return nil
+	}
+
+	err := writeArchive()
+	assertEqual(t, err.Error(), "failed to finalize zip archive: disk full", "error string")
+	assertEqual(t, errclose.IsFatal(err), true, "IsFatal")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.Fatal(), true, "Fatal()")
+	assertEqual(t, closeErr.ResourceName, "zip archive", "resource name")
+}
+
+func TestFinalizeWithoutError(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	var err error
+	errclose.Finalize(file, &err, "file")
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, file.closeWasCalled, true, "close was called")
+}
+
+func TestFinalizeWithExistingError(t *testing.T) {
+	err := errors.New("write failed")
+	errclose.Finalize(openFileWithCloseError(), &err, "gzip stream")
+	assertEqual(
+		t,
+		err.Error(),
+		"write failed (and failed to finalize gzip stream: close error)",
+		"error string",
+	)
+	assertEqual(t, errclose.IsFatal(err), true, "IsFatal")
+}
+
+func TestFatalOption(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.Fatal())
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errclose.IsFatal(err), true, "IsFatal")
+
+	var otherErr error
+	errclose.Close(openFileWithCloseError(), &otherErr, "file")
+	assertEqual(t, errclose.IsFatal(otherErr), false, "IsFatal without option")
+}
@@ -0,0 +1,160 @@
+package errclose
+
+import (
+	"io"
+)
+
+// FlushClose flushes the given writer, then closes the given closer, and handles errors from both
+// in the same way as [errclose.Close] handles close errors. This is for buffered writers on top of
+// other resources, such as a [bufio.Writer] wrapping a file, where buffered data is lost if you
+// close the file without flushing:
+//
+//	func writeReport(path string) (returnedErr error) {
+//		file, err := os.Create(path)
+//		if err != nil {
+//			return err
+//		}
+//		writer := bufio.NewWriter(file)
+//		defer errclose.FlushClose(writer, file, &returnedErr, "report file")
+//
+//		// Write to writer
+//	}
+//
+// The closer is closed also if flushing fails. Errors are labeled with their stage, on the
+// following formats:
+//
+//	failed to flush <resourceName>: <flush error>
+//	failed to close <resourceName>: <close error>
+//
+// If both fail, the close error is combined with the flush error, in the same way as errclose.Close
+// combines a close error with an existing error.
+//
+// If the writer is nil, the flush error is [errclose.ErrNilResource], and if the closer is nil,
+// the close error is ErrNilResource. For types that implement both Flush and Close, such as
+// [gzip.Writer], use [errclose.FlushAndClose].
+func FlushClose(
+	writer interface{ Flush() error },
+	closer io.Closer,
+	returnedErr *error,
+	resourceName string,
+) {
+	if isNilResource(writer) {
+		handleNilTeardown(returnedErr, "flush", resourceName)
+	} else {
+		recordCloseAttempt(resourceName)
+		if flushErr := writer.Flush(); flushErr != nil {
+			handleTeardownError(returnedErr, flushErr, "flush", resourceName)
+		}
+	}
+
+	Close(closer, returnedErr, resourceName)
+}
+
+// FlushAndClose calls [errclose.FlushClose] with a resource that implements both Flush and Close,
+// such as [gzip.Writer]:
+//
+//	defer errclose.FlushAndClose(gzipWriter, &returnedErr, "gzip writer")
+//
+// Note that for many such writers (including gzip.Writer), Close already flushes. In that case,
+// FlushAndClose is still useful for labeling a flush failure separately from a close failure.
+func FlushAndClose(
+	resource interface {
+		Flush() error
+		Close() error
+	},
+	returnedErr *error,
+	resourceName string,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	FlushClose(resource, resource, returnedErr, resourceName)
+}
+
+// FlushErr flushes the given writer, for writers that report flush errors through an Error method
+// instead of returning them from Flush, such as [csv.Writer]. Errors are handled in the same way as
+// [errclose.Close] handles close errors, so these writers can be flushed in a defer like other
+// resources:
+//
+//	func writeRecords(file *os.File, records [][]string) (returnedErr error) {
+//		writer := csv.NewWriter(file)
+//		defer errclose.FlushErr(writer, &returnedErr, "CSV writer")
+//
+//		for _, record := range records {
+//			if err := writer.Write(record); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}
+//
+// The error returned by Error after flushing is labeled as a flush error:
+//
+//	failed to flush <resourceName>: <flush error>
+//
+// FlushErr doesn't close anything, so close the underlying resource separately (with a deferred
+// [errclose.Close] before the call to FlushErr, so it runs after). If the writer is nil, the flush
+// error is [errclose.ErrNilResource].
+func FlushErr(
+	writer interface {
+		Flush()
+		Error() error
+	},
+	returnedErr *error,
+	resourceName string,
+) {
+	if isNilResource(writer) {
+		handleNilTeardown(returnedErr, "flush", resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	writer.Flush()
+	if flushErr := writer.Error(); flushErr != nil {
+		handleTeardownError(returnedErr, flushErr, "flush", resourceName)
+	}
+}
+
+// SyncClose syncs the given file to stable storage, then closes it, and handles errors from both in
+// the same way as [errclose.Close] handles close errors. Written data may be lost on a crash unless
+// the file is synced before closing, so this gives write paths durability with a single defer:
+//
+//	func writeSnapshot(path string, data []byte) (returnedErr error) {
+//		file, err := os.Create(path)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.SyncClose(file, &returnedErr, "snapshot file")
+//
+//		_, err = file.Write(data)
+//		return err
+//	}
+//
+// The file is closed also if syncing fails. Sync errors are labeled separately from close errors:
+//
+//	failed to sync <resourceName>: <sync error>
+//
+// If the function returns an error, the file is still synced, since the data written before the
+// failure may be needed for recovery. If the file is nil, the close error is
+// [errclose.ErrNilResource].
+func SyncClose(
+	file interface {
+		Sync() error
+		Close() error
+	},
+	returnedErr *error,
+	resourceName string,
+) {
+	if isNilResource(file) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	if syncErr := file.Sync(); syncErr != nil {
+		handleTeardownError(returnedErr, syncErr, "sync", resourceName)
+	}
+	Close(file, returnedErr, resourceName)
+}
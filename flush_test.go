@@ -0,0 +1,146 @@
+package errclose_test
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestFlushClose(t *testing.T) {
+	writer := &mockFlusher{flushErr: errors.New("flush error"), flushed: false}
+	file := openFileWithCloseError()
+
+	write := func() (returnedErr error) {
+		defer errclose.FlushClose(writer, file, &returnedErr, "report file")
+		return nil
+	}
+
+	err := write()
+	assertEqual(t, writer.flushed, true, "flushed")
+	assertEqual(t, file.closeWasCalled, true, "closeWasCalled")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to flush report file: flush error "+
+			"(and failed to close report file: close error)",
+		"error string",
+	)
+}
+
+func TestFlushCloseWithoutErrors(t *testing.T) {
+	writer := &mockFlusher{flushErr: nil, flushed: false}
+	file := openFileWithoutCloseError()
+
+	write := func() (returnedErr error) {
+		defer errclose.FlushClose(writer, file, &returnedErr, "report file")
+		return nil
+	}
+
+	err := write()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, writer.flushed, true, "flushed")
+	assertEqual(t, file.closeWasCalled, true, "closeWasCalled")
+}
+
+func TestFlushAndClose(t *testing.T) {
+	writer := &mockFlushCloser{
+		mockFlusher: mockFlusher{flushErr: errors.New("flush error"), flushed: false},
+		closed:      false,
+	}
+
+	write := func() (returnedErr error) {
+		defer errclose.FlushAndClose(writer, &returnedErr, "gzip writer")
+		return fallibleOperation()
+	}
+
+	err := write()
+	assertEqual(t, writer.closed, true, "closed")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to flush gzip writer: flush error)",
+		"error string",
+	)
+}
+
+type mockFlusher struct {
+	flushErr error
+	flushed  bool
+}
+
+func (flusher *mockFlusher) Flush() error {
+	flusher.flushed = true
+	return flusher.flushErr
+}
+
+type mockFlushCloser struct {
+	mockFlusher
+	closed bool
+}
+
+func (flushCloser *mockFlushCloser) Close() error {
+	flushCloser.closed = true
+	return nil
+}
+
+func TestSyncClose(t *testing.T) {
+	file := &mockSyncFile{syncErr: errors.New("sync error"), synced: false, closed: false}
+
+	write := func() (returnedErr error) {
+		defer errclose.SyncClose(file, &returnedErr, "snapshot file")
+		return fallibleOperation()
+	}
+
+	err := write()
+	assertEqual(t, file.synced, true, "synced")
+	assertEqual(t, file.closed, true, "closed")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to sync snapshot file: sync error)",
+		"error string",
+	)
+}
+
+func TestFlushErr(t *testing.T) {
+	write := func() (returnedErr error) {
+		writer := csv.NewWriter(failingWriter{})
+		defer errclose.FlushErr(writer, &returnedErr, "CSV writer")
+		return writer.Write([]string{"name", "value"})
+	}
+
+	err := write()
+	assertEqual(t, err.Error(), "failed to flush CSV writer: disk full", "error string")
+}
+
+func TestFlushErrWithoutError(t *testing.T) {
+	var output strings.Builder
+	write := func() (returnedErr error) {
+		writer := csv.NewWriter(&output)
+		defer errclose.FlushErr(writer, &returnedErr, "CSV writer")
+		return writer.Write([]string{"name", "value"})
+	}
+
+	err := write()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, output.String(), "name,value\n", "output")
+}
+
+type mockSyncFile struct {
+	syncErr error
+	synced  bool
+	closed  bool
+}
+
+func (file *mockSyncFile) Sync() error {
+	file.synced = true
+	return file.syncErr
+}
+
+func (file *mockSyncFile) Close() error {
+	file.closed = true
+	return nil
+}
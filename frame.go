@@ -0,0 +1,132 @@
+package errclose
+
+// Frame collects resources to close at the end of a goroutine (or some other unit of work), so that
+// functions deep in a call stack can register resources for closing without having to thread a
+// pointer to the error returned at the top of the stack.
+//
+// You'll typically create a Frame at the start of a goroutine, pass it to the functions that
+// acquire resources, and call [Frame.Err] when the work is done:
+//
+//	go func() {
+//		var frame errclose.Frame
+//		processJobs(jobs, &frame)
+//
+//		if err := frame.Err(); err != nil {
+//			slog.Error("Failed to clean up after jobs", "error", err)
+//		}
+//	}()
+//
+//	func processJobs(jobs <-chan Job, frame *errclose.Frame) {
+//		for job := range jobs {
+//			file, err := os.Open(job.Path)
+//			if err != nil {
+//				continue
+//			}
+//			frame.Add(file, "job file")
+//
+//			// Use file
+//		}
+//	}
+//
+// A Frame can also be used as a scope for many short-lived resources, such as the files opened for
+// each record in a parsing loop. [Frame.Err] keeps the frame's internal storage, so if you reuse
+// one Frame across iterations instead of creating a new one each time, adding and closing
+// resources doesn't allocate once the frame has grown to fit an iteration:
+//
+//	var frame errclose.Frame
+//	for record := range records {
+//		processRecord(record, &frame)
+//		if err := frame.Err(); err != nil {
+//			return err
+//		}
+//	}
+//
+// The zero value is ready to use. A Frame is not safe for concurrent use, so create one per
+// goroutine.
+type Frame struct {
+	resources []namedResource
+}
+
+type namedResource struct {
+	resource     interface{ Close() error }
+	resourceName string
+	// Options given when registering the resource, such as with Frame.AddWith
+	options []Option
+}
+
+// Add registers the given resource to be closed when [Frame.Err] is called. The resource name is
+// used to format the close error, as in [errclose.Close].
+func (frame *Frame) Add(resource interface{ Close() error }, resourceName string) {
+	frame.AddWith(resource, resourceName)
+}
+
+// AddWith registers the given resource to be closed when [Frame.Err] is called, like [Frame.Add],
+// but with options for how its close errors are handled, as in [errclose.Close]. This lets you
+// label resources where they're registered (see [errclose.WithLabels]):
+//
+//	frame.AddWith(conn, "connection", errclose.WithLabels(map[string]string{"tenant": tenantID}))
+func (frame *Frame) AddWith(
+	resource interface{ Close() error },
+	resourceName string,
+	options ...Option,
+) {
+	frame.resources = append(
+		frame.resources,
+		namedResource{resource: resource, resourceName: resourceName, options: options},
+	)
+}
+
+// Err closes all resources added to the frame, in the reverse order of how they were added, and
+// returns the combined close errors (or nil if all closes succeeded). The frame is emptied, so
+// calling Err again only closes resources added after the previous call.
+//
+// Close errors are formatted and combined in the same way as calling [errclose.Close] for each
+// resource. For example, if resource 1 and then resource 2 were added, and both fail to close, the
+// error looks like this:
+//
+//	failed to close <resource 2>: <close error> (and failed to close <resource 1>: <close error>)
+//
+// If several resources fail with the same close error (checked with [errors.Is]), such as wrappers
+// around the same broken connection, the error is only included once, with the number of
+// resources that failed, and the names of the first three:
+//
+//	failed to close <number of resources> resources (<names>): <close error>
+func (frame *Frame) Err() (returnedErr error) {
+	frame.CloseAll(&returnedErr)
+	return returnedErr
+}
+
+// CloseAll closes all resources added to the frame, like [Frame.Err], but handles the close errors
+// in the same way as [errclose.Close]: the error pointed to by returnedErr is set to the close
+// errors, or combined with them if it's non-nil. This lets you replace a chain of deferred Close
+// calls in a function that opens many resources with a single defer:
+//
+//	func example() (returnedErr error) {
+//		var frame errclose.Frame
+//		defer frame.CloseAll(&returnedErr)
+//
+//		file1, err := os.Open("/some/path")
+//		if err != nil {
+//			return err
+//		}
+//		frame.Add(file1, "file 1")
+//
+//		file2, err := os.Open("/other/path")
+//		if err != nil {
+//			return err
+//		}
+//		frame.Add(file2, "file 2")
+//
+//		// Use files
+//	}
+func (frame *Frame) CloseAll(returnedErr *error) {
+	closeGroup(
+		returnedErr,
+		len(frame.resources),
+		func(index int) namedResource { return frame.resources[index] },
+	)
+
+	// Keep the backing array, so a reused frame doesn't allocate
+	clear(frame.resources)
+	frame.resources = frame.resources[:0]
+}
@@ -0,0 +1,148 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestFrame(t *testing.T) {
+	var frame errclose.Frame
+	file1 := openFileWithoutCloseError()
+	file2 := openFileWithoutCloseError()
+	frame.Add(file1, "file 1")
+	frame.Add(file2, "file 2")
+
+	err := frame.Err()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, file1.closeWasCalled, true, "file1.closeWasCalled")
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+}
+
+func TestFrameWithCloseErrors(t *testing.T) {
+	var frame errclose.Frame
+	file1 := openFileWithCloseError()
+	file2 := openFileWithoutCloseError()
+	file3 := openFileWithCloseError()
+	frame.Add(file1, "file 1")
+	frame.Add(file2, "file 2")
+	frame.Add(file3, "file 3")
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file 3: close error (and failed to close file 1: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, file1.closeError), true, "errors.Is(file1.closeError)")
+	assertEqual(t, errors.Is(err, file3.closeError), true, "errors.Is(file3.closeError)")
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+}
+
+func TestFrameCloseOrder(t *testing.T) {
+	var frame errclose.Frame
+	var closeOrder []string
+	for _, name := range []string{"first", "second", "third"} {
+		frame.Add(closerFunc(func() error {
+			closeOrder = append(closeOrder, name)
+			return nil
+		}), name)
+	}
+
+	err := frame.Err()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, closeOrder, []string{"third", "second", "first"}, "close order")
+
+	err = frame.Err()
+	assertEqual(t, err, nil, "error from second call")
+	assertEqual(t, len(closeOrder), 3, "number of closes after second call")
+}
+
+func TestFrameCloseAll(t *testing.T) {
+	file1 := openFileWithCloseError()
+	file2 := openFileWithoutCloseError()
+	file3 := openFileWithCloseError()
+
+	useFiles := func() (returnedErr error) {
+		var frame errclose.Frame
+		defer frame.CloseAll(&returnedErr)
+
+		frame.Add(file1, "file 1")
+		frame.Add(file2, "file 2")
+		frame.Add(file3, "file 3")
+		return fallibleOperation()
+	}
+
+	err := useFiles()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file 3: close error) "+
+			"(and failed to close file 1: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is(errFallibleOperation)")
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+}
+
+func TestFrameDeduplicatesCloseErrors(t *testing.T) {
+	connErr := errors.New("connection reset")
+	brokenConn := closerFunc(func() error { return connErr })
+
+	var frame errclose.Frame
+	frame.Add(brokenConn, "reader")
+	frame.Add(openFileWithCloseError(), "file")
+	frame.Add(brokenConn, "writer")
+	frame.Add(brokenConn, "flusher")
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close 3 resources (flusher, writer, reader): connection reset "+
+			"(and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, connErr), true, "errors.Is result")
+}
+
+func TestFrameDeduplicatesFatalCloseErrors(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaults(errclose.Stats(func() any { return "stats" }))
+	errclose.SetDefaultsFor[*mockFile](errclose.Fatal())
+	connErr := errors.New("connection reset")
+
+	var frame errclose.Frame
+	frame.Add(&mockFile{closeWasCalled: false, closeError: connErr}, "file")
+	frame.Add(closerFunc(func() error { return connErr }), "conn")
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close 2 resources (conn, file): connection reset",
+		"error string",
+	)
+	assertEqual(t, errclose.IsFatal(err), true, "IsFatal")
+	assertEqual(t, errclose.Errors(err)[0].Stats, any("stats"), "stats of merged error")
+}
+
+func TestFrameDeduplicatesManyCloseErrors(t *testing.T) {
+	connErr := errors.New("connection reset")
+	brokenConn := closerFunc(func() error { return connErr })
+
+	var frame errclose.Frame
+	for _, name := range []string{"e", "d", "c", "b", "a"} {
+		frame.Add(brokenConn, name)
+	}
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close 5 resources (a, b, c, …): connection reset",
+		"error string",
+	)
+}
@@ -0,0 +1,52 @@
+package errclose
+
+// CloseG closes the given resource, and handles close errors in the same way as [errclose.Close].
+// Unlike Close, it takes the resource as a type parameter instead of an interface, so that passing
+// a resource that is not a pointer (such as a small struct handle) doesn't allocate to convert it
+// to an interface. This is for tight loops that close many small resources:
+//
+//	for _, handle := range handles {
+//		errclose.CloseG(handle, &returnedErr, "handle")
+//	}
+//
+// When passing pointers, Close and CloseG perform the same, since converting a pointer to an
+// interface doesn't allocate. The resource is only converted to an interface if the close fails,
+// or if debug events, close diagnostics or metrics are enabled (see [errclose.SetDebugEvents],
+// [errclose.SetCloseDiagnostics] and [errclose.SetMetrics]), defaults are set for any type (see
+// [errclose.SetDefaultsFor]), or the nil resource policy is not the default (see
+// [errclose.SetNilResourcePolicy]).
+func CloseG[Resource interface{ Close() error }](
+	resource Resource,
+	returnedErr *error,
+	resourceName string,
+	options ...Option,
+) {
+	if closeEventsEnabled() ||
+		metricsEnabled() ||
+		typeDefaultsSet() ||
+		NilResourcePolicy(nilResourcePolicy.Load()) != NilResourceError {
+		closeResource(resource, returnedErr, resourceName, options, 1)
+		return
+	}
+	if any(resource) == nil {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	// Type defaults are not set, so the resource is not needed to look up options
+	lists := withDefaults(nil, options)
+	stats := captureStats(lists)
+	closeErr := closeWithOptions(resource, resourceName, lists)
+	if closeErr == nil {
+		return
+	}
+
+	handleCloseErrorWithOptions(
+		returnedErr,
+		closeErr,
+		resolveResourceName(resource, resourceName),
+		lists,
+		stats,
+		1,
+	)
+}
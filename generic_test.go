@@ -0,0 +1,60 @@
+package errclose_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseG(t *testing.T) {
+	err := errors.New("existing error")
+	errclose.CloseG(valueHandle{id: 1, fail: true}, &err, "handle")
+	assertEqual(
+		t,
+		err.Error(),
+		"existing error (and failed to close handle: handle 1 failed)",
+		"error string",
+	)
+}
+
+func TestCloseGNilInterface(t *testing.T) {
+	var closer io.Closer
+	var err error
+	errclose.CloseG(closer, &err, "closer")
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is ErrNilResource")
+}
+
+func TestCloseGWithCallerAndDebugEvents(t *testing.T) {
+	// With debug events enabled, CloseG takes the same path as errclose.Close
+	errclose.SetDebugEvents(true)
+	defer errclose.SetDebugEvents(false)
+
+	var err error
+	errclose.CloseG(valueHandle{id: 1, fail: true}, &err, "handle", errclose.WithCaller())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(
+		t,
+		strings.Contains(closeErr.Caller, "generic_test.go:"),
+		true,
+		"caller is the CloseG call",
+	)
+}
+
+// valueHandle is a resource with a value receiver, which allocates when converted to an interface.
+type valueHandle struct {
+	id   int
+	fail bool
+}
+
+func (handle valueHandle) Close() error {
+	if handle.fail {
+		return fmt.Errorf("handle %d failed", handle.id)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package errclose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+var globalManager ShutdownManager
+
+// GlobalShutdownManager returns the package's default [errclose.ShutdownManager], which
+// [errclose.DeferGlobal], [errclose.DeferGlobalPhase] and [errclose.ShutdownGlobal] use. This is
+// for passing the global registry to functions that take a manager, such as [errclose.OnSignal].
+func GlobalShutdownManager() *ShutdownManager {
+	return &globalManager
+}
+
+// DeferGlobal registers the given resource to be closed when [errclose.ShutdownGlobal] is called.
+// The resource name is used to format the close error, as in [errclose.Close].
+//
+// This is intended for the main function of small programs, where resources are opened in various
+// places but should all be closed before the program exits:
+//
+//	func main() {
+//		if err := run(); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//		}
+//		if err := errclose.ShutdownGlobal(context.Background()); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//		}
+//	}
+//
+//	func run() error {
+//		file, err := os.Open("/some/path")
+//		if err != nil {
+//			return err
+//		}
+//		errclose.DeferGlobal(file, "file")
+//
+//		// Use file
+//	}
+//
+// If DeferGlobal is called after ShutdownGlobal has completed, the resource is handled according to
+// the policy set by [errclose.SetLateRegistrationPolicy].
+//
+// Resources registered with DeferGlobal are in shutdown phase 0. To control the order of shutdown
+// between groups of resources, use [errclose.DeferGlobalPhase].
+//
+// DeferGlobal registers the resource with the default [errclose.ShutdownManager] (see
+// [errclose.GlobalShutdownManager]), and is safe for concurrent use. To shut down a group of
+// resources independently of the global registry, use a ShutdownManager of your own.
+func DeferGlobal(resource interface{ Close() error }, resourceName string) {
+	globalManager.Defer(resource, resourceName)
+}
+
+// DeferGlobalPhase registers the given resource to be closed when [errclose.ShutdownGlobal] is
+// called, like [errclose.DeferGlobal], but in the given shutdown phase. ShutdownGlobal closes
+// resources phase by phase, starting with the lowest phase. See [ShutdownManager.DeferPhase] for
+// details.
+//
+// DeferGlobalPhase is safe for concurrent use.
+func DeferGlobalPhase(resource interface{ Close() error }, resourceName string, phase int) {
+	globalManager.DeferPhase(resource, resourceName, phase)
+}
+
+// ShutdownGlobal closes all resources registered with [errclose.DeferGlobal], in the reverse order
+// of how they were registered, and returns the combined close errors (or nil if all closes
+// succeeded). Resources registered with [errclose.DeferGlobalPhase] are closed phase by phase,
+// starting with the lowest phase. It calls [ShutdownManager.Shutdown] on the default manager (see
+// [errclose.GlobalShutdownManager]), so see that for details on error handling and contexts.
+//
+// Closed resources are removed from the global registry, so ShutdownGlobal is safe to call multiple
+// times: later calls only close resources registered after the previous call.
+func ShutdownGlobal(ctx context.Context) error {
+	return globalManager.Shutdown(ctx)
+}
+
+// LateRegistrationPolicy controls what happens when a resource is registered with
+// [errclose.DeferGlobal] after [errclose.ShutdownGlobal] has completed (or with a
+// [errclose.ShutdownManager] after its Shutdown method has completed). This can happen in shutdown
+// races, where one goroutine opens a resource while another is shutting down the program. Set the
+// policy with [errclose.SetLateRegistrationPolicy].
+//
+// Regardless of policy, late registrations are reported to the event log (see
+// [errclose.SetEventLog]), with the following event:
+//
+//	event=late_registration resource=<resourceName> error="already shut down"
+type LateRegistrationPolicy int32
+
+const (
+	// LateRegistrationKeep registers the resource as usual, so it's closed by the next call to
+	// [errclose.ShutdownGlobal] (or [ShutdownManager.Shutdown]). This is the default.
+	LateRegistrationKeep LateRegistrationPolicy = iota
+	// LateRegistrationClose closes the resource immediately. Since there is no error to return the
+	// close error through, it's only reported to the event log (as a close_failed event).
+	LateRegistrationClose
+	// LateRegistrationPanic panics with an error that wraps [errclose.ErrAlreadyShutDown], and
+	// includes the resource name. The resource is not closed.
+	LateRegistrationPanic
+)
+
+var lateRegistrationPolicy atomic.Int32
+
+// SetLateRegistrationPolicy sets what happens when a resource is registered with
+// [errclose.DeferGlobal] after [errclose.ShutdownGlobal] has completed (see
+// [errclose.LateRegistrationPolicy]). The policy applies to every [errclose.ShutdownManager].
+func SetLateRegistrationPolicy(policy LateRegistrationPolicy) {
+	lateRegistrationPolicy.Store(int32(policy))
+}
+
+func handleLateRegistration(manager *ShutdownManager, resource managedResource) {
+	resourceName := resource.resourceName
+	logEvent(eventLateRegistration, resourceName, ErrAlreadyShutDown)
+
+	switch LateRegistrationPolicy(lateRegistrationPolicy.Load()) {
+	case LateRegistrationClose:
+		recordCloseAttempt(resourceName)
+		if closeErr := resource.resource.Close(); closeErr != nil {
+			reportCloseFailure(resourceName, closeErr)
+			if strict.Load() {
+				panic(newClosePanic(resource.resource, closeErr, resourceName, "late registration"))
+			}
+		}
+	case LateRegistrationPanic:
+		panic(fmt.Errorf("errclose: failed to register %s: %w", resourceName, ErrAlreadyShutDown))
+	case LateRegistrationKeep:
+		fallthrough
+	default:
+		manager.lock.Lock()
+		defer manager.lock.Unlock()
+
+		manager.resources = append(manager.resources, resource)
+	}
+}
+
+// Main runs the given function as the body of your program, then closes all resources registered
+// with [errclose.DeferGlobal], and exits the program. It's meant to be called from your main
+// function:
+//
+//	func main() {
+//		errclose.Main(run)
+//	}
+//
+//	func run() error {
+//		file, err := os.Open("/some/path")
+//		if err != nil {
+//			return err
+//		}
+//		errclose.DeferGlobal(file, "file")
+//
+//		// Use file
+//	}
+//
+// Calling [os.Exit] directly skips deferred functions, so resources closed in defers are never
+// closed. Main only exits after global cleanup is done, which avoids this problem.
+//
+// If run returns an error, or [errclose.ShutdownGlobal] fails, then the errors are combined in the
+// same way as in [errclose.Close], and the combined error is printed to stderr. The program then
+// exits with code 1, or the code returned by the ExitCode method of the first error in the error
+// chain that implements interface{ ExitCode() int } (see [errclose.WithExitCode]). If there is no
+// error, the program exits with code 0.
+//
+// If run panics, Main still closes the global resources before the panic continues, so the program
+// crashes as it would without Main, but without leaving resources unclosed. Errors from
+// ShutdownGlobal are then printed to stderr before the panic message.
+func Main(run func() error) {
+	err := runAndShutdown(run)
+	if err == nil {
+		os.Exit(0)
+	}
+
+	_, _ = fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(exitCode(err))
+}
+
+func runAndShutdown(run func() error) error {
+	// Not recovering, so that the panic continues with its original stack trace
+	panicked := true
+	defer func() {
+		if panicked {
+			if err := ShutdownGlobal(context.Background()); err != nil {
+				_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			}
+		}
+	}()
+
+	runErr := run()
+	panicked = false
+	return combineErrors(runErr, ShutdownGlobal(context.Background()))
+}
+
+// WithExitCode wraps the given error with an exit code for [errclose.Main] to exit with. The
+// returned error has the same error message as the wrapped error, and unwraps to it. If err is nil,
+// WithExitCode returns nil.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return exitCodeError{err: err, code: code}
+}
+
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+func (err exitCodeError) Error() string {
+	return err.err.Error()
+}
+
+func (err exitCodeError) Unwrap() error {
+	return err.err
+}
+
+func (err exitCodeError) ExitCode() int {
+	return err.code
+}
+
+func exitCode(err error) int {
+	var errWithExitCode interface{ ExitCode() int }
+	if errors.As(err, &errWithExitCode) {
+		if code := errWithExitCode.ExitCode(); code > 0 {
+			return code
+		}
+	}
+	return 1
+}
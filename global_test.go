@@ -0,0 +1,354 @@
+package errclose_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestShutdownGlobal(t *testing.T) {
+	file1 := openFileWithCloseError()
+	file2 := openFileWithoutCloseError()
+	file3 := openFileWithCloseError()
+	errclose.DeferGlobal(file1, "file 1")
+	errclose.DeferGlobal(file2, "file 2")
+	errclose.DeferGlobal(file3, "file 3")
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file 3: close error (and failed to close file 1: close error)",
+		"error string",
+	)
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+
+	err = errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from second ShutdownGlobal")
+}
+
+func TestShutdownGlobalPhases(t *testing.T) {
+	var closed []string
+	closer := func(name string) interface{ Close() error } {
+		return closerFunc(func() error {
+			closed = append(closed, name)
+			return nil
+		})
+	}
+
+	errclose.DeferGlobalPhase(closer("database"), "database", 1)
+	errclose.DeferGlobal(closer("cache 1"), "cache 1")
+	errclose.DeferGlobalPhase(closer("server 1"), "server 1", -1)
+	errclose.DeferGlobal(closer("cache 2"), "cache 2")
+	errclose.DeferGlobalPhase(closer("server 2"), "server 2", -1)
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(
+		t,
+		closed,
+		[]string{"server 2", "server 1", "cache 2", "cache 1", "database"},
+		"close order",
+	)
+}
+
+func TestShutdownGlobalWithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	file1 := openFileWithoutCloseError()
+	errclose.DeferGlobal(file1, "file 1")
+	errclose.DeferGlobal(closerFunc(func() error {
+		cancel()
+		return errors.New("close error")
+	}), "file 2")
+
+	err := errclose.ShutdownGlobal(ctx)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file 2: close error "+
+			"(and shutdown interrupted with 1 resources left: context canceled)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, context.Canceled), true, "errors.Is(context.Canceled)")
+	assertEqual(t, file1.closeWasCalled, false, "file1.closeWasCalled")
+
+	err = errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from second ShutdownGlobal")
+	assertEqual(t, file1.closeWasCalled, true, "file1.closeWasCalled after second ShutdownGlobal")
+}
+
+func TestShutdownGlobalWithExceededDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	file := openFileWithoutCloseError()
+	errclose.DeferGlobal(file, "file")
+
+	err := errclose.ShutdownGlobal(ctx)
+	assertEqual(
+		t,
+		err.Error(),
+		"shutdown interrupted with 1 resources left: context deadline exceeded",
+		"error string",
+	)
+	assertEqual(
+		t,
+		errors.Is(err, errclose.ErrShutdownDeadlineExceeded),
+		true,
+		"errors.Is(ErrShutdownDeadlineExceeded)",
+	)
+
+	err = errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from second ShutdownGlobal")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled after second ShutdownGlobal")
+}
+
+func TestShutdownGlobalWithSlowResource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	slow := errclosetest.NewSlow(time.Hour, nil)
+	errclose.DeferGlobal(slow, "database pool")
+
+	err := errclose.ShutdownGlobal(ctx)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close database pool: context deadline exceeded",
+		"error string",
+	)
+	assertEqual(t, slow.CloseCalls(), 1, "close calls")
+}
+
+func TestShutdownGlobalSetsCloseContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), closeCtxKey{}, "shutdown")
+
+	resource := &contextAwareResource{closeCtxValue: nil}
+	errclose.DeferGlobal(resource, "resource")
+
+	err := errclose.ShutdownGlobal(ctx)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, resource.closeCtxValue, "shutdown", "value of context given to SetCloseContext")
+}
+
+type closeCtxKey struct{}
+
+type contextAwareResource struct {
+	closeCtxValue any
+}
+
+func (resource *contextAwareResource) SetCloseContext(ctx context.Context) {
+	resource.closeCtxValue = ctx.Value(closeCtxKey{})
+}
+
+func (resource *contextAwareResource) Close() error {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	if mainTest, ok := os.LookupEnv(mainTestEnvVar); ok {
+		runMainTest(mainTest)
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+const mainTestEnvVar = "ERRCLOSE_MAIN_TEST"
+
+// runMainTest calls errclose.Main in a subprocess started by runMainInSubprocess, since Main exits
+// the program.
+func runMainTest(mainTest string) {
+	switch mainTest {
+	case "success":
+		errclose.Main(func() error {
+			errclose.DeferGlobal(openFileWithoutCloseError(), "file")
+			return nil
+		})
+	case "close error":
+		errclose.Main(func() error {
+			errclose.DeferGlobal(openFileWithCloseError(), "file")
+			return fallibleOperation()
+		})
+	case "exit code":
+		errclose.Main(func() error {
+			return errclose.WithExitCode(fallibleOperation(), 3)
+		})
+	case "panic":
+		errclose.Main(func() error {
+			errclose.DeferGlobal(openFileWithCloseError(), "file")
+			panic("something went wrong")
+		})
+	}
+}
+
+func runMainInSubprocess(t *testing.T, mainTest string) (exitCode int, stderr string) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), mainTestEnvVar+"="+mainTest)
+	var stderrBuffer bytes.Buffer
+	cmd.Stderr = &stderrBuffer
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("Failed to run subprocess: %v", err)
+	}
+
+	return cmd.ProcessState.ExitCode(), stderrBuffer.String()
+}
+
+func TestMainSuccess(t *testing.T) {
+	exitCode, stderr := runMainInSubprocess(t, "success")
+	assertEqual(t, exitCode, 0, "exit code")
+	assertEqual(t, stderr, "", "stderr")
+}
+
+func TestMainCloseError(t *testing.T) {
+	exitCode, stderr := runMainInSubprocess(t, "close error")
+	assertEqual(t, exitCode, 1, "exit code")
+	assertEqual(
+		t,
+		stderr,
+		"operation failed (and failed to close file: close error)\n",
+		"stderr",
+	)
+}
+
+func TestMainExitCode(t *testing.T) {
+	exitCode, stderr := runMainInSubprocess(t, "exit code")
+	assertEqual(t, exitCode, 3, "exit code")
+	assertEqual(t, stderr, "operation failed\n", "stderr")
+}
+
+func TestMainPanic(t *testing.T) {
+	exitCode, stderr := runMainInSubprocess(t, "panic")
+	assertEqual(t, exitCode, 2, "exit code")
+	assertEqual(
+		t,
+		strings.HasPrefix(stderr, "failed to close file: close error\npanic: something went wrong"),
+		true,
+		"stderr starts with close error and then panic",
+	)
+}
+
+func TestLateRegistrationKeep(t *testing.T) {
+	var eventLog bytes.Buffer
+	errclose.SetEventLog(&eventLog)
+	defer errclose.SetEventLog(nil)
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from first ShutdownGlobal")
+
+	file := openFileWithoutCloseError()
+	errclose.DeferGlobal(file, "late file")
+	assertEqual(t, file.closeWasCalled, false, "file.closeWasCalled after late registration")
+	assertEqual(
+		t,
+		strings.HasSuffix(
+			eventLog.String(),
+			` event=late_registration resource="late file" error="already shut down"`+"\n",
+		),
+		true,
+		"event log contains late registration",
+	)
+
+	err = errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from second ShutdownGlobal")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled after second ShutdownGlobal")
+}
+
+func TestLateRegistrationClose(t *testing.T) {
+	errclose.SetLateRegistrationPolicy(errclose.LateRegistrationClose)
+	defer errclose.SetLateRegistrationPolicy(errclose.LateRegistrationKeep)
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from ShutdownGlobal")
+
+	file := openFileWithoutCloseError()
+	errclose.DeferGlobal(file, "late file")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled after late registration")
+}
+
+func TestLateRegistrationPanic(t *testing.T) {
+	errclose.SetLateRegistrationPolicy(errclose.LateRegistrationPanic)
+	defer errclose.SetLateRegistrationPolicy(errclose.LateRegistrationKeep)
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from ShutdownGlobal")
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		errclose.DeferGlobal(openFileWithoutCloseError(), "late file")
+	}()
+
+	panicErr, ok := recovered.(error)
+	assertEqual(t, ok, true, "panic value is error")
+	assertEqual(
+		t,
+		panicErr.Error(),
+		"errclose: failed to register late file: already shut down",
+		"panic error string",
+	)
+	assertEqual(
+		t,
+		errors.Is(panicErr, errclose.ErrAlreadyShutDown),
+		true,
+		"errors.Is(ErrAlreadyShutDown)",
+	)
+}
+
+func TestShutdownGlobalClosesResourcesRegisteredDuringShutdown(t *testing.T) {
+	lateFile := openFileWithoutCloseError()
+	errclose.DeferGlobal(closerFunc(func() error {
+		errclose.DeferGlobal(lateFile, "late file")
+		return nil
+	}), "file")
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, lateFile.closeWasCalled, true, "lateFile.closeWasCalled")
+}
+
+func TestShutdownGlobalPreparesClose(t *testing.T) {
+	ctx := context.WithValue(context.Background(), closeCtxKey{}, "shutdown")
+	resource := &preparableResource{
+		prepareCtxValue: nil,
+		prepareErr:      errors.New("flush failed"),
+		stages:          nil,
+	}
+	errclose.DeferGlobal(resource, "cache")
+
+	err := errclose.ShutdownGlobal(ctx)
+	assertEqual(t, err.Error(), "failed to prepare to close cache: flush failed", "error string")
+	assertEqual(t, resource.stages, []string{"prepare", "close"}, "stages")
+	assertEqual(t, resource.prepareCtxValue, "shutdown", "value of context given to PrepareClose")
+}
+
+type preparableResource struct {
+	prepareCtxValue any
+	prepareErr      error
+	stages          []string
+}
+
+func (resource *preparableResource) PrepareClose(ctx context.Context) error {
+	resource.prepareCtxValue = ctx.Value(closeCtxKey{})
+	resource.stages = append(resource.stages, "prepare")
+	return resource.prepareErr
+}
+
+func (resource *preparableResource) Close() error {
+	resource.stages = append(resource.stages, "close")
+	return nil
+}
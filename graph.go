@@ -0,0 +1,212 @@
+package errclose
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Graph closes resources in an order derived from their declared dependencies, for applications
+// where teardown order isn't strictly the reverse of setup order. Each resource is closed before
+// the resources it depends on, so that for example an HTTP server and a job queue are both closed
+// before the database pool that they use:
+//
+//	var shutdown errclose.Graph
+//	err := errors.Join(
+//		shutdown.Add("database", db),
+//		shutdown.Add("HTTP server", server, errclose.After("database")),
+//		shutdown.Add("job queue", queue, errclose.After("database")),
+//	)
+//	if err != nil {
+//		return err // Dependency cycle
+//	}
+//	defer shutdown.CloseAll(&returnedErr)
+//
+// The zero value is ready to use. A Graph is safe for concurrent use, so components can register
+// themselves wherever they're created.
+type Graph struct {
+	lock  sync.Mutex
+	nodes []graphNode
+}
+
+type graphNode struct {
+	name         string
+	resource     interface{ Close() error }
+	dependencies []string
+}
+
+// Dependency declares that a resource added to a [errclose.Graph] depends on other resources.
+// Create one with [errclose.After].
+type Dependency struct {
+	resourceNames []string
+}
+
+// After returns a [errclose.Dependency] on the resources with the given names, for
+// [Graph.Add]. The name reflects the setup order: the resource is set up after its dependencies,
+// and so is closed before them.
+func After(resourceNames ...string) Dependency {
+	return Dependency{resourceNames: resourceNames}
+}
+
+// Add adds a resource with the given name to the graph, with the given dependencies. The name is
+// used to format the close error, as in [errclose.Close], and to refer to the resource in
+// dependencies of other resources.
+//
+// Dependencies may refer to resources that haven't been added yet. If the new dependencies would
+// make a cycle (such as a resource depending on itself, or on a resource that depends on it), the
+// resource is not added, and Add returns a [errclose.DependencyCycleError].
+func (graph *Graph) Add(
+	resourceName string,
+	resource interface{ Close() error },
+	dependencies ...Dependency,
+) error {
+	var dependencyNames []string
+	for _, dependency := range dependencies {
+		dependencyNames = append(dependencyNames, dependency.resourceNames...)
+	}
+
+	graph.lock.Lock()
+	defer graph.lock.Unlock()
+
+	node := graphNode{name: resourceName, resource: resource, dependencies: dependencyNames}
+	if cycle := graph.findCycle(node); cycle != nil {
+		return &DependencyCycleError{Cycle: cycle}
+	}
+
+	graph.nodes = append(graph.nodes, node)
+	return nil
+}
+
+// findCycle returns the path of a dependency cycle from the given new node back to itself, or nil
+// if adding the node makes no cycle.
+func (graph *Graph) findCycle(newNode graphNode) []string {
+	visited := make(map[string]bool)
+
+	var visit func(name string, path []string) []string
+	visit = func(name string, path []string) []string {
+		path = append(path, name)
+		if name == newNode.name && len(path) > 1 {
+			return path
+		}
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+
+		dependencies := newNode.dependencies
+		if len(path) > 1 {
+			dependencies = graph.dependenciesOf(name)
+		}
+		for _, dependency := range dependencies {
+			if cycle := visit(dependency, slices.Clip(path)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	return visit(newNode.name, nil)
+}
+
+func (graph *Graph) dependenciesOf(resourceName string) []string {
+	var dependencies []string
+	for _, node := range graph.nodes {
+		if node.name == resourceName {
+			dependencies = append(dependencies, node.dependencies...)
+		}
+	}
+	return dependencies
+}
+
+// CloseAll closes all resources in the graph, and empties it. A resource is closed only after all
+// resources that depend on it have been closed. Among resources that are ready to close, the most
+// recently added one is closed first, so resources without dependencies are closed in the reverse
+// order of how they were added, like in [errclose.Frame]. Dependencies on resources that were never
+// added are ignored.
+//
+// All resources are closed, even if some of them fail. Close errors are wrapped with the name of
+// each resource, and combined with the error pointed to by returnedErr in the same way as in
+// [errclose.Close]:
+//
+//	<existing error> (and failed to close <resourceName>: <close error>)
+func (graph *Graph) CloseAll(returnedErr *error) {
+	graph.lock.Lock()
+	nodes := graph.nodes
+	graph.nodes = nil
+	graph.lock.Unlock()
+
+	for _, i := range closeOrder(nodes) {
+		Close(nodes[i].resource, returnedErr, nodes[i].name)
+	}
+}
+
+// Plan returns the names of the resources in the graph, in the order that [Graph.CloseAll] would
+// close them, without closing anything. This is for checking the teardown order in tests, or
+// printing it in a dry run. For the graph in the [errclose.Graph] example:
+//
+//	fmt.Println(strings.Join(shutdown.Plan(), " -> "))
+//	// job queue -> HTTP server -> database
+func (graph *Graph) Plan() []string {
+	graph.lock.Lock()
+	defer graph.lock.Unlock()
+
+	names := make([]string, 0, len(graph.nodes))
+	for _, i := range closeOrder(graph.nodes) {
+		names = append(names, graph.nodes[i].name)
+	}
+	return names
+}
+
+// closeOrder returns the indices of the given nodes in the order that they should be closed.
+func closeOrder(nodes []graphNode) []int {
+	// Number of unclosed resources that depend on each resource name
+	dependents := make(map[string]int)
+	for _, node := range nodes {
+		for _, dependency := range node.dependencies {
+			dependents[dependency]++
+		}
+	}
+
+	order := make([]int, 0, len(nodes))
+	closed := make([]bool, len(nodes))
+	for range nodes {
+		next := -1
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if !closed[i] && dependents[nodes[i].name] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			// Can't happen, since Add rejects cycles, but close the rest instead of leaking them
+			next = slices.Index(closed, false)
+		}
+
+		closed[next] = true
+		for _, dependency := range nodes[next].dependencies {
+			dependents[dependency]--
+		}
+		order = append(order, next)
+	}
+	return order
+}
+
+// Err closes all resources in the graph, like [Graph.CloseAll], and returns the combined close
+// errors (or nil if all closes succeeded).
+func (graph *Graph) Err() (returnedErr error) {
+	graph.CloseAll(&returnedErr)
+	return returnedErr
+}
+
+// DependencyCycleError is returned by [Graph.Add] when a resource's dependencies would make a
+// cycle. Its error message shows the cycle:
+//
+//	dependency cycle between resources: <name> -> <dependency> -> ... -> <name>
+type DependencyCycleError struct {
+	// Cycle is the resource names in the cycle, starting and ending with the added resource.
+	Cycle []string
+}
+
+func (err *DependencyCycleError) Error() string {
+	return "dependency cycle between resources: " + strings.Join(err.Cycle, " -> ")
+}
@@ -0,0 +1,89 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestGraph(t *testing.T) {
+	var closeOrder []string
+	resource := func(name string, closeErr error) closerFunc {
+		return func() error {
+			closeOrder = append(closeOrder, name)
+			return closeErr
+		}
+	}
+
+	errServerBusy := errors.New("server busy")
+
+	var graph errclose.Graph
+	addErr := errors.Join(
+		graph.Add("database", resource("database", nil)),
+		graph.Add("server", resource("server", errServerBusy), errclose.After("database")),
+		graph.Add("cache", resource("cache", nil), errclose.After("server")),
+		graph.Add("logger", resource("logger", nil)),
+		graph.Add("queue", resource("queue", nil), errclose.After("database", "logger")),
+	)
+	assertEqual(t, addErr, nil, "add error")
+
+	assertEqual(
+		t,
+		graph.Plan(),
+		[]string{"queue", "logger", "cache", "server", "database"},
+		"plan",
+	)
+	assertEqual(t, closeOrder, []string(nil), "close order after Plan")
+
+	err := graph.Err()
+	assertEqual(
+		t,
+		closeOrder,
+		[]string{"queue", "logger", "cache", "server", "database"},
+		"close order",
+	)
+	assertEqual(t, err.Error(), "failed to close server: server busy", "error string")
+}
+
+func TestGraphCycle(t *testing.T) {
+	var graph errclose.Graph
+	addErr := errors.Join(
+		graph.Add("server", closerFunc(nil), errclose.After("database")),
+		graph.Add("queue", closerFunc(nil), errclose.After("server")),
+	)
+	assertEqual(t, addErr, nil, "add error")
+
+	err := graph.Add("database", closerFunc(nil), errclose.After("queue"))
+	assertEqual(
+		t,
+		err.Error(),
+		"dependency cycle between resources: database -> queue -> server -> database",
+		"error string",
+	)
+
+	var cycleErr *errclose.DependencyCycleError
+	assertEqual(t, errors.As(err, &cycleErr), true, "errors.As result")
+
+	err = graph.Add("self", closerFunc(nil), errclose.After("self"))
+	assertEqual(
+		t,
+		err.Error(),
+		"dependency cycle between resources: self -> self",
+		"error string for self-dependency",
+	)
+}
+
+func TestGraphCombinesWithExistingError(t *testing.T) {
+	var graph errclose.Graph
+	assertEqual(t, graph.Add("file", openFileWithCloseError()), nil, "add error")
+
+	err := fallibleOperation()
+	graph.CloseAll(&err)
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+}
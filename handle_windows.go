@@ -0,0 +1,42 @@
+package errclose
+
+import (
+	"syscall"
+)
+
+// Handle is a raw Windows handle, such as one returned by [syscall.CreateFile]. Converting a handle
+// to Handle lets you close it with [errclose.Close] and the other functions in this package, so it
+// gets the same error handling as other resources:
+//
+//	handle, err := syscall.CreateFile(
+//		path, syscall.GENERIC_READ, 0, nil, syscall.OPEN_EXISTING, 0, 0,
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer errclose.Close(errclose.Handle(handle), &returnedErr, "file handle")
+//
+// If closing the handle fails with a well-known error code, a hint about what the error code
+// usually means is added to the error message. The error still unwraps to the [syscall.Errno], so
+// it can be checked with [errors.Is].
+type Handle syscall.Handle
+
+// Close closes the handle with [syscall.CloseHandle].
+func (handle Handle) Close() error {
+	if err := syscall.CloseHandle(syscall.Handle(handle)); err != nil {
+		return withErrnoHint(err)
+	}
+	return nil
+}
+
+// errorInvalidHandle is ERROR_INVALID_HANDLE, which is not defined in the syscall package.
+const errorInvalidHandle syscall.Errno = 6
+
+func closeErrnoHint(errno syscall.Errno) (hint string, ok bool) {
+	switch errno {
+	case errorInvalidHandle:
+		return "handle may already have been closed", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,124 @@
+//go:build integration
+
+// Integration tests that exercise close errors from real OS resources, rather than mocks. Run them
+// with:
+//
+//	go test -tags integration ./...
+//
+// Since close semantics differ between platforms, you can also run these against the package in
+// your own environment, with `go test -tags integration hermannm.dev/errclose`.
+
+package errclose_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestIntegrationFileClosedTwice(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.Close(file, &returnedErr, "file")
+	assertEqual(t, errors.Is(returnedErr, os.ErrClosed), true, "errors.Is(os.ErrClosed)")
+	assertEqual(
+		t,
+		strings.HasPrefix(returnedErr.Error(), "failed to close file: "),
+		true,
+		"error prefix",
+	)
+}
+
+func TestIntegrationReadOnlyFileOpenedTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file1, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file2, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.Close(file1, &returnedErr, "file 1")
+	errclose.Close(file2, &returnedErr, "file 2")
+	assertEqual(t, returnedErr, nil, "error")
+}
+
+func TestIntegrationConnClosedTwice(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			_ = conn.Close()
+		}
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.Close(conn, &returnedErr, "connection")
+	assertEqual(t, errors.Is(returnedErr, net.ErrClosed), true, "errors.Is(net.ErrClosed)")
+	assertEqual(
+		t,
+		errors.Is(errclose.StandardCloseErrors(returnedErr), errclose.ErrAlreadyClosed),
+		true,
+		"StandardCloseErrors maps to ErrAlreadyClosed",
+	)
+}
+
+func TestIntegrationListenerClosedTwice(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.Close(listener, &returnedErr, "listener")
+	assertEqual(t, errors.Is(returnedErr, net.ErrClosed), true, "errors.Is(net.ErrClosed)")
+}
+
+func TestIntegrationPipeClosedTwice(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.Close(reader, &returnedErr, "pipe reader")
+	errclose.Close(writer, &returnedErr, "pipe writer")
+	assertEqual(t, returnedErr, nil, "error from first close")
+
+	errclose.Close(writer, &returnedErr, "pipe writer")
+	assertEqual(t, errors.Is(returnedErr, os.ErrClosed), true, "errors.Is(os.ErrClosed)")
+}
@@ -0,0 +1,67 @@
+package errclose
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// ShutdownJournal returns an event handler for [ShutdownManager.OnEvent] that appends a record to
+// the given writer for every shutdown event. If the process is killed in the middle of a graceful
+// shutdown (e.g. with SIGKILL when a container's grace period runs out), the journal shows how far
+// the shutdown got, and which resource it was stuck on:
+//
+//	journal, err := os.OpenFile(
+//		"/var/log/app/shutdown.log",
+//		os.O_WRONLY|os.O_CREATE|os.O_APPEND,
+//		0o644,
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	shutdown.OnEvent(errclose.ShutdownJournal(journal))
+//
+// Each record is written with a single call to Write as soon as the event happens, so nothing is
+// buffered in the process. Writes to an [os.File] survive the process being killed, though not a
+// crash of the whole machine.
+//
+// Records are written in the [logfmt] format, one record per line, in the same style as the event
+// log (see [errclose.SetEventLog]):
+//
+//	time=2025-08-30T12:00:00.000000000Z event=phase_started phase=1
+//	time=2025-08-30T12:00:00.000000000Z event=resource_closing phase=1 resource="database"
+//	time=2025-08-30T12:00:00.000000000Z event=resource_closed phase=1 resource="database"
+//	time=2025-08-30T12:00:00.000000000Z event=done phase=0
+//
+// The event field is the [errclose.ShutdownEventKind] of the event. The resource field is only
+// included for resource events, and the error field (quoted like the resource field) is only
+// included when the event has an error, for resource_failed and done events.
+// Errors from writing to the given writer are ignored. The returned handler is safe for concurrent
+// use, and records are never interleaved in the writer.
+//
+// [logfmt]: https://brandur.org/logfmt
+func ShutdownJournal(writer io.Writer) func(event ShutdownEvent) {
+	var lock sync.Mutex
+	return func(event ShutdownEvent) {
+		record := make([]byte, 0, 128)
+		record = append(record, "time="...)
+		record = event.Time.UTC().AppendFormat(record, eventTimeFormat)
+		record = append(record, " event="...)
+		record = append(record, event.Kind.String()...)
+		record = append(record, " phase="...)
+		record = strconv.AppendInt(record, int64(event.Phase), 10)
+		if event.ResourceName != "" {
+			record = append(record, " resource="...)
+			record = strconv.AppendQuote(record, event.ResourceName)
+		}
+		if event.Err != nil {
+			record = append(record, " error="...)
+			record = strconv.AppendQuote(record, event.Err.Error())
+		}
+		record = append(record, '\n')
+
+		lock.Lock()
+		defer lock.Unlock()
+		_, _ = writer.Write(record)
+	}
+}
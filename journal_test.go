@@ -0,0 +1,46 @@
+package errclose_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestShutdownJournal(t *testing.T) {
+	var manager errclose.ShutdownManager
+	var journal strings.Builder
+	manager.OnEvent(errclose.ShutdownJournal(&journal))
+	manager.DeferPhase(openFileWithCloseError(), "database", 1)
+	manager.DeferPhase(openFileWithoutCloseError(), "server", -1)
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err.Error(), "failed to close database: close error", "error string")
+
+	timePattern := regexp.MustCompile(`^time=\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{9}Z `)
+	lines := strings.Split(strings.TrimSuffix(journal.String(), "\n"), "\n")
+	for i, line := range lines {
+		if !timePattern.MatchString(line) {
+			t.Fatalf("unexpected time format in journal line %q", line)
+		}
+		lines[i] = timePattern.ReplaceAllString(line, "")
+	}
+
+	assertEqual(
+		t,
+		lines,
+		[]string{
+			`event=phase_started phase=-1`,
+			`event=resource_closing phase=-1 resource="server"`,
+			`event=resource_closed phase=-1 resource="server"`,
+			`event=phase_started phase=1`,
+			`event=resource_closing phase=1 resource="database"`,
+			`event=resource_failed phase=1 resource="database" ` +
+				`error="failed to close database: close error"`,
+			`event=done phase=0 error="failed to close database: close error"`,
+		},
+		"journal lines",
+	)
+}
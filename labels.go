@@ -0,0 +1,24 @@
+package errclose
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Profiler label keys set on goroutines where this package closes resources (see withCloseLabels).
+const (
+	labelResource = "errclose.resource"
+	labelPhase    = "errclose.phase"
+)
+
+// withCloseLabels runs the given function with [pprof] labels for the resource name and teardown
+// phase. This is used in goroutines that the package starts to close resources, so that profiles
+// taken during shutdown attribute work in those goroutines to the resources being closed, instead
+// of showing anonymous goroutines.
+func withCloseLabels(resourceName string, phase string, function func()) {
+	pprof.Do(
+		context.Background(),
+		pprof.Labels(labelResource, resourceName, labelPhase, phase),
+		func(context.Context) { function() },
+	)
+}
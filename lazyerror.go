@@ -0,0 +1,42 @@
+package errclose
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// lazyError is an error that wraps another error with a message on the same format as
+// [fmt.Errorf], but only formats the message when Error is first called, and caches it after that.
+// This package creates errors on failure paths where callers often only check them with
+// [errors.Is] and [errors.As], so formatting them eagerly would be wasted work.
+type lazyError struct {
+	format  string
+	args    []any
+	wrapped error
+	message atomic.Pointer[string]
+}
+
+// lazyErrorf returns an error with the message fmt.Errorf(format, args...), which unwraps to the
+// given wrapped error. The format should wrap that error with %w.
+func lazyErrorf(wrapped error, format string, args ...any) *lazyError {
+	return &lazyError{
+		format:  format,
+		args:    args,
+		wrapped: wrapped,
+		message: atomic.Pointer[string]{},
+	}
+}
+
+func (err *lazyError) Error() string {
+	if message := err.message.Load(); message != nil {
+		return *message
+	}
+
+	message := fmt.Errorf(err.format, err.args...).Error()
+	err.message.Store(&message)
+	return message
+}
+
+func (err *lazyError) Unwrap() error {
+	return err.wrapped
+}
@@ -0,0 +1,150 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// CloseStringer closes the given resource, and handles close errors in the same way as
+// [errclose.Close], but takes the resource name as a [fmt.Stringer]. The String method is only
+// called if there is a close error, so this is cheaper than calling String yourself when the name
+// is expensive to build, such as the remote address of a connection:
+//
+//	defer errclose.CloseStringer(conn, &returnedErr, conn.RemoteAddr())
+//
+// If name is nil, the resource name is empty (so the name from an [errclose.NamedCloser] is used,
+// if the resource implements it). As with [errclose.Closef], the name is built when closing if
+// debug events are enabled (see [errclose.SetDebugEvents]).
+func CloseStringer(
+	resource interface{ Close() error },
+	returnedErr *error,
+	name fmt.Stringer,
+	options ...Option,
+) {
+	closeWithLazyName(
+		resource,
+		returnedErr,
+		lazyResourceName{scope: nil, name: "", stringer: name},
+		nil,
+		options,
+		1,
+	)
+}
+
+// CloseNameFunc closes the given resource, and handles close errors in the same way as
+// [errclose.Close], but takes a function that returns the resource name. The function is only
+// called if there is a close error, like the String method in [errclose.CloseStringer]:
+//
+//	defer errclose.CloseNameFunc(conn, &returnedErr, func() string {
+//		return "connection to " + conn.RemoteAddr().String()
+//	})
+//
+// Note that function literals that capture variables and method values (like conn.String) may
+// allocate at the call site, so for hot paths, prefer [errclose.CloseStringer] or a function
+// without captures. If name is nil, the resource name is empty.
+func CloseNameFunc(
+	resource interface{ Close() error },
+	returnedErr *error,
+	name func() string,
+	options ...Option,
+) {
+	lazyName := lazyResourceName{scope: nil, name: "", stringer: nil}
+	if name != nil {
+		lazyName.stringer = nameFunc(name)
+	}
+	closeWithLazyName(resource, returnedErr, lazyName, nil, options, 1)
+}
+
+type nameFunc func() string
+
+func (name nameFunc) String() string {
+	return name()
+}
+
+// lazyResourceName is a resource name that is only built if it's needed, from an optional scope
+// (see [errclose.Scope]), and either a plain name or a [fmt.Stringer]. It's passed by value, so
+// that building it doesn't allocate.
+type lazyResourceName struct {
+	scope    *NameScope
+	name     string
+	stringer fmt.Stringer
+}
+
+// resolve builds the name, using the name from the resource if it implements
+// [errclose.NamedCloser] and the name is empty (see resolveResourceName). If formatName is not
+// nil, it's called to build the plain name, as for [errclose.Closef].
+func (name lazyResourceName) resolve(
+	resource interface{ Close() error },
+	formatName func() string,
+) string {
+	resourceName := name.name
+	if name.stringer != nil {
+		resourceName = name.stringer.String()
+	} else if formatName != nil {
+		resourceName = formatName()
+	}
+	resourceName = resolveResourceName(resource, resourceName)
+
+	if name.scope != nil {
+		return name.scope.qualify(resourceName)
+	}
+	return resourceName
+}
+
+// closeWithLazyName implements the close functions that only build the resource name if it's
+// needed, and then only once. formatName is an optional function that formats the plain name (see
+// lazyResourceName.resolve). It's not part of lazyResourceName, since storing a function literal in
+// the name would make it escape to the heap. callerSkip is the number of stack frames between this
+// function and the caller of the errclose function.
+func closeWithLazyName(
+	resource interface{ Close() error },
+	returnedErr *error,
+	name lazyResourceName,
+	formatName func() string,
+	options []Option,
+	callerSkip int,
+) {
+	resolvedName := ""
+	isResolved := false
+	resolveName := func(resource interface{ Close() error }) string {
+		if !isResolved {
+			resolvedName = name.resolve(resource, formatName)
+			isResolved = true
+		}
+		return resolvedName
+	}
+
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resolveName(nil))
+		return
+	}
+	lists := withDefaults(resource, options)
+	if metricsEnabled() {
+		recordCloseAttempt(resolveName(resource))
+	}
+	if closeEventsEnabled() {
+		closeWithDebugEvent(resource, returnedErr, resolveName(resource), lists, callerSkip+1)
+		return
+	}
+
+	// The name is only needed by closeWithOptions for OnSlowClose and WithDebugLog, so avoid
+	// resolving it otherwise
+	closeName := ""
+	if needsCloseName(lists) {
+		closeName = resolveName(resource)
+	}
+
+	stats := captureStats(lists)
+	closeErr := closeWithOptions(resource, closeName, lists)
+	if closeErr == nil {
+		return
+	}
+
+	handleCloseErrorWithOptions(
+		returnedErr,
+		closeErr,
+		resolveName(resource),
+		lists,
+		stats,
+		callerSkip+1,
+	)
+}
@@ -0,0 +1,74 @@
+package errclose_test
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseStringer(t *testing.T) {
+	addr := &stringerCounter{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}}
+
+	var err error
+	errclose.CloseStringer(openFileWithoutCloseError(), &err, addr)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, addr.calls, 0, "String calls without close error")
+
+	errclose.CloseStringer(openFileWithCloseError(), &err, addr)
+	assertEqual(t, err.Error(), "failed to close 127.0.0.1:8000: close error", "error string")
+	assertEqual(t, addr.calls, 1, "String calls with close error")
+}
+
+func TestCloseNameFunc(t *testing.T) {
+	calls := 0
+	name := func() string {
+		calls++
+		return "connection"
+	}
+
+	err := errors.New("existing error")
+	errclose.CloseNameFunc(openFileWithoutCloseError(), &err, name)
+	assertEqual(t, calls, 0, "name calls without close error")
+
+	errclose.CloseNameFunc(openFileWithCloseError(), &err, name)
+	assertEqual(
+		t,
+		err.Error(),
+		"existing error (and failed to close connection: close error)",
+		"error string",
+	)
+	assertEqual(t, calls, 1, "name calls with close error")
+}
+
+type stringerCounter struct {
+	net.Addr
+	calls int
+}
+
+func (stringer *stringerCounter) String() string {
+	stringer.calls++
+	return stringer.Addr.String()
+}
+
+func TestClosefFormatsNameOnceWithMetrics(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	metrics := &countingMetrics{lock: sync.Mutex{}, attempts: nil, failures: nil}
+	errclose.SetMetrics(metrics)
+
+	addr := &stringerCounter{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8000}}
+
+	var err error
+	errclose.Closef(openFileWithCloseError(), &err, "connection to %v", addr)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close connection to 127.0.0.1:8000: close error",
+		"error string",
+	)
+	assertEqual(t, addr.calls, 1, "String calls")
+	assertEqual(t, metrics.attempts["connection to 127.0.0.1:8000"], 1, "attempts")
+	assertEqual(t, metrics.failures["connection to 127.0.0.1:8000"], 1, "failures")
+}
@@ -0,0 +1,133 @@
+package errclose
+
+import (
+	"runtime"
+	"sync"
+)
+
+// LeakGuard holds a resource, and closes it if the guard is garbage-collected before it was closed.
+// Create one with [errclose.CloseOrCleanup].
+type LeakGuard[T interface{ Close() error }] struct {
+	resource   T
+	resourceID ResourceID
+	once       sync.Once
+	cleanup    runtime.Cleanup
+}
+
+// CloseOrCleanup returns a [errclose.LeakGuard] for the given resource, which you should close
+// explicitly as usual (for example with [errclose.Close]). As a safety net, if the guard becomes
+// unreachable without having been closed, the Go runtime closes the resource when the guard is
+// garbage-collected, and the package reports the leak to the event log (see
+// [errclose.SetEventLog]) with the following event:
+//
+//	event=resource_leaked resource=<resourceName> resource_id=<ID>
+//
+// If closing the leaked resource fails, the close error is included in the event's error field.
+// The guard is given a new [errclose.ResourceID], which you can log when acquiring the resource
+// (see [LeakGuard.ResourceID]), to correlate the leak with where the resource was acquired.
+//
+// This is meant for resources that occasionally escape their owners, such as connections handed
+// between goroutines. Since the cleanup is tied to the guard, you must keep the guard reachable
+// for as long as you use the resource, and access the resource through [LeakGuard.Get]:
+//
+//	func openConn(addr string) (*errclose.LeakGuard[net.Conn], error) {
+//		conn, err := net.Dial("tcp", addr)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return errclose.CloseOrCleanup(conn, "connection to "+addr), nil
+//	}
+//
+//	func useConn(addr string) (returnedErr error) {
+//		conn, err := openConn(addr)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.Close(conn, &returnedErr, "connection")
+//
+//		// Use conn.Get()
+//	}
+//
+// The runtime gives no guarantee about when (or whether) cleanups run, so this does not replace
+// closing resources explicitly. See [runtime.AddCleanup] for details.
+//
+// The cleanup closes the resource with [pprof] labels errclose.resource (the resource name) and
+// errclose.phase ("leak cleanup"), so leaked resources show up by name in profiles.
+func CloseOrCleanup[T interface{ Close() error }](
+	resource T,
+	resourceName string,
+) *LeakGuard[T] {
+	guard := &LeakGuard[T]{
+		resource:   resource,
+		resourceID: NewResourceID(),
+		once:       sync.Once{},
+		cleanup:    runtime.Cleanup{},
+	}
+	guard.cleanup = runtime.AddCleanup(
+		guard,
+		closeLeakedResource[T],
+		leakedResource[T]{
+			resource:     resource,
+			resourceName: resourceName,
+			resourceID:   guard.resourceID,
+		},
+	)
+	return guard
+}
+
+// Get returns the guarded resource. You must keep the guard reachable while you use the resource
+// (see [errclose.CloseOrCleanup]).
+func (guard *LeakGuard[T]) Get() T {
+	return guard.resource
+}
+
+// ResourceID returns the ID of the guard, which is included in the resource_leaked event if the
+// resource leaks. To include it in close errors as well, close the guard with the
+// [errclose.WithResourceID] option:
+//
+//	defer errclose.Close(
+//		conn,
+//		&returnedErr,
+//		"connection",
+//		errclose.WithResourceID(conn.ResourceID()),
+//	)
+func (guard *LeakGuard[T]) ResourceID() ResourceID {
+	return guard.resourceID
+}
+
+// Close closes the guarded resource, and returns its close error as-is, so you can pass the guard
+// to [errclose.Close]. The runtime cleanup is canceled, so the resource won't be closed again when
+// the guard is garbage-collected. Calling Close more than once only closes the resource once (later
+// calls return nil).
+func (guard *LeakGuard[T]) Close() error {
+	var closeErr error
+	guard.once.Do(func() {
+		guard.cleanup.Stop()
+		closeErr = guard.resource.Close()
+	})
+	return closeErr
+}
+
+type leakedResource[T interface{ Close() error }] struct {
+	resource     T
+	resourceName string
+	resourceID   ResourceID
+}
+
+func closeLeakedResource[T interface{ Close() error }](leaked leakedResource[T]) {
+	withCloseLabels(leaked.resourceName, "leak cleanup", func() {
+		recordCloseAttempt(leaked.resourceName)
+		closeErr := leaked.resource.Close()
+		logEventWithDetails(
+			eventResourceLeaked,
+			leaked.resourceName,
+			closeErr,
+			"",
+			leaked.resourceID,
+		)
+		if closeErr != nil {
+			observe(leaked.resourceName, withHookDetails(closeErr, "", leaked.resourceID, nil))
+			recordCloseFailure(leaked.resourceName, closeErr)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package errclose_test
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseOrCleanup(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	useFile := func() (returnedErr error) {
+		guard := errclose.CloseOrCleanup(file, "file")
+		defer errclose.Close(guard, &returnedErr, "file")
+
+		assertEqual(t, guard.Get(), file, "guarded resource")
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+}
+
+func TestCloseOrCleanupWithLeakedResource(t *testing.T) {
+	var eventLog syncBuffer
+	errclose.SetEventLog(&eventLog)
+	defer errclose.SetEventLog(nil)
+
+	closed := make(chan struct{})
+	var resourceID errclose.ResourceID
+	leakResource := func() {
+		guard := errclose.CloseOrCleanup(
+			closerFunc(func() error {
+				close(closed)
+				return nil
+			}),
+			"leaked file",
+		)
+		resourceID = guard.ResourceID()
+	}
+	leakResource()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-closed:
+			// Wait for the event to be logged after the close
+			for !strings.Contains(eventLog.String(), "event=resource_leaked") {
+				time.Sleep(time.Millisecond)
+			}
+			assertEqual(
+				t,
+				strings.HasSuffix(
+					eventLog.String(),
+					` resource="leaked file" resource_id=`+resourceID.String()+"\n",
+				),
+				true,
+				"event log contains leaked resource",
+			)
+			return
+		case <-deadline:
+			t.Fatal("Leaked resource was not closed by runtime cleanup")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(data []byte) (int, error) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.buffer.Write(data)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.buffer.String()
+}
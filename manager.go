@@ -0,0 +1,381 @@
+package errclose
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ShutdownManager closes registered resources when the application shuts down. Long-lived
+// components register themselves wherever they're created, with a name for the close error and a
+// shutdown phase, and the application calls [ShutdownManager.Shutdown] when it's time to stop:
+//
+//	var shutdown errclose.ShutdownManager
+//
+//	db, err := sql.Open("postgres", dsn)
+//	if err != nil {
+//		return err
+//	}
+//	shutdown.DeferPhase(db, "database", phaseDatabases)
+//
+//	server := &http.Server{Addr: ":8000", Handler: handler}
+//	shutdown.DeferPhase(errclose.Shutdowner(server), "HTTP server", phaseServers)
+//
+//	// Run the application
+//
+//	return shutdown.Shutdown(ctx)
+//
+// The zero value is ready to use. A ShutdownManager is safe for concurrent use. The package has a
+// default manager, which [errclose.DeferGlobal] and [errclose.ShutdownGlobal] use (see
+// [errclose.GlobalShutdownManager]). Separate managers are independent of it and of each other, so
+// for example tests can shut down their own manager without touching the global one.
+type ShutdownManager struct {
+	lock      sync.Mutex
+	resources []managedResource
+	// The resources that a running call to Shutdown has taken out of resources, in reverse closing
+	// order. Kept in the manager so that Abort can close them.
+	pending []managedResource
+	// The cancel functions of the contexts of running calls to Shutdown, which Abort calls to
+	// interrupt them.
+	cancelShutdowns map[*context.CancelCauseFunc]struct{}
+	eventHandlers   shutdownEventHandlers
+	deadlineDump    io.Writer
+	phaseConfigs    map[int]PhaseConfig
+	// Set when a call to Shutdown has closed all registered resources, or Abort has been called.
+	shutDown bool
+}
+
+type managedResource struct {
+	namedResource
+	phase    int
+	severity Severity
+	id       ResourceID
+}
+
+func newManagedResource(
+	resource interface{ Close() error },
+	resourceName string,
+	phase int,
+	severity Severity,
+	options []Option,
+) managedResource {
+	id, options := registeredResourceID(options)
+	return managedResource{
+		namedResource: namedResource{
+			resource:     resource,
+			resourceName: resourceName,
+			options:      options,
+		},
+		phase:    phase,
+		severity: severity,
+		id:       id,
+	}
+}
+
+// Defer registers the given resource to be closed when [ShutdownManager.Shutdown] is called, in
+// shutdown phase 0. The resource name is used to format the close error, as in [errclose.Close].
+//
+// If Defer is called after Shutdown has completed, the resource is handled according to the policy
+// set by [errclose.SetLateRegistrationPolicy].
+//
+// Each registered resource is given a new [errclose.ResourceID], which is included in its close
+// errors, in [errclose.ShutdownEvent] and in [ShutdownManager.Plan]. To use an ID that you've
+// already logged when acquiring the resource, register it with [ShutdownManager.DeferWith] and the
+// [errclose.WithResourceID] option.
+func (manager *ShutdownManager) Defer(resource interface{ Close() error }, resourceName string) {
+	manager.DeferPhase(resource, resourceName, 0)
+}
+
+// DeferPhase registers the given resource to be closed when [ShutdownManager.Shutdown] is called,
+// like [ShutdownManager.Defer], but in the given shutdown phase. Shutdown closes resources phase by
+// phase, starting with the lowest phase. Within a phase, resources are closed in the reverse order
+// of how they were registered, unless the phase is configured to close them in parallel (see
+// [ShutdownManager.SetPhaseConfig]).
+//
+// This lets components register themselves for shutdown wherever they're created, while still
+// shutting down in the right order. For example, servers should stop accepting requests before the
+// databases that their handlers use are closed:
+//
+//	const (
+//		phaseServers   = -1
+//		phaseDatabases = 1
+//	)
+//
+//	manager.DeferPhase(db, "database", phaseDatabases)
+//	manager.DeferPhase(server, "HTTP server", phaseServers)
+func (manager *ShutdownManager) DeferPhase(
+	resource interface{ Close() error },
+	resourceName string,
+	phase int,
+) {
+	manager.DeferSeverity(resource, resourceName, phase, SeverityNormal)
+}
+
+// Shutdown closes all resources registered with the manager, phase by phase (starting with the
+// lowest phase), and in the reverse order of how they were registered within each phase. It
+// returns the combined close errors (or nil if all closes succeeded). Close errors are formatted
+// and combined in the same way as [Frame.Err]. This is the graceful way to shut down: to close the
+// remaining resources right away instead, call [ShutdownManager.Abort].
+//
+// Closed resources are removed from the manager, so Shutdown is safe to call multiple times: later
+// calls only close resources registered after the previous call. Resources that are registered
+// while Shutdown is running are also closed before it returns.
+//
+// If the given context is canceled before all resources have been closed, Shutdown stops closing
+// resources, and appends the context error to the returned error on the following format:
+//
+//	shutdown interrupted with <number> resources left: <context error>
+//
+// If the context was canceled because its deadline was exceeded, the returned error also matches
+// [errclose.ErrShutdownDeadlineExceeded] with [errors.Is]. The resources that were not closed are
+// kept in the manager, so a later call to Shutdown can close them.
+//
+// Resources that implement [errclose.CloseContextSetter] are given the context before they are
+// closed, and resources that implement [errclose.ClosePreparer] are prepared for closing with the
+// context. Resources that implement [errclose.Drainable] are closed with
+// [errclose.CloseDrainable], using the given context for the drain stage.
+//
+// Close failures of resources registered with [ShutdownManager.DeferSeverity] are handled
+// according to their severity (see [errclose.Severity]). If a critical resource fails to close,
+// Shutdown finishes its phase, and then closes the resources in the remaining phases right away,
+// like [ShutdownManager.Abort]. It then appends the following error (matching
+// [errclose.ErrShutdownAborted] with [errors.Is]):
+//
+//	critical close failure: shutdown aborted
+func (manager *ShutdownManager) Shutdown(ctx context.Context) (returnedErr error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	manager.lock.Lock()
+	if manager.cancelShutdowns == nil {
+		manager.cancelShutdowns = make(map[*context.CancelCauseFunc]struct{})
+	}
+	manager.cancelShutdowns[&cancel] = struct{}{}
+	manager.lock.Unlock()
+	defer func() {
+		manager.lock.Lock()
+		delete(manager.cancelShutdowns, &cancel)
+		manager.lock.Unlock()
+		cancel(nil)
+	}()
+
+	handlers := manager.getEventHandlers()
+	defer func() {
+		handlers.emit(ShutdownEventDone, 0, "", 0, returnedErr)
+	}()
+
+	var closing atomic.Pointer[[]managedResource]
+	stopWatchdog := manager.startDeadlineWatchdog(ctx, &closing)
+	defer stopWatchdog()
+
+	var phase shutdownPhase
+	defer func() {
+		phase.end()
+	}()
+	// Set when a critical resource fails to close, to abort the phases after the current one
+	criticalFailed := false
+
+	for {
+		if errors.Is(context.Cause(ctx), ErrShutdownAborted) {
+			// Abort has taken the remaining resources, and closes them itself
+			abortErr := fmt.Errorf("shutdown interrupted: %w", ErrShutdownAborted)
+			return combineErrors(returnedErr, abortErr)
+		}
+
+		resource, remaining, ok := manager.nextResource()
+		if !ok {
+			return returnedErr
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// Put the resource back, so a later call to Shutdown (or Abort) can close it
+			manager.lock.Lock()
+			manager.pending = append(manager.pending, resource)
+			manager.lock.Unlock()
+
+			interruptErr := withDeadlineSentinel(
+				fmt.Errorf("shutdown interrupted with %d resources left: %w", remaining+1, ctxErr),
+				ctxErr,
+				ErrShutdownDeadlineExceeded,
+			)
+			return combineErrors(returnedErr, interruptErr)
+		}
+
+		if criticalFailed && resource.phase != phase.number {
+			// Put the resource back, so that abort closes it along with the rest
+			manager.lock.Lock()
+			manager.pending = append(manager.pending, resource)
+			manager.lock.Unlock()
+
+			abortErr := manager.abort(handlers)
+			return combineErrors(combineErrors(returnedErr, abortErr), errCriticalCloseFailure)
+		}
+
+		if !phase.started || resource.phase != phase.number {
+			phase.end()
+			phase = manager.startPhase(ctx, resource.phase)
+			handlers.emit(ShutdownEventPhaseStarted, phase.number, "", 0, nil)
+		}
+
+		batch := []managedResource{resource}
+		if phase.config.Parallel {
+			batch = append(batch, manager.takePhase(phase.number)...)
+		}
+
+		closing.Store(&batch)
+		closeErr, critical := closeBatch(phase, batch, handlers)
+		closing.Store(nil)
+		if critical {
+			criticalFailed = true
+		}
+		if closeErr != nil {
+			combineIntoReturnedErr(&returnedErr, closeErr, resource.resourceName)
+		}
+	}
+}
+
+// nextResource removes the next resource to close from the manager, and returns it along with the
+// number of resources left after it. When the pending resources run out, it takes the resources
+// that have been registered since, and sorts them in closing order. If there are no resources
+// left, it marks the manager as shut down and returns false.
+func (manager *ShutdownManager) nextResource() (
+	resource managedResource,
+	remaining int,
+	ok bool,
+) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if len(manager.pending) == 0 {
+		if len(manager.resources) == 0 {
+			// Resources registered while we were closing have also been closed, so we're done
+			manager.shutDown = true
+			return resource, 0, false
+		}
+
+		manager.pending = manager.resources
+		manager.resources = nil
+		// Sort by descending phase, keeping the registration order within each phase, so that
+		// taking resources from the end closes the lowest phase first
+		slices.SortStableFunc(manager.pending, func(a managedResource, b managedResource) int {
+			return cmp.Compare(b.phase, a.phase)
+		})
+	}
+
+	last := len(manager.pending) - 1
+	resource = manager.pending[last]
+	manager.pending = manager.pending[:last]
+	return resource, len(manager.pending) + len(manager.resources), true
+}
+
+// ShutdownStep is a step in the plan returned by [ShutdownManager.Plan].
+type ShutdownStep struct {
+	Phase        int
+	ResourceName string
+	Severity     Severity
+	// The ID that the resource was registered with (see [errclose.ResourceID]).
+	ResourceID ResourceID
+}
+
+// Plan returns the resources registered with the manager, in the order that
+// [ShutdownManager.Shutdown] would close them, without closing anything. This is for checking the
+// shutdown order in tests, or printing it in a dry run:
+//
+//	if *dryRun {
+//		for _, step := range shutdown.Plan() {
+//			fmt.Printf("phase %d: close %s\n", step.Phase, step.ResourceName)
+//		}
+//		return nil
+//	}
+//
+// If Shutdown is running, the plan only includes the resources that it has yet to close.
+func (manager *ShutdownManager) Plan() []ShutdownStep {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	plan := make([]ShutdownStep, 0, len(manager.pending)+len(manager.resources))
+	addSteps := func(resources []managedResource) {
+		for i := len(resources) - 1; i >= 0; i-- {
+			plan = append(plan, ShutdownStep{
+				Phase:        resources[i].phase,
+				ResourceName: resources[i].resourceName,
+				Severity:     resources[i].severity,
+				ResourceID:   resources[i].id,
+			})
+		}
+	}
+
+	// Shutdown closes the pending resources first, and then the rest in the same order as
+	// nextResource sorts them
+	addSteps(manager.pending)
+	resources := slices.Clone(manager.resources)
+	slices.SortStableFunc(resources, func(a managedResource, b managedResource) int {
+		return cmp.Compare(b.phase, a.phase)
+	})
+	addSteps(resources)
+
+	return plan
+}
+
+// Abort closes all resources left in the manager right away, and returns the combined close
+// errors (or nil if all closes succeeded). Unlike [ShutdownManager.Shutdown], it doesn't wait for
+// one resource to close before closing the next: all resources are closed concurrently, regardless
+// of their shutdown phase. This is for when graceful shutdown takes too long, such as when the
+// user presses Ctrl+C a second time (see [errclose.OnSignal]).
+//
+// If Shutdown is running when Abort is called, Shutdown's context is canceled, and Shutdown
+// returns once the resource it's currently closing has finished, with the following error
+// appended (matching [errclose.ErrShutdownAborted] with [errors.Is]):
+//
+//	shutdown interrupted: shutdown aborted
+//
+// The resources that Shutdown didn't get to are closed by Abort, so no resource is closed twice.
+// Abort doesn't wait for the resource that Shutdown is currently closing, since that may be what's
+// holding up the shutdown.
+//
+// Abort gives resources a context that's already canceled, in the same places that Shutdown gives
+// them its context, so that context-aware resources don't wait for anything. Close errors are
+// formatted and combined in the same way as in [errclose.CloseAllConcurrent], leaving out those of
+// best-effort resources (see [errclose.SeverityBestEffort]).
+//
+// After Abort, the manager counts as shut down, so resources registered later are handled
+// according to the policy set by [errclose.SetLateRegistrationPolicy].
+func (manager *ShutdownManager) Abort() error {
+	handlers := manager.getEventHandlers()
+	err := manager.abort(handlers)
+	handlers.emit(ShutdownEventDone, 0, "", 0, err)
+	return err
+}
+
+// abort implements [ShutdownManager.Abort], without emitting [errclose.ShutdownEventDone], so that
+// Shutdown can use it to abort the remaining phases after a critical close failure.
+func (manager *ShutdownManager) abort(handlers shutdownEventHandlers) error {
+	manager.lock.Lock()
+	resources := append(manager.pending, manager.resources...)
+	manager.pending = nil
+	manager.resources = nil
+	manager.shutDown = true
+	for cancelShutdown := range manager.cancelShutdowns {
+		(*cancelShutdown)(ErrShutdownAborted)
+	}
+	manager.lock.Unlock()
+
+	namedResources := make([]namedResource, len(resources))
+	for i, resource := range resources {
+		namedResources[i] = resource.namedResource
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrShutdownAborted)
+
+	var err error
+	hooks := eventHooks(handlers, resources)
+	// Critical failures don't matter here, since everything is being closed right away anyway
+	criticalFailed := false
+	hooks.propagate = severityFilter(resources, &criticalFailed)
+	closeConcurrently(ctx, &err, 0, namedResources, hooks)
+	return err
+}
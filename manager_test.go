@@ -0,0 +1,244 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestShutdownManager(t *testing.T) {
+	var closed []string
+	closer := func(name string) interface{ Close() error } {
+		return closerFunc(func() error {
+			closed = append(closed, name)
+			return nil
+		})
+	}
+
+	var manager errclose.ShutdownManager
+	manager.DeferPhase(closer("database"), "database", 1)
+	manager.Defer(closer("cache"), "cache")
+	manager.DeferPhase(closer("server 1"), "server 1", -1)
+	manager.DeferPhase(closer("server 2"), "server 2", -1)
+	manager.Defer(openFileWithCloseError(), "file")
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(
+		t,
+		closed,
+		[]string{"server 2", "server 1", "cache", "database"},
+		"close order",
+	)
+
+	err = manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error from second Shutdown")
+}
+
+func TestShutdownManagerPlan(t *testing.T) {
+	var manager errclose.ShutdownManager
+	file := openFileWithoutCloseError()
+	manager.DeferPhase(file, "database", 1)
+	manager.Defer(file, "cache")
+	manager.DeferPhase(file, "server 1", -1)
+	manager.DeferPhase(file, "server 2", -1)
+
+	assertEqual(
+		t,
+		planWithoutIDs(t, manager.Plan()),
+		[]errclose.ShutdownStep{
+			{Phase: -1, ResourceName: "server 2", Severity: errclose.SeverityNormal},
+			{Phase: -1, ResourceName: "server 1", Severity: errclose.SeverityNormal},
+			{Phase: 0, ResourceName: "cache", Severity: errclose.SeverityNormal},
+			{Phase: 1, ResourceName: "database", Severity: errclose.SeverityNormal},
+		},
+		"plan",
+	)
+	assertEqual(t, file.closeWasCalled, false, "file.closeWasCalled after Plan")
+
+	var closed []string
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		if event.Kind == errclose.ShutdownEventResourceClosing {
+			closed = append(closed, event.ResourceName)
+		}
+	})
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, closed, []string{"server 2", "server 1", "cache", "database"}, "close order")
+	assertEqual(t, manager.Plan(), []errclose.ShutdownStep{}, "plan after Shutdown")
+}
+
+// planWithoutIDs checks that the steps in the given plan have distinct resource IDs, and returns
+// the steps with the IDs cleared, since they depend on the resources registered by other tests.
+func planWithoutIDs(t *testing.T, plan []errclose.ShutdownStep) []errclose.ShutdownStep {
+	t.Helper()
+
+	ids := make(map[errclose.ResourceID]bool)
+	for i, step := range plan {
+		if step.ResourceID == 0 || ids[step.ResourceID] {
+			t.Errorf("Unexpected resource ID %d for %s", step.ResourceID, step.ResourceName)
+		}
+		ids[step.ResourceID] = true
+		plan[i].ResourceID = 0
+	}
+	return plan
+}
+
+func TestShutdownManagersAreIndependent(t *testing.T) {
+	var manager1, manager2 errclose.ShutdownManager
+	file1 := openFileWithoutCloseError()
+	file2 := openFileWithoutCloseError()
+	globalFile := openFileWithoutCloseError()
+	manager1.Defer(file1, "file 1")
+	manager2.Defer(file2, "file 2")
+	errclose.DeferGlobal(globalFile, "global file")
+
+	err := manager1.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, file1.closeWasCalled, true, "file1.closeWasCalled")
+	assertEqual(t, file2.closeWasCalled, false, "file2.closeWasCalled")
+	assertEqual(t, globalFile.closeWasCalled, false, "globalFile.closeWasCalled")
+
+	err = errclose.GlobalShutdownManager().Shutdown(context.Background())
+	assertEqual(t, err, nil, "error from global manager")
+	assertEqual(t, globalFile.closeWasCalled, true, "globalFile.closeWasCalled after shutdown")
+	assertEqual(t, file2.closeWasCalled, false, "file2.closeWasCalled after global shutdown")
+
+	err = manager2.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error from manager 2")
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled after manager 2 shutdown")
+}
+
+func TestShutdownManagerLateRegistration(t *testing.T) {
+	var manager errclose.ShutdownManager
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error from first Shutdown")
+
+	file := openFileWithoutCloseError()
+	manager.Defer(file, "late file")
+	assertEqual(t, file.closeWasCalled, false, "file.closeWasCalled after late registration")
+
+	err = manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error from second Shutdown")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled after second Shutdown")
+}
+
+func TestShutdownManagerWithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var manager errclose.ShutdownManager
+	file := openFileWithoutCloseError()
+	manager.Defer(file, "file")
+
+	err := manager.Shutdown(ctx)
+	assertEqual(
+		t,
+		err.Error(),
+		"shutdown interrupted with 1 resources left: context canceled",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, context.Canceled), true, "errors.Is(context.Canceled)")
+	assertEqual(t, file.closeWasCalled, false, "file.closeWasCalled")
+}
+
+type recordingServer struct {
+	shutdownCtx context.Context //nolint:containedctx // Recorded for the test
+	err         error
+}
+
+func (server *recordingServer) Shutdown(ctx context.Context) error {
+	server.shutdownCtx = ctx
+	return server.err
+}
+
+func TestShutdowner(t *testing.T) {
+	ctx := context.WithValue(context.Background(), closeCtxKey{}, "shutdown")
+	server := &recordingServer{shutdownCtx: nil, err: errors.New("listener busy")}
+
+	var manager errclose.ShutdownManager
+	manager.Defer(errclose.Shutdowner(server), "HTTP server")
+
+	err := manager.Shutdown(ctx)
+	assertEqual(t, err.Error(), "failed to shut down HTTP server: listener busy", "error string")
+	assertEqual(t, server.shutdownCtx.Value(closeCtxKey{}), "shutdown", "context given to Shutdown")
+}
+
+func TestShutdownerWithoutManager(t *testing.T) {
+	server := &recordingServer{shutdownCtx: nil, err: nil}
+
+	var err error
+	errclose.Close(errclose.Shutdowner(server), &err, "HTTP server")
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, server.shutdownCtx, context.Background(), "context given to Shutdown")
+}
+
+// blockingResource blocks in Close until its close context is canceled.
+type blockingResource struct {
+	closeCtx     context.Context //nolint:containedctx // Set by the shutdown manager
+	closeStarted chan struct{}
+}
+
+func newBlockingResource() *blockingResource {
+	return &blockingResource{closeCtx: context.Background(), closeStarted: make(chan struct{})}
+}
+
+func (resource *blockingResource) SetCloseContext(ctx context.Context) {
+	resource.closeCtx = ctx
+}
+
+func (resource *blockingResource) Close() error {
+	close(resource.closeStarted)
+	<-resource.closeCtx.Done()
+	return nil
+}
+
+func TestShutdownManagerAbort(t *testing.T) {
+	var manager errclose.ShutdownManager
+	file1 := openFileWithoutCloseError()
+	file2 := openFileWithCloseError()
+	server := newBlockingResource()
+	manager.DeferPhase(file1, "file 1", 1)
+	manager.DeferPhase(file2, "file 2", 1)
+	manager.Defer(server, "server")
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- manager.Shutdown(context.Background())
+	}()
+	<-server.closeStarted
+
+	err := manager.Abort()
+	assertEqual(t, err.Error(), "failed to close file 2: close error", "error from Abort")
+	assertEqual(t, file1.closeWasCalled, true, "file1.closeWasCalled")
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+
+	err = <-shutdownErr
+	assertEqual(t, err.Error(), "shutdown interrupted: shutdown aborted", "error from Shutdown")
+	assertEqual(
+		t,
+		errors.Is(err, errclose.ErrShutdownAborted),
+		true,
+		"errors.Is(ErrShutdownAborted)",
+	)
+}
+
+func TestShutdownManagerAbortWithoutShutdown(t *testing.T) {
+	var manager errclose.ShutdownManager
+	server := newBlockingResource()
+	manager.Defer(server, "server")
+
+	err := manager.Abort()
+	assertEqual(t, err, nil, "error from Abort")
+	assertEqual(
+		t,
+		context.Cause(server.closeCtx),
+		errclose.ErrShutdownAborted,
+		"cause of close context",
+	)
+
+	err = manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error from Shutdown after Abort")
+}
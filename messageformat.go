@@ -0,0 +1,83 @@
+package errclose
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+type messageFormat struct {
+	close    string
+	combined string
+}
+
+var messageFormats atomic.Pointer[messageFormat]
+
+// SetMessageFormat replaces the messages of the errors returned by this package with your own
+// format strings, for codebases with their own error message conventions (or messages in other
+// languages). The formats are used with [fmt.Errorf], so the underlying errors are still wrapped.
+//
+// The close format is used for [errclose.CloseError] messages. It's given the teardown action
+// (e.g. "close" or "shut down"), the resource name and the close error, in that order. Use
+// explicit argument indexes to change the order, or to leave out the action:
+//
+//	errclose.SetMessageFormat("could not %s %s: %w", "%w, and %w")
+//	errclose.SetMessageFormat("%[2]s: %[1]s failed: %[3]w", "")
+//
+// The combined format is used when an error is combined with an existing error (see 'Error
+// format' on [errclose.Close]). It's given the existing error and the added error, in that order.
+//
+// An empty format uses the default message for that kind of error. Custom formats take precedence
+// over [errclose.SetErrorFormat]. The formats apply to errors created after the call.
+//
+// SetMessageFormat returns an error (without changing the formats) if a format doesn't use all
+// its arguments, uses a verb other than %s, %v or %w for them, or doesn't wrap the errors with %w.
+func SetMessageFormat(closeFormat string, combinedFormat string) error {
+	if closeFormat != "" {
+		testErr := errors.New("close error")
+		formatted := fmt.Errorf(closeFormat, "close", "resource", testErr)
+		if strings.Contains(formatted.Error(), "%!") || !errors.Is(formatted, testErr) {
+			return fmt.Errorf(
+				"errclose: invalid close message format %q (must format action and resource "+
+					"name, and wrap close error with %%w)",
+				closeFormat,
+			)
+		}
+	}
+
+	if combinedFormat != "" {
+		testErr1 := errors.New("existing error")
+		testErr2 := errors.New("close error")
+		formatted := fmt.Errorf(combinedFormat, testErr1, testErr2)
+		if strings.Contains(formatted.Error(), "%!") ||
+			!errors.Is(formatted, testErr1) ||
+			!errors.Is(formatted, testErr2) {
+			return fmt.Errorf(
+				"errclose: invalid combined message format %q (must wrap both errors with %%w)",
+				combinedFormat,
+			)
+		}
+	}
+
+	if closeFormat == "" && combinedFormat == "" {
+		messageFormats.Store(nil)
+	} else {
+		messageFormats.Store(&messageFormat{close: closeFormat, combined: combinedFormat})
+	}
+	return nil
+}
+
+func closeMessageFormat() string {
+	if format := messageFormats.Load(); format != nil {
+		return format.close
+	}
+	return ""
+}
+
+func combinedMessageFormat() string {
+	if format := messageFormats.Load(); format != nil {
+		return format.combined
+	}
+	return ""
+}
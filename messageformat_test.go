@@ -0,0 +1,60 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestSetMessageFormat(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	err := errclose.SetMessageFormat("kunne ikke lukke %[2]s: %[3]w", "%w, og %w")
+	assertEqual(t, err, nil, "error from SetMessageFormat")
+
+	file := openFileWithCloseError()
+	returnedErr := errors.New("forespørsel feilet")
+	errclose.Close(file, &returnedErr, "fil")
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"forespørsel feilet, og kunne ikke lukke fil: close error",
+		"error string",
+	)
+	assertEqual(t, errors.Is(returnedErr, file.closeError), true, "errors.Is close error")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(returnedErr, &closeErr), true, "errors.As CloseError")
+	assertEqual(t, closeErr.ResourceName, "fil", "CloseError.ResourceName")
+}
+
+func TestSetMessageFormatValidation(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	err := errclose.SetMessageFormat("failed to close %s: %v", "")
+	assertEqual(
+		t,
+		err.Error(),
+		`errclose: invalid close message format "failed to close %s: %v" `+
+			`(must format action and resource name, and wrap close error with %w)`,
+		"close format error",
+	)
+
+	err = errclose.SetMessageFormat("", "%w (and %v)")
+	assertEqual(
+		t,
+		err.Error(),
+		`errclose: invalid combined message format "%w (and %v)" (must wrap both errors with %w)`,
+		"combined format error",
+	)
+
+	var returnedErr error
+	errclose.Close(openFileWithCloseError(), &returnedErr, "file")
+	assertEqual(
+		t,
+		returnedErr.Error(),
+		"failed to close file: close error",
+		"error string after invalid formats",
+	)
+}
@@ -0,0 +1,133 @@
+package errclose
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Metrics receives counts of close attempts and failures from the package, per resource name. Set
+// it with [errclose.SetMetrics]. You can implement Metrics to bind the counts to your metrics
+// library, such as Prometheus counters with a resource label:
+//
+//	type closeMetrics struct {
+//		attempts, failures, ignored, timeouts *prometheus.CounterVec
+//	}
+//
+//	func (metrics closeMetrics) CloseAttempted(resourceName string) {
+//		metrics.attempts.WithLabelValues(resourceName).Inc()
+//	}
+//
+//	// ...
+//
+// For a ready-made implementation that publishes the counts with [expvar], see
+// [hermannm.dev/errclose/errcloseexpvar].
+//
+// Methods may be called concurrently, if resources are closed concurrently.
+type Metrics interface {
+	// CloseAttempted is called before every teardown operation that can fail, for the resource
+	// name that a failure would be reported under. This includes closing a resource with any of
+	// the package's functions, and also the other steps of helpers that do more than close, such
+	// as flushing in [errclose.FlushAndClose], draining in [errclose.DrainAndClose] or committing
+	// in [errclose.Finish]. Resources that are closed because of [errclose.LateRegistrationClose]
+	// or by the cleanup of [errclose.CloseOrCleanup] count as well. Resources closed by
+	// [ShutdownManager.Shutdown] or [errclose.CloseAllConcurrent] count as one attempt each, also
+	// if they implement [errclose.ClosePreparer] or [errclose.Drainable]: preparing and draining
+	// them is part of the close, so if several of the steps fail, only one failure is counted.
+	//
+	// Every call to CloseFailed is thus preceded by a call to CloseAttempted for the same
+	// resource name, so the failure count never exceeds the attempt count.
+	CloseAttempted(resourceName string)
+	// CloseFailed is called for every close error that is written to the event log as a
+	// close_failed event (see [errclose.SetEventLog]), with the unwrapped close error, except for
+	// errors from fallbacks given with [errclose.WithFallback], which are part of handling the
+	// close failure that was already counted. It's also called when the cleanup of
+	// [errclose.CloseOrCleanup] fails to close a leaked resource. A close that fails after
+	// [errclose.CloseWithTimeout] has timed out is only counted once, as a timeout. If the
+	// resource was closed with [errclose.WithLabels], the labels can be read from the close error
+	// with [errclose.Labels].
+	CloseFailed(resourceName string, closeErr error)
+	// CloseIgnored is called for close errors dropped by [errclose.Ignore] or [errclose.IgnoreIf],
+	// with the labels from [errclose.WithLabels] on the close error, as for CloseFailed.
+	CloseIgnored(resourceName string, closeErr error)
+	// CloseTimedOut is called for close failures that match [errclose.ErrCloseTimeout], in
+	// addition to CloseFailed.
+	CloseTimedOut(resourceName string)
+}
+
+var metrics atomic.Pointer[Metrics]
+
+// SetMetrics sets the [errclose.Metrics] to report close attempts and failures to. Close failures
+// are an early warning of descriptor leaks and flaky storage, which is easy to miss when close
+// errors are logged far from where they happened (or dropped). Pass nil to stop reporting metrics
+// (this is the default).
+//
+// When metrics are set, the resource name is resolved for every close attempt, so successful
+// closes with [errclose.Closef] call [fmt.Sprintf] for the resource name.
+func SetMetrics(closeMetrics Metrics) {
+	if closeMetrics == nil {
+		metrics.Store(nil)
+	} else {
+		metrics.Store(&closeMetrics)
+	}
+}
+
+// recordCloseAttempt reports a close attempt to the metrics set by [errclose.SetMetrics]. It must
+// be called before every teardown operation whose failure is reported with recordCloseFailure, so
+// that the counts agree. If the resource name has to be resolved or formatted, callers should
+// check metricsEnabled first, to avoid that work when metrics are disabled.
+func recordCloseAttempt(resourceName string) {
+	if closeMetrics := metrics.Load(); closeMetrics != nil {
+		(*closeMetrics).CloseAttempted(resourceName)
+	}
+}
+
+func metricsEnabled() bool {
+	return metrics.Load() != nil
+}
+
+func recordCloseFailure(resourceName string, closeErr error) {
+	if closeMetrics := metrics.Load(); closeMetrics != nil {
+		(*closeMetrics).CloseFailed(resourceName, closeErr)
+		if errors.Is(closeErr, ErrCloseTimeout) {
+			(*closeMetrics).CloseTimedOut(resourceName)
+		}
+	}
+}
+
+func recordCloseIgnored(resourceName string, closeErr error) {
+	if closeMetrics := metrics.Load(); closeMetrics != nil {
+		(*closeMetrics).CloseIgnored(resourceName, closeErr)
+	}
+}
+
+// closeAttempt is a close with several steps that fail separately, such as preparing, draining and
+// closing a resource in closeWithContext, which is counted as one attempt in the metrics.
+type closeAttempt struct {
+	failed bool
+}
+
+// withCloseAttempt records a close attempt for the given resource name, and returns the given
+// options with an internal option that makes the steps of the close count as part of that
+// attempt: close functions don't record attempts of their own, and only the first failed step is
+// recorded as a failure. It returns the options as-is if metrics are disabled, to avoid
+// allocating.
+func withCloseAttempt(options []Option, resourceName string) []Option {
+	if !metricsEnabled() {
+		return options
+	}
+	recordCloseAttempt(resourceName)
+	attempt := &optionSettings{attempt: &closeAttempt{failed: false}}
+	return append(options, Option{settings: attempt})
+}
+
+// attemptFromOptions returns the attempt added with withCloseAttempt, or nil if there is none.
+func attemptFromOptions(options optionLists) *closeAttempt {
+	for _, list := range options {
+		for _, option := range list {
+			if attempt := option.get().attempt; attempt != nil {
+				return attempt
+			}
+		}
+	}
+	return nil
+}
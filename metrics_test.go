@@ -0,0 +1,253 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestMetrics(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	metrics := &mockMetrics{events: nil}
+	errclose.SetMetrics(metrics)
+
+	errBenign := errors.New("already closed")
+
+	var err error
+	errclose.Closef(openFileWithoutCloseError(), &err, "file %d", 1)
+	errclose.Close(openFileWithCloseError(), &err, "file 2")
+	errclose.Close(
+		closerFunc(func() error { return errBenign }),
+		&err,
+		"connection",
+		errclose.Ignore(errBenign),
+	)
+	errclose.CloseG(
+		closerFunc(func() error { return errclose.ErrCloseTimeout }),
+		&err,
+		"pool",
+	)
+
+	assertEqual(
+		t,
+		metrics.events,
+		[]string{
+			"attempted: file 1",
+			"attempted: file 2",
+			"failed: file 2: close error",
+			"attempted: connection",
+			"ignored: connection: already closed",
+			"attempted: pool",
+			"failed: pool: timed out closing resource",
+			"timed out: pool",
+		},
+		"metrics events",
+	)
+}
+
+func TestMetricsCountsAgree(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	metrics := &countingMetrics{lock: sync.Mutex{}, attempts: nil, failures: nil}
+	errclose.SetMetrics(metrics)
+	lateClose := make(chan struct{})
+	errclose.SetObserver(func(resourceName string, closeErr error) {
+		if resourceName == "slow resource" && !errors.Is(closeErr, errclose.ErrCloseTimeout) {
+			close(lateClose)
+		}
+	})
+
+	// Every teardown step fails, so every attempt should be counted as a failure
+	resource := failingTeardown{}
+	var err error
+	errclose.FlushAndClose(resource, &err, "writer")
+	errclose.SyncClose(resource, &err, "file")
+	errclose.DrainAndClose(resource, &err, "response body")
+	errclose.Rows(resource, &err, "rows")
+	errclose.Shutdown(context.Background(), resource, &err, "server")
+	errclose.Do(resource.Close, &err, "clean up")
+	errclose.CloseWithRetry(resource, &err, "connection", errclose.Retry{
+		Attempts: 2,
+		Backoff:  0,
+		If:       nil,
+	})
+	errclose.Close(nil, &err, "missing resource")
+	errclose.Close(
+		resource,
+		&err,
+		"output file",
+		errclose.WithFallback(func(error) error { return errTeardown }),
+	)
+	errclose.CloseFiles(
+		[]*namedMockFile{{name: "/a", mockFile: *openFileWithCloseError(), onClose: nil}},
+		&err,
+		0,
+	)
+	errclose.CloseAndLog(resource, slog.New(slog.DiscardHandler), slog.LevelError, "cache")
+	func() {
+		defer func() { _ = recover() }()
+		errclose.MustClose(resource, "tool output")
+	}()
+
+	var manager errclose.ShutdownManager
+	_ = manager.Shutdown(context.Background())
+	errclose.SetLateRegistrationPolicy(errclose.LateRegistrationClose)
+	manager.Defer(resource, "late resource")
+
+	unblock := make(chan struct{})
+	slowResource := closerFunc(func() error {
+		<-unblock
+		return errTeardown
+	})
+	errclose.CloseWithTimeout(slowResource, &err, "slow resource", time.Millisecond)
+	close(unblock)
+	<-lateClose
+
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	assertEqual(t, metrics.failures, metrics.attempts, "failure counts")
+	assertEqual(t, len(metrics.attempts), 14, "number of resource names")
+}
+
+func TestMetricsWithFailingFallback(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	metrics := &countingMetrics{lock: sync.Mutex{}, attempts: nil, failures: nil}
+	errclose.SetMetrics(metrics)
+
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"output file",
+		errclose.WithFallback(func(error) error { return nil }),
+		errclose.WithFallback(func(error) error { return errTeardown }),
+	)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close output file: close error "+
+			"(and failed to run fallback for output file: teardown error)",
+		"error string",
+	)
+
+	attempts, failures := metrics.attempts["output file"], metrics.failures["output file"]
+	assertEqual(t, attempts >= failures, true, "attempts >= failures")
+	assertEqual(t, attempts, 1, "attempts")
+	assertEqual(t, failures, 1, "failures")
+}
+
+func TestMetricsCountsShutdownStepsAsOneAttempt(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	metrics := &countingMetrics{lock: sync.Mutex{}, attempts: nil, failures: nil}
+	errclose.SetMetrics(metrics)
+
+	var manager errclose.ShutdownManager
+	manager.Defer(
+		&preparablePool{
+			mockWorkerPool: mockWorkerPool{
+				stages:   nil,
+				drainErr: errTeardown,
+				closeErr: errTeardown,
+			},
+			prepareErr: errTeardown,
+		},
+		"failing pool",
+	)
+	manager.Defer(
+		&preparablePool{
+			mockWorkerPool: mockWorkerPool{stages: nil, drainErr: nil, closeErr: nil},
+			prepareErr:     nil,
+		},
+		"pool",
+	)
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to prepare to close failing pool: teardown error "+
+			"(and failed to drain failing pool: teardown error) "+
+			"(and failed to close failing pool: teardown error)",
+		"error string",
+	)
+	assertEqual(
+		t,
+		metrics.attempts,
+		map[string]int{"failing pool": 1, "pool": 1},
+		"attempts",
+	)
+	assertEqual(t, metrics.failures, map[string]int{"failing pool": 1}, "failures")
+}
+
+type preparablePool struct {
+	mockWorkerPool
+	prepareErr error
+}
+
+func (pool *preparablePool) PrepareClose(context.Context) error {
+	return pool.prepareErr
+}
+
+type countingMetrics struct {
+	lock     sync.Mutex
+	attempts map[string]int
+	failures map[string]int
+}
+
+func (metrics *countingMetrics) CloseAttempted(resourceName string) {
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	if metrics.attempts == nil {
+		metrics.attempts = make(map[string]int)
+	}
+	metrics.attempts[resourceName]++
+}
+
+func (metrics *countingMetrics) CloseFailed(resourceName string, _ error) {
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	if metrics.failures == nil {
+		metrics.failures = make(map[string]int)
+	}
+	metrics.failures[resourceName]++
+}
+
+func (metrics *countingMetrics) CloseIgnored(string, error) {}
+
+func (metrics *countingMetrics) CloseTimedOut(string) {}
+
+var errTeardown = errors.New("teardown error")
+
+// failingTeardown fails every teardown operation of the package's helpers.
+type failingTeardown struct{}
+
+func (failingTeardown) Flush() error                   { return errTeardown }
+func (failingTeardown) Sync() error                    { return errTeardown }
+func (failingTeardown) Err() error                     { return errTeardown }
+func (failingTeardown) Read([]byte) (int, error)       { return 0, errTeardown }
+func (failingTeardown) Shutdown(context.Context) error { return errTeardown }
+func (failingTeardown) Close() error                   { return errTeardown }
+
+type mockMetrics struct {
+	events []string
+}
+
+func (metrics *mockMetrics) CloseAttempted(resourceName string) {
+	metrics.events = append(metrics.events, "attempted: "+resourceName)
+}
+
+func (metrics *mockMetrics) CloseFailed(resourceName string, closeErr error) {
+	metrics.events = append(metrics.events, "failed: "+resourceName+": "+closeErr.Error())
+}
+
+func (metrics *mockMetrics) CloseIgnored(resourceName string, closeErr error) {
+	metrics.events = append(metrics.events, "ignored: "+resourceName+": "+closeErr.Error())
+}
+
+func (metrics *mockMetrics) CloseTimedOut(resourceName string) {
+	metrics.events = append(metrics.events, "timed out: "+resourceName)
+}
@@ -0,0 +1,128 @@
+package errclose
+
+import (
+	"io"
+	"slices"
+)
+
+// MultiCloser returns a closer that closes all the given closers in reverse order, and returns
+// their combined close errors. This is for handing a single closer to an API when you own several
+// resources, such as returning an [io.ReadCloser] that reads from a file through a decompressor:
+//
+//	type readCloser struct {
+//		io.Reader
+//		io.Closer
+//	}
+//
+//	func openCompressed(path string) (io.ReadCloser, error) {
+//		file, err := os.Open(path)
+//		if err != nil {
+//			return nil, err
+//		}
+//		decompressor, err := gzip.NewReader(file)
+//		if err != nil {
+//			return nil, errors.Join(err, file.Close())
+//		}
+//		return readCloser{decompressor, errclose.MultiCloser(file, decompressor)}, nil
+//	}
+//
+// All closers are closed, even if some of them fail. If a closer implements [errclose.NamedCloser]
+// (such as [os.File], or resources wrapped with [errclose.Named]), its close error is wrapped with
+// the name, like in [errclose.Close]. Errors from other closers are returned as-is. Close errors
+// are combined in the order they occur, on the following format:
+//
+//	<first close error> (and <second close error>)
+//
+// For nil closers, the close error is [errclose.ErrNilResource].
+func MultiCloser(closers ...interface{ Close() error }) interface{ Close() error } {
+	return multiCloser(closers)
+}
+
+type multiCloser []interface{ Close() error }
+
+func (closers multiCloser) Close() (returnedErr error) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		closer := closers[i]
+
+		var closeErr error
+		if isNilResource(closer) {
+			closeErr = nilResourceError()
+		} else {
+			closeErr = closer.Close()
+		}
+		if closeErr == nil {
+			continue
+		}
+
+		if resourceName := resolveResourceName(closer, ""); resourceName != "" {
+			wrapped := newCloseError(closeErr, "close", resourceName)
+			returnedErr = combineCloseError(returnedErr, wrapped)
+		} else {
+			returnedErr = combineErrors(returnedErr, closeErr)
+		}
+	}
+	return returnedErr
+}
+
+// JoinReadCloser returns an [io.ReadCloser] that reads from the given reader, and closes the given
+// closers in the order they are given when closed. This is for returning a stream that reads from
+// one object, but must also release other resources when the caller is done with it, such as a
+// lock or a temporary file:
+//
+//	func openExport(path string) (io.ReadCloser, error) {
+//		file, err := os.Open(path)
+//		if err != nil {
+//			return nil, err
+//		}
+//		decompressor, err := gzip.NewReader(file)
+//		if err != nil {
+//			return nil, errors.Join(err, file.Close())
+//		}
+//		return errclose.JoinReadCloser(decompressor, decompressor, file), nil
+//	}
+//
+// The reader is not closed, unless it's also one of the closers. Close errors are handled as in
+// [errclose.MultiCloser]: all closers are closed even if some of them fail, errors from closers
+// that implement [errclose.NamedCloser] (such as resources wrapped with [errclose.Named]) are
+// wrapped with their name, and the errors are combined in the order they occur.
+func JoinReadCloser(reader io.Reader, closers ...interface{ Close() error }) io.ReadCloser {
+	return joinedReadCloser{Reader: reader, closer: reversedMultiCloser(closers)}
+}
+
+// JoinWriteCloser returns an [io.WriteCloser] that writes to the given writer, and closes the
+// given closers in the order they are given when closed, like [errclose.JoinReadCloser]. Remember
+// to include the writer among the closers if it must be closed to flush its output, typically
+// first:
+//
+//	return errclose.JoinWriteCloser(compressor, compressor, errclose.Named(file, "export file"))
+func JoinWriteCloser(writer io.Writer, closers ...interface{ Close() error }) io.WriteCloser {
+	return joinedWriteCloser{Writer: writer, closer: reversedMultiCloser(closers)}
+}
+
+type joinedReadCloser struct {
+	io.Reader
+
+	closer multiCloser
+}
+
+func (joined joinedReadCloser) Close() error {
+	return joined.closer.Close()
+}
+
+type joinedWriteCloser struct {
+	io.Writer
+
+	closer multiCloser
+}
+
+func (joined joinedWriteCloser) Close() error {
+	return joined.closer.Close()
+}
+
+// reversedMultiCloser returns a multiCloser that closes the given closers in the order they are
+// given (since multiCloser closes in reverse order).
+func reversedMultiCloser(closers []interface{ Close() error }) multiCloser {
+	reversed := slices.Clone(closers)
+	slices.Reverse(reversed)
+	return multiCloser(reversed)
+}
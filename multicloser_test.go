@@ -0,0 +1,109 @@
+package errclose_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestMultiCloser(t *testing.T) {
+	var closeOrder []string
+	first := closerFunc(func() error {
+		closeOrder = append(closeOrder, "first")
+		return errors.New("first error")
+	})
+	second := closerFunc(func() error {
+		closeOrder = append(closeOrder, "second")
+		return nil
+	})
+	thirdErr := errors.New("third error")
+	third := closerFunc(func() error {
+		closeOrder = append(closeOrder, "third")
+		return thirdErr
+	})
+
+	closer := errclose.MultiCloser(first, second, errclose.Named(third, "third resource"))
+	err := closer.Close()
+
+	assertEqual(t, closeOrder, []string{"third", "second", "first"}, "close order")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close third resource: third error (and first error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, thirdErr), true, "errors.Is result")
+}
+
+func TestMultiCloserWithClose(t *testing.T) {
+	useResources := func() (returnedErr error) {
+		closer := errclose.MultiCloser(openFileWithoutCloseError(), openFileWithCloseError())
+		defer errclose.Close(closer, &returnedErr, "resources")
+		return nil
+	}
+
+	err := useResources()
+	assertEqual(t, err.Error(), "failed to close resources: close error", "error string")
+}
+
+func TestMultiCloserWithoutErrors(t *testing.T) {
+	file1 := openFileWithoutCloseError()
+	file2 := openFileWithoutCloseError()
+
+	err := errclose.MultiCloser(file1, file2).Close()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, file1.closeWasCalled, true, "file1.closeWasCalled")
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+}
+
+func TestMultiCloserNil(t *testing.T) {
+	err := errclose.MultiCloser(nil).Close()
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is result")
+}
+
+func TestJoinReadCloser(t *testing.T) {
+	var closeOrder []string
+	lock := closerFunc(func() error {
+		closeOrder = append(closeOrder, "lock")
+		return errors.New("unlock failed")
+	})
+	tempFile := closerFunc(func() error {
+		closeOrder = append(closeOrder, "temp file")
+		return errors.New("remove failed")
+	})
+
+	readCloser := errclose.JoinReadCloser(
+		strings.NewReader("content"),
+		errclose.Named(lock, "lock"),
+		errclose.Named(tempFile, "temporary file"),
+	)
+	content, err := io.ReadAll(readCloser)
+	assertEqual(t, err, nil, "read error")
+	assertEqual(t, string(content), "content", "content")
+
+	err = readCloser.Close()
+	assertEqual(t, closeOrder, []string{"lock", "temp file"}, "close order")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close lock: unlock failed "+
+			"(and failed to close temporary file: remove failed)",
+		"error string",
+	)
+}
+
+func TestJoinWriteCloser(t *testing.T) {
+	var output strings.Builder
+	file := openFileWithoutCloseError()
+
+	writeCloser := errclose.JoinWriteCloser(&output, file)
+	_, err := io.WriteString(writeCloser, "content")
+	assertEqual(t, err, nil, "write error")
+	assertEqual(t, output.String(), "content", "output")
+
+	assertEqual(t, writeCloser.Close(), nil, "close error")
+	assertEqual(t, file.closeWasCalled, true, "closeWasCalled")
+}
@@ -0,0 +1,97 @@
+package errclose
+
+// NamedCloser is implemented by resources that can describe themselves when closed, such as
+// [os.File] (where Name returns the file path). Library authors can implement it to give their
+// resources a useful name in close errors, without their users having to name them at every call
+// site.
+//
+// When [errclose.Close] or [errclose.Closef] is given an empty resource name, and the resource
+// implements NamedCloser, the name returned by the resource's Name method is used in the close
+// error instead. Since [errclose.Frame] and [errclose.DeferGlobal] close their resources with
+// Close, this also applies to resources added to them with an empty name:
+//
+//	frame.Add(file, "") // Close errors use file.Name()
+//
+// Name is only called if closing the resource fails (or debug events are enabled, see
+// [errclose.SetDebugEvents]).
+type NamedCloser interface {
+	Name() string
+	Close() error
+}
+
+// resolveResourceName returns the given resource name, or the name returned by the resource if it
+// implements [errclose.NamedCloser] and the given name is empty.
+func resolveResourceName(resource interface{ Close() error }, resourceName string) string {
+	if resourceName != "" {
+		return resourceName
+	}
+	if named, ok := resource.(NamedCloser); ok {
+		return named.Name()
+	}
+	return resourceName
+}
+
+// Named returns a [errclose.NamedCloser] that closes the given resource, and uses the given name in
+// close errors. This is for passing resources that don't implement NamedCloser to
+// [errclose.CloseAll].
+func Named(resource interface{ Close() error }, resourceName string) NamedCloser {
+	return namedCloser{resource: resource, resourceName: resourceName}
+}
+
+type namedCloser struct {
+	resource     interface{ Close() error }
+	resourceName string
+}
+
+func (closer namedCloser) Name() string {
+	return closer.resourceName
+}
+
+func (closer namedCloser) Close() error {
+	if isNilResource(closer.resource) {
+		return nilResourceError()
+	}
+	return closer.resource.Close()
+}
+
+// CloseAll closes the given resources in reverse order, and handles close errors in the same way as
+// [errclose.Close], using the name of each resource in its close error. This is a lighter
+// alternative to [errclose.Frame] when you open a few resources in a row, and want to close them
+// with a single defer:
+//
+//	func example() (returnedErr error) {
+//		input, err := os.Open("/input/path")
+//		if err != nil {
+//			return err
+//		}
+//		output, err := os.Create("/output/path")
+//		if err != nil {
+//			return errors.Join(err, input.Close())
+//		}
+//		conn, err := net.Dial("tcp", "localhost:8000")
+//		if err != nil {
+//			return errors.Join(err, output.Close(), input.Close())
+//		}
+//		defer errclose.CloseAll(&returnedErr, input, output, errclose.Named(conn, "connection"))
+//
+//		// Use resources
+//	}
+//
+// Since the arguments to a deferred call are evaluated when the defer statement runs, all the
+// resources must be opened before CloseAll is deferred. If you open resources one at a time and
+// want each one closed even if a later open fails, use a Frame instead.
+//
+// Resources that implement NamedCloser (such as [os.File]) can be passed directly. For other
+// resources, use [errclose.Named].
+//
+// If several resources fail with the same close error, the error is only included once, as
+// described on [Frame.Err].
+func CloseAll(returnedErr *error, resources ...NamedCloser) {
+	closeGroup(
+		returnedErr,
+		len(resources),
+		func(index int) namedResource {
+			return namedResource{resource: resources[index], resourceName: "", options: nil}
+		},
+	)
+}
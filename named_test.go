@@ -0,0 +1,81 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestNamedCloser(t *testing.T) {
+	file1 := &namedMockFile{mockFile: *openFileWithCloseError(), name: "/path/1", onClose: nil}
+	file2 := &namedMockFile{mockFile: *openFileWithCloseError(), name: "/path/2", onClose: nil}
+	file3 := &namedMockFile{mockFile: *openFileWithCloseError(), name: "/path/3", onClose: nil}
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.Close(file1, &returnedErr, "")
+		defer errclose.Closef(file2, &returnedErr, "")
+		defer errclose.Close(file3, &returnedErr, "explicit name")
+		return nil
+	}
+
+	err := useFiles()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close explicit name: close error "+
+			"(and failed to close /path/2: close error) "+
+			"(and failed to close /path/1: close error)",
+		"error string",
+	)
+}
+
+func TestNamedCloserInFrame(t *testing.T) {
+	file := &namedMockFile{mockFile: *openFileWithCloseError(), name: "/path", onClose: nil}
+
+	var frame errclose.Frame
+	frame.Add(file, "")
+
+	err := frame.Err()
+	assertEqual(t, err.Error(), "failed to close /path: close error", "error string")
+}
+
+func TestCloseAll(t *testing.T) {
+	file1 := openFileWithCloseError()
+	file2 := &namedMockFile{mockFile: *openFileWithoutCloseError(), name: "/path/2", onClose: nil}
+	file3 := &namedMockFile{mockFile: *openFileWithCloseError(), name: "/path/3", onClose: nil}
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.CloseAll(&returnedErr, errclose.Named(file1, "file 1"), file2, file3)
+		return fallibleOperation()
+	}
+
+	err := useFiles()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed "+
+			"(and failed to close /path/3: close error) "+
+			"(and failed to close file 1: close error)",
+		"error string",
+	)
+	assertEqual(t, file2.closeWasCalled, true, "file2.closeWasCalled")
+}
+
+func TestCloseAllDeduplicatesCloseErrors(t *testing.T) {
+	connErr := errors.New("connection reset")
+	brokenConn := closerFunc(func() error { return connErr })
+
+	var err error
+	errclose.CloseAll(
+		&err,
+		errclose.Named(brokenConn, "reader"),
+		errclose.Named(brokenConn, "writer"),
+	)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close 2 resources (writer, reader): connection reset",
+		"error string",
+	)
+}
@@ -0,0 +1,83 @@
+package errclose
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// NilErrorPolicy controls what happens when a function in this package gets a nil returnedErr
+// pointer, and the resource fails to close. Set it with [errclose.SetNilErrorPolicy].
+type NilErrorPolicy int32
+
+const (
+	// NilErrorPanic makes the package panic with a descriptive error, which includes the resource
+	// name and close error:
+	//
+	//	errclose: got nil returnedErr pointer when trying to close <resourceName>: <close error>
+	//
+	// The panic value wraps the close error, so it can be checked with [errors.Is] if recovered.
+	// This is the default.
+	NilErrorPanic NilErrorPolicy = iota
+	// NilErrorLog makes the package drop the close error after writing it to the event log (see
+	// [errclose.SetEventLog]) and passing it to the observer (see [errclose.SetObserver]). If
+	// neither is set, the close error is silently dropped.
+	//
+	// For [Collector.Into] and [ErrorSink.Into], close errors from [Collector.Close] and
+	// [ErrorSink.Close] are written to the event log and passed to the observer when the close
+	// fails, as usual. Errors added with [Collector.Add] or [ErrorSink.Set] are written and passed
+	// when they're dropped by Into, with an empty resource name.
+	NilErrorLog
+)
+
+var nilErrorPolicy atomic.Int32
+
+// SetNilErrorPolicy sets what happens when a function in this package gets a nil returnedErr
+// pointer, and the resource fails to close (see [errclose.NilErrorPolicy]).
+//
+// A nil returnedErr pointer is a programming error, but by the time it's discovered, the program is
+// often in a deferred call at the end of a function, or shutting down. A panic there replaces the
+// error that the function was about to return, so in production, you may prefer to set
+// [errclose.NilErrorLog] instead, and route close errors to your monitoring with an observer.
+func SetNilErrorPolicy(policy NilErrorPolicy) {
+	nilErrorPolicy.Store(int32(policy))
+}
+
+// handleNilReturnedErr is called when a resource fails to close and the returnedErr pointer is nil.
+// The caller must already have reported the error (see reportCloseFailure).
+func handleNilReturnedErr(err error, action string, resourceName string) {
+	switch currentNilErrorPolicy() {
+	case NilErrorLog:
+		// The caller has already written the error to the event log and passed it to the observer
+		return
+	case NilErrorPanic:
+		fallthrough
+	default:
+		panic(
+			fmt.Errorf(
+				"errclose: got nil returnedErr pointer when trying to %s: %w",
+				describeTeardown(action, resourceName),
+				err,
+			),
+		)
+	}
+}
+
+// handleNilCollectedErr is called by [Collector.Into] and [ErrorSink.Into] when the returnedErr
+// pointer is nil. unreported is the part of the collected errors that was added directly, instead
+// of from closing resources, and so hasn't been written to the event log or passed to the observer.
+// It's not reported to metrics, since it's not a close error.
+func handleNilCollectedErr(err error, unreported error) {
+	if unreported != nil && currentNilErrorPolicy() == NilErrorLog {
+		logEvent(eventCloseFailed, "", unreported)
+		observe("", unreported)
+	}
+
+	handleNilReturnedErr(err, "return collected errors", "")
+}
+
+func currentNilErrorPolicy() NilErrorPolicy {
+	if strict.Load() {
+		return NilErrorPanic
+	}
+	return NilErrorPolicy(nilErrorPolicy.Load())
+}
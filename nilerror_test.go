@@ -0,0 +1,106 @@
+package errclose_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestNilErrorPanic(t *testing.T) {
+	file := openFileWithCloseError()
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		errclose.Close(file, nil, "file")
+	}()
+
+	err, ok := recovered.(error)
+	assertEqual(t, ok, true, "panic value is error")
+	assertEqual(
+		t,
+		err.Error(),
+		"errclose: got nil returnedErr pointer when trying to close file: close error",
+		"panic error string",
+	)
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is result")
+}
+
+func TestNilErrorLog(t *testing.T) {
+	var buffer bytes.Buffer
+	errclose.SetEventLog(&buffer)
+	errclose.SetNilErrorPolicy(errclose.NilErrorLog)
+	defer func() {
+		errclose.SetEventLog(nil)
+		errclose.SetNilErrorPolicy(errclose.NilErrorPanic)
+	}()
+
+	file := openFileWithCloseError()
+	errclose.Close(file, nil, "file")
+
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+	assertEqual(
+		t,
+		strings.HasSuffix(buffer.String(), ` resource="file" error="close error"`+"\n"),
+		true,
+		"event log contains close error",
+	)
+}
+
+func TestNilErrorWithoutCloseError(t *testing.T) {
+	file := openFileWithoutCloseError()
+	errclose.Close(file, nil, "file")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+}
+
+func TestNilErrorLogObserver(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilErrorPolicy(errclose.NilErrorLog)
+
+	var observed []string
+	errclose.SetObserver(func(resourceName string, closeErr error) {
+		observed = append(observed, resourceName+": "+closeErr.Error())
+	})
+
+	errclose.Close(openFileWithCloseError(), nil, "file")
+	assertEqual(t, observed, []string{"file: close error"}, "observed errors")
+}
+
+func TestNilErrorLogCollectedErrors(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilErrorPolicy(errclose.NilErrorLog)
+
+	var observed []string
+	errclose.SetObserver(func(resourceName string, closeErr error) {
+		observed = append(observed, resourceName+": "+closeErr.Error())
+	})
+
+	var collector errclose.Collector
+	collector.Close(openFileWithCloseError(), "file")
+	collector.Add(errors.New("failed to remove temporary file"))
+	collector.Into(nil)
+
+	var sink errclose.ErrorSink
+	sink.Close(openFileWithCloseError(), "connection")
+	sink.Set(errors.New("copy failed"))
+	sink.Into(nil)
+
+	assertEqual(
+		t,
+		observed,
+		[]string{
+			"file: close error",
+			": failed to remove temporary file",
+			"connection: close error",
+			": copy failed",
+		},
+		"observed errors",
+	)
+}
+
+func TestWrapfWithNilPointer(t *testing.T) {
+	errclose.Wrapf(nil, "loading config")
+}
@@ -0,0 +1,81 @@
+package errclose
+
+import (
+	"sync/atomic"
+)
+
+// NilResourcePolicy controls how [errclose.Close] and [errclose.Closef] handle nil resources. Set
+// it with [errclose.SetNilResourcePolicy].
+//
+// This is useful for defensive defers placed before a resource is fully initialized, where the
+// resource may still be nil when the function returns.
+type NilResourcePolicy int32
+
+const (
+	// NilResourceError sets the close error to [errclose.ErrNilResource] if the resource is nil.
+	// A typed nil pointer in the resource interface is not considered nil, so its Close method is
+	// called as usual (some types, such as [os.File], return an error from Close on a nil
+	// receiver). This is the default.
+	NilResourceError NilResourcePolicy = iota
+	// NilResourceStrict works like NilResourceError, but also treats typed nil values in the
+	// resource interface (such as a nil *os.File) as nil resources, without calling their Close
+	// method. This prevents panics from Close methods that don't handle nil receivers.
+	NilResourceStrict
+	// NilResourceSkip silently skips nil resources (including typed nil values, as in
+	// NilResourceStrict), leaving the error pointed to by returnedErr unchanged.
+	NilResourceSkip
+)
+
+var nilResourcePolicy atomic.Int32
+
+// SetNilResourcePolicy sets how [errclose.Close] and [errclose.Closef] handle nil resources (see
+// [errclose.NilResourcePolicy]). The policy also applies to the other functions in the package that
+// take resources, such as [Frame.Err], [errclose.CloseAll] and [errclose.FlushAndClose], and to
+// closers that return the nil resource error instead of handling it, such as
+// [errclose.MultiCloser] (which then return nil under NilResourceSkip). For teardown operations
+// other than closing, such as [errclose.Shutdown] or [errclose.Do], the nil resource error is
+// labeled with the operation, e.g.:
+//
+//	failed to shut down <resourceName>: nil resource
+//
+// Checking for typed nil values uses reflection, but only when the policy is NilResourceStrict or
+// NilResourceSkip, so the default policy doesn't affect performance.
+func SetNilResourcePolicy(policy NilResourcePolicy) {
+	nilResourcePolicy.Store(int32(policy))
+}
+
+// isNilResource returns true if the given resource should be treated as nil, according to the nil
+// resource policy. It takes any value, so that it can also check resources with other teardown
+// methods than Close. Func values must be compared to nil by the caller, since a nil func in an
+// interface is only considered nil under NilResourceStrict and NilResourceSkip.
+func isNilResource(resource any) bool {
+	if resource == nil {
+		return true
+	}
+	return NilResourcePolicy(nilResourcePolicy.Load()) != NilResourceError && isNil(resource)
+}
+
+// handleNilResource handles a resource for which isNilResource returned true, according to the nil
+// resource policy.
+func handleNilResource(returnedErr *error, resourceName string) {
+	handleNilTeardown(returnedErr, "close", resourceName)
+}
+
+// handleNilTeardown works like handleNilResource, but for teardown operations other than closing,
+// labeled with the given action like in handleTeardownError.
+func handleNilTeardown(returnedErr *error, action string, resourceName string) {
+	if NilResourcePolicy(nilResourcePolicy.Load()) == NilResourceSkip {
+		return
+	}
+	recordCloseAttempt(resourceName)
+	handleTeardownError(returnedErr, ErrNilResource, action, resourceName)
+}
+
+// nilResourceError returns the close error for a nil resource, for closers that return their close
+// error instead of handling it: nil under NilResourceSkip, and ErrNilResource otherwise.
+func nilResourceError() error {
+	if NilResourcePolicy(nilResourcePolicy.Load()) == NilResourceSkip {
+		return nil
+	}
+	return ErrNilResource
+}
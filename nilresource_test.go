@@ -0,0 +1,117 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestNilResourceError(t *testing.T) {
+	var file *panickingCloser
+
+	err := errors.New("existing error")
+	func() {
+		defer func() {
+			assertEqual(t, recover(), "nil receiver", "recovered value")
+		}()
+		errclose.Close(file, &err, "file")
+	}()
+}
+
+func TestNilResourceStrict(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceStrict)
+
+	var file *panickingCloser
+	var err error
+	errclose.Close(file, &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: nil resource", "error string")
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "errors.Is(err, ErrNilResource)")
+
+	err = nil
+	errclose.Closef(file, &err, "file %d", 2)
+	assertEqual(t, err.Error(), "failed to close file 2: nil resource", "Closef error string")
+}
+
+func TestNilResourceSkip(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceSkip)
+
+	var file *panickingCloser
+	var err error
+	errclose.Close(file, &err, "file")
+	errclose.Close(nil, &err, "file")
+	errclose.Closef(nil, &err, "file %d", 2)
+	assertEqual(t, err, nil, "error")
+}
+
+func TestNilResourceStrictClosesNonNil(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceStrict)
+
+	file := openFileWithCloseError()
+	var err error
+	errclose.Close(file, &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestNilResourceSkipInHelpers(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceSkip)
+
+	var file *panickingCloser
+	var err error
+	errclose.CloseWithRetry(file, &err, "file", errclose.Retry{Attempts: 2, Backoff: 0, If: nil})
+	errclose.CloseWithTimeout(file, &err, "file", time.Second)
+	errclose.SyncClose(nil, &err, "file")
+	errclose.FlushAndClose(nil, &err, "writer")
+	errclose.DrainAndClose(nil, &err, "response body")
+	errclose.Shutdown(context.Background(), nil, &err, "server")
+	errclose.Do(nil, &err, "flush cache")
+	errclose.CloseAndLog(file, nil, slog.LevelError, "file")
+	errclose.MustClose(file, "file")
+	err = errors.Join(err, errclose.MultiCloser(file, nil).Close())
+	assertEqual(t, err, nil, "error")
+}
+
+func TestNilResourceStrictInHelpers(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilResourcePolicy(errclose.NilResourceStrict)
+
+	var file *panickingCloser
+	var server *panickingShutdowner
+	var err error
+	errclose.CloseWithRetry(file, &err, "file", errclose.Retry{Attempts: 2, Backoff: 0, If: nil})
+	errclose.Shutdown(context.Background(), server, &err, "server")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file: nil resource (and failed to shut down server: nil resource)",
+		"error string",
+	)
+
+	err = errclose.MultiCloser(file).Close()
+	assertEqual(t, errors.Is(err, errclose.ErrNilResource), true, "MultiCloser error")
+}
+
+type panickingShutdowner struct{}
+
+func (shutdowner *panickingShutdowner) Shutdown(context.Context) error {
+	if shutdowner == nil {
+		panic("nil receiver")
+	}
+	return nil
+}
+
+type panickingCloser struct{}
+
+func (closer *panickingCloser) Close() error {
+	if closer == nil {
+		panic("nil receiver")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package errclose
+
+import (
+	"sync/atomic"
+)
+
+var observer atomic.Pointer[func(resourceName string, closeErr error)]
+
+// SetObserver sets a function to be called for every close error handled by this package, with
+// the resource name and the unwrapped close error. This lets you count or log close failures across
+// a codebase from one place, without changing every call site:
+//
+//	errclose.SetObserver(func(resourceName string, closeErr error) {
+//		closeFailures.WithLabelValues(resourceName).Inc()
+//	})
+//
+// The observer is called for the same close errors that are written to the event log as
+// close_failed events (see [errclose.SetEventLog]), and for close errors from resources closed by
+// leak cleanups (see [errclose.CloseOrCleanup]). It's not called for close errors dropped by
+// [errclose.Ignore], but it is for those dropped by [errclose.IgnoreIf]. If close diagnostics are
+// enabled, it's also called with a [errclose.SlowCloseError] for slow closes (see
+// [errclose.SetCloseDiagnostics]). For other teardown errors, such as from [Started.Stop], the
+// resource name is the same as in the event log. If the close error has a trace ID (see
+// [errclose.SetTraceIDExtractor]), it can be read from the close error with [errclose.TraceID],
+// and labels given with [errclose.WithLabels] can be read with [errclose.Labels].
+//
+// The observer may be called concurrently, if resources are closed concurrently. Pass nil to
+// remove the observer (this is the default).
+func SetObserver(observe func(resourceName string, closeErr error)) {
+	if observe == nil {
+		observer.Store(nil)
+	} else {
+		observer.Store(&observe)
+	}
+}
+
+// reportCloseFailure writes a close_failed event to the event log, calls the observer set by
+// [errclose.SetObserver], and reports the failure to the metrics set by [errclose.SetMetrics].
+func reportCloseFailure(resourceName string, closeErr error) {
+	logEvent(eventCloseFailed, resourceName, closeErr)
+	observe(resourceName, closeErr)
+	recordCloseFailure(resourceName, closeErr)
+}
+
+// reportWrappedCloseFailure works like reportCloseFailure, but takes the wrapped close error, so
+// that its trace ID and resource ID are included in the event log entry and passed to the
+// observer.
+func reportWrappedCloseFailure(closeErr *CloseError) {
+	logWrappedCloseFailure(closeErr)
+	recordCloseFailure(closeErr.ResourceName, withHookDetails(closeErr.Err, "", 0, closeErr.Labels))
+}
+
+// logWrappedCloseFailure works like reportWrappedCloseFailure, but without recording the failure
+// in the metrics, for callers that count failures themselves.
+func logWrappedCloseFailure(closeErr *CloseError) {
+	logEventWithDetails(
+		eventCloseFailed,
+		closeErr.ResourceName,
+		closeErr.Err,
+		closeErr.TraceID,
+		closeErr.ResourceID,
+	)
+	observe(
+		closeErr.ResourceName,
+		withHookDetails(closeErr.Err, closeErr.TraceID, closeErr.ResourceID, closeErr.Labels),
+	)
+}
+
+func observe(resourceName string, closeErr error) {
+	if observe := observer.Load(); observe != nil {
+		(*observe)(resourceName, closeErr)
+	}
+}
@@ -0,0 +1,69 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestObserver(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	var observed []string
+	errclose.SetObserver(func(resourceName string, closeErr error) {
+		observed = append(observed, resourceName+": "+closeErr.Error())
+	})
+
+	useFiles := func() (returnedErr error) {
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file 1")
+		defer errclose.Close(
+			&mockFile{closeWasCalled: false, closeError: os.ErrClosed},
+			&returnedErr,
+			"file 2",
+			errclose.Ignore(os.ErrClosed),
+		)
+		defer errclose.Closef(openFileWithoutCloseError(), &returnedErr, "file %d", 3)
+		return nil
+	}
+	_ = useFiles()
+
+	started, err := errclose.StartStop(
+		func() error { return nil },
+		func() error { return errors.New("stop error") },
+		"worker",
+	)
+	assertEqual(t, err, nil, "error from StartStop")
+	var stopErr error
+	started.Stop(&stopErr)
+
+	errclose.CloseAndLog(
+		openFileWithCloseError(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		slog.LevelWarn,
+		"logged file",
+	)
+
+	errclose.DeferGlobal(openFileWithCloseError(), "global file")
+	_ = errclose.ShutdownGlobal(context.Background())
+
+	assertEqual(
+		t,
+		observed,
+		[]string{
+			"file 1: close error",
+			"worker: stop error",
+			"logged file: close error",
+			"global file: close error",
+		},
+		"observed errors",
+	)
+
+	errclose.SetObserver(nil)
+	_ = useFiles()
+	assertEqual(t, len(observed), 4, "number of observed errors after removing observer")
+}
@@ -0,0 +1,502 @@
+package errclose
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Option changes how [errclose.Close] handles close errors. Options are passed as trailing
+// arguments to Close:
+//
+//	defer errclose.Close(conn, &returnedErr, "connection", errclose.Ignore(net.ErrClosed))
+//
+// Options compose, so you can pass several of them to the same call. To pass options along with
+// format args for the resource name, use [errclose.ClosefWith]. The zero Option has no effect.
+type Option struct {
+	settings *optionSettings
+}
+
+// optionSettings holds the setting of an [errclose.Option]. Each option constructor sets only its
+// own field, so the type is excluded from the exhaustruct linter (see .golangci.yml).
+type optionSettings struct {
+	ignore   []error
+	ignoreIf func(closeErr error) bool
+	also     func(resourceName string, closeErr error)
+	stats    func() any
+	// Maximum number of bytes for DrainAndClose to read, 0 if unset, or unlimitedDrain
+	maxDrain      int64
+	recoverPanics bool
+	opaque        bool
+	caller        bool
+	// Options for CloseConn
+	halfClose     bool
+	closeDeadline time.Duration
+	keepPrimary   bool
+	slowClose     *slowCloseHook
+	debugLog      *debugLogHook
+	fatal         bool
+	osDetail      bool
+	fallback      func(closeErr error) error
+	warn          func(closeErr *CloseError)
+	// Internal option from withReportFilter, for summarizing repeated failures
+	reportFilter func(closeErr *CloseError) bool
+	// Internal option from contextOptions, which extracts the trace ID if the close fails
+	traceID func() string
+	// Internal option from closeWithContext, for counting the steps of a close as one attempt
+	attempt    *closeAttempt
+	labels     func() map[string]string
+	resourceID ResourceID
+}
+
+// noSettings is returned by Option.get for the zero Option, which has no effect.
+var noSettings optionSettings
+
+func (option Option) get() *optionSettings {
+	if option.settings == nil {
+		return &noSettings
+	}
+	return option.settings
+}
+
+var defaultOptions atomic.Pointer[[]Option]
+
+// SetDefaults sets options that apply to every call in the package that takes options, such as
+// [errclose.Close] and [errclose.Closef]. This lets an application set its close error policy in
+// one place, instead of repeating options at every call site:
+//
+//	errclose.SetDefaults(
+//		errclose.Ignore(net.ErrClosed),
+//		errclose.Also(func(resourceName string, closeErr error) {
+//			closeFailures.WithLabelValues(resourceName).Inc()
+//		}),
+//	)
+//
+// Default options are applied before the options given to each call, so for options where only
+// the last one is used (such as [errclose.Stats] and [errclose.MaxDrain]), options given to a call
+// override the defaults. Other options add to the defaults: errors ignored by default are still
+// ignored when a call gives its own [errclose.Ignore] option, and report functions from both
+// default and per-call [errclose.Also] options are called.
+//
+// To set defaults for a specific type of resource, use [errclose.SetDefaultsFor]. Call SetDefaults
+// with no options to remove the defaults (this is the default).
+func SetDefaults(options ...Option) {
+	if len(options) == 0 {
+		defaultOptions.Store(nil)
+	} else {
+		options = slices.Clone(options)
+		defaultOptions.Store(&options)
+	}
+}
+
+// optionLists are the options that apply to a call: the options set by [errclose.SetDefaults],
+// the options set by [errclose.SetDefaultsFor] for the type of the resource, and the options given
+// to the call, as lists to go through in order. The helpers that look up options take these,
+// instead of the lists being merged into one slice, since copying per-call options into a new
+// slice would make them escape to the heap.
+type optionLists [3][]Option
+
+// withDefaults returns the option lists for closing the given resource with the given options.
+// The resource is only used to look up defaults for its type, and may be nil for teardowns without
+// a resource.
+func withDefaults(resource any, options []Option) optionLists {
+	var defaults []Option
+	if stored := defaultOptions.Load(); stored != nil {
+		defaults = *stored
+	}
+	return optionLists{defaults, typeDefaultsFor(resource), options}
+}
+
+// Ignore returns an option that makes [errclose.Close] drop close errors that match any of the
+// given errors (checked with [errors.Is]), instead of setting or combining them with the error
+// pointed to by returnedErr. This is useful for benign close errors, such as when a connection was
+// already closed elsewhere:
+//
+//	defer errclose.Close(
+//		conn,
+//		&returnedErr,
+//		"connection",
+//		errclose.Ignore(net.ErrClosed, os.ErrClosed),
+//	)
+//
+// Ignored close errors are not written to the event log (see [errclose.SetEventLog]).
+func Ignore(errs ...error) Option {
+	return Option{settings: &optionSettings{ignore: errs}}
+}
+
+// IgnoreIf returns an option that makes [errclose.Close] drop close errors for which the given
+// predicate returns true, like [errclose.Ignore] does for matching errors. This is for benign close
+// errors that can't be matched against a fixed error, such as errors with a driver-specific code:
+//
+//	defer errclose.Close(
+//		conn,
+//		&returnedErr,
+//		"database connection",
+//		errclose.IgnoreIf(func(err error) bool {
+//			var pgErr *pgconn.PgError
+//			return errors.As(err, &pgErr) && pgErr.Code == "57P01" // admin_shutdown
+//		}),
+//	)
+//
+// Since a predicate may match more than intended, close errors dropped by IgnoreIf are still
+// passed to the observer (see [errclose.SetObserver]), so they remain visible. Unlike errors
+// dropped by Ignore, they're not written to the event log.
+func IgnoreIf(predicate func(closeErr error) bool) Option {
+	return Option{settings: &optionSettings{ignoreIf: predicate}}
+}
+
+// isIgnored returns true if the given close error should be dropped, according to the
+// [errclose.Ignore] and [errclose.IgnoreIf] options in the given options, or the functions
+// registered with [errclose.RegisterBenign]. Errors dropped by IgnoreIf or as benign are passed to
+// the observer.
+func isIgnored(closeErr error, resourceName string, options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			for _, ignored := range option.get().ignore {
+				if errors.Is(closeErr, ignored) {
+					recordCloseIgnored(resourceName, withLabelsFromOptions(closeErr, options))
+					return true
+				}
+			}
+			if ignoreIf := option.get().ignoreIf; ignoreIf != nil && ignoreIf(closeErr) {
+				reportIgnored(resourceName, closeErr, options)
+				return true
+			}
+		}
+	}
+	if isBenign(closeErr) {
+		reportIgnored(resourceName, closeErr, options)
+		return true
+	}
+	return false
+}
+
+// reportIgnored passes a close error dropped by [errclose.IgnoreIf] or as benign to the observer
+// and metrics.
+func reportIgnored(resourceName string, closeErr error, options optionLists) {
+	labels := labelsFromOptions(options)
+	observe(
+		resourceName,
+		withHookDetails(closeErr, "", resourceIDFromOptions(options), labels),
+	)
+	recordCloseIgnored(resourceName, withHookDetails(closeErr, "", 0, labels))
+}
+
+// withLabelsFromOptions returns the given close error for passing to metrics, wrapped to carry the
+// labels from the given options if there are any.
+func withLabelsFromOptions(closeErr error, options optionLists) error {
+	return withHookDetails(closeErr, "", 0, labelsFromOptions(options))
+}
+
+// Also returns an option that makes [errclose.Close] pass close errors to the given report
+// function, in addition to setting or combining them with the error pointed to by returnedErr.
+// This is useful for critical resources, where you want to both propagate close errors and observe
+// them in a logger, metrics or channel:
+//
+//	defer errclose.Close(
+//		wal,
+//		&returnedErr,
+//		"write-ahead log",
+//		errclose.Also(func(resourceName string, closeErr error) {
+//			walCloseFailures.Inc()
+//		}),
+//	)
+//
+// The report function gets the unwrapped close error (without the resource name). It's not called
+// for close errors dropped by [errclose.Ignore]. If multiple Also options are given, the report
+// functions are called in order.
+func Also(report func(resourceName string, closeErr error)) Option {
+	return Option{settings: &optionSettings{also: report}}
+}
+
+// reportAlso calls the report functions given with [errclose.Also] in the given options.
+func reportAlso(options optionLists, resourceName string, closeErr error) {
+	for _, list := range options {
+		for _, option := range list {
+			if report := option.get().also; report != nil {
+				report(resourceName, closeErr)
+			}
+		}
+	}
+}
+
+// Stats returns an option that makes [errclose.Close] take a snapshot of the resource's statistics
+// by calling the given function right before closing the resource. If the close fails, the
+// snapshot is attached to the close error, in the Stats field of [errclose.CloseError]. This is
+// useful for resources with a Stats method, such as [sql.DB], where the state at close time can
+// explain the failure:
+//
+//	defer errclose.Close(db, &returnedErr, "database", errclose.Stats(func() any {
+//		return db.Stats()
+//	}))
+//
+// The snapshot is taken before closing, since many resources reset their statistics when closed,
+// so the stats function is called also when the close succeeds. If multiple Stats options are
+// given, the last one is used.
+func Stats(snapshot func() any) Option {
+	return Option{settings: &optionSettings{stats: snapshot}}
+}
+
+// captureStats calls the last stats function given with [errclose.Stats] in the given options, if
+// any.
+func captureStats(options optionLists) any {
+	var stats func() any
+	for _, list := range options {
+		for _, option := range list {
+			if snapshot := option.get().stats; snapshot != nil {
+				stats = snapshot
+			}
+		}
+	}
+	if stats == nil {
+		return nil
+	}
+	return stats()
+}
+
+// RecoverPanics returns an option that makes [errclose.Close] recover panics from the resource's
+// Close method, and handle them as close errors. This is for third-party resources that panic when
+// closed in a bad state (e.g. when closed twice), where the panic would otherwise escape the defer
+// and replace the error your function was returning:
+//
+//	defer errclose.Close(client, &returnedErr, "client", errclose.RecoverPanics())
+//
+// The panic value is converted to an error on the following format (if the panic value is an
+// error, it's wrapped, so it can be checked with [errors.Is] and [errors.As]):
+//
+//	failed to close <resourceName>: panic: <panic value>
+func RecoverPanics() Option {
+	return Option{settings: &optionSettings{recoverPanics: true}}
+}
+
+// closeWithOptions calls Close on the given resource, recovering panics if the options include
+// [errclose.RecoverPanics], timing the close if they include [errclose.OnSlowClose], and logging
+// the close if they include [errclose.WithDebugLog]. The resource name is only used for those
+// last two, and is resolved with resolveResourceName.
+func closeWithOptions[Resource interface{ Close() error }](
+	resource Resource,
+	resourceName string,
+	options optionLists,
+) error {
+	recoverPanics := false
+	var slowClose *slowCloseHook
+	var debugLog *debugLogHook
+	for _, list := range options {
+		for _, option := range list {
+			recoverPanics = recoverPanics || option.get().recoverPanics
+			if hook := option.get().slowClose; hook != nil {
+				slowClose = hook
+			}
+			if hook := option.get().debugLog; hook != nil {
+				debugLog = hook
+			}
+		}
+	}
+
+	if slowClose == nil && debugLog == nil {
+		if recoverPanics {
+			return closeRecoveringPanics(resource)
+		}
+		return resource.Close()
+	}
+
+	start := time.Now()
+	if slowClose != nil {
+		defer func() {
+			if took := time.Since(start); took >= slowClose.threshold {
+				slowClose.report(resolveResourceName(resource, resourceName), took)
+			}
+		}()
+	}
+
+	var closeErr error
+	if recoverPanics {
+		closeErr = closeRecoveringPanics(resource)
+	} else {
+		closeErr = resource.Close()
+	}
+	if debugLog != nil && closeErr == nil {
+		debugLog.log(resolveResourceName(resource, resourceName), time.Since(start))
+	}
+	return closeErr
+}
+
+func closeRecoveringPanics[Resource interface{ Close() error }](
+	resource Resource,
+) (returnedErr error) {
+	defer recoverAsError(&returnedErr)
+
+	return resource.Close()
+}
+
+// Opaque returns an option that makes [errclose.Close] include the close error in the error
+// message, without wrapping it. The close error then can't be matched with [errors.Is] or
+// [errors.As], which is useful at API boundaries where you don't want callers to depend on the
+// sentinel errors of the resource's internals (such as a database driver):
+//
+//	defer errclose.Close(rows, &returnedErr, "query result", errclose.Opaque())
+//
+// The error message is the same as without the option. The [errclose.CloseError] with the
+// resource name is still part of the error chain, but its Err field is an opaque error with the
+// same message as the close error. Report functions given with [errclose.Also] get the original
+// close error.
+func Opaque() Option {
+	return Option{settings: &optionSettings{opaque: true}}
+}
+
+func isOpaque(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().opaque {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithCaller returns an option that makes [errclose.Close] record the file and line of the Close
+// call on close errors, in the Caller field of [errclose.CloseError]. When a close error surfaces
+// far from where it happened, the resource name alone may not be enough to find the call site:
+//
+//	defer errclose.Close(file, &returnedErr, "file", errclose.WithCaller())
+//
+// The caller is only looked up if the close fails, so the option doesn't slow down successful
+// closes.
+func WithCaller() Option {
+	return Option{settings: &optionSettings{caller: true}}
+}
+
+func hasCaller(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().caller {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleCloseErrorWithOptions works like handleCloseError, but applies the given options, and
+// attaches the given stats (from captureStats) to the close error. callerSkip is the number of
+// stack frames between this function and the caller of errclose.Close, for [errclose.WithCaller].
+func handleCloseErrorWithOptions(
+	returnedErr *error,
+	closeErr error,
+	resourceName string,
+	options optionLists,
+	stats any,
+	callerSkip int,
+) {
+	handleTeardownErrorWithOptions(
+		returnedErr,
+		closeErr,
+		"close",
+		resourceName,
+		options,
+		stats,
+		callerSkip+1,
+	)
+}
+
+// handleTeardownErrorWithOptions works like handleCloseErrorWithOptions, but lets the caller choose
+// the action in the error message, like handleTeardownError. This is for the steps that helpers
+// taking options run before closing (such as draining in [errclose.DrainAndClose]), so that the
+// options apply to them as well.
+func handleTeardownErrorWithOptions(
+	returnedErr *error,
+	closeErr error,
+	action string,
+	resourceName string,
+	options optionLists,
+	stats any,
+	callerSkip int,
+) {
+	if isIgnored(closeErr, resourceName, options) {
+		return
+	}
+
+	handleTracedTeardownError(
+		returnedErr,
+		closeErr,
+		action,
+		resourceName,
+		options,
+		stats,
+		traceIDFromOptions(options),
+		callerSkip+1,
+	)
+}
+
+// handleTracedTeardownError implements handleTeardownErrorWithOptions for close errors that have
+// been checked with isIgnored, tagging them with the given trace ID.
+func handleTracedTeardownError(
+	returnedErr *error,
+	closeErr error,
+	action string,
+	resourceName string,
+	options optionLists,
+	stats any,
+	traceID string,
+	callerSkip int,
+) {
+	labels := labelsFromOptions(options)
+	resourceID := resourceIDFromOptions(options)
+	reportAlso(options, resourceName, withHookDetails(closeErr, traceID, resourceID, labels))
+	fallbackErr := runFallbacks(options, closeErr)
+
+	wrapped := newCloseError(closeErr, action, resourceName)
+	wrapped.Stats = stats
+	wrapped.TraceID = traceID
+	wrapped.Labels = labels
+	wrapped.ResourceID = resourceID
+	if isOpaque(options) {
+		wrapped.Err = errors.New(wrapped.Err.Error())
+	}
+	if hasCaller(options) {
+		if _, file, line, ok := runtime.Caller(callerSkip + 1); ok {
+			wrapped.Caller = file + ":" + strconv.Itoa(line)
+		}
+	}
+	if isFatal(options) {
+		wrapped.fatal = true
+	}
+	if hasOSDetail(options) {
+		wrapped.OSDetail = osDetailOf(closeErr)
+	}
+	handleCloseErrorKeepingPrimary(returnedErr, wrapped, options)
+
+	if fallbackErr != nil {
+		fallbackCloseErr := newCloseError(fallbackErr, "run fallback for", resourceName)
+		fallbackCloseErr.TraceID = traceID
+		fallbackCloseErr.Labels = labels
+		fallbackCloseErr.ResourceID = resourceID
+		reportFallbackFailure(fallbackCloseErr, options)
+		setCloseErrorKeepingPrimary(returnedErr, fallbackCloseErr, options)
+	}
+}
+
+// handleCloseErrorKeepingPrimary works like handleWrappedError, but attaches the close error to
+// the existing error instead of combining them if the [errclose.KeepPrimary] option is set, and
+// handles it as a warning if the [errclose.WarnOnly] option is set and there is no existing error.
+func handleCloseErrorKeepingPrimary(returnedErr *error, closeErr *CloseError, options optionLists) {
+	reportCloseFailureWithOptions(closeErr, options)
+	setCloseErrorKeepingPrimary(returnedErr, closeErr, options)
+}
+
+// setCloseErrorKeepingPrimary sets the close error on returnedErr like
+// handleCloseErrorKeepingPrimary, without reporting it.
+func setCloseErrorKeepingPrimary(returnedErr *error, closeErr *CloseError, options optionLists) {
+	if warnIfOnlyCloseFailed(returnedErr, closeErr, options) {
+		return
+	}
+	if hasKeepPrimary(options) && returnedErr != nil && *returnedErr != nil {
+		*returnedErr = attachCloseError(*returnedErr, closeErr)
+		return
+	}
+	combineWrappedError(returnedErr, closeErr)
+}
@@ -0,0 +1,372 @@
+package errclose_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestIgnore(t *testing.T) {
+	conn := &mockFile{
+		closeWasCalled: false,
+		closeError:     &net.OpError{Op: "close", Net: "tcp", Err: net.ErrClosed},
+	}
+
+	useConn := func() (returnedErr error) {
+		defer errclose.Close(
+			conn,
+			&returnedErr,
+			"connection",
+			errclose.Ignore(net.ErrClosed, os.ErrClosed),
+		)
+		return nil
+	}
+
+	err := useConn()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, conn.closeWasCalled, true, "conn.closeWasCalled")
+}
+
+func TestIgnoreWithOtherError(t *testing.T) {
+	file := openFileWithCloseError()
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(file, &returnedErr, "file", errclose.Ignore(os.ErrClosed))
+		return fallibleOperation()
+	}
+
+	err := useFile()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is(closeError)")
+}
+
+func TestAlso(t *testing.T) {
+	file := openFileWithCloseError()
+	var reported []string
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(
+			file,
+			&returnedErr,
+			"file",
+			errclose.Also(func(resourceName string, closeErr error) {
+				reported = append(reported, resourceName+": "+closeErr.Error())
+			}),
+		)
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, reported, []string{"file: close error"}, "reported errors")
+}
+
+func TestAlsoWithIgnore(t *testing.T) {
+	file := &mockFile{closeWasCalled: false, closeError: os.ErrClosed}
+	reported := false
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(
+			file,
+			&returnedErr,
+			"file",
+			errclose.Ignore(os.ErrClosed),
+			errclose.Also(func(string, error) { reported = true }),
+		)
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, reported, false, "reported")
+}
+
+func TestStats(t *testing.T) {
+	type poolStats struct{ InUse int }
+	pool := openFileWithCloseError()
+	snapshots := 0
+
+	usePool := func() (returnedErr error) {
+		defer errclose.Close(pool, &returnedErr, "pool", errclose.Stats(func() any {
+			snapshots++
+			return poolStats{InUse: 12}
+		}))
+		return fallibleOperation()
+	}
+
+	err := usePool()
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As(CloseError)")
+	assertEqual(t, closeErr.Stats, poolStats{InUse: 12}, "CloseError.Stats")
+	assertEqual(t, snapshots, 1, "number of snapshots")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close pool: close error)",
+		"error string",
+	)
+}
+
+func TestRecoverPanics(t *testing.T) {
+	panicValue := errors.New("closed twice")
+	client := closerFunc(func() error { panic(panicValue) })
+
+	useClient := func() (returnedErr error) {
+		defer errclose.Close(client, &returnedErr, "client", errclose.RecoverPanics())
+		return fallibleOperation()
+	}
+
+	err := useClient()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close client: panic: closed twice)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, panicValue), true, "errors.Is(err, panicValue)")
+}
+
+func TestRecoverPanicsWithoutPanic(t *testing.T) {
+	file := openFileWithCloseError()
+	var err error
+	errclose.Close(file, &err, "file", errclose.RecoverPanics())
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestOpaque(t *testing.T) {
+	var alsoErr error
+	file := &mockFile{closeWasCalled: false, closeError: os.ErrClosed}
+
+	var err error
+	errclose.Close(
+		file,
+		&err,
+		"file",
+		errclose.Opaque(),
+		errclose.Also(func(resourceName string, closeErr error) { alsoErr = closeErr }),
+	)
+
+	assertEqual(t, err.Error(), "failed to close file: file already closed", "error string")
+	assertEqual(t, errors.Is(err, os.ErrClosed), false, "errors.Is(err, os.ErrClosed)")
+	assertEqual(t, alsoErr, os.ErrClosed, "error passed to Also")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As(err, &closeErr)")
+	assertEqual(t, closeErr.ResourceName, "file", "CloseError.ResourceName")
+}
+
+func TestWithCaller(t *testing.T) {
+	var err error
+	_, _, line, _ := runtime.Caller(0)
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.WithCaller())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As(err, &closeErr)")
+	assertEqual(
+		t,
+		strings.HasSuffix(closeErr.Caller, "options_test.go:"+strconv.Itoa(line+1)),
+		true,
+		"CloseError.Caller ("+closeErr.Caller+")",
+	)
+}
+
+func TestWithCallerAndDebugEvents(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDebugEvents(true)
+
+	var err error
+	_, _, line, _ := runtime.Caller(0)
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.WithCaller())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As(err, &closeErr)")
+	assertEqual(
+		t,
+		strings.HasSuffix(closeErr.Caller, "options_test.go:"+strconv.Itoa(line+1)),
+		true,
+		"CloseError.Caller ("+closeErr.Caller+")",
+	)
+}
+
+func TestZeroOption(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.Option{})
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestIgnoreIf(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	var observed []string
+	errclose.SetObserver(func(resourceName string, closeErr error) {
+		observed = append(observed, resourceName+": "+closeErr.Error())
+	})
+
+	isBenign := func(err error) bool { return err.Error() == "close error" }
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.IgnoreIf(isBenign))
+	assertEqual(t, err, nil, "error with ignored close error")
+	assertEqual(t, observed, []string{"file: close error"}, "observed errors")
+
+	errclose.Close(
+		closerFunc(func() error { return errors.New("disk full") }),
+		&err,
+		"other file",
+		errclose.IgnoreIf(isBenign),
+	)
+	assertEqual(t, err.Error(), "failed to close other file: disk full", "error string")
+}
+
+func TestKeepPrimary(t *testing.T) {
+	file := openFileWithCloseError()
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(file, &returnedErr, "file", errclose.KeepPrimary())
+		return fallibleOperation()
+	}
+
+	err := useFile()
+	assertEqual(t, err.Error(), "operation failed", "error string")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is primary error")
+	assertEqual(t, errors.Is(err, file.closeError), false, "errors.Is close error")
+
+	closeErr := errclose.CloseErrorFrom(err)
+	assertEqual(t, closeErr.Error(), "failed to close file: close error", "close error string")
+	assertEqual(t, errors.Is(closeErr, file.closeError), true, "errors.Is on attached error")
+}
+
+func TestKeepPrimaryWithMultipleCloseErrors(t *testing.T) {
+	useFiles := func() (returnedErr error) {
+		keepPrimary := errclose.KeepPrimary()
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file 1", keepPrimary)
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file 2", keepPrimary)
+		return fallibleOperation()
+	}
+
+	err := useFiles()
+	assertEqual(t, err.Error(), "operation failed", "error string")
+	assertEqual(t, errclose.CloseErrorFrom(err).ResourceName, "file 2", "first close error")
+}
+
+func TestKeepPrimaryWithoutExistingError(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.KeepPrimary())
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errclose.CloseErrorFrom(err).ResourceName, "file", "close error from chain")
+	assertEqual(t, errclose.CloseErrorFrom(errFallibleOperation) == nil, true, "no close error")
+}
+
+func TestOnSlowClose(t *testing.T) {
+	var reportedName string
+	var reportedTook time.Duration
+	onSlowClose := errclose.OnSlowClose(
+		10*time.Millisecond,
+		func(resourceName string, took time.Duration) {
+			reportedName = resourceName
+			reportedTook = took
+		},
+	)
+
+	slowCloser := closerFunc(func() error {
+		time.Sleep(20 * time.Millisecond)
+		return errors.New("close error")
+	})
+
+	var err error
+	errclose.Close(slowCloser, &err, "writer", onSlowClose)
+	assertEqual(t, err.Error(), "failed to close writer: close error", "error string")
+	assertEqual(t, reportedName, "writer", "reported resource name")
+	assertEqual(t, reportedTook >= 20*time.Millisecond, true, "reported duration")
+
+	reportedName = ""
+	errclose.Close(openFileWithoutCloseError(), &err, "fast file", onSlowClose)
+	assertEqual(t, reportedName, "", "reported resource name for fast close")
+}
+
+func TestOnSlowCloseWithLazyName(t *testing.T) {
+	var reportedName string
+	slowCloser := closerFunc(func() error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	var err error
+	errclose.CloseNameFunc(
+		slowCloser,
+		&err,
+		func() string { return "lazy writer" },
+		errclose.OnSlowClose(time.Millisecond, func(resourceName string, _ time.Duration) {
+			reportedName = resourceName
+		}),
+	)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, reportedName, "lazy writer", "reported resource name")
+}
+
+func TestSetDefaults(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	var reported []string
+	errclose.SetDefaults(
+		errclose.Ignore(os.ErrClosed),
+		errclose.Also(func(resourceName string, _ error) {
+			reported = append(reported, resourceName)
+		}),
+		errclose.Stats(func() any { return "default stats" }),
+	)
+
+	var err error
+	errclose.Close(closerFunc(func() error { return os.ErrClosed }), &err, "closed file")
+	assertEqual(t, err, nil, "error with ignored close error")
+
+	errclose.Closef(openFileWithCloseError(), &err, "file %d", 1)
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file 2",
+		errclose.Stats(func() any { return "call stats" }),
+	)
+	assertEqual(t, reported, []string{"file 1", "file 2"}, "reported resource names")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.Stats, "default stats", "stats from defaults")
+
+	closeErrs := errclose.Details(err).CloseErrors
+	assertEqual(t, len(closeErrs), 2, "number of close errors")
+
+	var secondErr error
+	errclose.Close(
+		openFileWithCloseError(),
+		&secondErr,
+		"file 3",
+		errclose.Stats(func() any { return "call stats" }),
+	)
+	assertEqual(t, errors.As(secondErr, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.Stats, "call stats", "stats from call options")
+}
+
+func TestSetDefaultsDoesNotAllocate(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaults(errclose.Ignore(os.ErrClosed))
+
+	file := openFileWithoutCloseError()
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		errclose.Close(file, &err, "file")
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
@@ -0,0 +1,71 @@
+package errclose
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// OSDetail describes the operating system error behind a close error, exposed on
+// [CloseError.OSDetail] when a resource is closed with the [errclose.WithOSDetail] option.
+type OSDetail struct {
+	// Op is the failed operation, from an [fs.PathError] (e.g. "close") or an [os.SyscallError]
+	// (e.g. "fsync") in the close error's chain. Empty if there is no such error.
+	Op string
+	// Path is the file path from an [fs.PathError] in the close error's chain. Empty if there is no
+	// such error.
+	Path string
+	// Errno is the platform error code (a [syscall.Errno]) in the close error's chain, or nil if
+	// there is none. Compare it with errors.Is, e.g. errors.Is(detail.Errno, syscall.ENOSPC).
+	Errno error
+	// Hint is a short explanation of what the error code typically means when returned by a close
+	// call, for well-known error codes such as EBADF ("descriptor may already have been closed") or
+	// ENOSPC ("data written to the descriptor may not have been stored"). Empty for other errors.
+	Hint string
+}
+
+// WithOSDetail returns an option that extracts structured details from close errors caused by the
+// operating system, and sets them on [CloseError.OSDetail]. This is for telemetry and error
+// handling that needs the failed path, operation or error code without parsing the error message:
+//
+//	defer errclose.Close(file, &returnedErr, "output file", errclose.WithOSDetail())
+//
+// Details are extracted from an [fs.PathError] (the error type returned by [os.File.Close]), an
+// [os.SyscallError] and a [syscall.Errno] in the close error's chain. If the close error contains
+// none of these, OSDetail is left nil. The error message is the same as without the option.
+func WithOSDetail() Option {
+	return Option{settings: &optionSettings{osDetail: true}}
+}
+
+func hasOSDetail(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().osDetail {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// osDetailOf extracts an OSDetail from the given close error, or returns nil if it doesn't contain
+// any OS-level errors.
+func osDetailOf(closeErr error) *OSDetail {
+	detail := OSDetail{Op: "", Path: "", Errno: nil, Hint: ""}
+
+	var pathErr *fs.PathError
+	var syscallErr *os.SyscallError
+	if errors.As(closeErr, &pathErr) {
+		detail.Op = pathErr.Op
+		detail.Path = pathErr.Path
+	} else if errors.As(closeErr, &syscallErr) {
+		detail.Op = syscallErr.Syscall
+	}
+
+	detail.Errno, detail.Hint = closeErrno(closeErr)
+
+	if detail.Op == "" && detail.Path == "" && detail.Errno == nil {
+		return nil
+	}
+	return &detail
+}
@@ -0,0 +1,76 @@
+package errclose_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWithOSDetail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.txt")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var returnedErr error
+	errclose.Close(file, &returnedErr, "output file", errclose.WithOSDetail())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(returnedErr, &closeErr), true, "errors.As result")
+	if closeErr.OSDetail == nil {
+		t.Fatal("expected OSDetail to be set")
+	}
+	assertEqual(t, closeErr.OSDetail.Op, "close", "op")
+	assertEqual(t, closeErr.OSDetail.Path, path, "path")
+	assertEqual(t, closeErr.OSDetail.Errno, nil, "errno")
+	assertEqual(t, closeErr.OSDetail.Hint, "", "hint")
+	assertEqual(t, closeErr.Error(), "failed to close output file: close "+path+
+		": file already closed", "error message")
+}
+
+func TestOSDetailWithSyscallError(t *testing.T) {
+	closer := closerFunc(func() error {
+		return os.NewSyscallError("fsync", errors.New("sync failed"))
+	})
+
+	var err error
+	errclose.Close(closer, &err, "file", errclose.WithOSDetail())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	if closeErr.OSDetail == nil {
+		t.Fatal("expected OSDetail to be set")
+	}
+	assertEqual(t, closeErr.OSDetail.Op, "fsync", "op")
+	assertEqual(t, closeErr.OSDetail.Path, "", "path")
+}
+
+func TestOSDetailNotSetWithoutOption(t *testing.T) {
+	closer := closerFunc(func() error {
+		return &fs.PathError{Op: "close", Path: "/some/path", Err: fs.ErrClosed}
+	})
+
+	var err error
+	errclose.Close(closer, &err, "file")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.OSDetail == nil, true, "OSDetail is nil")
+}
+
+func TestOSDetailNotSetForOtherErrors(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.WithOSDetail())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.OSDetail == nil, true, "OSDetail is nil")
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package errclose_test
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestOSDetailWithErrno(t *testing.T) {
+	testCases := []struct {
+		name  string
+		errno syscall.Errno
+		hint  string
+	}{
+		{"no space", syscall.ENOSPC, "data written to the descriptor may not have been stored"},
+		{"bad descriptor", syscall.EBADF, "descriptor may already have been closed"},
+		{"no hint", syscall.EPERM, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			closer := closerFunc(func() error {
+				return &fs.PathError{Op: "close", Path: "/data/output", Err: testCase.errno}
+			})
+
+			var err error
+			errclose.Close(closer, &err, "output file", errclose.WithOSDetail())
+
+			var closeErr *errclose.CloseError
+			assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+			if closeErr.OSDetail == nil {
+				t.Fatal("expected OSDetail to be set")
+			}
+			assertEqual(t, closeErr.OSDetail.Op, "close", "op")
+			assertEqual(t, closeErr.OSDetail.Path, "/data/output", "path")
+			assertEqual(
+				t,
+				errors.Is(closeErr.OSDetail.Errno, testCase.errno),
+				true,
+				"errors.Is(Errno)",
+			)
+			assertEqual(t, closeErr.OSDetail.Hint, testCase.hint, "hint")
+		})
+	}
+}
+
+func TestOSDetailWithInvalidFD(t *testing.T) {
+	var err error
+	errclose.Close(errclose.FD(-1), &err, "socket", errclose.WithOSDetail())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	if closeErr.OSDetail == nil {
+		t.Fatal("expected OSDetail to be set")
+	}
+	assertEqual(t, errors.Is(closeErr.OSDetail.Errno, syscall.EBADF), true, "errors.Is(Errno)")
+	assertEqual(t, closeErr.OSDetail.Hint, "descriptor may already have been closed", "hint")
+	assertEqual(t, closeErr.OSDetail.Op, "", "op")
+}
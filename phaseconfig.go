@@ -0,0 +1,130 @@
+package errclose
+
+import (
+	"context"
+	"time"
+)
+
+// PhaseConfig configures how a [errclose.ShutdownManager] closes the resources in a shutdown
+// phase. Set it with [ShutdownManager.SetPhaseConfig].
+type PhaseConfig struct {
+	// Parallel makes the manager close all resources in the phase concurrently, instead of one by
+	// one in the reverse order of how they were registered. This is for phases of independent
+	// resources, such as a pool of workers that each take a while to drain.
+	Parallel bool
+	// Timeout bounds how long the phase may take, if greater than 0. The resources in the phase
+	// are given a context with this timeout (derived from the context given to
+	// [ShutdownManager.Shutdown]). Every resource in the phase is still closed when the timeout
+	// is exceeded, but context-aware resources stop waiting, so that later phases get their time.
+	Timeout time.Duration
+}
+
+// SetPhaseConfig configures how the manager closes the resources in the given shutdown phase (see
+// [ShutdownManager.DeferPhase]). Phases without a config are closed sequentially, with no timeout
+// of their own. For example, to drain workers in parallel for at most 10 seconds, and then close
+// stores one by one with 30 seconds in total:
+//
+//	shutdown.SetPhaseConfig(phaseWorkers, errclose.PhaseConfig{
+//		Parallel: true,
+//		Timeout:  10 * time.Second,
+//	})
+//	shutdown.SetPhaseConfig(phaseStores, errclose.PhaseConfig{
+//		Parallel: false,
+//		Timeout:  30 * time.Second,
+//	})
+//
+// If the context given to Shutdown is canceled, Shutdown is interrupted as usual, regardless of
+// the phase timeouts. Close errors from parallel phases are combined in the order of the resource
+// names, as in [errclose.CloseAllConcurrent].
+func (manager *ShutdownManager) SetPhaseConfig(phase int, config PhaseConfig) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if manager.phaseConfigs == nil {
+		manager.phaseConfigs = make(map[int]PhaseConfig)
+	}
+	manager.phaseConfigs[phase] = config
+}
+
+// shutdownPhase is the phase that a call to Shutdown is currently closing.
+type shutdownPhase struct {
+	number  int
+	started bool
+	config  PhaseConfig
+	// The context for closing resources in the phase, with the phase timeout.
+	ctx    context.Context //nolint:containedctx // Scoped to one phase of one Shutdown call
+	cancel context.CancelFunc
+}
+
+func (manager *ShutdownManager) startPhase(ctx context.Context, number int) shutdownPhase {
+	manager.lock.Lock()
+	config := manager.phaseConfigs[number]
+	manager.lock.Unlock()
+
+	phase := shutdownPhase{number: number, started: true, config: config, ctx: ctx, cancel: nil}
+	if config.Timeout > 0 {
+		phase.ctx, phase.cancel = context.WithTimeout(ctx, config.Timeout)
+	}
+	return phase
+}
+
+// end releases the resources of the phase's context, if it has a timeout.
+func (phase shutdownPhase) end() {
+	if phase.cancel != nil {
+		phase.cancel()
+	}
+}
+
+// takePhase removes the pending resources in the given phase from the manager, and returns them.
+// Since pending resources are sorted by phase, these are at the end.
+func (manager *ShutdownManager) takePhase(phase int) []managedResource {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	start := len(manager.pending)
+	for start > 0 && manager.pending[start-1].phase == phase {
+		start--
+	}
+
+	resources := manager.pending[start:]
+	manager.pending = manager.pending[:start:start]
+	return resources
+}
+
+// closeBatch closes the given resources for Shutdown, concurrently if the phase is parallel, and
+// returns the combined close errors, leaving out those of best-effort resources. It also returns
+// whether a critical resource failed to close (see [errclose.Severity]).
+func closeBatch(
+	phase shutdownPhase,
+	resources []managedResource,
+	handlers shutdownEventHandlers,
+) (closeErr error, criticalFailed bool) {
+	if !phase.config.Parallel {
+		propagate := severityFilter(resources, &criticalFailed)
+		for i, resource := range resources {
+			handlers.emitClosing(resource)
+			var resourceErr error
+			closeWithContext(
+				phase.ctx,
+				resource.resource,
+				&resourceErr,
+				resource.resourceName,
+				resource.options,
+			)
+			handlers.emitClosed(resource, resourceErr)
+			if resourceErr != nil && propagate(i, resourceErr) {
+				combineIntoReturnedErr(&closeErr, resourceErr, resource.resourceName)
+			}
+		}
+		return closeErr, criticalFailed
+	}
+
+	namedResources := make([]namedResource, len(resources))
+	for i, resource := range resources {
+		namedResources[i] = resource.namedResource
+	}
+	hooks := eventHooks(handlers, resources)
+	hooks.propagate = severityFilter(resources, &criticalFailed)
+	closeConcurrently(phase.ctx, &closeErr, 0, namedResources, hooks)
+	return closeErr, criticalFailed
+}
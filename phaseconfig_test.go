@@ -0,0 +1,96 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestParallelPhase(t *testing.T) {
+	var manager errclose.ShutdownManager
+	manager.SetPhaseConfig(1, errclose.PhaseConfig{Parallel: true, Timeout: 0})
+
+	// Each worker waits for all of them to have started closing, which only happens if they're
+	// closed concurrently
+	var started sync.WaitGroup
+	started.Add(3)
+	worker := func(closeErr error) closerFunc {
+		return func() error {
+			started.Done()
+			allStarted := make(chan struct{})
+			go func() {
+				started.Wait()
+				close(allStarted)
+			}()
+			select {
+			case <-allStarted:
+				return closeErr
+			case <-time.After(5 * time.Second):
+				return errors.New("workers not closed concurrently")
+			}
+		}
+	}
+
+	var closed []string
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		if event.Kind == errclose.ShutdownEventPhaseStarted {
+			closed = append(closed, "phase")
+		}
+	})
+	manager.DeferPhase(worker(errors.New("worker busy")), "worker 2", 1)
+	manager.DeferPhase(worker(nil), "worker 1", 1)
+	manager.DeferPhase(worker(errors.New("worker busy")), "worker 3", 1)
+	manager.DeferPhase(closerFunc(func() error {
+		closed = append(closed, "store")
+		return nil
+	}), "store", 2)
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close worker 2: worker busy (and failed to close worker 3: worker busy)",
+		"error string",
+	)
+	assertEqual(t, closed, []string{"phase", "phase", "store"}, "phases and closes")
+}
+
+// ctxErrRecorder records the error of its close context at the time it's closed.
+type ctxErrRecorder struct {
+	closeCtx context.Context //nolint:containedctx // Set by the shutdown manager
+	ctxErr   error
+}
+
+func (resource *ctxErrRecorder) SetCloseContext(ctx context.Context) {
+	resource.closeCtx = ctx
+}
+
+func (resource *ctxErrRecorder) Close() error {
+	resource.ctxErr = resource.closeCtx.Err()
+	return nil
+}
+
+func TestPhaseTimeout(t *testing.T) {
+	var manager errclose.ShutdownManager
+	manager.SetPhaseConfig(1, errclose.PhaseConfig{Parallel: false, Timeout: 10 * time.Millisecond})
+
+	afterTimeout := &ctxErrRecorder{closeCtx: nil, ctxErr: nil}
+	nextPhase := &ctxErrRecorder{closeCtx: nil, ctxErr: nil}
+	manager.DeferPhase(afterTimeout, "store 2", 1)
+	manager.DeferPhase(newBlockingResource(), "store 1", 1)
+	manager.DeferPhase(nextPhase, "logger", 2)
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(
+		t,
+		afterTimeout.ctxErr,
+		context.DeadlineExceeded,
+		"context error for resource closed after phase timeout",
+	)
+	assertEqual(t, nextPhase.ctxErr, nil, "context error for resource in next phase")
+}
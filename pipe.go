@@ -0,0 +1,41 @@
+package errclose
+
+import (
+	"io"
+)
+
+// ClosePipeWriter closes the write side of a pipe created with [io.Pipe], and passes on the error
+// returned by your function to the read side. If the error pointed to by returnedErr is non-nil,
+// the pipe is closed with [io.PipeWriter.CloseWithError], so the reader gets that error instead of
+// [io.EOF]. Otherwise, the pipe is closed normally, so the reader gets io.EOF after reading all
+// data.
+//
+// This is meant for producer goroutines that write to a pipe:
+//
+//	reader, writer := io.Pipe()
+//	go func() (returnedErr error) {
+//		defer errclose.ClosePipeWriter(writer, &returnedErr, "pipe writer")
+//
+//		return json.NewEncoder(writer).Encode(data)
+//	}()
+//	// Use reader
+//
+// Close errors are handled in the same way as [errclose.Close] (though closing an io.PipeWriter
+// currently never fails). If returnedErr is nil, the pipe is closed normally.
+func ClosePipeWriter(writer *io.PipeWriter, returnedErr *error, resourceName string) {
+	if writer == nil {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	var closeErr error
+	if returnedErr != nil && *returnedErr != nil {
+		closeErr = writer.CloseWithError(*returnedErr)
+	} else {
+		closeErr = writer.Close()
+	}
+	if closeErr != nil {
+		handleCloseError(returnedErr, closeErr, resourceName)
+	}
+}
@@ -0,0 +1,43 @@
+package errclose_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestClosePipeWriter(t *testing.T) {
+	reader, writer := io.Pipe()
+
+	go func() {
+		var returnedErr error
+		defer errclose.ClosePipeWriter(writer, &returnedErr, "pipe writer")
+
+		_, returnedErr = writer.Write([]byte("data"))
+	}()
+
+	data, err := io.ReadAll(reader)
+	assertEqual(t, err, nil, "read error")
+	assertEqual(t, string(data), "data", "read data")
+}
+
+func TestClosePipeWriterWithError(t *testing.T) {
+	reader, writer := io.Pipe()
+
+	go func() {
+		var returnedErr error
+		defer errclose.ClosePipeWriter(writer, &returnedErr, "pipe writer")
+
+		if _, err := writer.Write([]byte("partial data")); err != nil {
+			returnedErr = err
+			return
+		}
+		returnedErr = fallibleOperation()
+	}()
+
+	data, err := io.ReadAll(reader)
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+	assertEqual(t, string(data), "partial data", "read data")
+}
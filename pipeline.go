@@ -0,0 +1,71 @@
+package errclose
+
+// Pipeline is a sequence of teardown steps, where each step can see whether the steps before it
+// failed. This is useful when teardown has data dependencies, such as skipping a flush when closing
+// the underlying connection already failed.
+//
+// Steps are added with [Pipeline.Add] as resources are acquired, and run in the reverse order of
+// how they were added when [Pipeline.Run] is called (like deferred calls):
+//
+//	func upload(ctx context.Context, object Object) (returnedErr error) {
+//		var teardown errclose.Pipeline
+//		defer teardown.Run(&returnedErr)
+//
+//		conn, err := dial(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		teardown.Add(func(error) error { return conn.Close() }, "connection")
+//
+//		writer := bufio.NewWriter(conn)
+//		teardown.Add(func(prevErr error) error {
+//			if prevErr != nil {
+//				return nil // Nothing to flush to if an earlier step failed
+//			}
+//			return writer.Flush()
+//		}, "buffered writer")
+//
+//		// Use writer
+//	}
+//
+// The zero value is ready to use. A Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	steps []pipelineStep
+}
+
+type pipelineStep struct {
+	step         func(prevErr error) error
+	resourceName string
+}
+
+// Add adds a teardown step to the pipeline. The step is given the combined errors from the steps
+// that ran before it (or nil if they all succeeded), and returns its own error. The resource name
+// is used to format the step's error, as in [errclose.Close].
+func (pipeline *Pipeline) Add(step func(prevErr error) error, resourceName string) {
+	pipeline.steps = append(pipeline.steps, pipelineStep{step: step, resourceName: resourceName})
+}
+
+// Run runs all steps in the pipeline, in the reverse order of how they were added, and handles step
+// errors in the same way as [errclose.Close] handles close errors:
+//
+//	failed to close <resourceName>: <step error>
+//
+// The prevErr given to each step only contains errors from earlier steps, not the existing error
+// pointed to by returnedErr. Errors from all steps are then combined with the existing error, in
+// the order that the steps ran.
+//
+// The pipeline is emptied, so calling Run again only runs steps added after the previous call.
+func (pipeline *Pipeline) Run(returnedErr *error) {
+	var prevErr error
+	for i := len(pipeline.steps) - 1; i >= 0; i-- {
+		step := pipeline.steps[i]
+		recordCloseAttempt(step.resourceName)
+		if err := step.step(prevErr); err != nil {
+			prevErr = wrapTeardownError(prevErr, err, "close", step.resourceName)
+			handleCloseError(returnedErr, err, step.resourceName)
+		}
+	}
+
+	clear(pipeline.steps)
+	pipeline.steps = pipeline.steps[:0]
+}
@@ -0,0 +1,81 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestPipeline(t *testing.T) {
+	var stepOrder []string
+	var prevErrs []error
+	connErr := errors.New("connection reset")
+
+	run := func() (returnedErr error) {
+		var teardown errclose.Pipeline
+		defer teardown.Run(&returnedErr)
+
+		teardown.Add(func(prevErr error) error {
+			stepOrder = append(stepOrder, "connection")
+			prevErrs = append(prevErrs, prevErr)
+			return connErr
+		}, "connection")
+
+		teardown.Add(func(prevErr error) error {
+			stepOrder = append(stepOrder, "writer")
+			prevErrs = append(prevErrs, prevErr)
+			return errors.New("flush failed")
+		}, "writer")
+
+		teardown.Add(func(prevErr error) error {
+			stepOrder = append(stepOrder, "reader")
+			prevErrs = append(prevErrs, prevErr)
+			return nil
+		}, "reader")
+
+		return fallibleOperation()
+	}
+
+	err := run()
+	assertEqual(t, stepOrder, []string{"reader", "writer", "connection"}, "step order")
+	assertEqual(t, prevErrs[0], nil, "prevErr given to first step")
+	assertEqual(t, prevErrs[1], nil, "prevErr given to second step")
+	assertEqual(
+		t,
+		prevErrs[2].Error(),
+		"failed to close writer: flush failed",
+		"prevErr given to third step",
+	)
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close writer: flush failed) "+
+			"(and failed to close connection: connection reset)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, connErr), true, "errors.Is(connErr)")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is(errFallibleOperation)")
+}
+
+func TestPipelineWithoutErrors(t *testing.T) {
+	stepsRun := 0
+
+	run := func() (returnedErr error) {
+		var teardown errclose.Pipeline
+		defer teardown.Run(&returnedErr)
+
+		for range 3 {
+			teardown.Add(func(error) error {
+				stepsRun++
+				return nil
+			}, "resource")
+		}
+
+		return nil
+	}
+
+	err := run()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, stepsRun, 3, "steps run")
+}
@@ -0,0 +1,82 @@
+package errclose
+
+import (
+	"errors"
+)
+
+// KeepPrimary returns an option that makes [errclose.Close] keep the existing error pointed to by
+// returnedErr as the identity of the returned error, when both the existing error and the close
+// fail. The returned error then has the same Error() message as the existing error, and only
+// unwraps to the existing error, so [errors.Is] and [errors.As] behave as they would for the
+// existing error alone. This is for callers that match on the message or identity of your
+// function's errors, where the usual combined message would break their handling:
+//
+//	defer errclose.Close(file, &returnedErr, "file", errclose.KeepPrimary())
+//
+// The close error is attached to the returned error, and can be retrieved with
+// [errclose.CloseErrorFrom]. It's also written to the event log and passed to the observer, as
+// usual (see [errclose.SetEventLog] and [errclose.SetObserver]). If there is no existing error,
+// the close error is returned as usual.
+//
+// Note that comparing the returned error with == to the existing error still fails, since the
+// returned error is a wrapper (compare with errors.Is instead).
+func KeepPrimary() Option {
+	return Option{settings: &optionSettings{keepPrimary: true}}
+}
+
+func hasKeepPrimary(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().keepPrimary {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CloseErrorFrom returns the close error attached to the given error with [errclose.KeepPrimary],
+// or else the first [errclose.CloseError] in the error's chain (checked with [errors.As]). It
+// returns nil if there is no close error.
+//
+// If several close errors were attached to the same error, the first one is returned. Use
+// [errors.As] or [errclose.Details] on the returned error's unwrap chain to get the others.
+func CloseErrorFrom(err error) *CloseError {
+	var attached *attachedCloseError
+	if errors.As(err, &attached) {
+		err = attached.closeErr
+	}
+
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		return closeErr
+	}
+	return nil
+}
+
+// attachCloseError returns an error that behaves like the given primary error, with the given
+// close error attached (see [errclose.KeepPrimary]).
+func attachCloseError(primary error, closeErr *CloseError) error {
+	//nolint:errorlint // Only attach to the outermost error, to keep its identity
+	if attached, ok := primary.(*attachedCloseError); ok {
+		return &attachedCloseError{
+			primary:  attached.primary,
+			closeErr: combineCloseError(attached.closeErr, closeErr),
+		}
+	}
+
+	return &attachedCloseError{primary: primary, closeErr: closeErr}
+}
+
+type attachedCloseError struct {
+	primary  error
+	closeErr error
+}
+
+func (err *attachedCloseError) Error() string {
+	return err.primary.Error()
+}
+
+func (err *attachedCloseError) Unwrap() error {
+	return err.primary
+}
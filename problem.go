@@ -0,0 +1,90 @@
+package errclose
+
+import (
+	"errors"
+	"strings"
+)
+
+// ProblemDetails is a problem details object for HTTP API error responses, as described by
+// [RFC 9457] (which replaces RFC 7807), returned by [errclose.Problem]. It's meant to be encoded as
+// JSON, and sent with the "application/problem+json" content type.
+//
+// [RFC 9457]: https://www.rfc-editor.org/rfc/rfc9457
+type ProblemDetails struct {
+	// Type is a URI reference that identifies the problem type. If empty, it's left out of the
+	// JSON, which means "about:blank".
+	Type string `json:"type,omitempty"`
+	// Title is a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status,omitempty"`
+	// Detail is the message of the operation's error, without the close errors that were combined
+	// with it.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference that identifies this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// CloseErrors is an extension member with the details of the close errors that were combined
+	// with the operation's error, or attached to it with [errclose.KeepPrimary], in order.
+	CloseErrors []CloseErrorDetails `json:"closeErrors,omitempty"`
+}
+
+// Problem converts an error returned by this package to a problem details object for an HTTP API
+// error response, keeping the operation's failure separate from the close failures. For example,
+// for the following error from [errclose.Close]:
+//
+//	query failed (and failed to close rows: bad connection)
+//
+// The problem details are (as JSON, with the Status and Title set by the caller):
+//
+//	{
+//		"title": "Internal Server Error",
+//		"status": 500,
+//		"detail": "query failed",
+//		"closeErrors": [{"resource": "rows", "action": "close", "error": "bad connection"}]
+//	}
+//
+// The Detail field is the message of the errors that the close errors were combined with (joined
+// with "; " if there are several), or of the error's primary error if it was returned with
+// [errclose.KeepPrimary]. If the error only consists of close errors, Detail is the full error
+// message. The close errors are listed in the CloseErrors extension member.
+//
+// Problem only sets Detail and CloseErrors, so set the other fields before writing the response:
+//
+//	problem := errclose.Problem(err)
+//	problem.Status = http.StatusInternalServerError
+//	problem.Title = http.StatusText(problem.Status)
+//
+//	w.Header().Set("Content-Type", "application/problem+json")
+//	w.WriteHeader(problem.Status)
+//	_ = json.NewEncoder(w).Encode(problem)
+//
+// Like [errclose.Details], Problem only splits errors combined by this package: if the error has
+// been wrapped by other code since, the Detail field includes the close errors' messages. If err
+// is nil, Problem returns the zero value.
+func Problem(err error) ProblemDetails {
+	problem := ProblemDetails{
+		Type:        "",
+		Title:       "",
+		Status:      0,
+		Detail:      "",
+		Instance:    "",
+		CloseErrors: nil,
+	}
+	if err == nil {
+		return problem
+	}
+
+	details := Details(err)
+	if len(details.Errors) == 0 {
+		problem.Detail = details.Message
+	} else {
+		problem.Detail = strings.Join(details.Errors, "; ")
+	}
+
+	problem.CloseErrors = details.CloseErrors
+	var attached *attachedCloseError
+	if errors.As(err, &attached) {
+		problem.CloseErrors = append(problem.CloseErrors, Details(attached.closeErr).CloseErrors...)
+	}
+	return problem
+}
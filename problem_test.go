@@ -0,0 +1,70 @@
+package errclose_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestProblem(t *testing.T) {
+	err := errors.New("query failed")
+	errclose.Close(openFileWithCloseError(), &err, "rows")
+
+	problem := errclose.Problem(err)
+	problem.Status = 500
+	problem.Title = "Internal Server Error"
+
+	output, marshalErr := json.Marshal(problem)
+	assertEqual(t, marshalErr, nil, "marshal error")
+	assertEqual(
+		t,
+		string(output),
+		`{"title":"Internal Server Error","status":500,"detail":"query failed",`+
+			`"closeErrors":[{"resource":"rows","action":"close","error":"close error"}]}`,
+		"JSON",
+	)
+}
+
+func TestProblemWithKeepPrimary(t *testing.T) {
+	err := errors.New("query failed")
+	errclose.Close(openFileWithCloseError(), &err, "rows", errclose.KeepPrimary())
+	errclose.Close(openFileWithCloseError(), &err, "connection", errclose.KeepPrimary())
+
+	assertEqual(
+		t,
+		errclose.Problem(err),
+		errclose.ProblemDetails{
+			Type:     "",
+			Title:    "",
+			Status:   0,
+			Detail:   "query failed",
+			Instance: "",
+			CloseErrors: []errclose.CloseErrorDetails{
+				{Resource: "rows", Action: "close", Error: "close error"},
+				{Resource: "connection", Action: "close", Error: "close error"},
+			},
+		},
+		"problem details",
+	)
+}
+
+func TestProblemWithOnlyCloseError(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+
+	problem := errclose.Problem(err)
+	assertEqual(t, problem.Detail, "failed to close file: close error", "problem detail")
+	assertEqual(
+		t,
+		problem.CloseErrors,
+		[]errclose.CloseErrorDetails{{Resource: "file", Action: "close", Error: "close error"}},
+		"problem close errors",
+	)
+}
+
+func TestProblemNil(t *testing.T) {
+	assertEqual(t, errclose.Problem(nil).Detail, "", "problem detail")
+	assertEqual(t, errclose.Problem(nil).CloseErrors == nil, true, "problem close errors are nil")
+}
@@ -0,0 +1,76 @@
+package errclose
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error that [errclose.Recover] converts recovered panics to. The error message
+// is on the following format:
+//
+//	panicked while <Action>: <Value>
+//
+// If the panic value is an error, PanicError wraps it, so it can be checked with [errors.Is] and
+// [errors.As].
+type PanicError struct {
+	// Action is the description of what was being done when the panic happened, as given to
+	// Recover.
+	Action string
+	// Value is the value that was passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine, as returned by [debug.Stack].
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	if err.Action == "" {
+		return fmt.Sprintf("panicked: %v", err.Value)
+	}
+	return fmt.Sprintf("panicked while %s: %v", err.Action, err.Value)
+}
+
+// Unwrap returns the panic value if it's an error, or nil otherwise.
+func (err *PanicError) Unwrap() error {
+	if valueErr, ok := err.Value.(error); ok {
+		return valueErr
+	}
+	return nil
+}
+
+// Recover recovers a panic, and converts it to an error that is set on the error pointed to by
+// returnedErr. It must be called directly in a defer statement (not from a deferred closure), since
+// it uses the builtin recover:
+//
+//	func processFile(path string) (returnedErr error) {
+//		defer errclose.Recover(&returnedErr, "processing file")
+//
+//		file, err := os.Open(path)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.Close(file, &returnedErr, "file")
+//
+//		// Use file
+//	}
+//
+// The panic is converted to a [errclose.PanicError], which includes the stack trace of the panic.
+// If returnedErr points to an existing non-nil error (for example a close error from a deferred
+// call that ran during the panic), the errors are combined on the same format as in
+// [errclose.Close]:
+//
+//	<existing error> (and panicked while <action>: <panic value>)
+//
+// If returnedErr is a nil pointer, the panic can't be returned as an error, so Recover panics again
+// with the original panic value.
+func Recover(returnedErr *error, action string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if returnedErr == nil {
+		panic(recovered)
+	}
+
+	panicErr := &PanicError{Action: action, Value: recovered, Stack: debug.Stack()}
+	*returnedErr = combineErrors(*returnedErr, panicErr)
+}
@@ -0,0 +1,58 @@
+package errclose_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestRecover(t *testing.T) {
+	process := func() (returnedErr error) {
+		defer errclose.Recover(&returnedErr, "processing file")
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file")
+		panic("something went wrong")
+	}
+
+	err := process()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close file: close error (and panicked while processing file: "+
+			"something went wrong)",
+		"error string",
+	)
+
+	var panicErr *errclose.PanicError
+	assertEqual(t, errors.As(err, &panicErr), true, "errors.As(err, &panicErr)")
+	assertEqual(t, panicErr.Value, "something went wrong", "panic value")
+	assertEqual(
+		t,
+		strings.Contains(string(panicErr.Stack), "recover_test.go"),
+		true,
+		"stack contains test file",
+	)
+}
+
+func TestRecoverErrorValue(t *testing.T) {
+	panicValue := errors.New("panic error")
+
+	process := func() (returnedErr error) {
+		defer errclose.Recover(&returnedErr, "processing file")
+		panic(panicValue)
+	}
+
+	err := process()
+	assertEqual(t, err.Error(), "panicked while processing file: panic error", "error string")
+	assertEqual(t, errors.Is(err, panicValue), true, "errors.Is(err, panicValue)")
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	process := func() (returnedErr error) {
+		defer errclose.Recover(&returnedErr, "processing file")
+		return nil
+	}
+
+	assertEqual(t, process(), nil, "error")
+}
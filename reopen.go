@@ -0,0 +1,109 @@
+package errclose
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Reopenable holds a resource that can be closed and replaced by a freshly opened instance, such as
+// a log file that should be reopened after log rotation, or a listener that must be recreated after
+// a config reload.
+//
+// Create a Reopenable with [errclose.NewReopenable]. It is safe for concurrent use.
+type Reopenable[T interface{ Close() error }] struct {
+	lock         sync.Mutex
+	current      T
+	open         func() (T, error)
+	resourceName string
+	closed       bool
+	closeErr     error
+}
+
+// NewReopenable calls the given open function to open the initial instance of a resource, and
+// returns a [errclose.Reopenable] holding it. The open function is called again for every call to
+// [Reopenable.Reopen]. The resource name is used to format errors from reopening (see
+// [Reopenable.Reopen] for the error format).
+//
+// If open returns an error, that error is returned with the resource name for context:
+//
+//	failed to open <resourceName>: <open error>
+func NewReopenable[T interface{ Close() error }](
+	open func() (T, error),
+	resourceName string,
+) (*Reopenable[T], error) {
+	resource, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", resourceName, err)
+	}
+
+	return &Reopenable[T]{
+		lock:         sync.Mutex{},
+		current:      resource,
+		open:         open,
+		resourceName: resourceName,
+		closed:       false,
+		closeErr:     nil,
+	}, nil
+}
+
+// Get returns the current instance of the resource. The returned instance may be closed by a
+// concurrent call to [Reopenable.Reopen], so callers that hold on to it across a reopen must be
+// prepared for that.
+func (reopenable *Reopenable[T]) Get() T {
+	reopenable.lock.Lock()
+	defer reopenable.lock.Unlock()
+
+	return reopenable.current
+}
+
+// Reopen opens a new instance of the resource, swaps it in as the current instance, and then closes
+// the previous instance.
+//
+// If opening the new instance fails, the current instance is kept, and the open error is returned
+// on the following format:
+//
+//	failed to reopen <resourceName>: <open error>
+//
+// If closing the previous instance fails, the new instance is still used, and the close error is
+// returned in the same format as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+//
+// If the Reopenable has been closed with [Reopenable.Close], no new instance is opened, and
+// [errclose.ErrAlreadyClosed] is returned on the same format as open errors:
+//
+//	failed to reopen <resourceName>: resource already closed
+func (reopenable *Reopenable[T]) Reopen() (returnedErr error) {
+	reopenable.lock.Lock()
+	if reopenable.closed {
+		reopenable.lock.Unlock()
+		return fmt.Errorf("failed to reopen %s: %w", reopenable.resourceName, ErrAlreadyClosed)
+	}
+	previous := reopenable.current
+	next, err := reopenable.open()
+	if err != nil {
+		reopenable.lock.Unlock()
+		return fmt.Errorf("failed to reopen %s: %w", reopenable.resourceName, err)
+	}
+	reopenable.current = next
+	reopenable.lock.Unlock()
+
+	Close(previous, &returnedErr, reopenable.resourceName)
+	return returnedErr
+}
+
+// Close closes the current instance of the resource, and returns its close error as-is. This lets
+// you pass the Reopenable itself to [errclose.Close], which adds the resource name to the error.
+//
+// The resource is only closed once: later calls return the error from the first call, and later
+// calls to [Reopenable.Reopen] fail with [errclose.ErrAlreadyClosed].
+func (reopenable *Reopenable[T]) Close() error {
+	reopenable.lock.Lock()
+	defer reopenable.lock.Unlock()
+
+	if !reopenable.closed {
+		reopenable.closeErr = reopenable.current.Close()
+		reopenable.closed = true
+	}
+	return reopenable.closeErr
+}
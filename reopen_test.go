@@ -0,0 +1,115 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestReopen(t *testing.T) {
+	var files []*mockFile
+	open := func() (*mockFile, error) {
+		file := openFileWithoutCloseError()
+		files = append(files, file)
+		return file, nil
+	}
+
+	reopenable, err := errclose.NewReopenable(open, "file")
+	assertEqual(t, err, nil, "error from NewReopenable")
+
+	err = reopenable.Reopen()
+	assertEqual(t, err, nil, "error from Reopen")
+	assertEqual(t, len(files), 2, "number of opened files")
+	assertEqual(t, files[0].closeWasCalled, true, "files[0].closeWasCalled")
+	assertEqual(t, files[1].closeWasCalled, false, "files[1].closeWasCalled")
+	assertEqual(t, reopenable.Get(), files[1], "current file")
+}
+
+func TestReopenWithCloseError(t *testing.T) {
+	var files []*mockFile
+	open := func() (*mockFile, error) {
+		file := openFileWithCloseError()
+		files = append(files, file)
+		return file, nil
+	}
+
+	reopenable, err := errclose.NewReopenable(open, "file")
+	assertEqual(t, err, nil, "error from NewReopenable")
+
+	err = reopenable.Reopen()
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errors.Is(err, files[0].closeError), true, "errors.Is result")
+	assertEqual(t, reopenable.Get(), files[1], "current file")
+}
+
+func TestReopenWithOpenError(t *testing.T) {
+	file := openFileWithoutCloseError()
+	openCalls := 0
+	open := func() (*mockFile, error) {
+		openCalls++
+		if openCalls > 1 {
+			return nil, errFallibleOperation
+		}
+		return file, nil
+	}
+
+	reopenable, err := errclose.NewReopenable(open, "file")
+	assertEqual(t, err, nil, "error from NewReopenable")
+
+	err = reopenable.Reopen()
+	assertEqual(t, err.Error(), "failed to reopen file: operation failed", "error string")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+	assertEqual(t, file.closeWasCalled, false, "file.closeWasCalled")
+	assertEqual(t, reopenable.Get(), file, "current file")
+}
+
+func TestReopenableClose(t *testing.T) {
+	var file *mockFile
+
+	useFile := func() (returnedErr error) {
+		reopenable, err := errclose.NewReopenable(
+			func() (*mockFile, error) {
+				file = openFileWithCloseError()
+				return file, nil
+			},
+			"file",
+		)
+		if err != nil {
+			return err
+		}
+		defer errclose.Close(reopenable, &returnedErr, "file")
+
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestReopenAfterClose(t *testing.T) {
+	openCalls := 0
+	closeCalls := 0
+	closeErr := errors.New("close error")
+	open := func() (closerFunc, error) {
+		openCalls++
+		return func() error {
+			closeCalls++
+			return closeErr
+		}, nil
+	}
+
+	reopenable, err := errclose.NewReopenable(open, "file")
+	assertEqual(t, err, nil, "error from NewReopenable")
+
+	assertEqual(t, reopenable.Close(), closeErr, "error from first Close")
+	assertEqual(t, reopenable.Close(), closeErr, "error from second Close")
+	assertEqual(t, closeCalls, 1, "close calls")
+
+	err = reopenable.Reopen()
+	assertEqual(t, err.Error(), "failed to reopen file: resource already closed", "error string")
+	assertEqual(t, errors.Is(err, errclose.ErrAlreadyClosed), true, "errors.Is result")
+	assertEqual(t, openCalls, 1, "open calls")
+	assertEqual(t, closeCalls, 1, "close calls after Reopen")
+}
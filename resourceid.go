@@ -0,0 +1,104 @@
+package errclose
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
+
+// ResourceID identifies a resource for its whole lifetime, so that a close failure in the logs can
+// be correlated with where the resource was acquired, and with leak reports. Get a new ID with
+// [errclose.NewResourceID]. The zero ResourceID means that the resource has no ID.
+//
+// IDs are assigned automatically to resources registered with a [errclose.ShutdownManager] and
+// guarded by [errclose.CloseOrCleanup]. For other resources, get an ID when acquiring the
+// resource, log it, and pass it to the close with [errclose.WithResourceID]:
+//
+//	conn, err := pool.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	connID := errclose.NewResourceID()
+//	slog.Debug("Acquired connection", "resource_id", connID)
+//	defer errclose.Close(conn, &returnedErr, "connection", errclose.WithResourceID(connID))
+type ResourceID uint64
+
+var lastResourceID atomic.Uint64
+
+// NewResourceID returns a new [errclose.ResourceID], which is unique within the process. IDs are
+// assigned in increasing order, starting at 1.
+func NewResourceID() ResourceID {
+	return ResourceID(lastResourceID.Add(1))
+}
+
+// String returns the ID in decimal, or "" for the zero ResourceID.
+func (id ResourceID) String() string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// WithResourceID returns an option that attaches the given resource ID to close failures, so
+// they can be correlated with where the resource was acquired (see [errclose.ResourceID]):
+//   - The ResourceID field of the [errclose.CloseError] is set to it
+//   - close_failed events in the event log get a resource_id field (see [errclose.SetEventLog])
+//   - The observer set by [errclose.SetObserver] and hooks from [errclose.Also] are passed a close
+//     error that carries the ID, which can be read with [errclose.ResourceIDOf]
+//
+// The ID is not passed to the metrics set by [errclose.SetMetrics], since it would give them
+// unbounded cardinality. If several WithResourceID options apply, the last one is used.
+func WithResourceID(id ResourceID) Option {
+	return Option{settings: &optionSettings{resourceID: id}}
+}
+
+// ResourceIDOf returns the resource ID that the given error was tagged with when a close failed
+// (see [errclose.WithResourceID]), or 0 if it has none. This works both for errors returned by the
+// package and for the close errors passed to the observer and [errclose.Also] hooks:
+//
+//	errclose.SetObserver(func(resourceName string, closeErr error) {
+//		slog.Warn(
+//			"Resource failed to close",
+//			"resource", resourceName,
+//			"resource_id", errclose.ResourceIDOf(closeErr),
+//			"error", closeErr,
+//		)
+//	})
+//
+// If the error combines several close errors with IDs, the first one found is returned.
+func ResourceIDOf(err error) ResourceID {
+	var traced *tracedError
+	if errors.As(err, &traced) && traced.resourceID != 0 {
+		return traced.resourceID
+	}
+	for _, closeErr := range Errors(err) {
+		if closeErr.ResourceID != 0 {
+			return closeErr.ResourceID
+		}
+	}
+	return 0
+}
+
+func resourceIDFromOptions(options optionLists) ResourceID {
+	var id ResourceID
+	for _, list := range options {
+		for _, option := range list {
+			if optionID := option.get().resourceID; optionID != 0 {
+				id = optionID
+			}
+		}
+	}
+	return id
+}
+
+// registeredResourceID returns the ID for a resource registered with the given options: the ID
+// from a [errclose.WithResourceID] option if there is one, or a new ID otherwise. If it's a new
+// ID, the options are returned with a WithResourceID option for it appended.
+func registeredResourceID(options []Option) (ResourceID, []Option) {
+	if id := resourceIDFromOptions(optionLists{options}); id != 0 {
+		return id, options
+	}
+	id := NewResourceID()
+	// Copied, so that we don't append to the caller's options
+	return id, append(options[:len(options):len(options)], WithResourceID(id))
+}
@@ -0,0 +1,98 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWithResourceID(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	var eventLog strings.Builder
+	errclose.SetEventLog(&eventLog)
+	var observed errclose.ResourceID
+	errclose.SetObserver(func(_ string, closeErr error) {
+		observed = errclose.ResourceIDOf(closeErr)
+	})
+
+	id := errclose.NewResourceID()
+	var reported errclose.ResourceID
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.WithResourceID(id),
+		errclose.Also(func(_ string, closeErr error) {
+			reported = errclose.ResourceIDOf(closeErr)
+		}),
+	)
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errclose.ResourceIDOf(err), id, "ID of returned error")
+	assertEqual(t, observed, id, "ID passed to observer")
+	assertEqual(t, reported, id, "ID passed to Also")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.ResourceID, id, "CloseError.ResourceID")
+	assertEqual(
+		t,
+		strings.HasSuffix(eventLog.String(), ` resource_id=`+id.String()+"\n"),
+		true,
+		"event log has resource ID ("+eventLog.String()+")",
+	)
+}
+
+func TestNewResourceID(t *testing.T) {
+	id1 := errclose.NewResourceID()
+	id2 := errclose.NewResourceID()
+	assertEqual(t, id1 != 0, true, "ID is set")
+	assertEqual(t, id2 > id1, true, "IDs are increasing")
+	assertEqual(t, errclose.ResourceID(0).String(), "", "string of zero ID")
+}
+
+func TestWithoutResourceID(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, errclose.ResourceIDOf(err), errclose.ResourceID(0), "ID")
+}
+
+func TestShutdownManagerResourceIDs(t *testing.T) {
+	var manager errclose.ShutdownManager
+	id := errclose.NewResourceID()
+	manager.DeferWith(
+		openFileWithCloseError(),
+		"database",
+		0,
+		errclose.SeverityNormal,
+		errclose.WithResourceID(id),
+	)
+	manager.Defer(openFileWithCloseError(), "cache")
+
+	plan := manager.Plan()
+	assertEqual(t, plan[1].ResourceID, id, "ID of database in plan")
+	cacheID := plan[0].ResourceID
+	assertEqual(t, cacheID != 0 && cacheID != id, true, "cache is given a new ID")
+
+	eventIDs := make(map[string]errclose.ResourceID)
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		if event.Kind == errclose.ShutdownEventResourceFailed {
+			eventIDs[event.ResourceName] = event.ResourceID
+		}
+	})
+
+	err := manager.Shutdown(context.Background())
+	errs := errclose.Errors(err)
+	assertEqual(t, len(errs), 2, "number of close errors")
+	assertEqual(t, errs[0].ResourceID, cacheID, "ID of cache error")
+	assertEqual(t, errs[1].ResourceID, id, "ID of database error")
+	assertEqual(
+		t,
+		eventIDs,
+		map[string]errclose.ResourceID{"cache": cacheID, "database": id},
+		"IDs in events",
+	)
+}
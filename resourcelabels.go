@@ -0,0 +1,92 @@
+package errclose
+
+import (
+	"errors"
+	"maps"
+)
+
+// WithLabels returns an option that attaches the given key/value labels to close failures, for
+// telling apart failures of resources that share a name, such as connections in different pools
+// or files for different tenants:
+//
+//	defer errclose.Close(
+//		conn,
+//		&returnedErr,
+//		"database connection",
+//		errclose.WithLabels(map[string]string{"pool": pool.Name, "shard": shard.ID}),
+//	)
+//
+// The labels are passed on wherever the close failure goes:
+//   - The Labels field of the [errclose.CloseError] is set to them
+//   - The observer set by [errclose.SetObserver] and hooks from [errclose.Also] are passed a close
+//     error that carries the labels, which can be read with [errclose.Labels]
+//   - The close errors passed to CloseFailed and CloseIgnored on the metrics set by
+//     [errclose.SetMetrics] also carry the labels, so adapters can break failures down by them.
+//     CloseAttempted only gets the resource name, so that attempts are counted the same way
+//     regardless of options.
+//
+// To label resources that are registered for closing later, pass the option when registering
+// them, with [Frame.AddWith] or [ShutdownManager.DeferWith].
+//
+// If several WithLabels options apply (including from [errclose.SetDefaults]), their labels are
+// merged, with later options overriding earlier ones for the same key. The map must not be
+// modified after it's passed to WithLabels.
+func WithLabels(labels map[string]string) Option {
+	// Stored as a function, so that reading the labels doesn't make escape analysis move the
+	// options of every close to the heap
+	return Option{settings: &optionSettings{labels: func() map[string]string { return labels }}}
+}
+
+// Labels returns the labels that the given error was tagged with when a close failed (see
+// [errclose.WithLabels]), or nil if it has none. This works both for errors returned by the
+// package and for the close errors passed to the observer, [errclose.Also] hooks and metrics:
+//
+//	errclose.SetObserver(func(resourceName string, closeErr error) {
+//		labels := errclose.Labels(closeErr)
+//		closeFailures.WithLabelValues(resourceName, labels["pool"]).Inc()
+//	})
+//
+// If the error combines several close errors with labels, the labels of the first one found are
+// returned. The returned map must not be modified.
+func Labels(err error) map[string]string {
+	var traced *tracedError
+	if errors.As(err, &traced) && traced.labels != nil {
+		return traced.labels
+	}
+	for _, closeErr := range Errors(err) {
+		if closeErr.Labels != nil {
+			return closeErr.Labels
+		}
+	}
+	return nil
+}
+
+// labelsFromOptions returns the labels from the [errclose.WithLabels] options in the given
+// options, or nil if there are none. The labels are only copied into a new map if several options
+// have labels, so that closes with a single labels option don't allocate.
+func labelsFromOptions(options optionLists) map[string]string {
+	var labels map[string]string
+	merged := false
+	for _, list := range options {
+		for _, option := range list {
+			getLabels := option.get().labels
+			if getLabels == nil {
+				continue
+			}
+			optionLabels := getLabels()
+			switch {
+			case optionLabels == nil:
+				continue
+			case labels == nil:
+				labels = optionLabels
+			case !merged:
+				labels = maps.Clone(labels)
+				merged = true
+				fallthrough
+			default:
+				maps.Copy(labels, optionLabels)
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,170 @@
+package errclose_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWithLabels(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	var observed map[string]string
+	errclose.SetObserver(func(_ string, closeErr error) {
+		observed = errclose.Labels(closeErr)
+	})
+
+	var reported map[string]string
+	labels := map[string]string{"tenant": "acme", "shard": "3"}
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.WithLabels(labels),
+		errclose.Also(func(_ string, closeErr error) {
+			reported = errclose.Labels(closeErr)
+		}),
+	)
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errclose.Labels(err), labels, "labels of returned error")
+	assertEqual(t, observed, labels, "labels passed to observer")
+	assertEqual(t, reported, labels, "labels passed to Also")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.Labels, labels, "CloseError.Labels")
+}
+
+func TestWithLabelsMergesDefaults(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaults(errclose.WithLabels(map[string]string{"service": "api", "shard": "1"}))
+
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.WithLabels(map[string]string{"shard": "2"}),
+	)
+	assertEqual(
+		t,
+		errclose.Labels(err),
+		map[string]string{"service": "api", "shard": "2"},
+		"merged labels",
+	)
+}
+
+func TestWithoutLabels(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, errclose.Labels(err), map[string]string(nil), "labels")
+}
+
+func TestWithLabelsInMetrics(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	metrics := &labelMetrics{lock: sync.Mutex{}, failed: nil, ignored: nil}
+	errclose.SetMetrics(metrics)
+
+	labels := map[string]string{"pool": "primary"}
+	errBenign := errors.New("already closed")
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "connection", errclose.WithLabels(labels))
+	errclose.Close(
+		closerFunc(func() error { return errBenign }),
+		&err,
+		"connection",
+		errclose.WithLabels(labels),
+		errclose.Ignore(errBenign),
+	)
+
+	assertEqual(t, metrics.failed, []map[string]string{labels}, "labels of failures")
+	assertEqual(t, metrics.ignored, []map[string]string{labels}, "labels of ignored errors")
+}
+
+func TestFrameAddWith(t *testing.T) {
+	labels := map[string]string{"tenant": "acme"}
+	var frame errclose.Frame
+	frame.AddWith(openFileWithCloseError(), "file 1", errclose.WithLabels(labels))
+	frame.Add(openFileWithCloseError(), "file 2")
+
+	errs := errclose.Errors(frame.Err())
+	assertEqual(t, len(errs), 2, "number of close errors")
+	assertEqual(t, errs[0].ResourceName, "file 2", "first resource name")
+	assertEqual(t, errs[0].Labels, map[string]string(nil), "labels of file 2")
+	assertEqual(t, errs[1].Labels, labels, "labels of file 1")
+}
+
+func TestShutdownManagerDeferWith(t *testing.T) {
+	labels := map[string]string{"shard": "3"}
+	var manager errclose.ShutdownManager
+	manager.DeferWith(
+		openFileWithCloseError(),
+		"connection pool",
+		0,
+		errclose.SeverityNormal,
+		errclose.WithLabels(labels),
+	)
+	manager.DeferWith(
+		closerFunc(func() error { return errBenignClose }),
+		"cache",
+		1,
+		errclose.SeverityNormal,
+		errclose.Ignore(errBenignClose),
+	)
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err.Error(), "failed to close connection pool: close error", "error string")
+	assertEqual(t, errclose.Labels(err), labels, "labels")
+}
+
+func TestCloseErrorLogValueWithLabels(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: removeTime,
+	}))
+
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.WithLabels(map[string]string{"tenant": "acme", "shard": "3"}),
+	)
+	logger.Error("Request failed", "error", err)
+
+	assertEqual(
+		t,
+		buffer.String(),
+		`{"level":"ERROR","msg":"Request failed","error":{"message":"failed to close file: `+
+			`close error","resource":"file","action":"close","error":"close error",`+
+			`"labels":{"shard":"3","tenant":"acme"}}}`+"\n",
+		"log output",
+	)
+}
+
+type labelMetrics struct {
+	lock    sync.Mutex
+	failed  []map[string]string
+	ignored []map[string]string
+}
+
+func (metrics *labelMetrics) CloseAttempted(string) {}
+
+func (metrics *labelMetrics) CloseFailed(_ string, closeErr error) {
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	metrics.failed = append(metrics.failed, errclose.Labels(closeErr))
+}
+
+func (metrics *labelMetrics) CloseIgnored(_ string, closeErr error) {
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+	metrics.ignored = append(metrics.ignored, errclose.Labels(closeErr))
+}
+
+func (metrics *labelMetrics) CloseTimedOut(string) {}
@@ -0,0 +1,78 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// WithResult opens a resource, passes it to the given run function, and closes it when run
+// returns. It returns the result from run, and the error from run combined with any close error
+// (in the same format as [errclose.Close]). This lets functions that return a value and an error
+// get close error handling without using named return values:
+//
+//	func readConfig(path string) (Config, error) {
+//		return errclose.WithResult(
+//			func() (*os.File, error) { return os.Open(path) },
+//			func(file *os.File) (Config, error) { return parseConfig(file) },
+//			"config file",
+//		)
+//	}
+//
+// If open fails, run is not called, and the open error is returned on the following format, along
+// with the zero value of the result type:
+//
+//	failed to open <resourceName>: <open error>
+//
+// The result from run is returned even if closing the resource fails. Callers that check the error
+// first will typically ignore it in that case, but it's there if they want to use a partial result.
+func WithResult[Resource interface{ Close() error }, Result any](
+	open func() (Resource, error),
+	run func(resource Resource) (Result, error),
+	resourceName string,
+) (result Result, returnedErr error) {
+	resource, err := open()
+	if err != nil {
+		return result, fmt.Errorf("failed to open %s: %w", resourceName, err)
+	}
+	defer Close(resource, &returnedErr, resourceName)
+
+	return run(resource)
+}
+
+// Using runs the given function, then closes the given resource, and returns the error from fn
+// combined with any close error (in the same format as [errclose.Close]). This is an alternative
+// to deferring Close with a named return value, for code that already has the resource open:
+//
+//	func writeReport(file *os.File, report Report) error {
+//		return errclose.Using(file, "report file", func() error {
+//			return json.NewEncoder(file).Encode(report)
+//		})
+//	}
+//
+// The resource is closed even if fn panics. To also return a value from fn, use
+// [errclose.UsingValue]. To open the resource as well, see [errclose.WithResult].
+func Using(
+	resource interface{ Close() error },
+	resourceName string,
+	fn func() error,
+) (returnedErr error) {
+	defer Close(resource, &returnedErr, resourceName)
+	return fn()
+}
+
+// UsingValue works like [errclose.Using], for functions that return a value along with an error:
+//
+//	func readConfig(file *os.File) (Config, error) {
+//		return errclose.UsingValue(file, "config file", func() (Config, error) {
+//			return parseConfig(file)
+//		})
+//	}
+//
+// The value from fn is returned even if closing the resource fails, as in [errclose.WithResult].
+func UsingValue[T any](
+	resource interface{ Close() error },
+	resourceName string,
+	fn func() (T, error),
+) (value T, returnedErr error) {
+	defer Close(resource, &returnedErr, resourceName)
+	return fn()
+}
@@ -0,0 +1,94 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWithResult(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	result, err := errclose.WithResult(
+		func() (*mockFile, error) { return file, nil },
+		func(*mockFile) (string, error) { return "result", nil },
+		"file",
+	)
+	assertEqual(t, result, "result", "result")
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+}
+
+func TestWithResultWithCloseError(t *testing.T) {
+	file := openFileWithCloseError()
+
+	result, err := errclose.WithResult(
+		func() (*mockFile, error) { return file, nil },
+		func(*mockFile) (string, error) { return "partial result", fallibleOperation() },
+		"file",
+	)
+	assertEqual(t, result, "partial result", "result")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is(closeError)")
+}
+
+func TestWithResultWithOpenError(t *testing.T) {
+	runWasCalled := false
+
+	result, err := errclose.WithResult(
+		func() (*mockFile, error) { return nil, fallibleOperation() },
+		func(*mockFile) (string, error) {
+			runWasCalled = true
+			return "result", nil
+		},
+		"file",
+	)
+	assertEqual(t, result, "", "result")
+	assertEqual(t, err.Error(), "failed to open file: operation failed", "error string")
+	assertEqual(t, runWasCalled, false, "runWasCalled")
+}
+
+func TestUsing(t *testing.T) {
+	file := openFileWithCloseError()
+
+	err := errclose.Using(file, "file", fallibleOperation)
+	assertEqual(t, file.closeWasCalled, true, "closeWasCalled")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+}
+
+func TestUsingWithoutErrors(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	err := errclose.Using(file, "file", func() error { return nil })
+	assertEqual(t, file.closeWasCalled, true, "closeWasCalled")
+	assertEqual(t, err, nil, "error")
+}
+
+func TestUsingWithPanic(t *testing.T) {
+	file := openFileWithoutCloseError()
+
+	defer func() {
+		assertEqual(t, recover(), "fn panicked", "recovered value")
+		assertEqual(t, file.closeWasCalled, true, "closeWasCalled")
+	}()
+	_ = errclose.Using(file, "file", func() error { panic("fn panicked") })
+}
+
+func TestUsingValue(t *testing.T) {
+	file := openFileWithCloseError()
+
+	value, err := errclose.UsingValue(file, "file", func() (int, error) { return 42, nil })
+	assertEqual(t, value, 42, "value")
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
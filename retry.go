@@ -0,0 +1,129 @@
+package errclose
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retry configures how [errclose.CloseWithRetry] retries failed closes.
+type Retry struct {
+	// Attempts is the maximum number of times to call Close. If it's 1 or less, Close is only
+	// called once.
+	Attempts int
+	// Backoff is how long to wait before the first retry. The wait is doubled for each following
+	// retry.
+	Backoff time.Duration
+	// If returns true if Close should be retried after failing with the given error. If nil,
+	// only transient errors are retried: timeouts, and errors that are temporary in the same way
+	// as for [CloseError.Temporary].
+	If func(closeErr error) bool
+}
+
+// CloseWithRetry closes the given resource like [errclose.Close], but retries Close if it fails
+// with a transient error. This is for resources where Close does work that can fail temporarily,
+// and that can safely be closed again after a failed close, such as a message producer whose Close
+// flushes buffered messages to a broker:
+//
+//	defer errclose.CloseWithRetry(
+//		producer,
+//		&returnedErr,
+//		"message producer",
+//		errclose.Retry{
+//			Attempts: 3,
+//			Backoff:  100 * time.Millisecond,
+//			If: func(closeErr error) bool {
+//				return errors.Is(closeErr, kafka.ErrBrokerUnavailable)
+//			},
+//		},
+//	)
+//
+// Don't use CloseWithRetry for resources that release their underlying handle even if Close fails,
+// since a retry then just returns an "already closed" error. For example, [os.File] releases its
+// file descriptor on the first Close, and returns [os.ErrClosed] on later calls.
+//
+// Retries stop when Close succeeds, when it fails with an error that shouldn't be retried (see
+// [Retry.If]), or after the given number of attempts. If Close failed more than once, the close
+// error includes the number of attempts, and the errors from both the first and the last attempt,
+// since the first error is usually the root cause:
+//
+//	gave up after <attempts> attempts: <first close error> (last attempt: <last close error>)
+//
+// Both errors can be checked with [errors.Is] and [errors.As]. If they have the same message, the
+// last error is left out. The close error is then wrapped with the resource name, and combined
+// with the error pointed to by returnedErr, in the same way as in errclose.Close.
+//
+// CloseWithRetry takes the same options as errclose.Close (see [errclose.Option]), and applies
+// defaults and benign errors in the same way. The options apply to the final close error, so a
+// close error dropped by [errclose.Ignore] is still retried if [Retry.If] says so.
+func CloseWithRetry(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	retry Retry,
+	options ...Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	lists := withDefaults(resource, options)
+	if metricsEnabled() {
+		recordCloseAttempt(resolveResourceName(resource, resourceName))
+	}
+	stats := captureStats(lists)
+
+	shouldRetry := retry.If
+	if shouldRetry == nil {
+		shouldRetry = isTemporary
+	}
+
+	maxAttempts := max(retry.Attempts, 1)
+	backoff := retry.Backoff
+
+	var firstErr, lastErr error
+	attempts := 0
+	for attempts < maxAttempts {
+		attempts++
+		lastErr = closeWithOptions(resource, resourceName, lists)
+		if lastErr == nil {
+			return
+		}
+		if firstErr == nil {
+			firstErr = lastErr
+		}
+
+		if attempts == maxAttempts || !shouldRetry(lastErr) {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	closeErr := firstErr
+	if attempts > 1 {
+		if lastErr.Error() == firstErr.Error() {
+			closeErr = lazyErrorf(
+				firstErr,
+				"gave up after %d attempts: %w",
+				attempts,
+				firstErr,
+			)
+		} else {
+			closeErr = fmt.Errorf(
+				"gave up after %d attempts: %w (last attempt: %w)",
+				attempts,
+				firstErr,
+				lastErr,
+			)
+		}
+	}
+	handleCloseErrorWithOptions(
+		returnedErr,
+		closeErr,
+		resolveResourceName(resource, resourceName),
+		lists,
+		stats,
+		1,
+	)
+}
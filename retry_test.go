@@ -0,0 +1,150 @@
+package errclose_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestCloseWithRetry(t *testing.T) {
+	// Fails on every 2nd close, so the first attempt succeeds
+	flaky := errclosetest.NewFlaky(2, temporaryError{})
+	_ = flaky.Close()
+
+	useFile := func() (returnedErr error) {
+		defer errclose.CloseWithRetry(
+			flaky,
+			&returnedErr,
+			"file",
+			errclose.Retry{Attempts: 3, Backoff: time.Millisecond, If: nil},
+		)
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, flaky.CloseCalls(), 3, "close calls")
+}
+
+func TestCloseWithRetryGivesUp(t *testing.T) {
+	flaky := errclosetest.NewFlaky(1, temporaryError{})
+
+	useFile := func() (returnedErr error) {
+		defer errclose.CloseWithRetry(
+			flaky,
+			&returnedErr,
+			"file",
+			errclose.Retry{Attempts: 3, Backoff: time.Millisecond, If: nil},
+		)
+		return fallibleOperation()
+	}
+
+	err := useFile()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: gave up after 3 attempts: try again)",
+		"error string",
+	)
+	assertEqual(t, flaky.CloseCalls(), 3, "close calls")
+}
+
+func TestCloseWithRetryKeepsFirstError(t *testing.T) {
+	resource := &alreadyClosedAfterFailure{closeErr: temporaryError{}, closed: false}
+
+	var err error
+	errclose.CloseWithRetry(
+		resource,
+		&err,
+		"producer",
+		errclose.Retry{Attempts: 3, Backoff: time.Millisecond, If: nil},
+	)
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close producer: gave up after 2 attempts: try again "+
+			"(last attempt: file already closed)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, temporaryError{}), true, "errors.Is first error")
+	assertEqual(t, errors.Is(err, os.ErrClosed), true, "errors.Is last error")
+}
+
+func TestCloseWithRetryOnlyRetriesTransientErrors(t *testing.T) {
+	flaky := errclosetest.NewFlaky(1, errors.New("permission denied"))
+
+	var err error
+	errclose.CloseWithRetry(
+		flaky,
+		&err,
+		"file",
+		errclose.Retry{Attempts: 3, Backoff: time.Millisecond, If: nil},
+	)
+	assertEqual(t, err.Error(), "failed to close file: permission denied", "error string")
+	assertEqual(t, flaky.CloseCalls(), 1, "close calls")
+}
+
+func TestCloseWithRetryIf(t *testing.T) {
+	errBrokerUnavailable := errors.New("broker unavailable")
+	flaky := errclosetest.NewFlaky(2, errBrokerUnavailable)
+	_ = flaky.Close()
+
+	var err error
+	errclose.CloseWithRetry(
+		flaky,
+		&err,
+		"producer",
+		errclose.Retry{
+			Attempts: 3,
+			Backoff:  time.Millisecond,
+			If: func(closeErr error) bool {
+				return errors.Is(closeErr, errBrokerUnavailable)
+			},
+		},
+	)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, flaky.CloseCalls(), 3, "close calls")
+}
+
+// alreadyClosedAfterFailure is a resource that fails its first close, and then returns
+// [os.ErrClosed] like [os.File] does.
+func TestCloseWithRetryOptions(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaultsFor[*errclosetest.Flaky](errclose.Ignore(errBenignClose))
+
+	var err error
+	errclose.CloseWithRetry(
+		errclosetest.NewFlaky(1, errBenignClose),
+		&err,
+		"flaky resource",
+		errclose.Retry{Attempts: 2, Backoff: 0, If: nil},
+	)
+	assertEqual(t, err, nil, "error with close error ignored by type default")
+
+	file := openFileWithCloseError()
+	errclose.CloseWithRetry(
+		file,
+		&err,
+		"file",
+		errclose.Retry{Attempts: 2, Backoff: 0, If: nil},
+		errclose.Ignore(file.closeError),
+	)
+	assertEqual(t, err, nil, "error with close error ignored by per-call option")
+}
+
+type alreadyClosedAfterFailure struct {
+	closeErr error
+	closed   bool
+}
+
+func (resource *alreadyClosedAfterFailure) Close() error {
+	if resource.closed {
+		return os.ErrClosed
+	}
+	resource.closed = true
+	return resource.closeErr
+}
@@ -0,0 +1,109 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// NameScope prefixes the names of resources closed through it with the name of a parent resource,
+// so that close errors from layered code say which parent the resource belonged to. Create one
+// with [errclose.Scope].
+type NameScope struct {
+	parent     *NameScope
+	nameFormat string
+	formatArgs []any
+}
+
+// Scope returns a [errclose.NameScope] with the given name, formatted from the format string and
+// args with [fmt.Sprintf]. Resources closed with the methods on the scope get the scope's name in
+// front of their own name in close errors:
+//
+//	func (uploader *Uploader) Close() (returnedErr error) {
+//		scope := errclose.Scope("S3 uploader for bucket %s", uploader.bucket)
+//		defer scope.Close(uploader.client, &returnedErr, "client")
+//		defer scope.Close(uploader.multipartWriter, &returnedErr, "multipart writer")
+//
+//		// Finish upload
+//	}
+//
+// If closing the multipart writer fails, the error looks like this:
+//
+//	failed to close S3 uploader for bucket <bucket>: multipart writer: <close error>
+//
+// The scope's name is only formatted if a close fails, like the resource name in
+// [errclose.Closef]. Scopes can be nested with [NameScope.Scope].
+func Scope(nameFormat string, formatArgs ...any) *NameScope {
+	return &NameScope{parent: nil, nameFormat: nameFormat, formatArgs: formatArgs}
+}
+
+// Scope returns a child scope of this scope, whose name is prefixed with the name of this scope,
+// as in "<parent name>: <child name>".
+func (scope *NameScope) Scope(nameFormat string, formatArgs ...any) *NameScope {
+	return &NameScope{parent: scope, nameFormat: nameFormat, formatArgs: formatArgs}
+}
+
+// Name returns the full name of the scope, including the names of its parent scopes.
+func (scope *NameScope) Name() string {
+	name := fmt.Sprintf(scope.nameFormat, scope.formatArgs...)
+	if scope.parent != nil {
+		return scope.parent.qualify(name)
+	}
+	return name
+}
+
+// qualify returns the given resource name prefixed with the scope's name, or the name unchanged if
+// the scope is nil.
+func (scope *NameScope) qualify(resourceName string) string {
+	if scope == nil {
+		return resourceName
+	}
+	if resourceName == "" {
+		return scope.Name()
+	}
+	return scope.Name() + ": " + resourceName
+}
+
+// Close closes the given resource like [errclose.Close], with the scope's name in front of the
+// given resource name in close errors:
+//
+//	failed to close <scope name>: <resourceName>: <close error>
+//
+// If the resource name is empty and the resource implements [errclose.NamedCloser], the name from
+// the resource is used. Close takes the same options as errclose.Close.
+func (scope *NameScope) Close(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	options ...Option,
+) {
+	closeWithLazyName(
+		resource,
+		returnedErr,
+		lazyResourceName{scope: scope, name: resourceName, stringer: nil},
+		nil,
+		options,
+		1,
+	)
+}
+
+// Closef closes the given resource like [errclose.Closef], with the scope's name in front of the
+// formatted resource name in close errors (see [NameScope.Close]). To pass options, use
+// [NameScope.ClosefWith].
+func (scope *NameScope) Closef(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	closefResource(resource, returnedErr, scope, nil, 1, resourceNameFormat, formatArgs...)
+}
+
+// ClosefWith works like [NameScope.Closef], but takes options like [errclose.ClosefWith].
+func (scope *NameScope) ClosefWith(
+	resource interface{ Close() error },
+	returnedErr *error,
+	options []Option,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	closefResource(resource, returnedErr, scope, options, 1, resourceNameFormat, formatArgs...)
+}
@@ -0,0 +1,71 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestScope(t *testing.T) {
+	scope := errclose.Scope("uploader for bucket %s", "reports")
+
+	useResources := func() (returnedErr error) {
+		defer scope.Close(openFileWithCloseError(), &returnedErr, "client")
+		defer scope.Closef(openFileWithCloseError(), &returnedErr, "part %d", 2)
+		return nil
+	}
+
+	err := useResources()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close uploader for bucket reports: part 2: close error "+
+			"(and failed to close uploader for bucket reports: client: close error)",
+		"error string",
+	)
+}
+
+func TestNestedScope(t *testing.T) {
+	scope := errclose.Scope("server").Scope("listener on port %d", 8000)
+	assertEqual(t, scope.Name(), "server: listener on port 8000", "scope name")
+
+	var err error
+	scope.Close(openFileWithCloseError(), &err, "connection")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close server: listener on port 8000: connection: close error",
+		"error string",
+	)
+}
+
+func TestScopeWithNamedCloser(t *testing.T) {
+	scope := errclose.Scope("pool")
+
+	var err error
+	scope.Close(errclose.Named(openFileWithCloseError(), "named file"), &err, "")
+	assertEqual(t, err.Error(), "failed to close pool: named file: close error", "error string")
+}
+
+func TestScopeWithOptions(t *testing.T) {
+	scope := errclose.Scope("pool")
+	closeErr := errors.New("already closed")
+
+	var err error
+	conn := closerFunc(func() error { return closeErr })
+	scope.Close(conn, &err, "conn", errclose.Ignore(closeErr))
+	scope.ClosefWith(conn, &err, []errclose.Option{errclose.Ignore(closeErr)}, "conn %d", 2)
+	assertEqual(t, err, nil, "error")
+}
+
+func TestScopeCloseDoesNotAllocate(t *testing.T) {
+	scope := errclose.Scope("uploader for bucket %s", "reports")
+	file := openFileWithoutCloseError()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		scope.Close(file, &err, "client")
+	})
+	assertEqual(t, allocs, 0.0, "allocations")
+}
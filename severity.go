@@ -0,0 +1,122 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// Severity controls how a [errclose.ShutdownManager] treats close failures of a registered
+// resource. Set it with [ShutdownManager.DeferSeverity].
+type Severity int8
+
+const (
+	// SeverityNormal is the default severity: close failures are included in the error returned
+	// by [ShutdownManager.Shutdown], and the shutdown carries on with the remaining resources.
+	SeverityNormal Severity = iota
+	// SeverityCritical is for resources whose close failures mean that the remaining shutdown
+	// phases can't be trusted to run gracefully, such as a database that fails to commit its
+	// final transaction. Close failures are included in the returned error, as for
+	// [errclose.SeverityNormal]. In addition, once the phase of the resource has been closed,
+	// the remaining phases are aborted: their resources are closed right away, like in
+	// [ShutdownManager.Abort].
+	SeverityCritical
+	// SeverityBestEffort is for resources whose close failures don't affect the outcome of the
+	// shutdown, such as a metrics exporter's final flush. Close failures are still reported (to
+	// the observer set by [errclose.SetObserver], to metrics, to the event log, and with
+	// [errclose.ShutdownEventResourceFailed]), but are not included in the error returned by
+	// [ShutdownManager.Shutdown] or [ShutdownManager.Abort].
+	SeverityBestEffort
+)
+
+// String returns the name of the severity in snake case, e.g. "best_effort".
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityNormal:
+		return "normal"
+	case SeverityCritical:
+		return "critical"
+	case SeverityBestEffort:
+		return "best_effort"
+	default:
+		return "unknown"
+	}
+}
+
+// DeferSeverity registers the given resource to be closed when [ShutdownManager.Shutdown] is
+// called, like [ShutdownManager.DeferPhase], but with the given severity for its close failures
+// (see [errclose.Severity]):
+//
+//	shutdown.DeferSeverity(db, "database", phaseDatabases, errclose.SeverityCritical)
+//	shutdown.DeferSeverity(exporter, "exporter", phaseTelemetry, errclose.SeverityBestEffort)
+//
+// If the database fails to close, the telemetry phase (if it comes later) is aborted rather than
+// closed gracefully. If the exporter fails to close, the failure is reported, but not included in
+// the error returned by Shutdown.
+func (manager *ShutdownManager) DeferSeverity(
+	resource interface{ Close() error },
+	resourceName string,
+	phase int,
+	severity Severity,
+) {
+	manager.DeferWith(resource, resourceName, phase, severity)
+}
+
+// DeferWith registers the given resource to be closed when [ShutdownManager.Shutdown] is called,
+// like [ShutdownManager.DeferSeverity], but with options for how its close errors are handled, as
+// in [errclose.Close]. This lets you label resources where they're registered (see
+// [errclose.WithLabels]):
+//
+//	shutdown.DeferWith(
+//		pool,
+//		"connection pool",
+//		phaseDatabases,
+//		errclose.SeverityNormal,
+//		errclose.WithLabels(map[string]string{"shard": shardID}),
+//	)
+//
+// The options only apply when the resource is closed by Shutdown or [ShutdownManager.Abort], not
+// when it's closed right away because of [errclose.LateRegistrationClose].
+func (manager *ShutdownManager) DeferWith(
+	resource interface{ Close() error },
+	resourceName string,
+	phase int,
+	severity Severity,
+	options ...Option,
+) {
+	registered := newManagedResource(resource, resourceName, phase, severity, options)
+	manager.lock.Lock()
+	if !manager.shutDown {
+		manager.resources = append(manager.resources, registered)
+		manager.lock.Unlock()
+		return
+	}
+	manager.lock.Unlock()
+
+	handleLateRegistration(manager, registered)
+}
+
+// errCriticalCloseFailure is appended to the error returned by Shutdown when it aborts the
+// remaining phases after a critical close failure.
+var errCriticalCloseFailure = fmt.Errorf("critical close failure: %w", ErrShutdownAborted)
+
+// severityFilter returns a function for closeHooks.propagate, which drops close errors from
+// best-effort resources, and sets criticalFailed if a critical resource failed to close. It's
+// called by closeConcurrently after all closes have finished, so criticalFailed doesn't have to be
+// synchronized.
+func severityFilter(
+	resources []managedResource,
+	criticalFailed *bool,
+) func(i int, closeErr error) bool {
+	return func(i int, _ error) bool {
+		switch resources[i].severity {
+		case SeverityBestEffort:
+			return false
+		case SeverityCritical:
+			*criticalFailed = true
+			return true
+		case SeverityNormal:
+			fallthrough
+		default:
+			return true
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestSeverityCritical(t *testing.T) {
+	var manager errclose.ShutdownManager
+	database := openFileWithCloseError()
+	cache := openFileWithoutCloseError()
+	exporter := openFileWithoutCloseError()
+	manager.DeferSeverity(database, "database", 0, errclose.SeverityCritical)
+	manager.Defer(cache, "cache")
+	manager.DeferPhase(exporter, "exporter", 1)
+
+	var phases []int
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		if event.Kind == errclose.ShutdownEventPhaseStarted {
+			phases = append(phases, event.Phase)
+		}
+	})
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close database: close error (and critical close failure: shutdown aborted)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, database.closeError), true, "errors.Is(closeError)")
+	assertEqual(
+		t,
+		errors.Is(err, errclose.ErrShutdownAborted),
+		true,
+		"errors.Is(ErrShutdownAborted)",
+	)
+	assertEqual(t, cache.closeWasCalled, true, "cache closed in same phase")
+	assertEqual(t, exporter.closeWasCalled, true, "exporter closed by abort")
+	assertEqual(t, phases, []int{0}, "started phases")
+}
+
+func TestSeverityCriticalInLastPhase(t *testing.T) {
+	var manager errclose.ShutdownManager
+	manager.DeferSeverity(openFileWithCloseError(), "database", 0, errclose.SeverityCritical)
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err.Error(), "failed to close database: close error", "error string")
+}
+
+func TestSeverityBestEffort(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	var observed []string
+	errclose.SetObserver(func(resourceName string, _ error) {
+		observed = append(observed, resourceName)
+	})
+
+	var manager errclose.ShutdownManager
+	manager.DeferSeverity(openFileWithCloseError(), "exporter", 0, errclose.SeverityBestEffort)
+	manager.DeferSeverity(openFileWithCloseError(), "tracer", 1, errclose.SeverityBestEffort)
+	manager.SetPhaseConfig(1, errclose.PhaseConfig{Parallel: true, Timeout: 0})
+
+	var failed []string
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		if event.Kind == errclose.ShutdownEventResourceFailed {
+			failed = append(failed, event.ResourceName)
+		}
+	})
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, observed, []string{"exporter", "tracer"}, "observed close errors")
+	assertEqual(t, failed, []string{"exporter", "tracer"}, "failed events")
+}
+
+func TestSeverityBestEffortWithAbort(t *testing.T) {
+	var manager errclose.ShutdownManager
+	manager.DeferSeverity(openFileWithCloseError(), "exporter", 0, errclose.SeverityBestEffort)
+	manager.Defer(openFileWithCloseError(), "file")
+
+	err := manager.Abort()
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestSeverityInPlan(t *testing.T) {
+	var manager errclose.ShutdownManager
+	manager.DeferSeverity(openFileWithoutCloseError(), "database", 1, errclose.SeverityCritical)
+
+	assertEqual(
+		t,
+		planWithoutIDs(t, manager.Plan()),
+		[]errclose.ShutdownStep{
+			{Phase: 1, ResourceName: "database", Severity: errclose.SeverityCritical},
+		},
+		"plan",
+	)
+	assertEqual(t, errclose.SeverityCritical.String(), "critical", "severity string")
+}
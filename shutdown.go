@@ -0,0 +1,56 @@
+package errclose
+
+import (
+	"context"
+)
+
+// Shutdown gracefully shuts down the given resource, and handles shutdown errors in the same way as
+// [errclose.Close] handles close errors. This is meant for servers with a Shutdown method, such as
+// [http.Server]:
+//
+//	func serve(ctx context.Context) (returnedErr error) {
+//		server := &http.Server{Addr: ":8000", Handler: handler}
+//		defer func() {
+//			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//			defer cancel()
+//			errclose.Shutdown(shutdownCtx, server, &returnedErr, "HTTP server")
+//		}()
+//
+//		// Run server
+//	}
+//
+// The error format is the same as for errclose.Close, except that the message says "shut down"
+// instead of "close", to distinguish shutdown failures from close failures:
+//
+//	failed to shut down <resourceName>: <shutdown error>
+//	<existing error> (and failed to shut down <resourceName>: <shutdown error>)
+//
+// If the resource is nil, the shutdown error is [errclose.ErrNilResource].
+func Shutdown(
+	ctx context.Context,
+	resource interface{ Shutdown(context.Context) error },
+	returnedErr *error,
+	resourceName string,
+) {
+	if isNilResource(resource) {
+		handleNilTeardown(returnedErr, "shut down", resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	shutdownErr := resource.Shutdown(ctx)
+	if shutdownErr == nil {
+		return
+	}
+
+	handleContextTeardownError(
+		ctx,
+		returnedErr,
+		shutdownErr,
+		"shut down",
+		resourceName,
+		withDefaults(resource, nil),
+		nil,
+		1,
+	)
+}
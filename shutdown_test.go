@@ -0,0 +1,49 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestShutdown(t *testing.T) {
+	server := &http.Server{Addr: "127.0.0.1:0"}
+
+	useServer := func() (returnedErr error) {
+		defer errclose.Shutdown(context.Background(), server, &returnedErr, "HTTP server")
+		return nil
+	}
+
+	err := useServer()
+	assertEqual(t, err, nil, "error")
+}
+
+func TestShutdownError(t *testing.T) {
+	shutdownErr := errors.New("connections still active")
+	server := mockServer{shutdownErr: shutdownErr}
+
+	useServer := func() (returnedErr error) {
+		defer errclose.Shutdown(context.Background(), server, &returnedErr, "HTTP server")
+		return fallibleOperation()
+	}
+
+	err := useServer()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to shut down HTTP server: connections still active)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, shutdownErr), true, "errors.Is(shutdownErr)")
+}
+
+type mockServer struct {
+	shutdownErr error
+}
+
+func (server mockServer) Shutdown(context.Context) error {
+	return server.shutdownErr
+}
@@ -0,0 +1,159 @@
+package errclose
+
+import (
+	"time"
+)
+
+// ShutdownEvent describes the progress of a [errclose.ShutdownManager] shutting down, for
+// observing shutdowns with [ShutdownManager.OnEvent].
+type ShutdownEvent struct {
+	Kind ShutdownEventKind
+	// The shutdown phase of the resource (see [ShutdownManager.DeferPhase]), or the phase that's
+	// starting for [errclose.ShutdownEventPhaseStarted]. Zero for [errclose.ShutdownEventDone].
+	Phase int
+	// The name that the resource was registered with. Empty for
+	// [errclose.ShutdownEventPhaseStarted] and [errclose.ShutdownEventDone].
+	ResourceName string
+	// The ID that the resource was registered with (see [errclose.ResourceID]). Zero for
+	// [errclose.ShutdownEventPhaseStarted] and [errclose.ShutdownEventDone].
+	ResourceID ResourceID
+	// The error from closing the resource for [errclose.ShutdownEventResourceFailed], formatted
+	// as in [errclose.Close] (see [errclose.CloseError]), or the error returned by
+	// [ShutdownManager.Shutdown] or [ShutdownManager.Abort] for [errclose.ShutdownEventDone].
+	// Nil for other events.
+	Err error
+	// When the event happened.
+	Time time.Time
+}
+
+// ShutdownEventKind is the kind of a [errclose.ShutdownEvent].
+type ShutdownEventKind int8
+
+const (
+	// ShutdownEventPhaseStarted is emitted before the first resource in a shutdown phase is
+	// closed. Phases with no resources are skipped.
+	ShutdownEventPhaseStarted ShutdownEventKind = iota
+	// ShutdownEventResourceClosing is emitted before a resource is closed.
+	ShutdownEventResourceClosing
+	// ShutdownEventResourceClosed is emitted after a resource was closed successfully.
+	ShutdownEventResourceClosed
+	// ShutdownEventResourceFailed is emitted after a resource failed to close, with the error
+	// (which also includes errors from preparing or draining the resource).
+	ShutdownEventResourceFailed
+	// ShutdownEventDone is emitted when a call to [ShutdownManager.Shutdown] or
+	// [ShutdownManager.Abort] is about to return, with the returned error.
+	ShutdownEventDone
+)
+
+// String returns the name of the event kind in snake case, e.g. "resource_closed".
+func (kind ShutdownEventKind) String() string {
+	switch kind {
+	case ShutdownEventPhaseStarted:
+		return "phase_started"
+	case ShutdownEventResourceClosing:
+		return "resource_closing"
+	case ShutdownEventResourceClosed:
+		return "resource_closed"
+	case ShutdownEventResourceFailed:
+		return "resource_failed"
+	case ShutdownEventDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// OnEvent registers a function to be called with events as the manager shuts down, so that logs,
+// progress UIs and tests can follow the shutdown without re-deriving it from the returned error:
+//
+//	shutdown.OnEvent(func(event errclose.ShutdownEvent) {
+//		if event.Kind == errclose.ShutdownEventResourceClosing {
+//			slog.Info("Closing " + event.ResourceName)
+//		}
+//	})
+//
+// For each resource, [ShutdownManager.Shutdown] emits [errclose.ShutdownEventResourceClosing]
+// before closing it, then [errclose.ShutdownEventResourceClosed] or
+// [errclose.ShutdownEventResourceFailed] after, and [errclose.ShutdownEventPhaseStarted] whenever
+// it moves on to a new phase. Each call to Shutdown ends with [errclose.ShutdownEventDone].
+// [ShutdownManager.Abort] emits the same resource events and a Done event, but no phase events,
+// since it closes all phases at once.
+//
+// Event handlers are called synchronously, in the order they were registered, so a slow handler
+// slows down the shutdown. During Abort, they may be called concurrently. Handlers must not call
+// OnEvent themselves.
+func (manager *ShutdownManager) OnEvent(handler func(event ShutdownEvent)) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	manager.eventHandlers = append(manager.eventHandlers, handler)
+}
+
+type shutdownEventHandlers []func(event ShutdownEvent)
+
+// getEventHandlers returns the event handlers registered with the manager, which can be used
+// without holding the lock, since OnEvent only appends to them.
+func (manager *ShutdownManager) getEventHandlers() shutdownEventHandlers {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	return manager.eventHandlers[:len(manager.eventHandlers):len(manager.eventHandlers)]
+}
+
+func (handlers shutdownEventHandlers) emit(
+	kind ShutdownEventKind,
+	phase int,
+	resourceName string,
+	resourceID ResourceID,
+	err error,
+) {
+	if len(handlers) == 0 {
+		return
+	}
+
+	event := ShutdownEvent{
+		Kind:         kind,
+		Phase:        phase,
+		ResourceName: resourceName,
+		ResourceID:   resourceID,
+		Err:          err,
+		Time:         time.Now(),
+	}
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// emitClosing emits [errclose.ShutdownEventResourceClosing] for the given resource.
+func (handlers shutdownEventHandlers) emitClosing(resource managedResource) {
+	handlers.emit(
+		ShutdownEventResourceClosing,
+		resource.phase,
+		resource.resourceName,
+		resource.id,
+		nil,
+	)
+}
+
+// emitClosed emits [errclose.ShutdownEventResourceFailed] for the given resource if closeErr is
+// non-nil, or [errclose.ShutdownEventResourceClosed] otherwise.
+func (handlers shutdownEventHandlers) emitClosed(resource managedResource, closeErr error) {
+	kind := ShutdownEventResourceClosed
+	if closeErr != nil {
+		kind = ShutdownEventResourceFailed
+	}
+	handlers.emit(kind, resource.phase, resource.resourceName, resource.id, closeErr)
+}
+
+// eventHooks returns hooks for closeConcurrently that emit events for the given resources.
+func eventHooks(handlers shutdownEventHandlers, resources []managedResource) closeHooks {
+	return closeHooks{
+		before: func(i int) {
+			handlers.emitClosing(resources[i])
+		},
+		after: func(i int, closeErr error) {
+			handlers.emitClosed(resources[i], closeErr)
+		},
+		propagate: nil,
+	}
+}
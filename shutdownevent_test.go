@@ -0,0 +1,93 @@
+package errclose_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+// recordEvents registers an event handler on the manager that formats the events as strings.
+func recordEvents(manager *errclose.ShutdownManager) func() []string {
+	var lock sync.Mutex
+	var events []string
+	manager.OnEvent(func(event errclose.ShutdownEvent) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if event.Time.IsZero() {
+			panic("event time not set")
+		}
+		formatted := fmt.Sprintf("%v phase=%d", event.Kind, event.Phase)
+		if event.ResourceName != "" {
+			formatted += " resource=" + event.ResourceName
+		}
+		if event.Err != nil {
+			formatted += " error=" + event.Err.Error()
+		}
+		events = append(events, formatted)
+	})
+
+	return func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return events
+	}
+}
+
+func TestShutdownEvents(t *testing.T) {
+	var manager errclose.ShutdownManager
+	events := recordEvents(&manager)
+	manager.DeferPhase(openFileWithoutCloseError(), "database", 1)
+	manager.DeferPhase(openFileWithCloseError(), "server", -1)
+	manager.Defer(openFileWithoutCloseError(), "cache")
+
+	err := manager.Shutdown(context.Background())
+	assertEqual(t, err.Error(), "failed to close server: close error", "error string")
+	assertEqual(
+		t,
+		events(),
+		[]string{
+			"phase_started phase=-1",
+			"resource_closing phase=-1 resource=server",
+			"resource_failed phase=-1 resource=server error=failed to close server: close error",
+			"phase_started phase=0",
+			"resource_closing phase=0 resource=cache",
+			"resource_closed phase=0 resource=cache",
+			"phase_started phase=1",
+			"resource_closing phase=1 resource=database",
+			"resource_closed phase=1 resource=database",
+			"done phase=0 error=failed to close server: close error",
+		},
+		"events",
+	)
+}
+
+func TestShutdownEventsFromAbort(t *testing.T) {
+	var manager errclose.ShutdownManager
+	events := recordEvents(&manager)
+	manager.DeferPhase(openFileWithoutCloseError(), "database", 1)
+	manager.Defer(openFileWithCloseError(), "cache")
+
+	err := manager.Abort()
+	assertEqual(t, err.Error(), "failed to close cache: close error", "error string")
+
+	// Abort closes resources concurrently, so sort the resource events
+	recorded := events()
+	slices.Sort(recorded[:len(recorded)-1])
+	assertEqual(
+		t,
+		recorded,
+		[]string{
+			"resource_closed phase=1 resource=database",
+			"resource_closing phase=0 resource=cache",
+			"resource_closing phase=1 resource=database",
+			"resource_failed phase=0 resource=cache error=failed to close cache: close error",
+			"done phase=0 error=failed to close cache: close error",
+		},
+		"events",
+	)
+}
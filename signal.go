@@ -0,0 +1,105 @@
+package errclose
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// OnSignal starts a goroutine that waits until the program receives one of the given signals (or
+// the given context is done), then shuts down the given manager by calling
+// [ShutdownManager.Shutdown]. It returns a channel that's closed when the shutdown has completed.
+// This is the glue for graceful shutdown of long-running services:
+//
+//	func main() {
+//		shutdownDone := errclose.OnSignal(
+//			context.Background(),
+//			errclose.GlobalShutdownManager(),
+//			10*time.Second,
+//			func(err error) {
+//				slog.Error("Shutdown failed", "error", err)
+//			},
+//			os.Interrupt,
+//			syscall.SIGTERM,
+//		)
+//
+//		// Start servers, and register them with errclose.DeferGlobal
+//
+//		<-shutdownDone
+//	}
+//
+// Wait for the returned channel before returning from main, since the program exits when main
+// returns, even if the shutdown is still running. If no signals are given, OnSignal waits for
+// [os.Interrupt] and [syscall.SIGTERM].
+//
+// The shutdown is given the grace period as a deadline, through the context passed to Shutdown
+// (which derives from the given context, but is not canceled with it). If the grace period is 0 or
+// less, the shutdown has no deadline.
+//
+// If the shutdown fails, the error is passed to the given report function (unless it's nil),
+// before the returned channel is closed.
+//
+// If the program receives one of the signals again while the shutdown is running, OnSignal calls
+// [ShutdownManager.Abort], so that a second Ctrl+C closes the remaining resources right away. The
+// returned channel is then closed once Abort returns, and the error from Abort is reported, along
+// with the error from Shutdown if it has returned by then. After the shutdown (or abort) has
+// completed, OnSignal stops listening for the signals, so later signals are handled as if OnSignal
+// was never called (for [os.Interrupt], this terminates the program by default).
+func OnSignal(
+	ctx context.Context,
+	manager *ShutdownManager,
+	gracePeriod time.Duration,
+	report func(err error),
+	signals ...os.Signal,
+) <-chan struct{} {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	// Register for the signals before returning, so none are missed after OnSignal returns
+	signalCtx, stop := signal.NotifyContext(ctx, signals...)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		<-signalCtx.Done()
+		// Listen for a second signal before stopping the first listener, so none are missed
+		secondSignal := make(chan os.Signal, 1)
+		signal.Notify(secondSignal, signals...)
+		defer signal.Stop(secondSignal)
+		stop()
+
+		shutdownCtx := context.WithoutCancel(ctx)
+		if gracePeriod > 0 {
+			var cancel context.CancelFunc
+			shutdownCtx, cancel = context.WithTimeout(shutdownCtx, gracePeriod)
+			defer cancel()
+		}
+
+		shutdownErr := make(chan error, 1)
+		go func() {
+			shutdownErr <- manager.Shutdown(shutdownCtx)
+		}()
+
+		var err error
+		select {
+		case err = <-shutdownErr:
+		case <-secondSignal:
+			abortErr := manager.Abort()
+			// Shutdown returns soon after Abort, unless it's stuck closing a resource
+			select {
+			case err = <-shutdownErr:
+			default:
+			}
+			err = combineErrors(err, abortErr)
+		}
+
+		if err != nil && report != nil {
+			report(err)
+		}
+	}()
+	return done
+}
@@ -0,0 +1,25 @@
+package errclose_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestOnSignalWithCanceledContext(t *testing.T) {
+	var manager errclose.ShutdownManager
+	file := openFileWithCloseError()
+	manager.Defer(file, "file")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var reported error
+	done := errclose.OnSignal(ctx, &manager, time.Second, func(err error) { reported = err })
+
+	cancel()
+	<-done
+
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+	assertEqual(t, reported.Error(), "failed to close file: close error", "reported error")
+}
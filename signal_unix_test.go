@@ -0,0 +1,52 @@
+//go:build unix
+
+package errclose_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+)
+
+func TestOnSignalWithSignal(t *testing.T) {
+	var manager errclose.ShutdownManager
+	file := openFileWithoutCloseError()
+	manager.Defer(file, "file")
+
+	done := errclose.OnSignal(context.Background(), &manager, time.Second, nil, syscall.SIGUSR1)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+}
+
+func TestOnSignalAbortsOnSecondSignal(t *testing.T) {
+	var manager errclose.ShutdownManager
+	file := openFileWithoutCloseError()
+	server := newBlockingResource()
+	manager.Defer(file, "file")
+	manager.Defer(server, "server")
+
+	done := errclose.OnSignal(context.Background(), &manager, 0, nil, syscall.SIGUSR1)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	<-server.closeStarted
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+	assertEqual(
+		t,
+		context.Cause(server.closeCtx),
+		errclose.ErrShutdownAborted,
+		"cause of server close context",
+	)
+}
@@ -0,0 +1,107 @@
+package errclose
+
+import (
+	"sync"
+)
+
+// ErrorSink collects errors from several goroutines, for functions that close resources in
+// background goroutines. The error pointed to by a returnedErr pointer must only be written by the
+// goroutine that returns it, so background goroutines can't pass it to [errclose.Close]. Instead,
+// they can close resources with [ErrorSink.Close], and the function can merge the collected errors
+// into its return value with [ErrorSink.Into] once the goroutines are done:
+//
+//	func proxy(conn net.Conn, upstream net.Conn) (returnedErr error) {
+//		var sink errclose.ErrorSink
+//		defer sink.Into(&returnedErr)
+//
+//		var wg sync.WaitGroup
+//		defer wg.Wait()
+//
+//		wg.Add(1)
+//		go func() {
+//			defer wg.Done()
+//			_, err := io.Copy(upstream, conn)
+//			sink.Set(err)
+//			sink.Close(upstream, "upstream connection")
+//		}()
+//
+//		// Copy from upstream to conn
+//	}
+//
+// Errors are combined on the same format as [errclose.Close] combines errors, in the order they
+// were added. See [errclose.Collector] for a variant for use on a single goroutine.
+//
+// The zero value is ready to use. An ErrorSink must not be copied after first use.
+type ErrorSink struct {
+	lock sync.Mutex
+	err  error
+	// The errors added with Set, which unlike close errors haven't been reported to the event log
+	// and observer.
+	unreported error
+}
+
+// Set adds the given error to the sink, combining it with previously added errors. If the error is
+// nil, Set does nothing. Set is safe for concurrent use.
+func (sink *ErrorSink) Set(err error) {
+	if err == nil {
+		return
+	}
+
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	sink.err = combineErrors(sink.err, err)
+	sink.unreported = combineErrors(sink.unreported, err)
+}
+
+// Close closes the given resource, and adds the close error (if any) to the sink, formatted in the
+// same way as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+//
+// Close is safe for concurrent use. The sink is not locked while the resource is closing.
+func (sink *ErrorSink) Close(
+	resource interface{ Close() error },
+	resourceName string,
+	options ...Option,
+) {
+	var closeErr error
+	closeResource(resource, &closeErr, resourceName, options, 1)
+	if closeErr == nil {
+		return
+	}
+
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	combineIntoReturnedErr(&sink.err, closeErr, resourceName)
+}
+
+// Err returns the errors added to the sink so far, combined into one error, or nil if there are
+// none. Err is safe for concurrent use.
+func (sink *ErrorSink) Err() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	return sink.err
+}
+
+// Into combines the errors added to the sink with the error pointed to by returnedErr, like
+// [Collector.Into], and empties the sink. It must only be called by the goroutine that owns
+// returnedErr, after the goroutines that add errors to the sink are done.
+func (sink *ErrorSink) Into(returnedErr *error) {
+	sink.lock.Lock()
+	err, unreported := sink.err, sink.unreported
+	sink.err, sink.unreported = nil, nil
+	sink.lock.Unlock()
+
+	if err == nil {
+		return
+	}
+
+	if returnedErr == nil {
+		handleNilCollectedErr(err, unreported)
+		return
+	}
+	*returnedErr = combineErrors(*returnedErr, err)
+}
@@ -0,0 +1,71 @@
+package errclose_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestErrorSink(t *testing.T) {
+	run := func() (returnedErr error) {
+		var sink errclose.ErrorSink
+		defer sink.Into(&returnedErr)
+
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sink.Close(openFileWithCloseError(), "file")
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink.Set(errors.New("copy error"))
+			sink.Set(nil)
+		}()
+
+		return fallibleOperation()
+	}
+
+	err := run()
+	message := err.Error()
+	assertEqual(t, strings.HasPrefix(message, "operation failed (and "), true, "error prefix")
+	assertEqual(
+		t,
+		strings.Count(message, "failed to close file: close error"),
+		10,
+		"close errors",
+	)
+	assertEqual(t, strings.Count(message, "copy error"), 1, "copy errors")
+}
+
+func TestErrorSinkCloseWithCaller(t *testing.T) {
+	var sink errclose.ErrorSink
+	sink.Close(openFileWithCloseError(), "file", errclose.WithCaller())
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(sink.Err(), &closeErr), true, "errors.As result")
+	assertEqual(
+		t,
+		strings.Contains(closeErr.Caller, "sink_test.go:"),
+		true,
+		"caller is the ErrorSink.Close call",
+	)
+}
+
+func TestErrorSinkWithoutErrors(t *testing.T) {
+	var sink errclose.ErrorSink
+	sink.Close(openFileWithoutCloseError(), "file")
+
+	var err error
+	sink.Into(&err)
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, sink.Err(), nil, "sink.Err()")
+}
@@ -0,0 +1,182 @@
+package errclose
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// CloseAndLog closes the given resource, and logs the close error (if any) instead of returning
+// it. This is for code paths where a close failure shouldn't fail the operation, such as closing
+// a response body after the response has been read:
+//
+//	defer errclose.CloseAndLog(response.Body, logger, slog.LevelWarn, "response body")
+//
+// The close error is logged with the given logger at the given level, with the message "Failed to
+// close resource", and the following attributes:
+//   - resource: The given resource name
+//   - error: The close error
+//   - fallback_error: The error from the fallback (only if a fallback given with
+//     [errclose.WithFallback] failed)
+//
+// If logger is nil, [slog.Default] is used.
+//
+// CloseAndLog takes the same options as [errclose.Close], so you can drop benign close errors
+// with [errclose.Ignore], or report them elsewhere as well with [errclose.Also]. The close error
+// is also written to the event log and passed to the observer, if they are set (see
+// [errclose.SetEventLog] and [errclose.SetObserver]). In strict mode (see [errclose.SetStrict]),
+// CloseAndLog panics with a [errclose.ClosePanic] instead of logging the close error.
+func CloseAndLog(
+	resource interface{ Close() error },
+	logger *slog.Logger,
+	level slog.Level,
+	resourceName string,
+	options ...Option,
+) {
+	lists := withDefaults(resource, options)
+	var closeErr error
+	if isNilResource(resource) {
+		closeErr = nilResourceError()
+		if closeErr != nil {
+			recordCloseAttempt(resourceName)
+		}
+	} else {
+		if metricsEnabled() {
+			recordCloseAttempt(resolveResourceName(resource, resourceName))
+		}
+		closeErr = closeWithOptions(resource, resourceName, lists)
+	}
+	if closeErr == nil {
+		return
+	}
+
+	resourceName = resolveResourceName(resource, resourceName)
+	if isIgnored(closeErr, resourceName, lists) {
+		return
+	}
+	reportAlso(lists, resourceName, closeErr)
+	fallbackErr := runFallbacks(lists, closeErr)
+	reportCloseFailure(resourceName, closeErr)
+
+	if strict.Load() {
+		panic(newClosePanic(resource, closeErr, resourceName, "close"))
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+	attrs := []slog.Attr{slog.String("resource", resourceName), slog.Any("error", closeErr)}
+	if fallbackErr != nil {
+		attrs = append(attrs, slog.Any("fallback_error", fallbackErr))
+	}
+	logger.LogAttrs(context.Background(), level, "Failed to close resource", attrs...)
+}
+
+// WithDebugLog returns an option that makes [errclose.Close] log successful closes with the given
+// logger at debug level, with the message "Closed resource", and the following attributes:
+//   - resource: The resource name
+//   - duration: How long the close took
+//
+// This is for tracing resource lifecycles during development, e.g. when looking for leaks, where
+// you want to see closes happening, not only failing:
+//
+//	defer errclose.Close(conn, &returnedErr, "connection", errclose.WithDebugLog(logger))
+//
+// The option doesn't change how close errors are handled, and failed closes are not logged. If
+// logger is nil, [slog.Default] is used. If multiple WithDebugLog options are given, the last one
+// is used. To trace closes without a logger, see [errclose.SetDebugEvents].
+func WithDebugLog(logger *slog.Logger) Option {
+	return Option{settings: &optionSettings{debugLog: &debugLogHook{logger: logger}}}
+}
+
+type debugLogHook struct {
+	logger *slog.Logger
+}
+
+func (hook *debugLogHook) log(resourceName string, took time.Duration) {
+	logger := hook.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(
+		context.Background(),
+		slog.LevelDebug,
+		"Closed resource",
+		slog.String("resource", resourceName),
+		slog.Duration("duration", took),
+	)
+}
+
+// LogValue implements [slog.LogValuer], so that logging a CloseError with [log/slog] emits
+// structured attributes instead of one long string:
+//   - message: The full error message
+//   - resource: The resource name
+//   - action: The teardown action that failed (e.g. "close" or "shut down")
+//   - error: The wrapped teardown error
+//   - stats: The stats snapshot (only if set, see [errclose.Stats])
+//   - os: The op, path, errno and hint from the OS detail (only if set, see
+//     [errclose.WithOSDetail])
+//   - resource_id: The resource ID (only if set, see [errclose.ResourceID])
+//   - labels: The labels, sorted by key (only if set, see [errclose.WithLabels])
+func (err *CloseError) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("message", err.Error()),
+		slog.String("resource", err.ResourceName),
+		slog.String("action", err.actionOrDefault()),
+		slog.Any("error", err.Err),
+	}
+	if err.Stats != nil {
+		attrs = append(attrs, slog.Any("stats", err.Stats))
+	}
+	if err.OSDetail != nil {
+		attrs = append(attrs, slog.Group(
+			"os",
+			slog.String("op", err.OSDetail.Op),
+			slog.String("path", err.OSDetail.Path),
+			slog.Any("errno", err.OSDetail.Errno),
+			slog.String("hint", err.OSDetail.Hint),
+		))
+	}
+	if err.ResourceID != 0 {
+		attrs = append(attrs, slog.Uint64("resource_id", uint64(err.ResourceID)))
+	}
+	if len(err.Labels) > 0 {
+		labels := make([]any, 0, len(err.Labels))
+		for _, key := range slices.Sorted(maps.Keys(err.Labels)) {
+			labels = append(labels, slog.String(key, err.Labels[key]))
+		}
+		attrs = append(attrs, slog.Group("labels", labels...))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements [slog.LogValuer] for errors combined by this package (see 'Error format' on
+// [errclose.Close]), with the following attributes:
+//   - message: The full error message
+//   - primary: The existing error that the other error was combined with
+//   - also: The error that was combined with the primary error (typically a [CloseError])
+func (err *combinedError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", err.Error()),
+		slog.Any("primary", err.errs[0]),
+		slog.Any("also", err.errs[1]),
+	)
+}
+
+// LogValue implements [slog.LogValuer], so that logging a PanicError with [log/slog] emits
+// structured attributes:
+//   - message: The full error message
+//   - action: The action given to [errclose.Recover]
+//   - value: The panic value
+//
+// The stack trace is left out, since it's often too long for log lines. Log the Stack field
+// explicitly if you need it.
+func (err *PanicError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", err.Error()),
+		slog.String("action", err.Action),
+		slog.Any("value", err.Value),
+	)
+}
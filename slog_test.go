@@ -0,0 +1,109 @@
+package errclose_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseAndLog(t *testing.T) {
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{
+		ReplaceAttr: removeTime,
+		AddSource:   false,
+		Level:       nil,
+	}))
+
+	file := openFileWithCloseError()
+	errclose.CloseAndLog(file, logger, slog.LevelWarn, "file")
+	assertEqual(t, file.closeWasCalled, true, "file.closeWasCalled")
+	assertEqual(
+		t,
+		output.String(),
+		`level=WARN msg="Failed to close resource" resource=file error="close error"`+"\n",
+		"log output",
+	)
+
+	output.Reset()
+	errclose.CloseAndLog(openFileWithoutCloseError(), logger, slog.LevelWarn, "file")
+	errclose.CloseAndLog(
+		&mockFile{closeWasCalled: false, closeError: os.ErrClosed},
+		logger,
+		slog.LevelWarn,
+		"file",
+		errclose.Ignore(os.ErrClosed),
+	)
+	assertEqual(t, output.String(), "", "log output without close error")
+}
+
+func removeTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{Key: "", Value: slog.Value{}}
+	}
+	return attr
+}
+
+func TestWithDebugLog(t *testing.T) {
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == "duration" {
+				return slog.String("duration", "<duration>")
+			}
+			return removeTime(groups, attr)
+		},
+		AddSource: false,
+		Level:     slog.LevelDebug,
+	}))
+
+	var err error
+	errclose.Close(openFileWithoutCloseError(), &err, "file", errclose.WithDebugLog(logger))
+	errclose.ClosefWith(
+		openFileWithoutCloseError(),
+		&err,
+		[]errclose.Option{errclose.WithDebugLog(logger)},
+		"file %d",
+		2,
+	)
+	errclose.Close(openFileWithCloseError(), &err, "failing file", errclose.WithDebugLog(logger))
+
+	assertEqual(t, err.Error(), "failed to close failing file: close error", "error string")
+	assertEqual(
+		t,
+		output.String(),
+		`level=DEBUG msg="Closed resource" resource=file duration=<duration>`+"\n"+
+			`level=DEBUG msg="Closed resource" resource="file 2" duration=<duration>`+"\n",
+		"log output",
+	)
+}
+
+func TestCloseErrorLogValue(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: removeTime,
+	}))
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	logger.Error("Request failed", "error", err)
+
+	err = errors.New("request error")
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	logger.Error("Request failed", "error", err)
+
+	assertEqual(
+		t,
+		buffer.String(),
+		`{"level":"ERROR","msg":"Request failed","error":{"message":"failed to close file: `+
+			`close error","resource":"file","action":"close","error":"close error"}}`+"\n"+
+			`{"level":"ERROR","msg":"Request failed","error":{"message":"request error `+
+			`(and failed to close file: close error)","primary":"request error",`+
+			`"also":{"message":"failed to close file: close error","resource":"file",`+
+			`"action":"close","error":"close error"}}}`+"\n",
+		"log output",
+	)
+}
@@ -0,0 +1,48 @@
+package errclose
+
+import (
+	"time"
+)
+
+// OnSlowClose returns an option that makes [errclose.Close] call the given report function if
+// closing the resource takes at least the given threshold, with the resource name and how long the
+// close took. This is for resources where a slow close is a problem in itself, even if it
+// succeeds, such as a buffered writer flushing a large file to object storage:
+//
+//	defer errclose.Close(
+//		writer,
+//		&returnedErr,
+//		"S3 object writer",
+//		errclose.OnSlowClose(10*time.Second, func(resourceName string, took time.Duration) {
+//			slog.Warn("Slow close", "resource", resourceName, "duration", took)
+//		}),
+//	)
+//
+// The report function is called after the close returns (also if it fails), and doesn't change
+// how close errors are handled. To find closes that hang, see [errclose.SetCloseDiagnostics]. If
+// multiple OnSlowClose options are given, the last one is used.
+func OnSlowClose(
+	threshold time.Duration,
+	report func(resourceName string, took time.Duration),
+) Option {
+	hook := &slowCloseHook{threshold: threshold, report: report}
+	return Option{settings: &optionSettings{slowClose: hook}}
+}
+
+type slowCloseHook struct {
+	threshold time.Duration
+	report    func(resourceName string, took time.Duration)
+}
+
+// needsCloseName returns true if the options use the resource name when closing the resource
+// (before a close error is handled), so the name must be formatted up front.
+func needsCloseName(options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if option.get().slowClose != nil || option.get().debugLog != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package errclose
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// Finish ends the given transaction based on the error pointed to by returnedErr: if it's nil, the
+// transaction is committed, otherwise it's rolled back. You'll typically call this in a defer
+// statement right after beginning a transaction, such as a [sql.Tx]:
+//
+//	func transferFunds(ctx context.Context, db *sql.DB) (returnedErr error) {
+//		tx, err := db.BeginTx(ctx, nil)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.Finish(tx, &returnedErr, "transfer transaction")
+//
+//		// Use tx
+//	}
+//
+// If committing fails, the commit error is wrapped on the following format:
+//
+//	failed to commit <resourceName>: <commit error>
+//
+// If rolling back fails, the rollback error is combined with the existing error:
+//
+//	<existing error> (and failed to roll back <resourceName>: <rollback error>)
+//
+// Rollback errors matching [sql.ErrTxDone] are ignored, since they just mean that the transaction
+// was already committed or rolled back.
+//
+// If the function panics, the transaction is rolled back before the panic continues. For this to
+// work, Finish must be called directly in the defer statement (not from a deferred closure), since
+// it uses recover to detect the panic. A rollback error in this case is written to the event log
+// and passed to the observer (see [errclose.SetEventLog] and [errclose.SetObserver]).
+//
+// If the transaction is nil, the commit or rollback error is [errclose.ErrNilResource].
+func Finish(
+	tx interface {
+		Commit() error
+		Rollback() error
+	},
+	returnedErr *error,
+	resourceName string,
+) {
+	if recovered := recover(); recovered != nil {
+		if !isNilResource(tx) {
+			recordCloseAttempt(resourceName)
+			if rollbackErr := tx.Rollback(); rollbackErr != nil &&
+				!errors.Is(rollbackErr, sql.ErrTxDone) {
+				reportCloseFailure(resourceName, rollbackErr)
+			}
+		}
+		panic(recovered)
+	}
+
+	if returnedErr == nil || *returnedErr == nil {
+		if isNilResource(tx) {
+			handleNilTeardown(returnedErr, "commit", resourceName)
+		} else {
+			recordCloseAttempt(resourceName)
+			if commitErr := tx.Commit(); commitErr != nil {
+				handleTeardownError(returnedErr, commitErr, "commit", resourceName)
+			}
+		}
+		return
+	}
+
+	if isNilResource(tx) {
+		handleNilTeardown(returnedErr, "roll back", resourceName)
+		return
+	}
+	recordCloseAttempt(resourceName)
+	if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+		handleTeardownError(returnedErr, rollbackErr, "roll back", resourceName)
+	}
+}
+
+// Rows closes the given rows, such as [sql.Rows], and checks the error from iterating over them,
+// which you'd otherwise have to check separately with rows.Err:
+//
+//	func listUsers(ctx context.Context, db *sql.DB) (users []User, returnedErr error) {
+//		rows, err := db.QueryContext(ctx, "SELECT id, name FROM users")
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer errclose.Rows(rows, &returnedErr, "user query")
+//
+//		for rows.Next() {
+//			// Scan rows
+//		}
+//		return users, nil
+//	}
+//
+// The iteration error (if any) is handled first, on the following format:
+//
+//	failed to read rows from <resourceName>: <iteration error>
+//
+// Then the rows are closed, and the close error is handled in the same way as [errclose.Close].
+// Both errors are combined with the existing error, if any:
+//
+//	<existing error> (and failed to read rows from <resourceName>: <iteration error>)
+//
+// If the rows are nil, the close error is [errclose.ErrNilResource].
+func Rows(
+	rows interface {
+		Err() error
+		Close() error
+	},
+	returnedErr *error,
+	resourceName string,
+) {
+	if isNilResource(rows) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+
+	recordCloseAttempt(resourceName)
+	if iterationErr := rows.Err(); iterationErr != nil {
+		handleTeardownError(returnedErr, iterationErr, "read rows from", resourceName)
+	}
+	Close(rows, returnedErr, resourceName)
+}
@@ -0,0 +1,166 @@
+package errclose_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestFinishCommits(t *testing.T) {
+	tx := &mockTx{commitErr: nil, rollbackErr: nil, committed: false, rolledBack: false}
+
+	transaction := func() (returnedErr error) {
+		defer errclose.Finish(tx, &returnedErr, "transaction")
+		return nil
+	}
+
+	err := transaction()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, tx.committed, true, "committed")
+	assertEqual(t, tx.rolledBack, false, "rolledBack")
+}
+
+func TestFinishCommitError(t *testing.T) {
+	tx := &mockTx{
+		commitErr:   errors.New("commit error"),
+		rollbackErr: nil,
+		committed:   false,
+		rolledBack:  false,
+	}
+
+	transaction := func() (returnedErr error) {
+		defer errclose.Finish(tx, &returnedErr, "transaction")
+		return nil
+	}
+
+	err := transaction()
+	assertEqual(t, err.Error(), "failed to commit transaction: commit error", "error string")
+}
+
+func TestFinishRollsBack(t *testing.T) {
+	tx := &mockTx{
+		commitErr:   nil,
+		rollbackErr: errors.New("rollback error"),
+		committed:   false,
+		rolledBack:  false,
+	}
+
+	transaction := func() (returnedErr error) {
+		defer errclose.Finish(tx, &returnedErr, "transaction")
+		return fallibleOperation()
+	}
+
+	err := transaction()
+	assertEqual(t, tx.committed, false, "committed")
+	assertEqual(t, tx.rolledBack, true, "rolledBack")
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to roll back transaction: rollback error)",
+		"error string",
+	)
+}
+
+func TestFinishIgnoresTxDone(t *testing.T) {
+	tx := &mockTx{commitErr: nil, rollbackErr: sql.ErrTxDone, committed: false, rolledBack: false}
+
+	transaction := func() (returnedErr error) {
+		defer errclose.Finish(tx, &returnedErr, "transaction")
+		return fallibleOperation()
+	}
+
+	err := transaction()
+	assertEqual(t, err.Error(), "operation failed", "error string")
+}
+
+func TestFinishRollsBackOnPanic(t *testing.T) {
+	tx := &mockTx{commitErr: nil, rollbackErr: nil, committed: false, rolledBack: false}
+
+	transaction := func() (returnedErr error) {
+		defer errclose.Finish(tx, &returnedErr, "transaction")
+		panic("transaction panicked")
+	}
+
+	func() {
+		defer func() {
+			assertEqual(t, recover(), "transaction panicked", "recovered value")
+		}()
+		_ = transaction()
+	}()
+	assertEqual(t, tx.committed, false, "committed")
+	assertEqual(t, tx.rolledBack, true, "rolledBack")
+}
+
+type mockTx struct {
+	commitErr   error
+	rollbackErr error
+	committed   bool
+	rolledBack  bool
+}
+
+func (tx *mockTx) Commit() error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *mockTx) Rollback() error {
+	tx.rolledBack = true
+	return tx.rollbackErr
+}
+
+func TestRows(t *testing.T) {
+	rows := &mockRows{iterationErr: errors.New("iteration error"), closeErr: nil, closed: false}
+
+	query := func() (returnedErr error) {
+		defer errclose.Rows(rows, &returnedErr, "user query")
+		return nil
+	}
+
+	err := query()
+	assertEqual(t, rows.closed, true, "closed")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to read rows from user query: iteration error",
+		"error string",
+	)
+}
+
+func TestRowsWithCloseError(t *testing.T) {
+	iterationErr := errors.New("iteration error")
+	closeErr := errors.New("close error")
+	rows := &mockRows{iterationErr: iterationErr, closeErr: closeErr, closed: false}
+
+	query := func() (returnedErr error) {
+		defer errclose.Rows(rows, &returnedErr, "user query")
+		return fallibleOperation()
+	}
+
+	err := query()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to read rows from user query: iteration error) "+
+			"(and failed to close user query: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, iterationErr), true, "errors.Is(err, iterationErr)")
+	assertEqual(t, errors.Is(err, closeErr), true, "errors.Is(err, closeErr)")
+}
+
+type mockRows struct {
+	iterationErr error
+	closeErr     error
+	closed       bool
+}
+
+func (rows *mockRows) Err() error {
+	return rows.iterationErr
+}
+
+func (rows *mockRows) Close() error {
+	rows.closed = true
+	return rows.closeErr
+}
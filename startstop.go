@@ -0,0 +1,67 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// Started is a handle to a started component, returned by [errclose.StartStop].
+type Started struct {
+	stop         func() error
+	resourceName string
+}
+
+// StartStop calls the given start function, and if it succeeds, returns a handle for calling the
+// corresponding stop function. This keeps start/stop pairs symmetric: the stop function is only
+// ever called for components that were successfully started, and errors from both halves are
+// formatted consistently.
+//
+//	func run() (returnedErr error) {
+//		worker, err := errclose.StartStop(pool.Start, pool.Stop, "worker pool")
+//		if err != nil {
+//			return err
+//		}
+//		defer worker.Stop(&returnedErr)
+//
+//		// Use worker pool
+//	}
+//
+// If start fails, the error is returned on the following format, and stop is never called:
+//
+//	failed to start <resourceName>: <start error>
+//
+// See [Started.Stop] for the format of stop errors.
+func StartStop(start func() error, stop func() error, resourceName string) (*Started, error) {
+	if err := start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", resourceName, err)
+	}
+
+	return &Started{stop: stop, resourceName: resourceName}, nil
+}
+
+// Stop calls the stop function given to [errclose.StartStop], and handles stop errors in the same
+// way as [errclose.Close] handles close errors, with the following format:
+//
+//	failed to stop <resourceName>: <stop error>
+//
+// If returnedErr points to an existing non-nil error, then the existing error and the stop error
+// are combined on the following format:
+//
+//	<existing error> (and failed to stop <resourceName>: <stop error>)
+func (started *Started) Stop(returnedErr *error) {
+	recordCloseAttempt(started.resourceName)
+	if err := started.stop(); err != nil {
+		handleTeardownError(returnedErr, err, "stop", started.resourceName)
+	}
+}
+
+// Close calls the stop function given to [errclose.StartStop]. This lets you pass the handle to
+// [errclose.Close], or register it in a [errclose.Frame] or with [errclose.DeferGlobal]. Stop
+// errors are formatted like in [Started.Stop], with the "stop" action rather than "close":
+//
+//	failed to stop <resourceName>: <stop error>
+func (started *Started) Close() error {
+	if err := started.stop(); err != nil {
+		return &teardownError{action: "stop", err: err, fatal: false}
+	}
+	return nil
+}
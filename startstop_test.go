@@ -0,0 +1,90 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestStartStop(t *testing.T) {
+	stopWasCalled := false
+	stop := func() error {
+		stopWasCalled = true
+		return nil
+	}
+
+	run := func() (returnedErr error) {
+		started, err := errclose.StartStop(func() error { return nil }, stop, "worker")
+		if err != nil {
+			return err
+		}
+		defer started.Stop(&returnedErr)
+
+		return nil
+	}
+
+	err := run()
+	assertEqual(t, err, nil, "error")
+	assertEqual(t, stopWasCalled, true, "stopWasCalled")
+}
+
+func TestStartStopWithStartError(t *testing.T) {
+	stopWasCalled := false
+	stop := func() error {
+		stopWasCalled = true
+		return nil
+	}
+
+	started, err := errclose.StartStop(fallibleOperation, stop, "worker")
+	assertEqual(t, started, (*errclose.Started)(nil), "started")
+	assertEqual(t, err.Error(), "failed to start worker: operation failed", "error string")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+	assertEqual(t, stopWasCalled, false, "stopWasCalled")
+}
+
+func TestStartStopWithStopError(t *testing.T) {
+	stopErr := errors.New("stop error")
+
+	run := func() (returnedErr error) {
+		started, err := errclose.StartStop(
+			func() error { return nil },
+			func() error { return stopErr },
+			"worker",
+		)
+		if err != nil {
+			return err
+		}
+		defer started.Stop(&returnedErr)
+
+		return fallibleOperation()
+	}
+
+	err := run()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to stop worker: stop error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, stopErr), true, "errors.Is(stopErr)")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is(errFallibleOperation)")
+}
+
+func TestStartedCloseWithStopError(t *testing.T) {
+	stopErr := errors.New("stop error")
+	started, err := errclose.StartStop(
+		func() error { return nil },
+		func() error { return stopErr },
+		"worker",
+	)
+	assertEqual(t, err, nil, "start error")
+
+	errclose.Close(started, &err, "worker")
+	assertEqual(t, err.Error(), "failed to stop worker: stop error", "error string")
+	assertEqual(t, errors.Is(err, stopErr), true, "errors.Is result")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.ResourceName, "worker", "resource name")
+}
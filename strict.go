@@ -0,0 +1,129 @@
+package errclose
+
+import (
+	"sync/atomic"
+)
+
+// MustClose closes the given resource, and panics if the close fails. The panic value is a
+// [errclose.ClosePanic], with the message:
+//
+//	failed to close <resourceName>: <close error>
+//
+// This is for tests and short-lived tools, where a close failure should crash loudly rather than
+// be handled:
+//
+//	file, err := os.Create(path)
+//	// ...
+//	defer errclose.MustClose(file, "output file")
+//
+// If the resource is nil, MustClose panics with [errclose.ErrNilResource] as the close error,
+// unless the nil resource policy is [errclose.NilResourceSkip].
+func MustClose(resource interface{ Close() error }, resourceName string) {
+	var closeErr error
+	if isNilResource(resource) {
+		closeErr = nilResourceError()
+		if closeErr != nil {
+			recordCloseAttempt(resourceName)
+		}
+	} else {
+		if metricsEnabled() {
+			recordCloseAttempt(resolveResourceName(resource, resourceName))
+		}
+		closeErr = resource.Close()
+	}
+	if closeErr == nil {
+		return
+	}
+
+	resourceName = resolveResourceName(resource, resourceName)
+	reportCloseFailure(resourceName, closeErr)
+	panic(newClosePanic(resource, closeErr, resourceName, "close"))
+}
+
+// ClosePanic is the value that [errclose.MustClose] panics with when the close fails, and that
+// strict mode panics with instead of logging close errors (see [errclose.SetStrict]). It's an error
+// with the same message as the close error:
+//
+//	failed to close <resourceName>: <close error>
+//
+// Recover-based frameworks, such as panic-reporting middleware, can detect it with a type
+// assertion on the recovered value, and report its fields as structured data instead of a bare
+// message:
+//
+//	if closePanic, ok := recovered.(*errclose.ClosePanic); ok {
+//		report(closePanic.Error(), closePanic.Labels, closePanic.AcquisitionSite)
+//	}
+//
+// ClosePanic wraps the [errclose.CloseError], so it can also be checked with [errors.Is] and
+// [errors.As]. With the %+v verb, it prints the details of the close error, followed by the labels
+// and acquisition site.
+type ClosePanic struct {
+	// Err is the close error.
+	Err *CloseError
+	// Labels describe the close that panicked, with the same keys as the [pprof] labels that the
+	// package sets on goroutines where it closes resources: errclose.resource (the resource name)
+	// and errclose.phase ("close", or "late registration" for resources closed because of
+	// [errclose.LateRegistrationClose]).
+	Labels map[string]string
+	// AcquisitionSite is where the resource was acquired, if the resource implements
+	// [errclose.AcquisitionSiteReporter]. Otherwise, it's empty.
+	AcquisitionSite string
+}
+
+func (err *ClosePanic) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the close error.
+func (err *ClosePanic) Unwrap() error {
+	return err.Err
+}
+
+// AcquisitionSiteReporter is an optional interface for resources that record where they were
+// acquired, such as the stack trace of the call that opened them. When closing the resource fails
+// with a panic (see [errclose.ClosePanic]), the site is included in the panic value, so that panic
+// reports show where the failing resource came from, not just where it was closed.
+//
+// AcquisitionSite is only called if the close panics. Resources tracked with errclosetest.Track
+// implement it, returning the stack trace of the Track call.
+type AcquisitionSiteReporter interface {
+	AcquisitionSite() string
+}
+
+func newClosePanic(
+	resource interface{ Close() error },
+	closeErr error,
+	resourceName string,
+	phase string,
+) *ClosePanic {
+	acquisitionSite := ""
+	if !isNilResource(resource) {
+		if reporter, ok := resource.(AcquisitionSiteReporter); ok {
+			acquisitionSite = reporter.AcquisitionSite()
+		}
+	}
+
+	return &ClosePanic{
+		Err:             newCloseError(closeErr, "close", resourceName),
+		Labels:          map[string]string{labelResource: resourceName, labelPhase: phase},
+		AcquisitionSite: acquisitionSite,
+	}
+}
+
+var strict atomic.Bool
+
+// SetStrict enables or disables strict mode. In strict mode, close errors that the package would
+// otherwise only log are turned into panics with a [errclose.ClosePanic], like in
+// [errclose.MustClose]:
+//   - [errclose.CloseAndLog] panics instead of logging the close error
+//   - A nil returnedErr pointer always panics, regardless of [errclose.NilErrorPolicy]
+//   - Resources closed because of [errclose.LateRegistrationClose] panic if the close fails
+//
+// Close errors dropped with [errclose.Ignore] are still dropped, since ignoring them is an
+// explicit choice at the call site.
+//
+// This is meant for tests and short-lived tools, where silently degrading hides bugs (strict mode
+// is disabled by default).
+func SetStrict(enabled bool) {
+	strict.Store(enabled)
+}
@@ -0,0 +1,156 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestMustClose(t *testing.T) {
+	file := openFileWithCloseError()
+
+	err := recoverError(func() { errclose.MustClose(file, "file") })
+	assertEqual(t, err.Error(), "failed to close file: close error", "panic error string")
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is close error")
+
+	err = recoverError(func() { errclose.MustClose(openFileWithoutCloseError(), "file") })
+	assertEqual(t, err, nil, "panic without close error")
+}
+
+func TestStrictCloseAndLog(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetStrict(true)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	err := recoverError(func() {
+		errclose.CloseAndLog(openFileWithCloseError(), logger, slog.LevelWarn, "file")
+	})
+	assertEqual(t, err.Error(), "failed to close file: close error", "panic error string")
+}
+
+func TestMustClosePanicValue(t *testing.T) {
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		errclose.MustClose(openFileWithCloseError(), "file")
+	}()
+
+	closePanic, ok := recovered.(*errclose.ClosePanic)
+	assertEqual(t, ok, true, "panic value is *ClosePanic")
+	assertEqual(t, closePanic.Err.ResourceName, "file", "closePanic.Err.ResourceName")
+	assertEqual(
+		t,
+		closePanic.Labels,
+		map[string]string{"errclose.resource": "file", "errclose.phase": "close"},
+		"closePanic.Labels",
+	)
+	assertEqual(t, closePanic.AcquisitionSite, "", "closePanic.AcquisitionSite")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(closePanic, &closeErr), true, "errors.As CloseError")
+	assertEqual(
+		t,
+		fmt.Sprintf("%+v", closePanic),
+		"failed to close file: close error\n"+
+			"    resource: file\n"+
+			"    action: close\n"+
+			`    labels: errclose.phase="close" errclose.resource="file"`,
+		"%+v output",
+	)
+}
+
+func TestClosePanicAcquisitionSite(t *testing.T) {
+	resource := acquiredFile{
+		mockFile:        *openFileWithCloseError(),
+		acquisitionSite: "main.go:42",
+	}
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		errclose.MustClose(&resource, "file")
+	}()
+
+	closePanic, ok := recovered.(*errclose.ClosePanic)
+	assertEqual(t, ok, true, "panic value is *ClosePanic")
+	assertEqual(t, closePanic.AcquisitionSite, "main.go:42", "closePanic.AcquisitionSite")
+	assertEqual(
+		t,
+		fmt.Sprintf("%+v", closePanic),
+		"failed to close file: close error\n"+
+			"    resource: file\n"+
+			"    action: close\n"+
+			`    labels: errclose.phase="close" errclose.resource="file"`+"\n"+
+			"    acquired at: main.go:42",
+		"%+v output",
+	)
+}
+
+func TestStrictLateRegistrationClosePanicValue(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetStrict(true)
+	errclose.SetLateRegistrationPolicy(errclose.LateRegistrationClose)
+
+	err := errclose.ShutdownGlobal(context.Background())
+	assertEqual(t, err, nil, "error from ShutdownGlobal")
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		errclose.DeferGlobal(openFileWithCloseError(), "late file")
+	}()
+
+	closePanic, ok := recovered.(*errclose.ClosePanic)
+	assertEqual(t, ok, true, "panic value is *ClosePanic")
+	assertEqual(
+		t,
+		closePanic.Labels,
+		map[string]string{"errclose.resource": "late file", "errclose.phase": "late registration"},
+		"closePanic.Labels",
+	)
+}
+
+func TestStrictOverridesNilErrorPolicy(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetNilErrorPolicy(errclose.NilErrorLog)
+	errclose.SetStrict(true)
+
+	err := recoverError(func() { errclose.Close(openFileWithCloseError(), nil, "file") })
+	assertEqual(
+		t,
+		err.Error(),
+		"errclose: got nil returnedErr pointer when trying to close file: close error",
+		"panic error string",
+	)
+}
+
+type acquiredFile struct {
+	mockFile
+	acquisitionSite string
+}
+
+func (file *acquiredFile) AcquisitionSite() string {
+	return file.acquisitionSite
+}
+
+// recoverError calls the given function, and returns the error it panicked with (or nil if it
+// didn't panic).
+func recoverError(function func()) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			recoveredErr, ok := recovered.(error)
+			if !ok {
+				panic(recovered)
+			}
+			err = recoveredErr
+		}
+	}()
+
+	function()
+	return nil
+}
@@ -0,0 +1,86 @@
+package errclose
+
+import (
+	"sync/atomic"
+)
+
+var repeatSummary atomic.Bool
+
+// SetRepeatSummary enables or disables summarizing repeated close failures (disabled by default).
+// This is for long-running loops that keep failing to close the same kind of resource, such as a
+// batch job that opens a connection per item while the database is down, where every failure
+// would otherwise grow the returned error and produce an event log entry:
+//
+//	var collector errclose.Collector
+//	defer collector.Into(&returnedErr)
+//
+//	for item := range items {
+//		conn, err := pool.Get(ctx)
+//		// ...
+//		collector.Close(conn, "database connection")
+//	}
+//
+// When enabled, only the 1st, 2nd, 4th, 8th (and so on, doubling) occurrence of an identical close
+// failure is written to the event log and passed to the observer (see [errclose.SetEventLog] and
+// [errclose.SetObserver]), while the other occurrences are only counted. Metrics set with
+// [errclose.SetMetrics] still count every failure.
+//
+// In [Collector.Close], close failures with the same message are then included only once in the
+// collected errors, with the final count in [CloseError.Occurrences], and at the end of the
+// message if it's more than 1:
+//
+//	failed to close <resourceName>: <close error> (occurred <count> times)
+//
+// [Frame.Err], [Frame.CloseAll] and [errclose.CloseAll] already include resources that fail with
+// the same close error only once (see [Frame.Err]), so for them, only the event log entries and
+// observer calls are summarized.
+func SetRepeatSummary(enabled bool) {
+	repeatSummary.Store(enabled)
+}
+
+func repeatSummaryEnabled() bool {
+	return repeatSummary.Load()
+}
+
+// isSummaryOccurrence returns true if the given occurrence of a repeated close failure (counting
+// from 1) should be reported when summarizing repeated failures: the 1st, 2nd, 4th, 8th and so on.
+func isSummaryOccurrence(occurrence int) bool {
+	return occurrence > 0 && occurrence&(occurrence-1) == 0
+}
+
+// withReportFilter returns the given options with an internal option that makes close failures
+// only be reported to the event log and observer if the filter returns true (they're still counted
+// in the metrics). The given options slice is not modified.
+func withReportFilter(options []Option, filter func(closeErr *CloseError) bool) []Option {
+	filtered := make([]Option, len(options), len(options)+1)
+	copy(filtered, options)
+	return append(filtered, Option{settings: &optionSettings{reportFilter: filter}})
+}
+
+// reportCloseFailureWithOptions works like reportCloseFailure, but only records the failure in the
+// metrics if a filter added with withReportFilter rejects it, and counts the failure once per
+// attempt added with withCloseAttempt.
+func reportCloseFailureWithOptions(closeErr *CloseError, options optionLists) {
+	if !isReportFiltered(closeErr, options) {
+		logWrappedCloseFailure(closeErr)
+	}
+	if attempt := attemptFromOptions(options); attempt != nil {
+		if attempt.failed {
+			return
+		}
+		attempt.failed = true
+	}
+	recordCloseFailure(closeErr.ResourceName, withHookDetails(closeErr.Err, "", 0, closeErr.Labels))
+}
+
+// isReportFiltered returns true if a filter added with withReportFilter rejects the close error.
+func isReportFiltered(closeErr *CloseError, options optionLists) bool {
+	for _, list := range options {
+		for _, option := range list {
+			if filter := option.get().reportFilter; filter != nil && !filter(closeErr) {
+				return true
+			}
+		}
+	}
+	return false
+}
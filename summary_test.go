@@ -0,0 +1,67 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCollectorWithRepeatSummary(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetRepeatSummary(true)
+	var observed int
+	errclose.SetObserver(func(string, error) { observed++ })
+
+	connErr := errors.New("connection refused")
+	var collector errclose.Collector
+	for range 10 {
+		collector.Close(closerFunc(func() error { return connErr }), "connection")
+	}
+	collector.Add(errors.New("remove failed"))
+	collector.Close(openFileWithCloseError(), "file")
+
+	err := collector.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close connection: connection refused (occurred 10 times) "+
+			"(and remove failed) (and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, connErr), true, "errors.Is(err, connErr)")
+	assertEqual(t, errclose.Errors(err)[0].Occurrences, 10, "CloseError.Occurrences")
+	// The 1st, 2nd, 4th and 8th connection failures, and the file failure
+	assertEqual(t, observed, 5, "number of observed close failures")
+
+	var returnedErr error
+	collector.Into(&returnedErr)
+	assertEqual(t, returnedErr.Error(), err.Error(), "error string from Into")
+	assertEqual(t, collector.Err(), nil, "error after Into")
+}
+
+func TestFrameWithRepeatSummary(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetRepeatSummary(true)
+	var observed []string
+	errclose.SetObserver(func(resourceName string, _ error) {
+		observed = append(observed, resourceName)
+	})
+
+	connErr := errors.New("connection reset")
+	brokenConn := closerFunc(func() error { return connErr })
+
+	var frame errclose.Frame
+	for _, name := range []string{"e", "d", "c", "b", "a"} {
+		frame.Add(brokenConn, name)
+	}
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close 5 resources (a, b, c, …): connection reset",
+		"error string",
+	)
+	assertEqual(t, observed, []string{"a", "b", "d"}, "observed resource names")
+}
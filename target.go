@@ -0,0 +1,92 @@
+package errclose
+
+// ErrorTarget is where [errclose.CloseTo] and the other To variants in this package put close
+// errors, as an alternative to a pointer to a returned error. This is for frameworks with their
+// own error accumulation, such as request-scoped error collectors, which can't give a pointer to
+// an error.
+//
+// The functions taking an ErrorTarget call Err to get the existing error, combine it with the
+// close error in the same way as [errclose.Close] does, and call SetErr with the result. For a
+// pointer to an error, use [errclose.ErrorPointer].
+type ErrorTarget interface {
+	Err() error
+	SetErr(err error)
+}
+
+// ErrorPointer returns an [errclose.ErrorTarget] that reads and writes the error pointed to by
+// returnedErr. This lets code that takes an ErrorTarget be used with a named error return:
+//
+//	func example() (returnedErr error) {
+//		target := errclose.ErrorPointer(&returnedErr)
+//		// Pass target to code that takes an ErrorTarget
+//	}
+//
+// Unlike [errclose.Close], the returned target doesn't handle a nil returnedErr pointer, so
+// returnedErr must not be nil.
+func ErrorPointer(returnedErr *error) ErrorTarget {
+	return errorPointer{returnedErr: returnedErr}
+}
+
+type errorPointer struct {
+	returnedErr *error
+}
+
+func (pointer errorPointer) Err() error {
+	return *pointer.returnedErr
+}
+
+func (pointer errorPointer) SetErr(err error) {
+	*pointer.returnedErr = err
+}
+
+// CloseTo works like [errclose.Close], but puts the close error in the given
+// [errclose.ErrorTarget] instead of the error pointed to by a returnedErr pointer:
+//
+//	errclose.CloseTo(file, requestErrors, "file")
+//
+// SetErr is called on the target after the resource is closed, with the target's existing error
+// combined with the close error (or unchanged, if the close succeeded).
+func CloseTo(
+	resource interface{ Close() error },
+	target ErrorTarget,
+	resourceName string,
+	options ...Option,
+) {
+	err := target.Err()
+	closeResource(resource, &err, resourceName, options, 1)
+	target.SetErr(err)
+}
+
+// ClosefTo works like [errclose.Closef], but puts the close error in the given
+// [errclose.ErrorTarget], like [errclose.CloseTo].
+func ClosefTo(
+	resource interface{ Close() error },
+	target ErrorTarget,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	err := target.Err()
+	closefResource(resource, &err, nil, nil, 1, resourceNameFormat, formatArgs...)
+	target.SetErr(err)
+}
+
+// ClosefToWith works like [errclose.ClosefTo], but takes options like [errclose.ClosefWith].
+func ClosefToWith(
+	resource interface{ Close() error },
+	target ErrorTarget,
+	options []Option,
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	err := target.Err()
+	closefResource(resource, &err, nil, options, 1, resourceNameFormat, formatArgs...)
+	target.SetErr(err)
+}
+
+// CloseAllTo works like [Frame.CloseAll], but puts the close errors in the given
+// [errclose.ErrorTarget], like [errclose.CloseTo].
+func (frame *Frame) CloseAllTo(target ErrorTarget) {
+	err := target.Err()
+	frame.CloseAll(&err)
+	target.SetErr(err)
+}
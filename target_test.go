@@ -0,0 +1,100 @@
+package errclose_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+type mockErrorTarget struct {
+	err error
+}
+
+func (target *mockErrorTarget) Err() error {
+	return target.err
+}
+
+func (target *mockErrorTarget) SetErr(err error) {
+	target.err = err
+}
+
+func TestCloseTo(t *testing.T) {
+	target := &mockErrorTarget{err: errors.New("request failed")}
+
+	errclose.CloseTo(openFileWithCloseError(), target, "file")
+	assertEqual(
+		t,
+		target.err.Error(),
+		"request failed (and failed to close file: close error)",
+		"error string",
+	)
+
+	errclose.CloseTo(openFileWithoutCloseError(), target, "other file")
+	assertEqual(
+		t,
+		target.err.Error(),
+		"request failed (and failed to close file: close error)",
+		"error string after successful close",
+	)
+}
+
+func TestClosefTo(t *testing.T) {
+	target := &mockErrorTarget{err: nil}
+
+	errclose.ClosefTo(openFileWithCloseError(), target, "file %d", 1)
+	assertEqual(t, target.err.Error(), "failed to close file 1: close error", "error string")
+}
+
+func TestClosefToWith(t *testing.T) {
+	target := &mockErrorTarget{err: nil}
+
+	errclose.ClosefToWith(
+		openFileWithCloseError(),
+		target,
+		[]errclose.Option{errclose.WithCaller()},
+		"file %d",
+		1,
+	)
+	assertEqual(t, target.err.Error(), "failed to close file 1: close error", "error string")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(target.err, &closeErr), true, "errors.As result")
+	assertEqual(
+		t,
+		strings.Contains(closeErr.Caller, "target_test.go:"),
+		true,
+		"caller is the ClosefToWith call",
+	)
+}
+
+func TestFrameCloseAllTo(t *testing.T) {
+	target := &mockErrorTarget{err: nil}
+
+	var frame errclose.Frame
+	frame.Add(openFileWithCloseError(), "file 1")
+	frame.Add(openFileWithoutCloseError(), "file 2")
+	frame.CloseAllTo(target)
+
+	assertEqual(t, target.err.Error(), "failed to close file 1: close error", "error string")
+}
+
+func TestErrorPointer(t *testing.T) {
+	useFile := func() (returnedErr error) {
+		defer errclose.CloseTo(
+			openFileWithCloseError(),
+			errclose.ErrorPointer(&returnedErr),
+			"file",
+		)
+		return errors.New("operation failed")
+	}
+
+	err := useFile()
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error string",
+	)
+}
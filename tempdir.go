@@ -0,0 +1,82 @@
+package errclose
+
+import (
+	"fmt"
+	"os"
+)
+
+// TempDir is a temporary directory created by [errclose.MkdirTemp].
+type TempDir struct {
+	// Path is the path of the temporary directory.
+	Path string
+	keep bool
+}
+
+// MkdirTemp creates a new temporary directory with [os.MkdirTemp], and returns a [errclose.TempDir]
+// for removing it again when you're done with it:
+//
+//	func build() (returnedErr error) {
+//		tempDir, err := errclose.MkdirTemp("", "build-*")
+//		if err != nil {
+//			return err
+//		}
+//		defer tempDir.Remove(&returnedErr)
+//
+//		// Use tempDir.Path
+//	}
+//
+// Failing to remove temporary directories is easy to miss (e.g. due to file locks on Windows), so
+// the deferred [TempDir.Remove] reports removal errors in the same way as [errclose.Close] reports
+// close errors.
+//
+// If creating the directory fails, the error is returned on the following format:
+//
+//	failed to create temporary directory: <error>
+func MkdirTemp(dir string, pattern string) (*TempDir, error) {
+	path, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	return &TempDir{Path: path, keep: false}, nil
+}
+
+// Remove removes the temporary directory and everything in it (with [os.RemoveAll]), unless
+// [TempDir.Keep] has been called. Removal errors are handled in the same way as [errclose.Close]
+// handles close errors, with the following format:
+//
+//	failed to remove temporary directory <path>: <removal error>
+//
+// If returnedErr points to an existing non-nil error, then the existing error and the removal
+// error are combined on the following format:
+//
+//	<existing error> (and failed to remove temporary directory <path>: <removal error>)
+func (tempDir *TempDir) Remove(returnedErr *error) {
+	resourceName := "temporary directory " + tempDir.Path
+	recordCloseAttempt(resourceName)
+	if err := tempDir.Close(); err != nil {
+		handleTeardownError(returnedErr, err, "remove", resourceName)
+	}
+}
+
+// Close removes the temporary directory in the same way as [TempDir.Remove], but returns the
+// removal error as-is. This lets you register the directory in a [errclose.Frame] or with
+// [errclose.DeferGlobal].
+func (tempDir *TempDir) Close() error {
+	if tempDir.keep {
+		return nil
+	}
+	return os.RemoveAll(tempDir.Path)
+}
+
+// Keep marks the temporary directory to be kept, so that [TempDir.Remove] and [TempDir.Close] don't
+// remove it. This is an escape hatch for debugging, e.g. when you want to inspect the contents of
+// the directory after a failure:
+//
+//	if err != nil && debug {
+//		tempDir.Keep()
+//		slog.Info("Keeping temporary directory for debugging", "path", tempDir.Path)
+//	}
+func (tempDir *TempDir) Keep() {
+	tempDir.keep = true
+}
@@ -0,0 +1,63 @@
+package errclose_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestMkdirTemp(t *testing.T) {
+	var path string
+
+	build := func() (returnedErr error) {
+		tempDir, err := errclose.MkdirTemp(t.TempDir(), "build-*")
+		if err != nil {
+			return err
+		}
+		defer tempDir.Remove(&returnedErr)
+
+		path = tempDir.Path
+		return os.WriteFile(filepath.Join(tempDir.Path, "output"), nil, 0o600)
+	}
+
+	err := build()
+	assertEqual(t, err, nil, "error")
+	_, err = os.Stat(path)
+	assertEqual(t, errors.Is(err, os.ErrNotExist), true, "temporary directory removed")
+}
+
+func TestMkdirTempKeep(t *testing.T) {
+	var path string
+
+	build := func() (returnedErr error) {
+		tempDir, err := errclose.MkdirTemp(t.TempDir(), "build-*")
+		if err != nil {
+			return err
+		}
+		defer tempDir.Remove(&returnedErr)
+
+		path = tempDir.Path
+		tempDir.Keep()
+		return nil
+	}
+
+	err := build()
+	assertEqual(t, err, nil, "error")
+	_, err = os.Stat(path)
+	assertEqual(t, err, nil, "error from stat of kept temporary directory")
+}
+
+func TestMkdirTempError(t *testing.T) {
+	_, err := errclose.MkdirTemp(filepath.Join(t.TempDir(), "missing"), "build-*")
+	assertEqual(
+		t,
+		strings.HasPrefix(err.Error(), "failed to create temporary directory: "),
+		true,
+		"error prefix",
+	)
+	assertEqual(t, errors.Is(err, os.ErrNotExist), true, "errors.Is(os.ErrNotExist)")
+}
@@ -0,0 +1,59 @@
+package errclose
+
+import (
+	"testing"
+)
+
+// Cleanup registers a function with [testing.TB.Cleanup] that closes the given resource when the
+// test (and its subtests) complete. If closing the resource fails, the test fails with
+// [testing.TB.Errorf], with the close error on the same format as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+//
+// This is meant for test helpers that open resources for a test:
+//
+//	func createTempFile(t *testing.T) *os.File {
+//		t.Helper()
+//
+//		file, err := os.CreateTemp(t.TempDir(), "test")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		errclose.Cleanup(t, file, "temp file")
+//		return file
+//	}
+func Cleanup(t testing.TB, resource interface{ Close() error }, resourceName string) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		var closeErr error
+		Close(resource, &closeErr, resourceName)
+		if closeErr != nil {
+			t.Errorf("%v", closeErr)
+		}
+	})
+}
+
+// Cleanupf works like [errclose.Cleanup], but takes a format string and args to construct the
+// resource name (like [errclose.Closef]). The formatting is only performed if there is a close
+// error.
+func Cleanupf(
+	t testing.TB,
+	resource interface{ Close() error },
+	resourceNameFormat string,
+	formatArgs ...any,
+) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		var closeErr error
+		Closef(resource, &closeErr, resourceNameFormat, formatArgs...)
+		if closeErr != nil {
+			t.Errorf("%v", closeErr)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package errclose_test
+
+import (
+	"fmt"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCleanup(t *testing.T) {
+	fakeT := newFakeTB()
+	file := openFileWithCloseError()
+	errclose.Cleanup(fakeT, file, "file")
+
+	assertEqual(t, file.closeWasCalled, false, "close was called before cleanup")
+	fakeT.runCleanups()
+	assertEqual(t, file.closeWasCalled, true, "close was called")
+	assertEqual(t, fakeT.errors, []string{"failed to close file: close error"}, "test errors")
+}
+
+func TestCleanupWithoutCloseError(t *testing.T) {
+	fakeT := newFakeTB()
+	file := openFileWithoutCloseError()
+	errclose.Cleanup(fakeT, file, "file")
+
+	fakeT.runCleanups()
+	assertEqual(t, file.closeWasCalled, true, "close was called")
+	assertEqual(t, len(fakeT.errors), 0, "number of test errors")
+}
+
+func TestCleanupf(t *testing.T) {
+	fakeT := newFakeTB()
+	errclose.Cleanupf(fakeT, openFileWithCloseError(), "file at path %s", "/some/path")
+
+	fakeT.runCleanups()
+	assertEqual(
+		t,
+		fakeT.errors,
+		[]string{"failed to close file at path /some/path: close error"},
+		"test errors",
+	)
+}
+
+// fakeTB records cleanups and errors instead of failing the test. It embeds testing.TB to
+// implement the interface, but only the methods used by errclose.Cleanup are implemented.
+type fakeTB struct {
+	testing.TB
+
+	cleanups []func()
+	errors   []string
+}
+
+func newFakeTB() *fakeTB {
+	return &fakeTB{TB: nil, cleanups: nil, errors: nil}
+}
+
+func (fakeT *fakeTB) Helper() {}
+
+func (fakeT *fakeTB) Cleanup(cleanup func()) {
+	fakeT.cleanups = append(fakeT.cleanups, cleanup)
+}
+
+func (fakeT *fakeTB) Errorf(format string, args ...any) {
+	fakeT.errors = append(fakeT.errors, fmt.Sprintf(format, args...))
+}
+
+func (fakeT *fakeTB) runCleanups() {
+	for i := len(fakeT.cleanups) - 1; i >= 0; i-- {
+		fakeT.cleanups[i]()
+	}
+}
@@ -0,0 +1,75 @@
+package errclose
+
+import (
+	"time"
+)
+
+// CloseWithTimeout closes the given resource like [errclose.Close], but stops waiting for Close to
+// return after the given timeout. This is for resources whose Close may hang indefinitely, such
+// as network clients and cgo-backed handles:
+//
+//	defer errclose.CloseWithTimeout(client, &returnedErr, "client", 5*time.Second)
+//
+// If the timeout elapses before Close returns, the close error matches [errclose.ErrCloseTimeout]
+// with [errors.Is], and is formatted on the following format:
+//
+//	failed to close <resourceName>: timed out closing resource after <timeout>
+//
+// Close is called in a separate goroutine, which keeps running after a timeout until Close
+// returns. If Close then fails, the late close error is reported to the observer and event log
+// (see [errclose.SetObserver] and [errclose.SetEventLog]), since the function that deferred
+// CloseWithTimeout has already returned.
+//
+// The goroutine is given [pprof] labels for the resource, like the goroutines of
+// [errclose.CloseFiles].
+//
+// CloseWithTimeout takes the same options as errclose.Close (see [errclose.Option]), and applies
+// defaults and benign errors in the same way. The options are not applied to late close errors.
+func CloseWithTimeout(
+	resource interface{ Close() error },
+	returnedErr *error,
+	resourceName string,
+	timeout time.Duration,
+	options ...Option,
+) {
+	if isNilResource(resource) {
+		handleNilResource(returnedErr, resourceName)
+		return
+	}
+	resourceName = resolveResourceName(resource, resourceName)
+	recordCloseAttempt(resourceName)
+	lists := withDefaults(resource, options)
+	stats := captureStats(lists)
+
+	// Unbuffered, so that a close that finishes after the timeout is reported as a late close
+	done := make(chan error)
+	timedOut := make(chan struct{})
+	go withCloseLabels(resourceName, "close", func() {
+		closeErr := closeWithOptions(resource, resourceName, lists)
+
+		select {
+		case done <- closeErr:
+		case <-timedOut:
+			if closeErr != nil {
+				// The close was already counted as a failure when it timed out, so we don't
+				// report it to the metrics again
+				logEvent(eventCloseFailed, resourceName, closeErr)
+				observe(resourceName, closeErr)
+			}
+		}
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case closeErr := <-done:
+		if closeErr != nil {
+			handleCloseErrorWithOptions(returnedErr, closeErr, resourceName, lists, stats, 1)
+		}
+	case <-timer.C:
+		close(timedOut)
+		timeoutErr := lazyErrorf(ErrCloseTimeout, "%w after %s", ErrCloseTimeout, timeout)
+		handleCloseErrorWithOptions(returnedErr, timeoutErr, resourceName, lists, stats, 1)
+	}
+}
@@ -0,0 +1,78 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hermannm.dev/errclose"
+	"hermannm.dev/errclose/errclosetest"
+)
+
+func TestCloseWithTimeout(t *testing.T) {
+	useFile := func() (returnedErr error) {
+		defer errclose.CloseWithTimeout(openFileWithCloseError(), &returnedErr, "file", time.Hour)
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+}
+
+func TestCloseWithTimeoutOptions(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaultsFor[*mockFile](errclose.Ignore(errBenignClose))
+
+	var err error
+	errclose.CloseWithTimeout(
+		&mockFile{closeWasCalled: false, closeError: errBenignClose},
+		&err,
+		"file",
+		time.Hour,
+	)
+	assertEqual(t, err, nil, "error with close error ignored by type default")
+
+	slow := errclosetest.NewSlow(50*time.Millisecond, nil)
+	errclose.CloseWithTimeout(
+		slow,
+		&err,
+		"client",
+		time.Millisecond,
+		errclose.Ignore(errclose.ErrCloseTimeout),
+	)
+	assertEqual(t, err, nil, "error with timeout ignored by per-call option")
+}
+
+func TestCloseWithTimeoutElapsed(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+
+	lateErrs := make(chan string, 1)
+	errclose.SetObserver(func(resourceName string, closeErr error) {
+		if !errors.Is(closeErr, errclose.ErrCloseTimeout) {
+			lateErrs <- resourceName + ": " + closeErr.Error()
+		}
+	})
+
+	slow := errclosetest.NewSlow(50*time.Millisecond, errors.New("flush failed"))
+
+	useClient := func() (returnedErr error) {
+		defer errclose.CloseWithTimeout(slow, &returnedErr, "client", time.Millisecond)
+		return nil
+	}
+
+	err := useClient()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close client: timed out closing resource after 1ms",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, errclose.ErrCloseTimeout), true, "errors.Is(ErrCloseTimeout)")
+
+	select {
+	case lateErr := <-lateErrs:
+		assertEqual(t, lateErr, "client: flush failed", "late close error")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Late close error was not reported to observer")
+	}
+}
@@ -0,0 +1,165 @@
+package errclose
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var traceIDExtractor atomic.Pointer[func(ctx context.Context) string]
+
+// SetTraceIDExtractor sets a function that gets the trace ID (or request ID) from a context, so
+// that close failures can be correlated with the request or job that caused them. Pass nil to
+// remove the extractor (this is the default). For example, with OpenTelemetry:
+//
+//	errclose.SetTraceIDExtractor(func(ctx context.Context) string {
+//		spanContext := trace.SpanContextFromContext(ctx)
+//		if !spanContext.HasTraceID() {
+//			return ""
+//		}
+//		return spanContext.TraceID().String()
+//	})
+//
+// The extractor is called with the context of functions that close a resource with a context:
+// [errclose.CloseContext], [errclose.CloseContextf], [errclose.AwaitClosed],
+// [errclose.CloseDrainable], [errclose.Shutdown], [errclose.CloseAllConcurrent] and
+// [ShutdownManager.Shutdown]. It's only called when a teardown fails, and if it returns a
+// non-empty trace ID:
+//   - The TraceID field of the [errclose.CloseError] is set to it
+//   - close_failed events in the event log get a trace_id field (see [errclose.SetEventLog])
+//   - The observer set by [errclose.SetObserver] and hooks from [errclose.Also] are passed a close
+//     error that carries the trace ID, which can be read with [errclose.TraceID]
+//
+// The trace ID is not passed to the metrics set by [errclose.SetMetrics], since it would give them
+// unbounded cardinality.
+//
+// The extractor may be called concurrently, if resources are closed concurrently.
+func SetTraceIDExtractor(extract func(ctx context.Context) string) {
+	if extract == nil {
+		traceIDExtractor.Store(nil)
+	} else {
+		traceIDExtractor.Store(&extract)
+	}
+}
+
+// TraceID returns the trace ID that the given error was tagged with when a close failed (see
+// [errclose.SetTraceIDExtractor]), or "" if it has none. This works both for errors returned by
+// the package and for the close errors passed to the observer and [errclose.Also] hooks:
+//
+//	errclose.SetObserver(func(resourceName string, closeErr error) {
+//		slog.Warn(
+//			"Resource failed to close",
+//			"resource", resourceName,
+//			"error", closeErr,
+//			"trace_id", errclose.TraceID(closeErr),
+//		)
+//	})
+//
+// If the error combines several close errors with trace IDs, the first one found is returned.
+func TraceID(err error) string {
+	var traced *tracedError
+	if errors.As(err, &traced) && traced.traceID != "" {
+		return traced.traceID
+	}
+	for _, closeErr := range Errors(err) {
+		if closeErr.TraceID != "" {
+			return closeErr.TraceID
+		}
+	}
+	return ""
+}
+
+// tracedError is the close error passed to hooks when the failure has a trace ID, resource ID or
+// labels (see [errclose.WithResourceID] and [errclose.WithLabels]). It has the same message as the
+// close error, and unwraps to it.
+type tracedError struct {
+	err        error
+	traceID    string
+	resourceID ResourceID
+	labels     map[string]string
+}
+
+func (err *tracedError) Error() string {
+	return err.err.Error()
+}
+
+func (err *tracedError) Unwrap() error {
+	return err.err
+}
+
+// withHookDetails returns the given close error for passing to hooks, wrapped to carry the trace
+// ID, resource ID and labels if any of them are set.
+func withHookDetails(
+	closeErr error,
+	traceID string,
+	resourceID ResourceID,
+	labels map[string]string,
+) error {
+	if traceID == "" && resourceID == 0 && labels == nil {
+		return closeErr
+	}
+	return &tracedError{err: closeErr, traceID: traceID, resourceID: resourceID, labels: labels}
+}
+
+// extractTraceID returns the trace ID from the given context, using the extractor set by
+// [errclose.SetTraceIDExtractor], or "" if none is set.
+func extractTraceID(ctx context.Context) string {
+	extract := traceIDExtractor.Load()
+	if extract == nil || ctx == nil {
+		return ""
+	}
+	return (*extract)(ctx)
+}
+
+// contextOptions returns an internal option for passing the context of a close to
+// handleTeardownErrorWithOptions, so that the trace ID is only extracted if the close fails. It
+// returns nil if no trace ID extractor is set, to avoid allocating.
+func contextOptions(ctx context.Context) []Option {
+	if traceIDExtractor.Load() == nil {
+		return nil
+	}
+	traceID := func() string { return extractTraceID(ctx) }
+	return []Option{{settings: &optionSettings{traceID: traceID}}}
+}
+
+// traceIDFromOptions returns the trace ID from the context passed with contextOptions, or "" if
+// there is none.
+func traceIDFromOptions(options optionLists) string {
+	for _, list := range options {
+		for _, option := range list {
+			if traceID := option.get().traceID; traceID != nil {
+				return traceID()
+			}
+		}
+	}
+	return ""
+}
+
+// handleContextTeardownError works like handleTeardownErrorWithOptions, but tags the close error
+// with the trace ID from the given context. callerSkip is the number of stack frames between this
+// function and the caller of the errclose function, for [errclose.WithCaller].
+func handleContextTeardownError(
+	ctx context.Context,
+	returnedErr *error,
+	err error,
+	action string,
+	resourceName string,
+	options optionLists,
+	stats any,
+	callerSkip int,
+) {
+	if isIgnored(err, resourceName, options) {
+		return
+	}
+
+	handleTracedTeardownError(
+		returnedErr,
+		err,
+		action,
+		resourceName,
+		options,
+		stats,
+		extractTraceID(ctx),
+		callerSkip+1,
+	)
+}
@@ -0,0 +1,80 @@
+package errclose_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+type traceIDKey struct{}
+
+func setTraceIDFromContext() {
+	errclose.SetTraceIDExtractor(func(ctx context.Context) string {
+		traceID, _ := ctx.Value(traceIDKey{}).(string)
+		return traceID
+	})
+}
+
+func TestTraceIDFromCloseContext(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	setTraceIDFromContext()
+	var buffer bytes.Buffer
+	errclose.SetEventLog(&buffer)
+	var observedTraceID string
+	errclose.SetObserver(func(_ string, closeErr error) {
+		observedTraceID = errclose.TraceID(closeErr)
+	})
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "4bf92f3577b34da6")
+	client := &contextClient{closeCtxValue: nil, closeErr: errors.New("disconnect failed")}
+
+	var err error
+	errclose.CloseContext(ctx, client, &err, "client")
+
+	assertEqual(t, err.Error(), "failed to close client: disconnect failed", "error string")
+	assertEqual(t, errclose.TraceID(err), "4bf92f3577b34da6", "trace ID of returned error")
+	assertEqual(t, errclose.Errors(err)[0].TraceID, "4bf92f3577b34da6", "CloseError.TraceID")
+	assertEqual(t, observedTraceID, "4bf92f3577b34da6", "trace ID of observed error")
+
+	expectedLine := regexp.MustCompile(
+		`^time=\S+ event=close_failed resource="client" error="disconnect failed" ` +
+			`trace_id="4bf92f3577b34da6"\n$`,
+	)
+	if !expectedLine.Match(buffer.Bytes()) {
+		t.Errorf("Unexpected event log output: %q", buffer.String())
+	}
+}
+
+func TestTraceIDFromCloseAllConcurrent(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	setTraceIDFromContext()
+	var alsoTraceIDs []string
+	errclose.SetDefaults(errclose.Also(func(_ string, closeErr error) {
+		alsoTraceIDs = append(alsoTraceIDs, errclose.TraceID(closeErr))
+	}))
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "request-1")
+	var err error
+	errclose.CloseAllConcurrent(ctx, &err, 1, errclose.Named(openFileWithCloseError(), "file"))
+
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errclose.TraceID(err), "request-1", "trace ID of returned error")
+	assertEqual(t, alsoTraceIDs, []string{"request-1"}, "trace IDs passed to Also")
+}
+
+func TestTraceIDWithoutContext(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	setTraceIDFromContext()
+	var observedErr error
+	errclose.SetObserver(func(_ string, closeErr error) { observedErr = closeErr })
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+
+	assertEqual(t, errclose.TraceID(err), "", "trace ID of returned error")
+	assertEqual(t, errclose.TraceID(observedErr), "", "trace ID of observed error")
+}
@@ -0,0 +1,71 @@
+package errclose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Translate returns a closer that closes the given resource, and passes any close error through
+// the given translate function before returning it. This lets you map errors from third-party
+// resources to your own errors before they are wrapped, so your public API's error contract doesn't
+// depend on third-party error types:
+//
+//	defer errclose.Close(
+//		errclose.Translate(client, errclose.StandardCloseErrors),
+//		&returnedErr,
+//		"storage client",
+//	)
+//
+// If translate returns nil, the close error is dropped.
+func Translate(
+	resource interface{ Close() error },
+	translate func(closeErr error) error,
+) interface{ Close() error } {
+	return translatingCloser{resource: resource, translate: translate}
+}
+
+type translatingCloser struct {
+	resource  interface{ Close() error }
+	translate func(closeErr error) error
+}
+
+func (closer translatingCloser) Close() error {
+	if closeErr := closer.resource.Close(); closeErr != nil {
+		return closer.translate(closeErr)
+	}
+	return nil
+}
+
+// StandardCloseErrors translates the given close error to one of the package's sentinel errors,
+// for use with [errclose.Translate]:
+//   - [errclose.ErrAlreadyClosed] if the error is [os.ErrClosed] or [net.ErrClosed]
+//   - [errclose.ErrCloseTimeout] if the error is [os.ErrDeadlineExceeded],
+//     [context.DeadlineExceeded], or a [net.Error] that reports a timeout
+//   - [errclose.ErrResourceBroken] for any other error
+//
+// The returned error wraps the sentinel error, and includes the message of the original error, on
+// the following format:
+//
+//	<sentinel error message>: <original error message>
+//
+// The original error is not wrapped, so it can't be reached with [errors.Is] or [errors.As]. Only
+// the sentinel error can.
+func StandardCloseErrors(closeErr error) error {
+	var sentinel error
+	var netErr net.Error
+	switch {
+	case errors.Is(closeErr, os.ErrClosed), errors.Is(closeErr, net.ErrClosed):
+		sentinel = ErrAlreadyClosed
+	case errors.Is(closeErr, os.ErrDeadlineExceeded),
+		errors.Is(closeErr, context.DeadlineExceeded),
+		errors.As(closeErr, &netErr) && netErr.Timeout():
+		sentinel = ErrCloseTimeout
+	default:
+		sentinel = ErrResourceBroken
+	}
+
+	return fmt.Errorf("%w: %v", sentinel, closeErr) //nolint:errorlint // Deliberately opaque
+}
@@ -0,0 +1,72 @@
+package errclose_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestTranslate(t *testing.T) {
+	errTranslated := errors.New("translated")
+	translate := func(error) error { return errTranslated }
+
+	useFile := func() (returnedErr error) {
+		defer errclose.Close(
+			errclose.Translate(openFileWithCloseError(), translate),
+			&returnedErr,
+			"file",
+		)
+		return nil
+	}
+
+	err := useFile()
+	assertEqual(t, err.Error(), "failed to close file: translated", "error string")
+	assertEqual(t, errors.Is(err, errTranslated), true, "errors.Is result")
+}
+
+func TestStandardCloseErrors(t *testing.T) {
+	testCases := []struct {
+		closeErr         error
+		expectedSentinel error
+		expectedMessage  string
+	}{
+		{
+			closeErr:         os.ErrClosed,
+			expectedSentinel: errclose.ErrAlreadyClosed,
+			expectedMessage:  "resource already closed: file already closed",
+		},
+		{
+			closeErr:         net.ErrClosed,
+			expectedSentinel: errclose.ErrAlreadyClosed,
+			expectedMessage:  "resource already closed: use of closed network connection",
+		},
+		{
+			closeErr:         context.DeadlineExceeded,
+			expectedSentinel: errclose.ErrCloseTimeout,
+			expectedMessage:  "timed out closing resource: context deadline exceeded",
+		},
+		{
+			closeErr:         errors.New("close error"),
+			expectedSentinel: errclose.ErrResourceBroken,
+			expectedMessage:  "resource broken: close error",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.closeErr.Error(), func(t *testing.T) {
+			err := errclose.StandardCloseErrors(testCase.closeErr)
+			assertEqual(t, err.Error(), testCase.expectedMessage, "error string")
+			assertEqual(t, errors.Is(err, testCase.expectedSentinel), true, "errors.Is(sentinel)")
+			assertEqual(
+				t,
+				errors.Is(err, testCase.closeErr),
+				false,
+				"errors.Is(original error)",
+			)
+		})
+	}
+}
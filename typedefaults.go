@@ -0,0 +1,108 @@
+package errclose
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var typeDefaults struct {
+	// Held while replacing entries, so that concurrent calls to SetDefaultsFor don't overwrite each
+	// other. Lookups only load entries.
+	lock    sync.Mutex
+	entries atomic.Pointer[[]typeDefaultsEntry]
+}
+
+type typeDefaultsEntry struct {
+	resourceType reflect.Type
+	matches      func(resource any) bool
+	options      []Option
+}
+
+// SetDefaultsFor sets options that apply to every resource of the given type, when it's closed by
+// a function in the package that takes options, such as [errclose.Close], [errclose.Closef] and
+// [Collector.Close], or by [Frame] and [ShutdownManager]. The type can be a concrete type, which
+// only matches resources of exactly that type, or an interface, which matches all resources that
+// implement it. This lets an application set the close error policy for a kind of resource in one
+// place, instead of repeating options wherever such resources are closed:
+//
+//	errclose.SetDefaultsFor[*os.File](errclose.Ignore(os.ErrClosed))
+//	// Used by errclose.CloseConn
+//	errclose.SetDefaultsFor[net.Conn](errclose.CloseDeadline(2 * time.Second))
+//
+// Defaults for a type are applied after the defaults set by [errclose.SetDefaults], and before
+// the options given to each call, so options given to a call override them in the same way as
+// they override the package defaults (see [errclose.SetDefaults]). If a resource matches several
+// types with defaults (such as a concrete type and an interface it implements), the defaults for
+// all of them are applied, in the order the types were first given to SetDefaultsFor.
+//
+// The type is matched against the resource as it's passed to the package: a resource wrapped by
+// an adapter such as [errclose.Stopper] has the adapter's type.
+//
+// Calling SetDefaultsFor again for the same type replaces its defaults. Call it with no options to
+// remove the defaults for the type. The defaults for all types are included in [Config], so
+// [errclose.SaveConfig] can be used to restore them in tests.
+func SetDefaultsFor[Resource any](options ...Option) {
+	resourceType := reflect.TypeFor[Resource]()
+	entry := typeDefaultsEntry{
+		resourceType: resourceType,
+		matches: func(resource any) bool {
+			_, ok := resource.(Resource)
+			return ok
+		},
+		options: slices.Clone(options),
+	}
+
+	typeDefaults.lock.Lock()
+	defer typeDefaults.lock.Unlock()
+
+	var entries []typeDefaultsEntry
+	if stored := typeDefaults.entries.Load(); stored != nil {
+		entries = slices.Clone(*stored)
+	}
+
+	index := slices.IndexFunc(entries, func(existing typeDefaultsEntry) bool {
+		return existing.resourceType == resourceType
+	})
+	switch {
+	case index == -1 && len(options) != 0:
+		entries = append(entries, entry)
+	case index != -1 && len(options) != 0:
+		entries[index] = entry
+	case index != -1:
+		entries = slices.Delete(entries, index, index+1)
+	}
+
+	if len(entries) == 0 {
+		typeDefaults.entries.Store(nil)
+	} else {
+		typeDefaults.entries.Store(&entries)
+	}
+}
+
+func typeDefaultsSet() bool {
+	return typeDefaults.entries.Load() != nil
+}
+
+// typeDefaultsFor returns the options set by [errclose.SetDefaultsFor] for the types that the given
+// resource matches, or nil if there are none.
+func typeDefaultsFor(resource any) []Option {
+	stored := typeDefaults.entries.Load()
+	if stored == nil || resource == nil {
+		return nil
+	}
+
+	var options []Option
+	for _, entry := range *stored {
+		if entry.matches(resource) {
+			if options == nil {
+				// Most resources match a single type, so avoid copying its options
+				options = entry.options
+			} else {
+				options = append(slices.Clip(options), entry.options...)
+			}
+		}
+	}
+	return options
+}
@@ -0,0 +1,93 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+var errBenignClose = errors.New("benign close error")
+
+func TestSetDefaultsForConcreteType(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaultsFor[*mockFile](errclose.Ignore(errBenignClose))
+
+	var err error
+	errclose.Close(&mockFile{closeWasCalled: false, closeError: errBenignClose}, &err, "file")
+	assertEqual(t, err, nil, "error from resource of type with defaults")
+
+	errclose.Close(closerFunc(func() error { return errBenignClose }), &err, "closer")
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close closer: benign close error",
+		"error from resource of other type",
+	)
+}
+
+func TestSetDefaultsForInterface(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	var alsoReported []string
+	errclose.SetDefaultsFor[interface{ Name() string }](
+		errclose.Also(func(resourceName string, _ error) {
+			alsoReported = append(alsoReported, resourceName)
+		}),
+	)
+
+	var frame errclose.Frame
+	frame.Add(
+		&namedMockFile{mockFile: *openFileWithCloseError(), name: "named file", onClose: nil},
+		"",
+	)
+	frame.Add(openFileWithCloseError(), "unnamed file")
+
+	err := frame.Err()
+	assertEqual(
+		t,
+		err.Error(),
+		"failed to close unnamed file: close error (and failed to close named file: close error)",
+		"error string",
+	)
+	assertEqual(t, alsoReported, []string{"named file"}, "resources reported to Also")
+}
+
+func TestSetDefaultsForOverriddenPerCall(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaults(errclose.Stats(func() any { return "package default" }))
+	errclose.SetDefaultsFor[*mockFile](errclose.Stats(func() any { return "type default" }))
+
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file")
+	assertEqual(t, errclose.Errors(err)[0].Stats, any("type default"), "stats from type default")
+
+	err = nil
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.Stats(func() any { return "per call" }),
+	)
+	assertEqual(t, errclose.Errors(err)[0].Stats, any("per call"), "stats from per-call option")
+}
+
+func TestSetDefaultsForRemove(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	errclose.SetDefaultsFor[*mockFile](errclose.Ignore(errBenignClose))
+	errclose.SetDefaultsFor[*mockFile]()
+
+	var err error
+	errclose.Close(&mockFile{closeWasCalled: false, closeError: errBenignClose}, &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: benign close error", "error string")
+}
+
+func TestSaveConfigRestoresTypeDefaults(t *testing.T) {
+	func() {
+		defer errclose.SaveConfig().Restore()
+		errclose.SetDefaultsFor[*mockFile](errclose.Ignore(errBenignClose))
+	}()
+
+	var err error
+	errclose.Close(&mockFile{closeWasCalled: false, closeError: errBenignClose}, &err, "file")
+	assertEqual(t, err.Error(), "failed to close file: benign close error", "error string")
+}
@@ -0,0 +1,168 @@
+package errclose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format implements [fmt.Formatter], so that the %+v verb prints the error with its details, one
+// per line after the message, for logging close failures verbosely:
+//
+//	failed to close file: close error
+//	    resource: file
+//	    action: close
+//	    caller: /app/main.go:42
+//
+// The resource name and action are always included, followed by the fields that are set: the
+// trace ID, caller, occurrences, stats and OS detail. If the error in the Err field's chain also
+// implements fmt.Formatter (such as errors that capture a stack trace), its %+v output is included
+// as the cause. The %v and %s verbs print the same message as Error, and %q prints it quoted.
+func (err *CloseError) Format(state fmt.State, verb rune) {
+	formatError(state, verb, err, err.writeDetail)
+}
+
+func (err *CloseError) writeDetail(writer io.Writer) {
+	writeDetailField(writer, "resource", err.ResourceName)
+	writeDetailField(writer, "action", err.actionOrDefault())
+	if err.TraceID != "" {
+		writeDetailField(writer, "trace ID", err.TraceID)
+	}
+	if err.Caller != "" {
+		writeDetailField(writer, "caller", err.Caller)
+	}
+	if err.Occurrences > 1 {
+		writeDetailField(writer, "occurrences", strconv.Itoa(err.Occurrences))
+	}
+	if err.Stats != nil {
+		writeDetailField(writer, "stats", fmt.Sprintf("%+v", err.Stats))
+	}
+	if err.OSDetail != nil {
+		writeDetailField(writer, "OS detail", fmt.Sprintf("%+v", *err.OSDetail))
+	}
+
+	var cause fmt.Formatter
+	if errors.As(err.Err, &cause) {
+		writeDetailField(writer, "cause", fmt.Sprintf("%+v", cause))
+	}
+}
+
+// Format implements [fmt.Formatter], so that the %+v verb prints the details of the slow close
+// after the message, including the goroutine dump if one was taken. The %v and %s verbs print the
+// same message as Error, and %q prints it quoted.
+func (err *SlowCloseError) Format(state fmt.State, verb rune) {
+	formatError(state, verb, err, func(writer io.Writer) {
+		writeDetailField(writer, "resource", err.ResourceName)
+		writeDetailField(writer, "caller", err.Caller)
+		writeDetailField(writer, "goroutine", strconv.FormatUint(err.Goroutine, 10))
+		writeDetailField(writer, "threshold", err.Threshold.String())
+		if err.Goroutines != nil {
+			writeDetailField(writer, "goroutines", string(err.Goroutines))
+		}
+	})
+}
+
+// Format implements [fmt.Formatter], so that the %+v verb prints the details of the close error
+// after the message, followed by the labels and the acquisition site (if set). The %v and %s verbs
+// print the same message as Error, and %q prints it quoted.
+func (err *ClosePanic) Format(state fmt.State, verb rune) {
+	formatError(state, verb, err, func(writer io.Writer) {
+		err.Err.writeDetail(writer)
+
+		labels := make([]string, 0, len(err.Labels))
+		for key, value := range err.Labels {
+			labels = append(labels, key+"="+strconv.Quote(value))
+		}
+		slices.Sort(labels)
+		writeDetailField(writer, "labels", strings.Join(labels, " "))
+
+		if err.AcquisitionSite != "" {
+			writeDetailField(writer, "acquired at", err.AcquisitionSite)
+		}
+	})
+}
+
+// Format implements [fmt.Formatter], so that the %+v verb prints the stack trace of the panic
+// after the message. The %v and %s verbs print the same message as Error, and %q prints it quoted.
+func (err *PanicError) Format(state fmt.State, verb rune) {
+	formatError(state, verb, err, func(writer io.Writer) {
+		writeDetailField(writer, "stack", string(err.Stack))
+	})
+}
+
+// Format implements [fmt.Formatter], so that the %+v verb prints each of the combined errors with
+// %+v, on separate lines.
+func (err *combinedError) Format(state fmt.State, verb rune) {
+	if verb != 'v' || !state.Flag('+') {
+		formatError(state, verb, err, nil)
+		return
+	}
+
+	for i, part := range combinedParts(nil, err) {
+		if i != 0 {
+			_, _ = io.WriteString(state, "\nand ")
+		}
+		_, _ = fmt.Fprintf(state, "%+v", part)
+	}
+}
+
+// combinedParts appends the errors combined in the given error to parts, in order.
+func combinedParts(parts []error, err error) []error {
+	//nolint:errorlint // Only flattening errors combined by this package
+	if combined, ok := err.(*combinedError); ok {
+		parts = combinedParts(parts, combined.errs[0])
+		return combinedParts(parts, combined.errs[1])
+	}
+	return append(parts, err)
+}
+
+// Format implements [fmt.Formatter], so that the %+v verb prints the primary error with %+v,
+// followed by the attached close error.
+func (err *attachedCloseError) Format(state fmt.State, verb rune) {
+	if verb != 'v' || !state.Flag('+') {
+		formatError(state, verb, err, nil)
+		return
+	}
+
+	_, _ = fmt.Fprintf(state, "%+v\nand %+v", err.primary, err.closeErr)
+}
+
+// formatError implements [fmt.Formatter] for the errors in this package: %v and %s print the
+// error message, %q prints it quoted, and %+v prints the message followed by the details written
+// by writeDetail (if not nil).
+func formatError(state fmt.State, verb rune, err error, writeDetail func(writer io.Writer)) {
+	switch verb {
+	case 'v':
+		_, _ = io.WriteString(state, err.Error())
+		if state.Flag('+') && writeDetail != nil {
+			writeDetail(state)
+		}
+	case 's':
+		_, _ = io.WriteString(state, err.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(state, "%q", err.Error())
+	default:
+		_, _ = fmt.Fprintf(state, "%%!%c(%s)", verb, err.Error())
+	}
+}
+
+// detailIndent is the indentation of the detail fields written by writeDetailField, and of the
+// continuation lines of multi-line values.
+const detailIndent = "    "
+
+// writeDetailField writes a field of the %+v output of an error, on its own line. Lines after the
+// first in multi-line values are indented below the field name.
+func writeDetailField(writer io.Writer, name string, value string) {
+	value = strings.TrimSuffix(value, "\n")
+	value = strings.ReplaceAll(value, "\n", "\n"+detailIndent+detailIndent)
+	_, _ = io.WriteString(writer, "\n"+detailIndent+name+":")
+	if strings.Contains(value, "\n") {
+		_, _ = io.WriteString(writer, "\n"+detailIndent+detailIndent)
+	} else {
+		_, _ = io.WriteString(writer, " ")
+	}
+	_, _ = io.WriteString(writer, value)
+}
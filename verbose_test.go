@@ -0,0 +1,112 @@
+package errclose_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestCloseErrorVerboseFormat(t *testing.T) {
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.Stats(func() any { return struct{ OpenFiles int }{OpenFiles: 3} }),
+	)
+
+	assertEqual(t, fmt.Sprintf("%v", err), "failed to close file: close error", "%v output")
+	assertEqual(t, fmt.Sprintf("%s", err), "failed to close file: close error", "%s output")
+	assertEqual(t, fmt.Sprintf("%q", err), `"failed to close file: close error"`, "%q output")
+	assertEqual(
+		t,
+		fmt.Sprintf("%+v", err),
+		"failed to close file: close error\n"+
+			"    resource: file\n"+
+			"    action: close\n"+
+			"    stats: {OpenFiles:3}",
+		"%+v output",
+	)
+}
+
+func TestCloseErrorVerboseFormatWithCaller(t *testing.T) {
+	var err error
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.WithCaller())
+
+	expected := regexp.MustCompile(
+		`^failed to close file: close error\n` +
+			`    resource: file\n` +
+			`    action: close\n` +
+			`    caller: .*verbose_test\.go:\d+$`,
+	)
+	if output := fmt.Sprintf("%+v", err); !expected.MatchString(output) {
+		t.Errorf("Unexpected %%+v output: %q", output)
+	}
+}
+
+func TestCombinedErrorVerboseFormat(t *testing.T) {
+	err := errors.New("operation failed")
+	errclose.Close(openFileWithCloseError(), &err, "file 1")
+	errclose.Close(openFileWithCloseError(), &err, "file 2")
+
+	assertEqual(
+		t,
+		fmt.Sprintf("%v", err),
+		"operation failed (and failed to close file 1: close error) "+
+			"(and failed to close file 2: close error)",
+		"%v output",
+	)
+	assertEqual(
+		t,
+		fmt.Sprintf("%+v", err),
+		"operation failed\n"+
+			"and failed to close file 1: close error\n"+
+			"    resource: file 1\n"+
+			"    action: close\n"+
+			"and failed to close file 2: close error\n"+
+			"    resource: file 2\n"+
+			"    action: close",
+		"%+v output",
+	)
+}
+
+func TestKeepPrimaryVerboseFormat(t *testing.T) {
+	err := errors.New("operation failed")
+	errclose.Close(openFileWithCloseError(), &err, "file", errclose.KeepPrimary())
+
+	assertEqual(t, fmt.Sprintf("%v", err), "operation failed", "%v output")
+	assertEqual(
+		t,
+		fmt.Sprintf("%+v", err),
+		"operation failed\n"+
+			"and failed to close file: close error\n"+
+			"    resource: file\n"+
+			"    action: close",
+		"%+v output",
+	)
+}
+
+func TestPanicErrorVerboseFormatInCloseError(t *testing.T) {
+	panicking := func() (returnedErr error) {
+		defer errclose.Recover(&returnedErr, "closing")
+		panic("bad state")
+	}
+	var err error
+	errclose.Close(closerFunc(panicking), &err, "client")
+
+	output := fmt.Sprintf("%+v", err)
+	expectedStart := "failed to close client: panicked while closing: bad state\n" +
+		"    resource: client\n" +
+		"    action: close\n" +
+		"    cause:\n" +
+		"        panicked while closing: bad state\n" +
+		"            stack:\n" +
+		"                goroutine "
+	if !strings.HasPrefix(output, expectedStart) {
+		t.Errorf("Unexpected %%+v output: %q", output)
+	}
+}
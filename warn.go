@@ -0,0 +1,53 @@
+package errclose
+
+// WarnOnly returns an option that turns close errors into warnings when the operation itself
+// succeeded: if the error pointed to by returnedErr is nil when the close fails, the close error is
+// passed to the given function instead of being set as the returned error. This is for request
+// handlers where failing an otherwise successful request because a cleanup failed is too strict,
+// but the failure should still be reported to the client, e.g. as response metadata:
+//
+//	defer errclose.Close(
+//		upstreamResponse.Body,
+//		&returnedErr,
+//		"upstream response body",
+//		errclose.WarnOnly(func(closeErr *errclose.CloseError) {
+//			_ = grpc.SetTrailer(ctx, metadata.Pairs("close-warning", closeErr.Error()))
+//		}),
+//	)
+//
+// For HTTP responses, see the errclosehttp package, which adds the warning as a response header
+// or trailer.
+//
+// If the operation failed (the error pointed to by returnedErr is not nil), the close error is
+// combined with it as usual, and warn is not called. Either way, the close error is still written
+// to the event log, passed to the observer and counted in the metrics (see [errclose.SetEventLog],
+// [errclose.SetObserver] and [errclose.SetMetrics]). Errors from fallbacks given with
+// [errclose.WithFallback] are handled in the same way as the close error.
+//
+// [Collector.Close] checks the errors collected so far. [Frame] (and Collector, when summarizing
+// repeated failures with [errclose.SetRepeatSummary]) close each resource into its own error, so
+// WarnOnly options that apply there (such as from [errclose.SetDefaults]) turn every close failure
+// into a warning. If multiple WarnOnly options are given, all of the functions are called.
+func WarnOnly(warn func(closeErr *CloseError)) Option {
+	return Option{settings: &optionSettings{warn: warn}}
+}
+
+// warnIfOnlyCloseFailed calls the functions given with [errclose.WarnOnly] in the given options
+// with the close error, if the error pointed to by returnedErr is nil. It returns true if the close
+// error was handled as a warning, in which case it should not be set on returnedErr.
+func warnIfOnlyCloseFailed(returnedErr *error, closeErr *CloseError, options optionLists) bool {
+	if returnedErr == nil || *returnedErr != nil {
+		return false
+	}
+
+	warned := false
+	for _, list := range options {
+		for _, option := range list {
+			if warn := option.get().warn; warn != nil {
+				warn(closeErr)
+				warned = true
+			}
+		}
+	}
+	return warned
+}
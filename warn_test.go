@@ -0,0 +1,59 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWarnOnly(t *testing.T) {
+	var warnings []string
+	warnOnly := errclose.WarnOnly(func(closeErr *errclose.CloseError) {
+		warnings = append(warnings, closeErr.Error())
+	})
+
+	useFile := func(operationErr error) (returnedErr error) {
+		defer errclose.Close(openFileWithCloseError(), &returnedErr, "file", warnOnly)
+		return operationErr
+	}
+
+	assertEqual(t, useFile(nil), nil, "error when only the close failed")
+	assertEqual(
+		t,
+		warnings,
+		[]string{"failed to close file: close error"},
+		"warnings when only the close failed",
+	)
+
+	err := useFile(errFallibleOperation)
+	assertEqual(
+		t,
+		err.Error(),
+		"operation failed (and failed to close file: close error)",
+		"error when the operation failed",
+	)
+	assertEqual(t, len(warnings), 1, "number of warnings after the operation failed")
+}
+
+func TestWarnOnlyIsObserved(t *testing.T) {
+	defer errclose.SaveConfig().Restore()
+	var observed []string
+	errclose.SetObserver(func(resourceName string, _ error) {
+		observed = append(observed, resourceName)
+	})
+
+	var warned *errclose.CloseError
+	var err error
+	errclose.Close(
+		openFileWithCloseError(),
+		&err,
+		"file",
+		errclose.WarnOnly(func(closeErr *errclose.CloseError) { warned = closeErr }),
+		errclose.WithFallback(func(error) error { return errors.New("remove failed") }),
+	)
+
+	assertEqual(t, err, nil, "returned error")
+	assertEqual(t, warned.Error(), "failed to run fallback for file: remove failed", "last warning")
+	assertEqual(t, observed, []string{"file", "file"}, "observed resource names")
+}
@@ -0,0 +1,40 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// Wrapf wraps the error pointed to by returnedErr with the given message, if the error is non-nil.
+// It's meant to be deferred together with [errclose.Close], to add context to all errors returned
+// by a function, including close errors:
+//
+//	func loadConfig(path string) (returnedErr error) {
+//		defer errclose.Wrapf(&returnedErr, "loading config from %s", path)
+//
+//		file, err := os.Open(path)
+//		if err != nil {
+//			return err
+//		}
+//		defer errclose.Close(file, &returnedErr, "file")
+//
+//		// Use file
+//	}
+//
+// Deferred calls run in the reverse order of their defer statements, so Wrapf must be deferred
+// before Close in order to also wrap close errors. In the example above, if both the function body
+// and closing the file fail, the returned error looks like this:
+//
+//	loading config from <path>: <existing error> (and failed to close file: <close error>)
+//
+// The message is formatted with [fmt.Sprintf] (only if there is an error), and the error is
+// wrapped with %w on the format "<message>: <error>", so the underlying error can still be checked
+// with [errors.Is] and [errors.As]. If returnedErr is a nil pointer, there is no error to wrap, so
+// Wrapf does nothing.
+func Wrapf(returnedErr *error, messageFormat string, formatArgs ...any) {
+	if returnedErr == nil || *returnedErr == nil {
+		return
+	}
+
+	message := fmt.Sprintf(messageFormat, formatArgs...)
+	*returnedErr = lazyErrorf(*returnedErr, "%s: %w", message, *returnedErr)
+}
@@ -0,0 +1,61 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWrapf(t *testing.T) {
+	var file *mockFile
+
+	loadConfig := func(path string) (returnedErr error) {
+		defer errclose.Wrapf(&returnedErr, "loading config from %s", path)
+
+		file = openFileWithCloseError()
+		defer errclose.Close(file, &returnedErr, "file")
+
+		return fallibleOperation()
+	}
+
+	err := loadConfig("/example/path")
+	assertEqual(
+		t,
+		err.Error(),
+		"loading config from /example/path: operation failed "+
+			"(and failed to close file: close error)",
+		"error string",
+	)
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is(closeError)")
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is(errFallibleOperation)")
+}
+
+func TestWrapfWithoutError(t *testing.T) {
+	loadConfig := func(path string) (returnedErr error) {
+		defer errclose.Wrapf(&returnedErr, "loading config from %s", path)
+
+		defer errclose.Close(openFileWithoutCloseError(), &returnedErr, "file")
+
+		return nil
+	}
+
+	err := loadConfig("/example/path")
+	assertEqual(t, err, nil, "error")
+}
+
+func TestWrapfFormatsMessageWhenCalled(t *testing.T) {
+	paths := []string{"/etc/app.conf"}
+
+	err := errFallibleOperation
+	errclose.Wrapf(&err, "loading config from %v", paths)
+	paths[0] = "/changed/path"
+
+	assertEqual(t, errors.Is(err, errFallibleOperation), true, "errors.Is result")
+	assertEqual(
+		t,
+		err.Error(),
+		"loading config from [/etc/app.conf]: operation failed",
+		"error string",
+	)
+}
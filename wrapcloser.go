@@ -0,0 +1,68 @@
+package errclose
+
+import (
+	"fmt"
+)
+
+// WrapCloser returns a closer that closes the given resource, and wraps close errors with the
+// given resource name, on the same format as [errclose.Close]:
+//
+//	failed to close <resourceName>: <close error>
+//
+// This lets libraries attach a name to a resource where it's created, so that callers get a
+// descriptive close error even if they close it with a plain Close call, or hand it to a framework
+// that does:
+//
+//	func OpenStore(path string) (io.Closer, error) {
+//		db, err := sql.Open("sqlite", path)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return errclose.WrapCloser(db, "store at "+path), nil
+//	}
+//
+// The close error is a [errclose.CloseError], so the resource name can be retrieved with
+// [errors.As]. The returned closer also implements [errclose.NamedCloser]. If it's passed to
+// errclose.Close with the same name (or an empty name), the name isn't repeated in the error
+// message.
+//
+// If the resource is nil, the close error wraps [errclose.ErrNilResource].
+func WrapCloser(resource interface{ Close() error }, resourceName string) NamedCloser {
+	return wrappedCloser{resource: resource, name: func() string { return resourceName }}
+}
+
+// WrapCloserf works like [errclose.WrapCloser], but takes a format string and args to construct
+// the resource name (like [errclose.Closef]). The formatting is only performed if there is a close
+// error, or if the name is requested through [NamedCloser.Name].
+func WrapCloserf(
+	resource interface{ Close() error },
+	resourceNameFormat string,
+	formatArgs ...any,
+) NamedCloser {
+	return wrappedCloser{
+		resource: resource,
+		name:     func() string { return fmt.Sprintf(resourceNameFormat, formatArgs...) },
+	}
+}
+
+type wrappedCloser struct {
+	resource interface{ Close() error }
+	name     func() string
+}
+
+func (closer wrappedCloser) Name() string {
+	return closer.name()
+}
+
+func (closer wrappedCloser) Close() error {
+	var closeErr error
+	if isNilResource(closer.resource) {
+		closeErr = nilResourceError()
+	} else {
+		closeErr = closer.resource.Close()
+	}
+	if closeErr == nil {
+		return nil
+	}
+	return newCloseError(closeErr, "close", closer.name())
+}
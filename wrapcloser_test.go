@@ -0,0 +1,58 @@
+package errclose_test
+
+import (
+	"errors"
+	"testing"
+
+	"hermannm.dev/errclose"
+)
+
+func TestWrapCloser(t *testing.T) {
+	file := openFileWithCloseError()
+	err := errclose.WrapCloser(file, "file").Close()
+
+	assertEqual(t, err.Error(), "failed to close file: close error", "error string")
+	assertEqual(t, errors.Is(err, file.closeError), true, "errors.Is result")
+
+	var closeErr *errclose.CloseError
+	assertEqual(t, errors.As(err, &closeErr), true, "errors.As result")
+	assertEqual(t, closeErr.ResourceName, "file", "CloseError.ResourceName")
+}
+
+func TestWrapCloserf(t *testing.T) {
+	closer := errclose.WrapCloserf(openFileWithCloseError(), "file at path %s", "/some/path")
+
+	assertEqual(t, closer.Name(), "file at path /some/path", "closer name")
+	assertEqual(
+		t,
+		closer.Close().Error(),
+		"failed to close file at path /some/path: close error",
+		"error string",
+	)
+}
+
+func TestWrapCloserWithClose(t *testing.T) {
+	for _, resourceName := range []string{"file", ""} {
+		useFile := func() (returnedErr error) {
+			defer errclose.Close(
+				errclose.WrapCloser(openFileWithCloseError(), "file"),
+				&returnedErr,
+				resourceName,
+			)
+			return fallibleOperation()
+		}
+
+		err := useFile()
+		assertEqual(
+			t,
+			err.Error(),
+			"operation failed (and failed to close file: close error)",
+			"error string with resource name '"+resourceName+"'",
+		)
+	}
+}
+
+func TestWrapCloserWithoutError(t *testing.T) {
+	err := errclose.WrapCloser(openFileWithoutCloseError(), "file").Close()
+	assertEqual(t, err, nil, "error")
+}